@@ -32,6 +32,7 @@ import (
 	"github.com/abcxyz/guardian/pkg/commands/drift/statefiles"
 	"github.com/abcxyz/guardian/pkg/commands/entrypoints"
 	"github.com/abcxyz/guardian/pkg/commands/iamcleanup"
+	"github.com/abcxyz/guardian/pkg/commands/locks"
 	"github.com/abcxyz/guardian/pkg/commands/plan"
 	"github.com/abcxyz/guardian/pkg/commands/policy"
 	"github.com/abcxyz/guardian/pkg/commands/run"
@@ -103,6 +104,20 @@ var rootCmd = func() cli.Command {
 			"cleanup": func() cli.Command {
 				return &cleanup.CleanupCommand{}
 			},
+			"locks": func() cli.Command {
+				return &cli.RootCommand{
+					Name:        "locks",
+					Description: "Perform operations related to apply locks",
+					Commands: map[string]cli.CommandFactory{
+						"list": func() cli.Command {
+							return &locks.ListCommand{}
+						},
+						"release": func() cli.Command {
+							return &locks.ReleaseCommand{}
+						},
+					},
+				}
+			},
 			"run": func() cli.Command {
 				return &run.RunCommand{}
 			},
@@ -114,6 +129,9 @@ var rootCmd = func() cli.Command {
 						"enforce": func() cli.Command {
 							return &policy.EnforceCommand{}
 						},
+						"eval": func() cli.Command {
+							return &policy.EvalCommand{}
+						},
 						"fetch-data": func() cli.Command {
 							return &policy.FetchDataCommand{}
 						},
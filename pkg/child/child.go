@@ -17,6 +17,7 @@ package child
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -28,6 +29,31 @@ import (
 	"github.com/abcxyz/pkg/logging"
 )
 
+// DefaultGracePeriod is the default time a child process is given to exit after
+// being interrupted, before it is killed.
+const DefaultGracePeriod = 2 * time.Second
+
+// ErrKilled is returned when a child process did not exit within the grace
+// period after being interrupted and was killed. On platforms where processes
+// are not interrupted, it is matched by the error of a process killed as soon
+// as the context is canceled.
+var ErrKilled = errors.New("process was killed after the grace period")
+
+// killedError is the error of a process killed without being interrupted
+// first. It is reported as the plain error of the process, but matches
+// ErrKilled.
+type killedError struct {
+	err error
+}
+
+func (e *killedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *killedError) Unwrap() []error {
+	return []error{ErrKilled, e.err}
+}
+
 // RunConfig are the inputs for a run operation.
 type RunConfig struct {
 	Stdout     io.Writer
@@ -46,6 +72,11 @@ type RunConfig struct {
 	// process, no matter what the user configured. These take precedence over all
 	// other configurables.
 	OverrideEnvVars []string
+
+	// GracePeriod is the time the child process is given to exit after it is
+	// interrupted because the context is canceled, before it is killed. Defaults
+	// to DefaultGracePeriod.
+	GracePeriod time.Duration
 }
 
 // Run executes a child process with the provided arguments.
@@ -87,10 +118,15 @@ func Run(ctx context.Context, cfg *RunConfig) (int, error) {
 	logger.DebugContext(ctx, "computed environment", "env", env)
 	cmd.Env = env
 
-	// add small wait delay to kill subprocesses if context is canceled
+	// add a wait delay to kill subprocesses if context is canceled, giving them
+	// time to clean up after being interrupted
 	// https://github.com/golang/go/issues/23019
 	// https://github.com/golang/go/issues/50436
-	cmd.WaitDelay = 2 * time.Second
+	gracePeriod := cfg.GracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultGracePeriod
+	}
+	cmd.WaitDelay = gracePeriod
 
 	logger.DebugContext(ctx, "command started")
 
@@ -99,6 +135,12 @@ func Run(ctx context.Context, cfg *RunConfig) (int, error) {
 	}
 
 	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil && killed(cmd.ProcessState) {
+			if !interrupts {
+				return cmd.ProcessState.ExitCode(), fmt.Errorf("failed to run command: %w", &killedError{err: err})
+			}
+			return cmd.ProcessState.ExitCode(), fmt.Errorf("failed to run command: %w of %s: %w", ErrKilled, gracePeriod, err)
+		}
 		return cmd.ProcessState.ExitCode(), fmt.Errorf("failed to run command: %w", err)
 	}
 
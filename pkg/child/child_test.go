@@ -17,6 +17,8 @@ package child
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"runtime"
 	"strings"
 	"testing"
@@ -157,7 +159,7 @@ func TestRun_Cancel(t *testing.T) {
 			if err != nil {
 				errStr := tc.err
 				if tc.name == "cancels_context_after_2s" && runtime.GOOS == "windows" {
-					errStr = "failed to run command: exit status"
+					errStr = "failed to run command: process was killed after the grace period"
 				}
 				if diff := testutil.DiffErrString(err, errStr); diff != "" {
 					t.Error(diff)
@@ -178,6 +180,67 @@ func TestRun_Cancel(t *testing.T) {
 	}
 }
 
+func TestRun_GracePeriod(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("processes are only interrupted on linux")
+	}
+
+	ctx := logging.WithLogger(t.Context(), logging.TestLogger(t))
+
+	cases := []struct {
+		name      string
+		script    string
+		expStdout string
+		err       string
+		expKilled bool
+	}{
+		{
+			name:      "exits_when_interrupted",
+			script:    `trap 'echo interrupted; exit 0' INT; echo started; sleep 5 & wait`,
+			expStdout: "started\ninterrupted",
+			err:       "failed to run command: context deadline exceeded",
+		},
+		{
+			name:      "killed_after_grace_period",
+			script:    `trap 'echo ignored' INT; echo started; sleep 5; sleep 5`,
+			expStdout: "started",
+			err:       "failed to run command: process was killed after the grace period of 500ms: signal: killed",
+			expKilled: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+			defer cancel()
+
+			var stdout, stderr bytes.Buffer
+
+			_, err := Run(ctx, &RunConfig{
+				Stdout:      &stdout,
+				Stderr:      &stderr,
+				Command:     "bash",
+				Args:        []string{"-c", tc.script},
+				GracePeriod: 500 * time.Millisecond,
+			})
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+			if got, want := errors.Is(err, ErrKilled), tc.expKilled; got != want {
+				t.Errorf("expected killed %t to be %t", got, want)
+			}
+
+			if got, want := strings.TrimSpace(stdout.String()), tc.expStdout; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		})
+	}
+}
+
 func TestEnviron(t *testing.T) {
 	t.Parallel()
 
@@ -320,3 +383,16 @@ func TestEnviron(t *testing.T) {
 		})
 	}
 }
+
+func TestKilledError(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("failed to run command: %w", &killedError{err: errors.New("signal: killed")})
+
+	if got, want := err.Error(), "failed to run command: signal: killed"; got != want {
+		t.Errorf("expected error %q to be %q", got, want)
+	}
+	if !errors.Is(err, ErrKilled) {
+		t.Errorf("expected error %q to match ErrKilled", err)
+	}
+}
@@ -16,10 +16,23 @@
 
 package child
 
-import "os/exec"
+import (
+	"os"
+	"os/exec"
+)
 
 // setSysProcAttr sets the sysProcAttr.
+// interrupts is false as processes are killed as soon as the context is
+// canceled.
+const interrupts = false
+
 func setSysProcAttr(cmd *exec.Cmd) {}
 
 // setCancel sets the Cancel behavior for a child process.
 func setCancel(cmd *exec.Cmd) {}
+
+// killed returns true if the process was killed, which is always the case for
+// a canceled process as it is not interrupted first.
+// killed returns true if the process was terminated by a signal, rather than
+// exiting by itself.
+func killed(state *os.ProcessState) bool { return state != nil && !state.Exited() }
@@ -17,11 +17,16 @@
 package child
 
 import (
+	"os"
 	"os/exec"
 	"syscall"
 )
 
 // setSysProcAttr sets the sysProcAttr.
+// interrupts is true as processes are interrupted when the context is canceled
+// and only killed after the grace period.
+const interrupts = true
+
 func setSysProcAttr(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		// kill children if parent is dead
@@ -31,9 +36,19 @@ func setSysProcAttr(cmd *exec.Cmd) {
 	}
 }
 
-// setCancel sets the Cancel behavior for a child process.
+// setCancel sets the Cancel behavior for a child process, which is interrupted
+// so it can exit gracefully before the wait delay elapses.
 func setCancel(cmd *exec.Cmd) {
 	cmd.Cancel = func() error {
-		return cmd.Process.Signal(syscall.SIGQUIT) // Want passthrough
+		return cmd.Process.Signal(syscall.SIGINT) // Want passthrough
+	}
+}
+
+// killed returns true if the process was killed.
+func killed(state *os.ProcessState) bool {
+	if state == nil {
+		return false
 	}
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	return ok && ws.Signaled() && ws.Signal() == syscall.SIGKILL
 }
@@ -0,0 +1,194 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package codeowners parses CODEOWNERS files and resolves the owners of
+// repository paths.
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// File is a parsed CODEOWNERS file.
+type File struct {
+	Rules []*Rule
+}
+
+// Rule is a pattern of a CODEOWNERS file and the owners of the paths it
+// matches.
+type Rule struct {
+	// Pattern is the gitignore style pattern of the rule.
+	Pattern string
+
+	// Owners are the owners of the matched paths, as written in the file, e.g.
+	// @user, @org/team or an email address.
+	Owners []string
+
+	// Line is the line number of the rule in the file.
+	Line int
+
+	re *regexp.Regexp
+}
+
+// ParseFile parses the CODEOWNERS file at the given path.
+func ParseFile(pth string) (*File, error) {
+	f, err := os.Open(pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open codeowners file: %w", err)
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Parse parses a CODEOWNERS file. GitLab section headers are supported, rules
+// without owners in a section use the default owners of the section. Unlike
+// GitLab, the last matching rule of the file applies across sections.
+func Parse(r io.Reader) (*File, error) {
+	var rules []*Rule
+	var sectionOwners []string
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if owners, ok := parseSection(fields); ok {
+			sectionOwners = owners
+			continue
+		}
+
+		pattern := strings.TrimPrefix(fields[0], `\`)
+		owners := make([]string, 0, len(fields)-1)
+		for _, o := range fields[1:] {
+			if strings.HasPrefix(o, "#") {
+				break
+			}
+			owners = append(owners, o)
+		}
+		if len(owners) == 0 {
+			owners = append(owners, sectionOwners...)
+		}
+
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pattern %q on line %d: %w", pattern, line, err)
+		}
+
+		rules = append(rules, &Rule{
+			Pattern: pattern,
+			Owners:  owners,
+			Line:    line,
+			re:      re,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read codeowners file: %w", err)
+	}
+
+	return &File{Rules: rules}, nil
+}
+
+// Match returns the last rule matching the path, nil if no rule matches. Paths
+// are relative to the repository root, directories end with a slash.
+func (f *File) Match(pth string) *Rule {
+	pth = strings.TrimPrefix(pth, "/")
+	for i := len(f.Rules) - 1; i >= 0; i-- {
+		if f.Rules[i].re.MatchString(pth) {
+			return f.Rules[i]
+		}
+	}
+	return nil
+}
+
+// Owners returns the owners of the path, nil if the path has no owners. Paths
+// are relative to the repository root, directories end with a slash.
+func (f *File) Owners(pth string) []string {
+	if r := f.Match(pth); r != nil && len(r.Owners) > 0 {
+		return r.Owners
+	}
+	return nil
+}
+
+// parseSection parses a GitLab section header, e.g. "[Section] @owner" or
+// "^[Optional Section][2] @owner", and returns the default owners of the
+// section.
+func parseSection(fields []string) ([]string, bool) {
+	header := strings.Join(fields, " ")
+	if !strings.HasPrefix(strings.TrimPrefix(header, "^"), "[") {
+		return nil, false
+	}
+
+	end := strings.LastIndex(header, "]")
+	if end < 0 {
+		return nil, false
+	}
+	return strings.Fields(header[end+1:]), true
+}
+
+// compilePattern converts a gitignore style pattern to a regular expression.
+// Patterns containing a slash other than a trailing one are relative to the
+// repository root, others match at any depth. A pattern matches the contents
+// of the directories it matches, unless its last segment contains a wildcard.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	p := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	lastSegment := p[strings.LastIndex(p, "/")+1:]
+	switch {
+	case dirOnly:
+		b.WriteString("/.*")
+	case !strings.ContainsAny(lastSegment, "*?"):
+		b.WriteString("(?:/.*)?")
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile pattern: %w", err)
+	}
+	return re, nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeowners
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+const testFile = `
+# default owners
+*                     @org/platform
+
+*.md                  @docs-owner # inline comment
+/terraform/           @org/infra
+terraform/prod/       @org/infra @org/sre
+modules/**/iam/       @org/security
+docs/*                @org/docs
+\#notes               @notes-owner
+**/generated          security@example.com
+
+[Networking] @org/network
+modules/network/
+terraform/*/vpc.tf
+`
+
+func TestFile_Owners(t *testing.T) {
+	t.Parallel()
+
+	f, err := Parse(strings.NewReader(testFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{
+			name: "default",
+			path: "main.go",
+			want: []string{"@org/platform"},
+		},
+		{
+			name: "extension_at_any_depth",
+			path: "pkg/commands/README.md",
+			want: []string{"@docs-owner"},
+		},
+		{
+			name: "anchored_directory",
+			path: "terraform/dev/main.tf",
+			want: []string{"@org/infra"},
+		},
+		{
+			name: "last_match_wins",
+			path: "terraform/prod/main.tf",
+			want: []string{"@org/infra", "@org/sre"},
+		},
+		{
+			name: "directory_path",
+			path: "terraform/prod/",
+			want: []string{"@org/infra", "@org/sre"},
+		},
+		{
+			name: "double_star",
+			path: "modules/gcp/project/iam/main.tf",
+			want: []string{"@org/security"},
+		},
+		{
+			name: "single_star_matches_direct_children",
+			path: "docs/guide.txt",
+			want: []string{"@org/docs"},
+		},
+		{
+			name: "single_star_skips_nested",
+			path: "docs/guides/guide.txt",
+			want: []string{"@org/platform"},
+		},
+		{
+			name: "escaped_hash",
+			path: "#notes",
+			want: []string{"@notes-owner"},
+		},
+		{
+			name: "email_owner",
+			path: "pkg/generated/file.go",
+			want: []string{"security@example.com"},
+		},
+		{
+			name: "section_default_owners",
+			path: "modules/network/main.tf",
+			want: []string{"@org/network"},
+		},
+		{
+			name: "section_wildcard_segment",
+			path: "terraform/dev/vpc.tf",
+			want: []string{"@org/network"},
+		},
+		{
+			name: "leading_slash",
+			path: "/terraform/dev/main.tf",
+			want: []string{"@org/infra"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(f.Owners(tc.path), tc.want); diff != "" {
+				t.Errorf("owners not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestFile_Owners_NoMatch(t *testing.T) {
+	t.Parallel()
+
+	f, err := Parse(strings.NewReader("/terraform/ @org/infra\n/modules/ \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := f.Owners("README.md"); got != nil {
+		t.Errorf("expected no owners, got %q", got)
+	}
+
+	// a matching rule without owners unsets the owners
+	if got := f.Owners("modules/bucket/main.tf"); got != nil {
+		t.Errorf("expected no owners, got %q", got)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		path    string
+		want    int
+		wantErr string
+	}{
+		{
+			name: "success",
+			path: "testdata/CODEOWNERS",
+			want: 2,
+		},
+		{
+			name:    "missing",
+			path:    "testdata/MISSING",
+			wantErr: "failed to open codeowners file",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseFile(tc.path)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				return
+			}
+			if len(got.Rules) != tc.want {
+				t.Errorf("expected %d rules, got %d", tc.want, len(got.Rules))
+			}
+		})
+	}
+}
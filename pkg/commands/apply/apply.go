@@ -21,9 +21,12 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,7 +35,10 @@ import (
 	"github.com/abcxyz/guardian/internal/metricswrap"
 	"github.com/abcxyz/guardian/pkg/checkterraform"
 	"github.com/abcxyz/guardian/pkg/commands/plan"
+	"github.com/abcxyz/guardian/pkg/environments"
 	"github.com/abcxyz/guardian/pkg/flags"
+	"github.com/abcxyz/guardian/pkg/git"
+	"github.com/abcxyz/guardian/pkg/locks"
 	"github.com/abcxyz/guardian/pkg/platform"
 	"github.com/abcxyz/guardian/pkg/storage"
 	"github.com/abcxyz/guardian/pkg/terraform"
@@ -51,6 +57,7 @@ var _ cli.Command = (*ApplyCommand)(nil)
 // RunResult is the result of a apply operation.
 type RunResult struct {
 	commentDetails string
+	stateDiff      *terraform.StateDiff
 }
 
 // ApplyCommand performs terraform apply on the given working directory.
@@ -62,23 +69,40 @@ type ApplyCommand struct {
 	planFilename      string
 	planFileLocalPath string
 	storagePrefix     string
+	deleted           bool
+	binaryName        string
 
 	platformConfig platform.Config
 
 	flags.CommonFlags
+	flags.EnvelopeFlags
+	flags.TerraformFlags
+	flags.RedactFlags
+	flags.TimeoutFlags
 
 	flagStorage                string
+	flagEnvironment            string
 	flagAllowLockfileChanges   bool
+	flagAllowLegacyPlanFiles   bool
 	flagLockTimeout            time.Duration
+	flagApplyTimeout           time.Duration
 	flagSkipReporting          bool
+	flagUpsertStatus           bool
+	flagApplyLock              bool
+	flagApplyLockTTL           time.Duration
 	flagDisallowedProviders    []string
 	flagDisallowedProvisioners []string
 	flagAllowedProviders       []string
 	flagAllowedProvisioners    []string
 
+	lockClient      *locks.Client
 	storageClient   storage.Storage
 	terraformClient terraform.Terraform
 	platformClient  platform.Platform
+	gitClient       git.Git
+	redactor        *terraform.Redactor
+
+	newTerraformClient func(dir string) terraform.Terraform
 }
 
 // Desc provides a short, one-line description of the command.
@@ -100,7 +124,11 @@ func (c *ApplyCommand) Flags() *cli.FlagSet {
 	set := c.NewFlagSet()
 
 	c.platformConfig.RegisterFlags(set)
-	c.Register(set)
+	c.CommonFlags.Register(set)
+	c.EnvelopeFlags.Register(set)
+	c.TerraformFlags.Register(set)
+	c.RedactFlags.Register(set)
+	c.TimeoutFlags.Register(set)
 
 	f := set.NewSection("COMMAND OPTIONS")
 
@@ -114,6 +142,13 @@ func (c *ApplyCommand) Flags() *cli.FlagSet {
 		}),
 	})
 
+	f.StringVar(&cli.StringVar{
+		Name:    "environment",
+		Target:  &c.flagEnvironment,
+		Example: "dev",
+		Usage:   "The environment of the directory to apply, as declared in its guardian.hcl file. Required when the directory declares environments.",
+	})
+
 	f.BoolVar(&cli.BoolVar{
 		Name:    "allow-lockfile-changes",
 		Target:  &c.flagAllowLockfileChanges,
@@ -121,6 +156,15 @@ func (c *ApplyCommand) Flags() *cli.FlagSet {
 		Usage:   "Allow modification of the Terraform lockfile.",
 	})
 
+	f.BoolVar(&cli.BoolVar{
+		Name:    "allow-legacy-plan-files",
+		Target:  &c.flagAllowLegacyPlanFiles,
+		Default: false,
+		Example: "true",
+		Usage: "Allow applying plan files created by older versions of Guardian, which are not bound to an " +
+			"entrypoint, Terraform version, file contents or commit and cannot be verified.",
+	})
+
 	f.DurationVar(&cli.DurationVar{
 		Name:    "lock-timeout",
 		Target:  &c.flagLockTimeout,
@@ -129,6 +173,14 @@ func (c *ApplyCommand) Flags() *cli.FlagSet {
 		Usage:   "The duration Terraform should wait to obtain a lock when running commands that modify state.",
 	})
 
+	f.DurationVar(&cli.DurationVar{
+		Name:    "apply-timeout",
+		Target:  &c.flagApplyTimeout,
+		EnvVar:  "GUARDIAN_APPLY_TIMEOUT",
+		Example: "1h",
+		Usage:   "The maximum duration of terraform apply, unlimited if not set.",
+	})
+
 	f.BoolVar(&cli.BoolVar{
 		Name:    "skip-reporting",
 		Target:  &c.flagSkipReporting,
@@ -137,6 +189,30 @@ func (c *ApplyCommand) Flags() *cli.FlagSet {
 		Usage:   "Skips reporting of the apply status on the change request.",
 	})
 
+	f.BoolVar(&cli.BoolVar{
+		Name:    "upsert-status",
+		Target:  &c.flagUpsertStatus,
+		Default: false,
+		Example: "true",
+		Usage:   "Updates the previous apply status comment for the directory in place, keeping a short history, instead of posting a new comment.",
+	})
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "apply-lock",
+		Target:  &c.flagApplyLock,
+		Default: false,
+		Example: "true",
+		Usage:   "Holds the apply lock of the directory while applying, and fails if another change request holds it or applied the directory after it was planned.",
+	})
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "apply-lock-ttl",
+		Target:  &c.flagApplyLockTTL,
+		Default: locks.DefaultTTL,
+		Example: "30m",
+		Usage:   "The duration of the apply lock lease, after which the lock can be taken over by another change request if it was not released.",
+	})
+
 	f.StringSliceVar(&cli.StringSliceVar{
 		Name:    "disallowed-providers",
 		Target:  &c.flagDisallowedProviders,
@@ -198,7 +274,13 @@ func (c *ApplyCommand) Run(ctx context.Context, args []string) error {
 		c.flagStorage = path.Join("file://", cwd)
 	}
 
+	// a deleted directory can only be applied using a plan to destroy it, which
+	// is verified once the plan file is downloaded
 	dirAbs, err := util.PathEvalAbs(c.FlagDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		c.deleted = true
+		dirAbs, err = filepath.Abs(c.FlagDir)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to absolute path for directory: %w", err)
 	}
@@ -210,8 +292,25 @@ func (c *ApplyCommand) Run(ctx context.Context, args []string) error {
 	}
 	c.childPath = childPath
 
+	redactor, err := c.Redactor()
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+	c.redactor = redactor
+
+	tfOpts, err := c.ClientOptions(ctx, c.directory)
+	if err != nil {
+		return fmt.Errorf("failed to configure terraform client: %w", err)
+	}
+
 	tfEnvVars := []string{"TF_IN_AUTOMATION=true"}
-	c.terraformClient = terraform.NewTerraformClient(c.directory, tfEnvVars)
+	c.newTerraformClient = func(dir string) terraform.Terraform {
+		return terraform.NewTerraformClient(dir, tfEnvVars, tfOpts...)
+	}
+	tfClient := terraform.NewTerraformClient(c.directory, tfEnvVars, tfOpts...)
+	c.terraformClient = tfClient
+	c.binaryName = terraform.BinaryName(tfClient.Binary())
+	c.gitClient = git.NewGitClient(cwd)
 
 	platform, err := platform.NewPlatform(ctx, &c.platformConfig)
 	if err != nil {
@@ -229,6 +328,21 @@ func (c *ApplyCommand) Run(ctx context.Context, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create storage client: %w", err)
 	}
+
+	// locks are shared by all change requests, so they are not stored in an
+	// envelope
+	if c.flagApplyLock {
+		repository, err := c.platformConfig.Repository()
+		if err != nil {
+			return fmt.Errorf("failed to determine repository for apply locks: %w", err)
+		}
+		c.lockClient = locks.NewClient(sc, repository, c.flagApplyLockTTL)
+	}
+
+	sc, err = c.WrapStorage(ctx, sc)
+	if err != nil {
+		return fmt.Errorf("failed to configure plan file envelope: %w", err)
+	}
 	c.storageClient = sc
 
 	return c.Process(ctx)
@@ -246,13 +360,29 @@ func (c *ApplyCommand) Process(ctx context.Context) (merr error) {
 		c.planFilename = "tfplan.binary"
 	}
 
-	planStoragePath := path.Join(c.storagePrefix, c.childPath, c.planFilename)
+	planStoragePath := path.Join(c.storagePrefix, c.entrypoint(), c.planFilename)
 	logger.DebugContext(ctx, "plan storage path", "path", planStoragePath)
 
-	planData, planExitCode, err := c.downloadGuardianPlan(ctx, planStoragePath)
+	planData, metadata, err := c.downloadGuardianPlan(ctx, planStoragePath)
 	if err != nil {
-		return fmt.Errorf("failed to download guardian plan file: %w", err)
+		merr = fmt.Errorf("failed to download guardian plan file: %w", err)
+
+		// a plan file with an invalid signature was not created by a trusted plan
+		// run, report it so it does not fail silently
+		if errors.Is(err, storage.ErrInvalidSignature) && !c.flagSkipReporting {
+			if err := c.reportStatus(ctx, platform.StatusFailure, &platform.StatusParams{
+				Operation:    "apply",
+				Dir:          c.entrypoint(),
+				Message:      "The plan file signature could not be verified, please re-run plan for this directory.",
+				ErrorMessage: err.Error(),
+			}); err != nil {
+				merr = errors.Join(merr, fmt.Errorf("failed to report status: %w", err))
+			}
+		}
+
+		return merr
 	}
+	planExitCode := metadata[plan.MetaKeyExitCode]
 
 	// we always want to delete the plan file to keep things clean
 	defer func() {
@@ -261,6 +391,41 @@ func (c *ApplyCommand) Process(ctx context.Context) (merr error) {
 		}
 	}()
 
+	if ref := metadata[plan.MetaKeyDestroyRef]; c.deleted && ref != "" {
+		checkoutDir, err := os.MkdirTemp("", "guardian-destroy-*")
+		if err != nil {
+			return errors.Join(merr, fmt.Errorf("failed to create temporary checkout directory: %w", err))
+		}
+		defer func() {
+			if err := os.RemoveAll(checkoutDir); err != nil {
+				merr = errors.Join(merr, fmt.Errorf("failed to delete temporary checkout directory: %w", err))
+			}
+		}()
+
+		if err := c.checkoutDeletedDirectory(ctx, ref, checkoutDir); err != nil {
+			return errors.Join(merr, fmt.Errorf("failed to checkout deleted directory: %w", err))
+		}
+	}
+
+	if err := c.verifyGuardianPlan(ctx, metadata); err != nil {
+		merr = errors.Join(merr, fmt.Errorf("failed to verify guardian plan file: %w", err))
+
+		if c.flagSkipReporting {
+			return merr
+		}
+
+		if err := c.reportStatus(ctx, platform.StatusStalePlan, &platform.StatusParams{
+			Operation:    "apply",
+			Dir:          c.entrypoint(),
+			Message:      "The plan file does not match the changes being applied, please re-run plan for this directory.",
+			ErrorMessage: err.Error(),
+		}); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("failed to report status: %w", err))
+		}
+
+		return merr
+	}
+
 	// exit code of 0 means success with no diff, skip apply
 	if planExitCode == "0" {
 		logger.DebugContext(ctx, "plan file has no diff, exiting", "plan_exit_code", planExitCode)
@@ -268,6 +433,20 @@ func (c *ApplyCommand) Process(ctx context.Context) (merr error) {
 		return merr
 	}
 
+	if c.lockClient != nil {
+		holder, err := c.acquireApplyLock(ctx, metadata)
+		if err != nil {
+			return errors.Join(merr, err)
+		}
+
+		defer func() {
+			util.Headerf(c.Stdout(), "Releasing apply lock")
+			if err := c.lockClient.Release(ctx, c.entrypoint(), holder); err != nil {
+				merr = errors.Join(merr, fmt.Errorf("failed to release apply lock: %w", err))
+			}
+		}()
+	}
+
 	tempDir, err := os.MkdirTemp("", "guardian-plans-*")
 	if err != nil {
 		return fmt.Errorf("failed to create temporary plan directory: %w", err)
@@ -290,7 +469,7 @@ func (c *ApplyCommand) Process(ctx context.Context) (merr error) {
 
 	sp := &platform.StatusParams{
 		Operation: operation,
-		Dir:       c.childPath,
+		Dir:       c.entrypoint(),
 		HasDiff:   true,
 	}
 
@@ -300,90 +479,369 @@ func (c *ApplyCommand) Process(ctx context.Context) (merr error) {
 	if err != nil {
 		merr = errors.Join(merr, fmt.Errorf("failed to run Guardian apply: %w", err))
 		status = platform.StatusFailure
+		sp.Message = plan.TimeoutMessage(err)
+	} else if c.deleted {
+		status = platform.StatusDestroy
+		sp.Message = "> ⚠️ **This directory was deleted, all of its resources were destroyed.**"
 	}
 
 	sp.Details = result.commentDetails
 
+	if d := result.stateDiff; d != nil {
+		if err := c.saveStateDiff(ctx, d); err != nil {
+			logger.WarnContext(ctx, "failed to save state diff", "error", err)
+		}
+		sp.Message = strings.TrimSpace(sp.Message + "\n\n" + stateDiffMessage(d))
+	}
+
 	if c.flagSkipReporting {
 		return merr
 	}
 
-	if err := c.platformClient.ReportStatus(ctx, status, sp); err != nil {
+	if err := c.reportStatus(ctx, status, sp); err != nil {
 		merr = errors.Join(merr, fmt.Errorf("failed to report status: %w", err))
 	}
 
 	return merr
 }
 
+// checkoutDeletedDirectory writes the repository at the commit the destroy plan
+// was created from to the checkout directory, and applies the deleted directory
+// from there.
+func (c *ApplyCommand) checkoutDeletedDirectory(ctx context.Context, ref, checkoutDir string) error {
+	util.Headerf(c.Stdout(), "Checking out deleted directory")
+
+	if err := c.gitClient.ExtractRef(ctx, ref, checkoutDir); err != nil {
+		return fmt.Errorf("failed to extract commit %s, it must be fetched to apply the plan: %w", ref, err)
+	}
+
+	dir := filepath.Join(checkoutDir, filepath.FromSlash(c.childPath))
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("directory %s does not exist at commit %s: %w", c.childPath, ref, err)
+	}
+
+	c.Outf("Destroying %s using the configuration at %s", c.childPath, ref)
+
+	c.directory = dir
+	c.terraformClient = c.newTerraformClient(dir)
+	return nil
+}
+
+// entrypoint returns the directory qualified with the selected environment,
+// which identifies the plan file, apply lock and status reports of the run.
+func (c *ApplyCommand) entrypoint() string {
+	return environments.Qualify(c.childPath, c.flagEnvironment)
+}
+
+// acquireApplyLock acquires the apply lock of the directory for the change
+// request being applied and returns its number. When the lock is held by
+// another change request, or the directory was applied after the plan was
+// created, this is reported on the change request.
+func (c *ApplyCommand) acquireApplyLock(ctx context.Context, metadata map[string]string) (int, error) {
+	util.Headerf(c.Stdout(), "Acquiring apply lock")
+
+	holder, err := c.changeRequestNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to determine change request number: %w", err)
+	}
+
+	serial := locks.AnySerial
+	if v, ok := metadata[plan.MetaKeyLockSerial]; ok {
+		serial, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse plan lock serial %q: %w", v, err)
+		}
+	}
+
+	lock, err := c.lockClient.Acquire(ctx, c.entrypoint(), holder, c.platformConfig.CommitSHA(), serial)
+	if err == nil {
+		c.Outf("Acquired apply lock until %s", lock.ExpiresAt.UTC().Format(time.RFC3339))
+		return holder, nil
+	}
+
+	merr := fmt.Errorf("failed to acquire apply lock: %w", err)
+	if c.flagSkipReporting {
+		return 0, merr
+	}
+
+	// a lock that expired without being released counts as an apply by its
+	// holder
+	var applier int
+	if lock != nil {
+		applier = lock.LastHolder
+		if lock.Expired(time.Now()) {
+			applier = lock.Holder
+		}
+	}
+
+	var status platform.Status
+	var message string
+	switch {
+	case errors.Is(err, locks.ErrLocked) && lock != nil && lock.Held(time.Now()):
+		status = platform.StatusFailure
+		message = fmt.Sprintf("This directory is locked by #%d while it is being applied, until %s at the latest. Please re-run plan for this directory once the apply has completed.",
+			lock.Holder, lock.ExpiresAt.UTC().Format(time.RFC3339))
+	case errors.Is(err, locks.ErrLocked):
+		status = platform.StatusFailure
+		message = "This directory is being applied by another change request. Please re-run plan for this directory once the apply has completed."
+	case errors.Is(err, locks.ErrStale) && applier > 0:
+		status = platform.StatusStalePlan
+		message = fmt.Sprintf("This directory was applied by #%d after the plan was created, please re-run plan for this directory.", applier)
+	case errors.Is(err, locks.ErrStale):
+		status = platform.StatusStalePlan
+		message = "This directory was applied by another change request after the plan was created, please re-run plan for this directory."
+	default:
+		return 0, merr
+	}
+
+	if err := c.reportStatus(ctx, status, &platform.StatusParams{
+		Operation:    "apply",
+		Dir:          c.entrypoint(),
+		Message:      message,
+		ErrorMessage: err.Error(),
+	}); err != nil {
+		merr = errors.Join(merr, fmt.Errorf("failed to report status: %w", err))
+	}
+
+	return 0, merr
+}
+
+// changeRequestNumber returns the number of the change request being applied,
+// looking it up by commit when the run was not triggered by the change request.
+func (c *ApplyCommand) changeRequestNumber(ctx context.Context) (int, error) {
+	if number := c.platformConfig.ChangeRequestNumber(); number > 0 {
+		return number, nil
+	}
+
+	sha := c.platformConfig.CommitSHA()
+	if sha == "" {
+		return 0, fmt.Errorf("commit sha is required to find the change request")
+	}
+
+	resp, err := c.platformClient.ListChangeRequestsByCommit(ctx, sha, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list change requests for commit %s: %w", sha, err)
+	}
+	if resp == nil || len(resp.PullRequests) == 0 {
+		return 0, fmt.Errorf("no change requests found for commit %s", sha)
+	}
+
+	return resp.PullRequests[0].Number, nil
+}
+
+// reportStatus reports the status of the run with sensitive values masked,
+// updating the previous status report in place when requested.
+func (c *ApplyCommand) reportStatus(ctx context.Context, st platform.Status, p *platform.StatusParams) error {
+	p.Details = c.redactor.Redact(p.Details)
+	p.Message = c.redactor.Redact(p.Message)
+	p.ErrorMessage = c.redactor.Redact(p.ErrorMessage)
+
+	if c.flagUpsertStatus {
+		return c.platformClient.UpsertStatus(ctx, st, p) //nolint:wrapcheck // Want passthrough
+	}
+	return c.platformClient.ReportStatus(ctx, st, p) //nolint:wrapcheck // Want passthrough
+}
+
 // terraformApply runs the required Terraform commands for a full run of
 // a Guardian apply using the Terraform CLI.
 func (c *ApplyCommand) terraformApply(ctx context.Context) (*RunResult, error) {
+	out := c.redactor.Writer(c.Stdout())
+	errOut := c.redactor.Writer(c.Stderr())
+	defer func() {
+		if err := errors.Join(out.Flush(), errOut.Flush()); err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "failed to flush output", "error", err)
+		}
+	}()
+
 	var stdout, stderr strings.Builder
-	multiStdout := io.MultiWriter(c.Stdout(), &stdout)
-	multiStderr := io.MultiWriter(c.Stderr(), &stderr)
+	multiStdout := io.MultiWriter(out, &stdout)
+	multiStderr := io.MultiWriter(errOut, &stderr)
+
+	config, err := environments.Load(c.directory)
+	if err != nil {
+		return &RunResult{}, fmt.Errorf("failed to load environments: %w", err)
+	}
+
+	env, err := config.Select(c.flagEnvironment)
+	if err != nil {
+		return &RunResult{}, fmt.Errorf("failed to select environment: %w", err)
+	}
 
 	lockfileMode := "none"
 	if !c.flagAllowLockfileChanges {
 		lockfileMode = "readonly"
 	}
 
-	util.Headerf(c.Stdout(), "Initializing Terraform")
-	if _, err := c.terraformClient.Init(ctx, c.Stdout(), multiStderr, &terraform.InitOptions{
-		Input:       pointer.To(false),
-		NoColor:     pointer.To(true),
-		Lockfile:    pointer.To(lockfileMode),
-		LockTimeout: pointer.To(c.flagLockTimeout.String()),
+	util.Headerf(out, "Initializing Terraform")
+	if _, err := terraform.RunStep(ctx, c.binaryName, "init", c.FlagInitTimeout, func(ctx context.Context) (int, error) {
+		return c.terraformClient.Init(ctx, out, multiStderr, &terraform.InitOptions{ //nolint:wrapcheck // Want passthrough
+			BackendConfigs: env.BackendConfigs,
+			Input:          pointer.To(false),
+			NoColor:        pointer.To(true),
+			Lockfile:       pointer.To(lockfileMode),
+			LockTimeout:    pointer.To(c.flagLockTimeout.String()),
+		})
 	}); err != nil {
 		return &RunResult{commentDetails: stderr.String()}, fmt.Errorf("failed to initialize: %w", err)
 	}
 
 	stderr.Reset()
 
-	util.Headerf(c.Stdout(), "Validating Terraform")
-	if _, err := c.terraformClient.Validate(ctx, c.Stdout(), multiStderr, &terraform.ValidateOptions{
-		NoColor: pointer.To(true),
+	// the workspace is not created when applying, as it is created by the plan
+	if env.Workspace != "" {
+		util.Headerf(out, "Selecting Terraform Workspace")
+		if _, err := c.terraformClient.WorkspaceSelect(ctx, out, multiStderr, &terraform.WorkspaceSelectOptions{
+			Name: pointer.To(env.Workspace),
+		}); err != nil {
+			return &RunResult{commentDetails: stderr.String()}, fmt.Errorf("failed to select workspace: %w", err)
+		}
+
+		stderr.Reset()
+	}
+
+	util.Headerf(out, "Validating Terraform")
+	if _, err := terraform.RunStep(ctx, c.binaryName, "validate", c.FlagValidateTimeout, func(ctx context.Context) (int, error) {
+		return c.terraformClient.Validate(ctx, out, multiStderr, &terraform.ValidateOptions{ //nolint:wrapcheck // Want passthrough
+			NoColor: pointer.To(true),
+		})
 	}); err != nil {
 		return &RunResult{commentDetails: stderr.String()}, fmt.Errorf("failed to validate: %w", err)
 	}
 
 	stderr.Reset()
 
-	util.Headerf(c.Stdout(), "Applying Terraform")
-	if _, err := c.terraformClient.Apply(ctx, multiStdout, multiStderr, &terraform.ApplyOptions{
-		File:                   pointer.To(c.planFileLocalPath),
-		CompactWarnings:        pointer.To(true),
-		Input:                  pointer.To(false),
-		NoColor:                pointer.To(true),
-		LockTimeout:            pointer.To(c.flagLockTimeout.String()),
-		DisallowedProviders:    c.flagDisallowedProviders,
-		DisallowedProvisioners: c.flagDisallowedProvisioners,
-		AllowedProviders:       c.flagAllowedProviders,
-		AllowedProvisioners:    c.flagAllowedProvisioners,
+	// values that are sensitive in the plan are masked in the apply output, the
+	// plan and state can only be shown once initialized
+	planJSON := c.showJSON(ctx, pointer.To(c.planFileLocalPath))
+	c.redactor = c.planRedactor(ctx, planJSON)
+	before := c.stateSnapshot(ctx)
+	if err := errors.Join(out.Flush(), errOut.Flush()); err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "failed to flush output", "error", err)
+	}
+	out = c.redactor.Writer(c.Stdout())
+	errOut = c.redactor.Writer(c.Stderr())
+	multiStdout = io.MultiWriter(out, &stdout)
+	multiStderr = io.MultiWriter(errOut, &stderr)
+
+	util.Headerf(out, "Applying Terraform")
+	if _, err := terraform.RunStep(ctx, c.binaryName, "apply", c.flagApplyTimeout, func(ctx context.Context) (int, error) {
+		return c.terraformClient.Apply(ctx, multiStdout, multiStderr, &terraform.ApplyOptions{ //nolint:wrapcheck // Want passthrough
+			File:                   pointer.To(c.planFileLocalPath),
+			CompactWarnings:        pointer.To(true),
+			Input:                  pointer.To(false),
+			NoColor:                pointer.To(true),
+			LockTimeout:            pointer.To(c.flagLockTimeout.String()),
+			DisallowedProviders:    c.flagDisallowedProviders,
+			DisallowedProvisioners: c.flagDisallowedProvisioners,
+			AllowedProviders:       c.flagAllowedProviders,
+			AllowedProvisioners:    c.flagAllowedProvisioners,
+		})
 	}); err != nil {
-		return &RunResult{commentDetails: stderr.String()}, fmt.Errorf("failed to apply: %w", err)
+		return &RunResult{
+			commentDetails: stderr.String(),
+			stateDiff:      c.stateDiff(ctx, before, planJSON),
+		}, fmt.Errorf("failed to apply: %w", err)
 	}
 
 	stderr.Reset()
 
-	return &RunResult{commentDetails: stdout.String()}, nil
+	return &RunResult{
+		commentDetails: stdout.String(),
+		stateDiff:      c.stateDiff(ctx, before, planJSON),
+	}, nil
+}
+
+// showJSON returns the JSON representation of the plan file, or of the state
+// if file is nil. Nil is returned if it cannot be shown, as the features using
+// it are best effort.
+func (c *ApplyCommand) showJSON(ctx context.Context, file *string) []byte {
+	var out strings.Builder
+	if _, err := c.terraformClient.Show(ctx, &out, io.Discard, &terraform.ShowOptions{
+		File:    file,
+		NoColor: pointer.To(true),
+		JSON:    pointer.To(true),
+	}); err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "failed to show json", "file", file, "error", err)
+		return nil
+	}
+	return []byte(out.String())
+}
+
+// planRedactor returns the redactor also masking the values that are sensitive
+// in the plan JSON. The current redactor is returned if the plan could not be
+// shown, as the redaction rules still apply.
+func (c *ApplyCommand) planRedactor(ctx context.Context, planJSON []byte) *terraform.Redactor {
+	if planJSON == nil {
+		return c.redactor
+	}
+
+	r, err := c.redactor.WithPlanJSON(planJSON)
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "failed to read sensitive values from plan", "error", err)
+		return c.redactor
+	}
+	return r
+}
+
+// stateSnapshot returns a snapshot of the current state, or nil if the state
+// could not be shown.
+func (c *ApplyCommand) stateSnapshot(ctx context.Context) *terraform.StateSnapshot {
+	stateJSON := c.showJSON(ctx, nil)
+	if stateJSON == nil {
+		return nil
+	}
+
+	s, err := terraform.ParseStateSnapshot(stateJSON)
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "failed to snapshot state", "error", err)
+		return nil
+	}
+	return s
+}
+
+// stateDiff returns the changes to the state since the snapshot before the
+// apply, compared with the changes in the plan JSON. Nil is returned if the
+// state could not be snapshot before or after the apply.
+func (c *ApplyCommand) stateDiff(ctx context.Context, before *terraform.StateSnapshot, planJSON []byte) *terraform.StateDiff {
+	if before == nil {
+		return nil
+	}
+
+	after := c.stateSnapshot(ctx)
+	if after == nil {
+		return nil
+	}
+
+	d := terraform.DiffStates(before, after)
+
+	// without a plan summary the changes cannot be compared with the plan
+	if planJSON == nil {
+		return d
+	}
+	summary, err := terraform.ParsePlanSummary(planJSON)
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "failed to summarize plan", "error", err)
+		return d
+	}
+	d.ComparePlan(summary)
+
+	return d
 }
 
 // downloadGuardianPlan downloads the Guardian plan binary from the configured Guardian storage bucket
-// and returns the plan data and plan exit code.
-func (c *ApplyCommand) downloadGuardianPlan(ctx context.Context, path string) (planData []byte, planExitCode string, outErr error) {
+// and returns the plan data and plan metadata.
+func (c *ApplyCommand) downloadGuardianPlan(ctx context.Context, path string) (planData []byte, metadata map[string]string, outErr error) {
 	util.Headerf(c.Stdout(), "Downloading Guardian plan file")
 
 	rc, metadata, err := c.storageClient.GetObject(ctx, path)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to download object: %w", err)
+		return nil, nil, fmt.Errorf("failed to download object: %w", err)
 	}
 
 	if metadata != nil {
-		exitCode, ok := metadata[plan.MetaKeyExitCode]
-		if !ok {
-			return nil, "", fmt.Errorf("failed to determine plan exit code: %w", err)
+		if _, ok := metadata[plan.MetaKeyExitCode]; !ok {
+			return nil, nil, fmt.Errorf("failed to determine plan exit code: %w", err)
 		}
-		planExitCode = exitCode
 	}
 
 	defer func() {
@@ -394,11 +852,161 @@ func (c *ApplyCommand) downloadGuardianPlan(ctx context.Context, path string) (p
 
 	data, err := io.ReadAll(rc)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to read plan data: %w", err)
+		return nil, nil, fmt.Errorf("failed to read plan data: %w", err)
 	}
 	planData = data
 
-	return planData, planExitCode, nil
+	return planData, metadata, nil
+}
+
+// verifiedMetadataKeys are the plan file metadata keys that bind a plan file to
+// the entrypoint, Terraform version, file contents and commit it was created
+// for. Plan files created by older versions of Guardian have none of them.
+var verifiedMetadataKeys = []string{
+	plan.MetaKeyEntrypoint,
+	plan.MetaKeyTerraformVersion,
+	plan.MetaKeyContentHash,
+	plan.MetaKeyCommitSHA,
+}
+
+// verifyGuardianPlan verifies the Guardian plan file was created for the
+// entrypoint, Terraform version, file contents and commit being applied. Plan
+// files created by older versions of Guardian cannot be verified and are only
+// applied when explicitly allowed.
+func (c *ApplyCommand) verifyGuardianPlan(ctx context.Context, metadata map[string]string) error {
+	util.Headerf(c.Stdout(), "Verifying Guardian plan file")
+
+	if _, ok := metadata[plan.MetaKeyDestroyRef]; ok != c.deleted {
+		if c.deleted {
+			return fmt.Errorf("directory %q was deleted, but the plan file was not created to destroy it", c.childPath)
+		}
+		return fmt.Errorf("plan file was created to destroy directory %q, but it exists", c.childPath)
+	}
+
+	if !slices.ContainsFunc(verifiedMetadataKeys, func(k string) bool {
+		_, ok := metadata[k]
+		return ok
+	}) {
+		if !c.flagAllowLegacyPlanFiles {
+			return fmt.Errorf("plan file was created by an older version of Guardian and cannot be verified, re-run plan or set -allow-legacy-plan-files to apply it")
+		}
+		logging.FromContext(ctx).WarnContext(ctx, "applying legacy plan file without verification",
+			"entrypoint", c.entrypoint())
+		return nil
+	}
+
+	want, err := requiredMetadata(metadata, plan.MetaKeyEntrypoint)
+	if err != nil {
+		return err
+	}
+	if want != c.entrypoint() {
+		return fmt.Errorf("plan file was created for entrypoint %q, expected %q", want, c.entrypoint())
+	}
+
+	if c.binaryName != "" {
+		want, err := requiredMetadata(metadata, plan.MetaKeyTerraformBinary)
+		if err != nil {
+			return err
+		}
+		if want != c.binaryName {
+			return fmt.Errorf("plan file was created with %q, current binary is %q", want, c.binaryName)
+		}
+	}
+
+	want, err = requiredMetadata(metadata, plan.MetaKeyTerraformVersion)
+	if err != nil {
+		return err
+	}
+	got, err := terraform.GetVersion(ctx, c.terraformClient)
+	if err != nil {
+		return fmt.Errorf("failed to get terraform version: %w", err)
+	}
+	if got != want {
+		return fmt.Errorf("plan file was created with terraform version %q, current version is %q", want, got)
+	}
+
+	// a deleted directory has no content to verify, the destroy ref is verified
+	// instead
+	if !c.deleted {
+		want, err := requiredMetadata(metadata, plan.MetaKeyContentHash)
+		if err != nil {
+			return err
+		}
+
+		cwd, err := c.WorkingDir()
+		if err != nil {
+			return fmt.Errorf("failed to get current working directory: %w", err)
+		}
+
+		got, err := terraform.EntrypointContentHash(ctx, cwd, c.directory)
+		if err != nil {
+			return fmt.Errorf("failed to compute entrypoint content hash: %w", err)
+		}
+		if got != want {
+			return fmt.Errorf("plan file content hash %q does not match current content hash %q", want, got)
+		}
+	}
+
+	// the local platform has no change requests to bind a plan file to
+	if c.platformConfig.Type == platform.TypeLocal {
+		return nil
+	}
+
+	want, err = requiredMetadata(metadata, plan.MetaKeyCommitSHA)
+	if err != nil {
+		return err
+	}
+	return c.verifyPlanCommit(ctx, want)
+}
+
+// verifyPlanCommit verifies the plan file was created for the head commit of
+// the change request being applied.
+func (c *ApplyCommand) verifyPlanCommit(ctx context.Context, want string) error {
+	sha := c.platformConfig.CommitSHA()
+	if sha == "" {
+		return fmt.Errorf("commit sha is required to verify the plan file commit")
+	}
+
+	number, err := c.changeRequestNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to determine change request number: %w", err)
+	}
+
+	resp, err := c.platformClient.ListChangeRequestsByCommit(ctx, sha, nil)
+	if err != nil {
+		return fmt.Errorf("failed to list change requests for commit %s: %w", sha, err)
+	}
+
+	var pr *platform.PullRequest
+	if resp != nil {
+		for _, p := range resp.PullRequests {
+			if p.Number == number {
+				pr = p
+				break
+			}
+		}
+	}
+	if pr == nil {
+		return fmt.Errorf("change request #%d was not found for commit %s", number, sha)
+	}
+	if pr.HeadSHA == "" {
+		return fmt.Errorf("failed to determine the head commit of change request #%d", number)
+	}
+
+	if pr.HeadSHA != want {
+		return fmt.Errorf("plan file was created for commit %s, latest change request commit is %s", want, pr.HeadSHA)
+	}
+	return nil
+}
+
+// requiredMetadata returns the value of a plan file metadata key, or an error if
+// it is missing.
+func requiredMetadata(metadata map[string]string, key string) (string, error) {
+	v, ok := metadata[key]
+	if !ok {
+		return "", fmt.Errorf("plan file is missing %q metadata", key)
+	}
+	return v, nil
 }
 
 // handleDeleteGuardianPlan deletes the Guardian plan binary from the configured Guardian storage bucket.
@@ -15,13 +15,21 @@
 package apply
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"path"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 
+	"github.com/abcxyz/guardian/pkg/git"
+	"github.com/abcxyz/guardian/pkg/github"
+	"github.com/abcxyz/guardian/pkg/locks"
 	"github.com/abcxyz/guardian/pkg/platform"
 	"github.com/abcxyz/guardian/pkg/storage"
 	"github.com/abcxyz/guardian/pkg/terraform"
@@ -34,6 +42,10 @@ var terraformMock = &terraform.MockTerraformClient{
 		Stdout:   "terraform init success",
 		ExitCode: 0,
 	},
+	WorkspaceSelectResponse: &terraform.MockTerraformResponse{
+		Stdout:   "terraform workspace select success",
+		ExitCode: 0,
+	},
 	ValidateResponse: &terraform.MockTerraformResponse{
 		Stdout:   "terraform validate success",
 		ExitCode: 0,
@@ -42,6 +54,28 @@ var terraformMock = &terraform.MockTerraformClient{
 		Stdout:   "terraform apply success",
 		ExitCode: 0,
 	},
+	VersionResponse: &terraform.MockTerraformResponse{
+		Stdout:   `{"terraform_version":"1.9.0"}`,
+		ExitCode: 0,
+	},
+}
+
+// testPlanMetadata returns the metadata of a plan file created for the
+// entrypoint directory with terraformMock at commit abc123.
+func testPlanMetadata(tb testing.TB, dir, entrypoint string) map[string]string {
+	tb.Helper()
+
+	contentHash, err := terraform.EntrypointContentHash(tb.Context(), ".", dir)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return map[string]string{
+		"plan_entrypoint":        entrypoint,
+		"plan_terraform_version": "1.9.0",
+		"plan_content_hash":      contentHash,
+		"plan_commit_sha":        "abc123",
+	}
 }
 
 var terraformErrorMock = &terraform.MockTerraformClient{
@@ -61,6 +95,44 @@ var terraformErrorMock = &terraform.MockTerraformClient{
 	},
 }
 
+// hangingApplyClient is a Terraform client whose apply only ends when the
+// context is canceled.
+type hangingApplyClient struct {
+	*terraform.MockTerraformClient
+}
+
+func (c *hangingApplyClient) Apply(ctx context.Context, stdout, stderr io.Writer, opts *terraform.ApplyOptions) (int, error) {
+	<-ctx.Done()
+	return -1, fmt.Errorf("failed to run command: %w", ctx.Err())
+}
+
+// stateClient is a Terraform client showing the plan JSON for the plan file and
+// each state in turn for the state.
+type stateClient struct {
+	*terraform.MockTerraformClient
+	planJSON string
+
+	mu     sync.Mutex
+	states []string
+}
+
+func (c *stateClient) Show(ctx context.Context, stdout, stderr io.Writer, opts *terraform.ShowOptions) (int, error) {
+	if opts.File != nil {
+		fmt.Fprint(stdout, c.planJSON)
+		return 0, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.states) == 0 {
+		return 1, fmt.Errorf("no state")
+	}
+	fmt.Fprint(stdout, c.states[0])
+	c.states = c.states[1:]
+	return 0, nil
+}
+
 func TestApply_Process(t *testing.T) {
 	t.Parallel()
 
@@ -69,15 +141,28 @@ func TestApply_Process(t *testing.T) {
 	cases := []struct {
 		name                     string
 		directory                string
+		deleted                  bool
+		flagEnvironment          string
+		binaryName               string
+		extractFiles             map[string]string
 		flagAllowLockfileChanges bool
+		flagAllowLegacyPlanFiles bool
 		flagLockTimeout          time.Duration
 		planExitCode             string
+		planMetadata             map[string]string
+		commitSHA                string
+		changeRequestNumber      int
+		listChangeRequestsResp   *platform.ListChangeRequestsByCommitResponse
+		downloadErr              error
+		applyLock                string
 		storageParent            string
 		storagePrefix            string
-		terraformClient          *terraform.MockTerraformClient
+		flagApplyTimeout         time.Duration
+		terraformClient          terraform.Terraform
 		err                      string
 		expPlatformClientReqs    []*platform.Request
 		expStorageClientReqs     []*storage.Request
+		expApplyLock             *locks.Lock
 		expStdout                string
 		expStderr                string
 		resolveJobLogsURLErr     error
@@ -87,6 +172,7 @@ func TestApply_Process(t *testing.T) {
 			directory: "testdir",
 
 			storagePrefix:            "",
+			flagAllowLegacyPlanFiles: true,
 			flagAllowLockfileChanges: true,
 			flagLockTimeout:          10 * time.Minute,
 			planExitCode:             "2",
@@ -112,11 +198,341 @@ func TestApply_Process(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:            "success_environment",
+			directory:       "testdata/environments",
+			flagEnvironment: "dev",
+
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			planMetadata:             testPlanMetadata(t, "testdata/environments", "testdata/environments@dev"),
+			commitSHA:                "merge123",
+			changeRequestNumber:      1,
+			listChangeRequestsResp: &platform.ListChangeRequestsByCommitResponse{
+				PullRequests: []*platform.PullRequest{{ID: 1, Number: 1, HeadSHA: "abc123"}},
+			},
+			terraformClient: terraformMock,
+			expStdout:       "terraform workspace select success",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "ListChangeRequestsByCommit",
+					Params: []any{"merge123", (*platform.ListChangeRequestsByCommitOptions)(nil)},
+				},
+				{
+					Name:   "Status",
+					Params: []any{platform.StatusSuccess, &platform.StatusParams{HasDiff: true, Details: "terraform apply success", Dir: "testdata/environments@dev", Operation: "apply"}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdata/environments@dev/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdata/environments@dev/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:      "success_redacts_sensitive_values",
+			directory: "testdir",
+
+			storagePrefix:            "",
+			flagAllowLegacyPlanFiles: true,
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			terraformClient: &terraform.MockTerraformClient{
+				ShowJSONResponse: &terraform.MockTerraformResponse{
+					Stdout: `{"output_changes": {"token": {"after": "output-token-value", "after_sensitive": true}}}`,
+				},
+				ApplyResponse: &terraform.MockTerraformResponse{
+					Stdout: "token = output-token-value",
+				},
+			},
+			expStdout: "token = (redacted)",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "Status",
+					Params: []any{platform.StatusSuccess, &platform.StatusParams{HasDiff: true, Details: "token = (redacted)", Dir: "testdir", Operation: "apply"}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "CreateObject",
+					Params: []any{
+						"testdir/state-diff.json",
+						"{\n  \"created\": [],\n  \"updated\": [],\n  \"deleted\": [],\n  \"outputs\": [],\n  \"unplanned\": [],\n  \"unapplied\": []\n}",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:      "success_state_diff",
+			directory: "testdir",
+
+			storagePrefix:            "",
+			flagAllowLegacyPlanFiles: true,
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			terraformClient: &stateClient{
+				MockTerraformClient: terraformMock,
+				planJSON: `{"resource_changes": [
+					{"address": "google_storage_bucket.b", "mode": "managed", "change": {"actions": ["update"]}},
+					{"address": "google_service_account.sa", "mode": "managed", "change": {"actions": ["create"]}}
+				]}`,
+				states: []string{
+					`{"values": {"outputs": {"bucket": {"value": "old"}}, "root_module": {"resources": [
+						{"address": "google_storage_bucket.b", "mode": "managed", "values": {"name": "old"}}
+					]}}}`,
+					`{"values": {"outputs": {"bucket": {"value": "new"}}, "root_module": {"resources": [
+						{"address": "google_storage_bucket.b", "mode": "managed", "values": {"name": "new"}},
+						{"address": "google_project_iam_member.m", "mode": "managed", "values": {"role": "roles/viewer"}}
+					]}}}`,
+				},
+			},
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusSuccess, &platform.StatusParams{
+						HasDiff:   true,
+						Details:   "terraform apply success",
+						Dir:       "testdir",
+						Operation: "apply",
+						Message: "**Applied changes**\n\n" +
+							"| Created | Updated | Deleted |\n| ---: | ---: | ---: |\n| 1 | 1 | 0 |\n\n" +
+							"**Outputs changed**\n\n" +
+							"| Output | Action | Before | After |\n| --- | --- | --- | --- |\n| `bucket` | 🟨 update | `\"old\"` | `\"new\"` |\n\n" +
+							"> ⚠️ **The applied changes differ from the plan.** " +
+							"This can happen when the plan has unknown values, or a provider changes resources it did not plan to change.\n>\n" +
+							"> - Changed without a planned change: `google_project_iam_member.m`\n" +
+							"> - Planned but not changed: `google_service_account.sa`",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "CreateObject",
+					Params: []any{
+						"testdir/state-diff.json",
+						`{
+  "created": [
+    "google_project_iam_member.m"
+  ],
+  "updated": [
+    "google_storage_bucket.b"
+  ],
+  "deleted": [],
+  "outputs": [
+    {
+      "name": "bucket",
+      "action": "update",
+      "before": "old",
+      "after": "new"
+    }
+  ],
+  "unplanned": [
+    "google_project_iam_member.m"
+  ],
+  "unapplied": [
+    "google_service_account.sa"
+  ]
+}`,
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:      "handles_apply_timeout",
+			directory: "testdir",
+
+			storagePrefix:            "",
+			flagAllowLegacyPlanFiles: true,
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			flagApplyTimeout:         time.Millisecond,
+			planExitCode:             "2",
+			terraformClient:          &hangingApplyClient{MockTerraformClient: terraformMock},
+			err:                      "failed to run Guardian apply: failed to apply: terraform apply timed out after 1ms",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusFailure, &platform.StatusParams{
+						HasDiff:   true,
+						Dir:       "testdir",
+						Operation: "apply",
+						Message:   "> ⚠️ **terraform apply timed out after 1ms.** It was interrupted and exited, releasing the state lock.",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:      "rejects_undeclared_environment",
+			directory: "testdata/environments",
+
+			storagePrefix:            "",
+			flagAllowLegacyPlanFiles: true,
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			terraformClient:          terraformMock,
+			err:                      `failed to run Guardian apply: failed to select environment: an environment must be selected, declared environments are ["dev"]`,
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "Status",
+					Params: []any{platform.StatusFailure, &platform.StatusParams{HasDiff: true, Dir: "testdata/environments", Operation: "apply"}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdata/environments/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdata/environments/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:      "success_destroy",
+			directory: "testdir",
+			deleted:   true,
+			extractFiles: map[string]string{
+				"testdir/main.tf": "",
+			},
+
+			storagePrefix:            "",
+			flagAllowLegacyPlanFiles: true,
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			planMetadata: map[string]string{
+				"plan_destroy_ref": "abc123",
+			},
+			terraformClient: terraformMock,
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusDestroy, &platform.StatusParams{
+						HasDiff:   true,
+						Details:   "terraform apply success",
+						Message:   "> ⚠️ **This directory was deleted, all of its resources were destroyed.**",
+						Dir:       "testdir",
+						Operation: "apply",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+			},
+			expStdout: "Destroying testdir using the configuration at abc123",
+		},
+		{
+			name:      "rejects_deleted_without_destroy_plan",
+			directory: "testdir",
+			deleted:   true,
+
+			storagePrefix:            "",
+			flagAllowLegacyPlanFiles: true,
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			terraformClient:          terraformMock,
+			err:                      `failed to verify guardian plan file: directory "testdir" was deleted, but the plan file was not created to destroy it`,
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusStalePlan, &platform.StatusParams{
+						Dir:          "testdir",
+						Operation:    "apply",
+						Message:      "The plan file does not match the changes being applied, please re-run plan for this directory.",
+						ErrorMessage: `directory "testdir" was deleted, but the plan file was not created to destroy it`,
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+			},
+		},
 		{
 			name:      "skips_no_diff",
 			directory: "testdir",
 
 			storagePrefix:            "",
+			flagAllowLegacyPlanFiles: true,
 			flagAllowLockfileChanges: true,
 			flagLockTimeout:          10 * time.Minute,
 			planExitCode:             "0",
@@ -142,6 +558,7 @@ func TestApply_Process(t *testing.T) {
 			directory: "testdir",
 
 			storagePrefix:            "",
+			flagAllowLegacyPlanFiles: true,
 			flagAllowLockfileChanges: true,
 			flagLockTimeout:          10 * time.Minute,
 			planExitCode:             "2",
@@ -170,29 +587,623 @@ func TestApply_Process(t *testing.T) {
 				},
 			},
 		},
-	}
-
-	for _, tc := range cases {
-		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
+		{
+			name:      "rejects_stale_entrypoint",
+			directory: "testdir",
 
-			mockStorageClient := &storage.MockStorageClient{
-				Metadata: map[string]string{
-					"plan_exit_code": tc.planExitCode,
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			planMetadata: map[string]string{
+				"plan_entrypoint": "otherdir",
+			},
+			terraformClient: terraformMock,
+			err:             `failed to verify guardian plan file: plan file was created for entrypoint "otherdir", expected "testdir"`,
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusStalePlan, &platform.StatusParams{
+						Dir:          "testdir",
+						Operation:    "apply",
+						Message:      "The plan file does not match the changes being applied, please re-run plan for this directory.",
+						ErrorMessage: `plan file was created for entrypoint "otherdir", expected "testdir"`,
+					}},
 				},
-			}
-			mockPlatformClient := &platform.MockPlatform{}
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:       "rejects_binary_mismatch",
+			directory:  "testdir",
+			binaryName: "tofu",
 
-			c := &ApplyCommand{
-				directory:                tc.directory,
-				childPath:                tc.directory,
-				planFilename:             "test-tfplan.binary",
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			planMetadata: map[string]string{
+				"plan_entrypoint":       "testdir",
+				"plan_terraform_binary": "terraform",
+			},
+			terraformClient: terraformMock,
+			err:             `failed to verify guardian plan file: plan file was created with "terraform", current binary is "tofu"`,
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusStalePlan, &platform.StatusParams{
+						Dir:          "testdir",
+						Operation:    "apply",
+						Message:      "The plan file does not match the changes being applied, please re-run plan for this directory.",
+						ErrorMessage: `plan file was created with "terraform", current binary is "tofu"`,
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:      "rejects_stale_commit",
+			directory: "testdata/entrypoint",
+
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			planMetadata:             testPlanMetadata(t, "testdata/entrypoint", "testdata/entrypoint"),
+			commitSHA:                "merge123",
+			changeRequestNumber:      1,
+			listChangeRequestsResp: &platform.ListChangeRequestsByCommitResponse{
+				PullRequests: []*platform.PullRequest{
+					{ID: 2, Number: 2, HeadSHA: "abc123"},
+					{ID: 1, Number: 1, HeadSHA: "def456"},
+				},
+			},
+			terraformClient: terraformMock,
+			err:             "failed to verify guardian plan file: plan file was created for commit abc123, latest change request commit is def456",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "ListChangeRequestsByCommit",
+					Params: []any{"merge123", (*platform.ListChangeRequestsByCommitOptions)(nil)},
+				},
+				{
+					Name: "Status",
+					Params: []any{platform.StatusStalePlan, &platform.StatusParams{
+						Dir:          "testdata/entrypoint",
+						Operation:    "apply",
+						Message:      "The plan file does not match the changes being applied, please re-run plan for this directory.",
+						ErrorMessage: "plan file was created for commit abc123, latest change request commit is def456",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:      "accepts_matching_commit",
+			directory: "testdata/entrypoint",
+
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			planMetadata:             testPlanMetadata(t, "testdata/entrypoint", "testdata/entrypoint"),
+			commitSHA:                "merge123",
+			changeRequestNumber:      1,
+			listChangeRequestsResp: &platform.ListChangeRequestsByCommitResponse{
+				PullRequests: []*platform.PullRequest{{ID: 1, Number: 1, HeadSHA: "abc123"}},
+			},
+			terraformClient: terraformMock,
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "ListChangeRequestsByCommit",
+					Params: []any{"merge123", (*platform.ListChangeRequestsByCommitOptions)(nil)},
+				},
+				{
+					Name:   "Status",
+					Params: []any{platform.StatusSuccess, &platform.StatusParams{HasDiff: true, Details: "terraform apply success", Dir: "testdata/entrypoint", Operation: "apply"}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:      "rejects_commit_without_change_request",
+			directory: "testdata/entrypoint",
+
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			planMetadata:             testPlanMetadata(t, "testdata/entrypoint", "testdata/entrypoint"),
+			commitSHA:                "merge123",
+			changeRequestNumber:      1,
+			listChangeRequestsResp: &platform.ListChangeRequestsByCommitResponse{
+				PullRequests: []*platform.PullRequest{{ID: 2, Number: 2, HeadSHA: "abc123"}},
+			},
+			terraformClient: terraformMock,
+			err:             "failed to verify guardian plan file: change request #1 was not found for commit merge123",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "ListChangeRequestsByCommit",
+					Params: []any{"merge123", (*platform.ListChangeRequestsByCommitOptions)(nil)},
+				},
+				{
+					Name: "Status",
+					Params: []any{platform.StatusStalePlan, &platform.StatusParams{
+						Dir:          "testdata/entrypoint",
+						Operation:    "apply",
+						Message:      "The plan file does not match the changes being applied, please re-run plan for this directory.",
+						ErrorMessage: "change request #1 was not found for commit merge123",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:      "rejects_change_request_without_head_commit",
+			directory: "testdata/entrypoint",
+
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			planMetadata:             testPlanMetadata(t, "testdata/entrypoint", "testdata/entrypoint"),
+			commitSHA:                "merge123",
+			changeRequestNumber:      1,
+			listChangeRequestsResp: &platform.ListChangeRequestsByCommitResponse{
+				PullRequests: []*platform.PullRequest{{ID: 1, Number: 1}},
+			},
+			terraformClient: terraformMock,
+			err:             "failed to verify guardian plan file: failed to determine the head commit of change request #1",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "ListChangeRequestsByCommit",
+					Params: []any{"merge123", (*platform.ListChangeRequestsByCommitOptions)(nil)},
+				},
+				{
+					Name: "Status",
+					Params: []any{platform.StatusStalePlan, &platform.StatusParams{
+						Dir:          "testdata/entrypoint",
+						Operation:    "apply",
+						Message:      "The plan file does not match the changes being applied, please re-run plan for this directory.",
+						ErrorMessage: "failed to determine the head commit of change request #1",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:      "rejects_missing_commit_sha",
+			directory: "testdata/entrypoint",
+
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			planMetadata:             testPlanMetadata(t, "testdata/entrypoint", "testdata/entrypoint"),
+			terraformClient:          terraformMock,
+			err:                      "failed to verify guardian plan file: commit sha is required to verify the plan file commit",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusStalePlan, &platform.StatusParams{
+						Dir:          "testdata/entrypoint",
+						Operation:    "apply",
+						Message:      "The plan file does not match the changes being applied, please re-run plan for this directory.",
+						ErrorMessage: "commit sha is required to verify the plan file commit",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:      "rejects_missing_metadata",
+			directory: "testdata/entrypoint",
+
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			planMetadata: map[string]string{
+				"plan_entrypoint": "testdata/entrypoint",
+				"plan_commit_sha": "abc123",
+			},
+			terraformClient: terraformMock,
+			err:             "failed to verify guardian plan file: plan file is missing \"plan_terraform_version\" metadata",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusStalePlan, &platform.StatusParams{
+						Dir:          "testdata/entrypoint",
+						Operation:    "apply",
+						Message:      "The plan file does not match the changes being applied, please re-run plan for this directory.",
+						ErrorMessage: "plan file is missing \"plan_terraform_version\" metadata",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:      "rejects_legacy_plan_file",
+			directory: "testdata/entrypoint",
+
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			terraformClient:          terraformMock,
+			err:                      "failed to verify guardian plan file: plan file was created by an older version of Guardian and cannot be verified, re-run plan or set -allow-legacy-plan-files to apply it",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusStalePlan, &platform.StatusParams{
+						Dir:          "testdata/entrypoint",
+						Operation:    "apply",
+						Message:      "The plan file does not match the changes being applied, please re-run plan for this directory.",
+						ErrorMessage: "plan file was created by an older version of Guardian and cannot be verified, re-run plan or set -allow-legacy-plan-files to apply it",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdata/entrypoint/test-tfplan.binary",
+					},
+				},
+			},
+		},
+		{
+			name:      "success_with_apply_lock",
+			directory: "testdir",
+
+			storagePrefix:            "",
+			flagAllowLegacyPlanFiles: true,
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			planMetadata: map[string]string{
+				"plan_lock_serial": "2",
+			},
+			commitSHA: "merge123",
+			listChangeRequestsResp: &platform.ListChangeRequestsByCommitResponse{
+				PullRequests: []*platform.PullRequest{{ID: 7, Number: 7}},
+			},
+			applyLock:       `{"entrypoint":"testdir","serial":2,"last_holder":4}`,
+			terraformClient: terraformMock,
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "ListChangeRequestsByCommit",
+					Params: []any{"merge123", (*platform.ListChangeRequestsByCommitOptions)(nil)},
+				},
+				{
+					Name:   "Status",
+					Params: []any{platform.StatusSuccess, &platform.StatusParams{HasDiff: true, Details: "terraform apply success", Dir: "testdir", Operation: "apply"}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+			},
+			expApplyLock: &locks.Lock{
+				Entrypoint: "testdir",
+				Serial:     3,
+				LastHolder: 7,
+			},
+		},
+		{
+			name:      "rejects_locked_entrypoint",
+			directory: "testdir",
+
+			storagePrefix:            "",
+			flagAllowLegacyPlanFiles: true,
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			planMetadata: map[string]string{
+				"plan_lock_serial": "2",
+			},
+			commitSHA: "merge123",
+			listChangeRequestsResp: &platform.ListChangeRequestsByCommitResponse{
+				PullRequests: []*platform.PullRequest{{ID: 7, Number: 7}},
+			},
+			applyLock:       `{"entrypoint":"testdir","holder":4,"expires_at":"2100-01-01T00:00:00Z","serial":2}`,
+			terraformClient: terraformMock,
+			err:             "failed to acquire apply lock: entrypoint is locked by change request #4",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "ListChangeRequestsByCommit",
+					Params: []any{"merge123", (*platform.ListChangeRequestsByCommitOptions)(nil)},
+				},
+				{
+					Name: "Status",
+					Params: []any{platform.StatusFailure, &platform.StatusParams{
+						Dir:          "testdir",
+						Operation:    "apply",
+						Message:      "This directory is locked by #4 while it is being applied, until 2100-01-01T00:00:00Z at the latest. Please re-run plan for this directory once the apply has completed.",
+						ErrorMessage: "entrypoint is locked by change request #4",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+			},
+			expApplyLock: &locks.Lock{
+				Entrypoint: "testdir",
+				Holder:     4,
+				Serial:     2,
+			},
+		},
+		{
+			name:      "rejects_stale_lock_serial",
+			directory: "testdir",
+
+			storagePrefix:            "",
+			flagAllowLegacyPlanFiles: true,
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			planMetadata: map[string]string{
+				"plan_lock_serial": "1",
+			},
+			commitSHA: "merge123",
+			listChangeRequestsResp: &platform.ListChangeRequestsByCommitResponse{
+				PullRequests: []*platform.PullRequest{{ID: 7, Number: 7}},
+			},
+			applyLock:       `{"entrypoint":"testdir","serial":2,"last_holder":4}`,
+			terraformClient: terraformMock,
+			err:             "failed to acquire apply lock: entrypoint was applied since the plan was created: plan serial 1, current serial 2",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "ListChangeRequestsByCommit",
+					Params: []any{"merge123", (*platform.ListChangeRequestsByCommitOptions)(nil)},
+				},
+				{
+					Name: "Status",
+					Params: []any{platform.StatusStalePlan, &platform.StatusParams{
+						Dir:          "testdir",
+						Operation:    "apply",
+						Message:      "This directory was applied by #4 after the plan was created, please re-run plan for this directory.",
+						ErrorMessage: "entrypoint was applied since the plan was created: plan serial 1, current serial 2",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+				{
+					Name: "DeleteObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+			},
+			expApplyLock: &locks.Lock{
+				Entrypoint: "testdir",
+				Serial:     2,
+				LastHolder: 4,
+			},
+		},
+		{
+			name:      "rejects_invalid_signature",
+			directory: "testdir",
+
+			storagePrefix:            "",
+			flagAllowLegacyPlanFiles: true,
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			planExitCode:             "2",
+			downloadErr:              fmt.Errorf("failed to open envelope: %w", storage.ErrInvalidSignature),
+			terraformClient:          terraformMock,
+			err:                      "failed to download guardian plan file: failed to download object: failed to open envelope: invalid envelope signature",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusFailure, &platform.StatusParams{
+						Dir:          "testdir",
+						Operation:    "apply",
+						Message:      "The plan file signature could not be verified, please re-run plan for this directory.",
+						ErrorMessage: "failed to download object: failed to open envelope: invalid envelope signature",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "GetObject",
+					Params: []any{
+						"testdir/test-tfplan.binary",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			metadata := map[string]string{
+				"plan_exit_code": tc.planExitCode,
+			}
+			for k, v := range tc.planMetadata {
+				metadata[k] = v
+			}
+
+			mockStorageClient := &storage.MockStorageClient{
+				Metadata:    metadata,
+				DownloadErr: tc.downloadErr,
+			}
+			mockPlatformClient := &platform.MockPlatform{
+				ListChangeRequestsByCommitResp: tc.listChangeRequestsResp,
+			}
+
+			c := &ApplyCommand{
+				directory:    tc.directory,
+				childPath:    tc.directory,
+				planFilename: "test-tfplan.binary",
+				platformConfig: platform.Config{
+					Type:   platform.TypeGitHub,
+					GitHub: github.Config{GitHubSHA: tc.commitSHA, GitHubPullRequestNumber: tc.changeRequestNumber},
+				},
 				storagePrefix:            tc.storagePrefix,
 				flagAllowLockfileChanges: tc.flagAllowLockfileChanges,
+				flagAllowLegacyPlanFiles: tc.flagAllowLegacyPlanFiles,
 				flagLockTimeout:          tc.flagLockTimeout,
+				flagApplyTimeout:         tc.flagApplyTimeout,
 				storageClient:            mockStorageClient,
 				terraformClient:          tc.terraformClient,
 				platformClient:           mockPlatformClient,
+				deleted:                  tc.deleted,
+				flagEnvironment:          tc.flagEnvironment,
+				binaryName:               tc.binaryName,
+				gitClient:                &git.MockGitClient{ExtractFiles: tc.extractFiles},
+				newTerraformClient: func(dir string) terraform.Terraform {
+					return tc.terraformClient
+				},
+			}
+
+			if tc.applyLock != "" {
+				sc, err := storage.NewFilesystemStorage(ctx, t.TempDir())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := sc.CreateObject(ctx, path.Join("guardian-locks/owner/repo", tc.directory, "apply.lock"), []byte(tc.applyLock)); err != nil {
+					t.Fatal(err)
+				}
+				c.lockClient = locks.NewClient(sc, "owner/repo", time.Hour)
 			}
 
 			_, stdout, stderr := c.Pipe()
@@ -202,6 +1213,21 @@ func TestApply_Process(t *testing.T) {
 				t.Error(diff)
 			}
 
+			if c.lockClient != nil {
+				got, err := c.lockClient.Get(ctx, tc.directory)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				opts := []cmp.Option{
+					cmpopts.IgnoreUnexported(locks.Lock{}),
+					cmpopts.IgnoreFields(locks.Lock{}, "AcquiredAt", "ExpiresAt", "ReleasedAt"),
+				}
+				if diff := cmp.Diff(got, tc.expApplyLock, opts...); diff != "" {
+					t.Errorf("apply lock not as expected; (-got,+want): %s", diff)
+				}
+			}
+
 			if diff := cmp.Diff(mockPlatformClient.Reqs, tc.expPlatformClientReqs); diff != "" {
 				t.Errorf("Platform calls not as expected; (-got,+want): %s", diff)
 			}
@@ -0,0 +1,131 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/abcxyz/guardian/pkg/storage"
+	"github.com/abcxyz/guardian/pkg/terraform"
+	"github.com/abcxyz/guardian/pkg/util"
+)
+
+const (
+	// stateDiffFilename is the name of the state diff artifact, stored next to
+	// the plan file of the entrypoint.
+	stateDiffFilename = "state-diff.json"
+
+	// maxStateDiffRows is the maximum number of resources or outputs listed in
+	// the state diff.
+	maxStateDiffRows = 50
+
+	// maxOutputValueLength is the maximum length of an output value rendered in
+	// the state diff, longer values are truncated.
+	maxOutputValueLength = 100
+)
+
+var outputActionText = map[string]string{
+	terraform.ActionCreate: "🟩 create",
+	terraform.ActionUpdate: "🟨 update",
+	terraform.ActionDelete: "🟥 delete",
+}
+
+// saveStateDiff uploads the state diff of the apply as a JSON artifact to the
+// configured Guardian storage client, replacing the diff of the previous apply.
+func (c *ApplyCommand) saveStateDiff(ctx context.Context, d *terraform.StateDiff) error {
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state diff: %w", err)
+	}
+
+	objectPath := path.Join(c.storagePrefix, c.entrypoint(), stateDiffFilename)
+
+	c.Outf("State diff path: %s %s", c.storageClient.Parent(), objectPath)
+
+	if err := c.storageClient.CreateObject(ctx, objectPath, b,
+		storage.WithContentType("application/json"),
+		storage.WithAllowOverwrite(true),
+	); err != nil {
+		return fmt.Errorf("failed to save state diff: %w", err)
+	}
+	return nil
+}
+
+// stateDiffMessage renders the state diff as markdown for the status comment,
+// warning when the resources that changed differ from the plan.
+func stateDiffMessage(d *terraform.StateDiff) string {
+	var b strings.Builder
+
+	if d.HasChanges() {
+		fmt.Fprintf(&b, "**Applied changes**\n\n")
+		fmt.Fprintf(&b, "| Created | Updated | Deleted |\n")
+		fmt.Fprintf(&b, "| ---: | ---: | ---: |\n")
+		fmt.Fprintf(&b, "| %d | %d | %d |", len(d.Created), len(d.Updated), len(d.Deleted))
+	}
+
+	if len(d.Outputs) > 0 {
+		fmt.Fprintf(&b, "\n\n**Outputs changed**\n\n")
+		fmt.Fprintf(&b, "| Output | Action | Before | After |\n")
+		fmt.Fprintf(&b, "| --- | --- | --- | --- |")
+		for i, o := range d.Outputs {
+			if i == maxStateDiffRows {
+				fmt.Fprintf(&b, "\n| _and %d more_ | | | |", len(d.Outputs)-i)
+				break
+			}
+			fmt.Fprintf(&b, "\n| `%s` | %s | %s | %s |", o.Name, outputActionText[o.Action],
+				outputValueText(o, o.Before, o.Action != terraform.ActionCreate),
+				outputValueText(o, o.After, o.Action != terraform.ActionDelete))
+		}
+	}
+
+	if !d.MatchesPlan() {
+		fmt.Fprintf(&b, "\n\n> ⚠️ **The applied changes differ from the plan.** "+
+			"This can happen when the plan has unknown values, or a provider changes resources it did not plan to change.\n>")
+		if len(d.Unplanned) > 0 {
+			fmt.Fprintf(&b, "\n> - Changed without a planned change: %s", util.CodeList(d.Unplanned, maxStateDiffRows))
+		}
+		if len(d.Unapplied) > 0 {
+			fmt.Fprintf(&b, "\n> - Planned but not changed: %s", util.CodeList(d.Unapplied, maxStateDiffRows))
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// outputValueText renders an output value for the state diff table, or a
+// placeholder if the value is sensitive or does not exist.
+func outputValueText(o *terraform.OutputChange, v any, exists bool) string {
+	if !exists {
+		return ""
+	}
+	if o.Sensitive {
+		return "_(sensitive)_"
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "_(unknown)_"
+	}
+
+	s := string(b)
+	if r := []rune(s); len(r) > maxOutputValueLength {
+		s = string(r[:maxOutputValueLength]) + "…"
+	}
+	return "`" + strings.ReplaceAll(s, "|", `\|`) + "`"
+}
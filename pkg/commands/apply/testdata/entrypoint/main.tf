@@ -0,0 +1,8 @@
+terraform {
+  backend "gcs" {
+    bucket = "my-bucket"
+    prefix = "entrypoint"
+  }
+}
+
+resource "null_resource" "entrypoint" {}
@@ -22,21 +22,32 @@ import (
 	"flag"
 	"fmt"
 	"io/fs"
+	"os"
+	"path/filepath"
 	"slices"
 
 	"golang.org/x/exp/maps"
 
 	"github.com/abcxyz/guardian/internal/metricswrap"
+	"github.com/abcxyz/guardian/pkg/environments"
 	"github.com/abcxyz/guardian/pkg/git"
 	"github.com/abcxyz/guardian/pkg/platform"
 	"github.com/abcxyz/guardian/pkg/terraform"
 	"github.com/abcxyz/guardian/pkg/util"
 	"github.com/abcxyz/pkg/cli"
 	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/pointer"
 )
 
 var _ cli.Command = (*EntrypointsCommand)(nil)
 
+// entrypointEnvironment is an item of the output when environments are
+// enabled, the environment is empty for entrypoints without environments.
+type entrypointEnvironment struct {
+	Dir         string `json:"dir"`
+	Environment string `json:"environment,omitempty"`
+}
+
 type EntrypointsCommand struct {
 	cli.BaseCommand
 
@@ -47,12 +58,18 @@ type EntrypointsCommand struct {
 	flagDestRef                 string
 	flagSourceRef               string
 	flagDetectChanges           bool
+	flagDetectDeleted           bool
+	flagEnvironments            bool
 	flagFailUnresolvableModules bool
 	flagMaxDepth                int
 	flagSkipReporting           bool
 
 	parsedFlagMaxDepth *int
 
+	// deletedEnvironments are the environments of deleted entrypoints, keyed by
+	// their absolute path, as their config only exists at the source ref.
+	deletedEnvironments map[string][]string
+
 	platformClient platform.Platform
 
 	newGitClient func(ctx context.Context, dir string) git.Git
@@ -110,6 +127,18 @@ func (c *EntrypointsCommand) Flags() *cli.FlagSet {
 		Usage:  "Detect file changes, including all local module dependencies, and run for all entrypoint directories.",
 	})
 
+	f.BoolVar(&cli.BoolVar{
+		Name:   "detect-deleted",
+		Target: &c.flagDetectDeleted,
+		Usage:  "Include entrypoint directories deleted by the changes, so their resources can be planned for destruction. Requires detect-changes.",
+	})
+
+	f.BoolVar(&cli.BoolVar{
+		Name:   "environments",
+		Target: &c.flagEnvironments,
+		Usage:  `Output one item per environment declared in the guardian.hcl file of each entrypoint directory, as objects with "dir" and "environment" keys.`,
+	})
+
 	f.BoolVar(&cli.BoolVar{
 		Name:    "fail-unresolvable-modules",
 		Target:  &c.flagFailUnresolvableModules,
@@ -140,6 +169,10 @@ func (c *EntrypointsCommand) Flags() *cli.FlagSet {
 			merr = errors.Join(merr, fmt.Errorf("invalid flag: source-ref and dest-ref are required to detect changes, to ignore changes set the detect-changes flag"))
 		}
 
+		if c.flagDetectDeleted && !c.flagDetectChanges {
+			merr = errors.Join(merr, fmt.Errorf("invalid flag: detect-changes is required to detect deleted entrypoints"))
+		}
+
 		if c.flagMaxDepth != -1 {
 			c.parsedFlagMaxDepth = &c.flagMaxDepth
 		}
@@ -241,9 +274,8 @@ func (c *EntrypointsCommand) Process(ctx context.Context) error {
 	// sort them for consistent results
 	slices.Sort(modifiedEntrypoints)
 
-	results := modifiedEntrypoints
-
-	if err := c.writeOutput(cwd, results); err != nil {
+	results, err := c.writeOutput(cwd, modifiedEntrypoints)
+	if err != nil {
 		return fmt.Errorf("failed to write output: %w", err)
 	}
 
@@ -253,7 +285,7 @@ func (c *EntrypointsCommand) Process(ctx context.Context) error {
 
 	if err := c.platformClient.ReportEntrypointsSummary(ctx, &platform.EntrypointsSummaryParams{
 		Message: "Guardian will run for the following directories",
-		Dirs:    modifiedEntrypoints,
+		Dirs:    results,
 	}); err != nil {
 		return fmt.Errorf("failed to create report: %w", err)
 	}
@@ -312,26 +344,145 @@ func (c *EntrypointsCommand) detectEntrypointChanges(ctx context.Context, dir st
 		}
 	}
 
+	if c.flagDetectDeleted {
+		deletedDirs, err := gitClient.DiffDeletedDirsAbs(ctx, c.flagSourceRef, c.flagDestRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find git diff deleted directories: %w", err)
+		}
+		logger.DebugContext(ctx, "git diff deleted directories", "directories", deletedDirs)
+
+		deletedEntrypoints, err := c.deletedEntrypoints(ctx, gitClient, dir, deletedDirs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find deleted entrypoints: %w", err)
+		}
+		for _, entrypoint := range deletedEntrypoints {
+			modifiedEntrypoints[entrypoint] = struct{}{}
+		}
+	}
+
 	modifiedDirs := maps.Keys(modifiedEntrypoints)
 
 	return modifiedDirs, nil
 }
 
-// writeOutput writes the command output.
-func (c *EntrypointsCommand) writeOutput(cwd string, results []string) error {
-	// convert to child path for output
-	// using absolute path creates an ugly github workflow name
-	for k, dir := range results {
+// deletedEntrypoints returns the deleted directories that were entrypoints at
+// the source ref, so their resources can be planned for destruction.
+func (c *EntrypointsCommand) deletedEntrypoints(ctx context.Context, gitClient git.Git, dir string, deletedDirs []string) (_ []string, outErr error) {
+	if len(deletedDirs) == 0 {
+		return nil, nil
+	}
+
+	tempDir, err := os.MkdirTemp("", "guardian-entrypoints-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			outErr = errors.Join(outErr, fmt.Errorf("failed to delete temporary directory: %w", err))
+		}
+	}()
+
+	if err := gitClient.ExtractRef(ctx, c.flagSourceRef, tempDir); err != nil {
+		return nil, fmt.Errorf("failed to extract source ref: %w", err)
+	}
+
+	entrypoints := make([]string, 0, len(deletedDirs))
+	for _, deletedDir := range deletedDirs {
+		rel, err := filepath.Rel(dir, deletedDir)
+		if err != nil || !filepath.IsLocal(rel) {
+			continue
+		}
+
+		// only the deleted directory is checked, its subdirectories are reported
+		// separately if they were deleted
+		found, err := terraform.GetEntrypointDirectories(filepath.Join(tempDir, rel), pointer.To(0))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to find terraform directories: %w", err)
+		}
+
+		if len(found) == 0 {
+			continue
+		}
+		entrypoints = append(entrypoints, deletedDir)
+
+		if c.flagEnvironments {
+			config, err := environments.Load(filepath.Join(tempDir, rel))
+			if err != nil {
+				return nil, fmt.Errorf("failed to load environments for %s: %w", rel, err)
+			}
+			if c.deletedEnvironments == nil {
+				c.deletedEnvironments = make(map[string][]string)
+			}
+			c.deletedEnvironments[deletedDir] = config.Names()
+		}
+	}
+
+	logging.FromContext(ctx).DebugContext(ctx, "calculated deleted entrypoints", "entrypoints", entrypoints)
+
+	return entrypoints, nil
+}
+
+// writeOutput writes the command output and returns the entrypoints it
+// contains, qualified with their environment when environments are enabled.
+func (c *EntrypointsCommand) writeOutput(cwd string, dirs []string) ([]string, error) {
+	results := make([]string, 0, len(dirs))
+	items := make([]*entrypointEnvironment, 0, len(dirs))
+
+	for _, dir := range dirs {
+		// convert to child path for output
+		// using absolute path creates an ugly github workflow name
 		childPath, err := util.ChildPath(cwd, dir)
 		if err != nil {
-			return fmt.Errorf("failed to get child path for [%s]: %w", dir, err)
+			return nil, fmt.Errorf("failed to get child path for [%s]: %w", dir, err)
+		}
+
+		if !c.flagEnvironments {
+			results = append(results, childPath)
+			continue
+		}
+
+		names, err := c.environmentNames(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get environments for [%s]: %w", childPath, err)
+		}
+
+		if len(names) == 0 {
+			results = append(results, childPath)
+			items = append(items, &entrypointEnvironment{Dir: childPath})
+			continue
+		}
+
+		for _, name := range names {
+			results = append(results, environments.Qualify(childPath, name))
+			items = append(items, &entrypointEnvironment{Dir: childPath, Environment: name})
 		}
-		results[k] = childPath
 	}
 
-	if err := json.NewEncoder(c.Stdout()).Encode(results); err != nil {
-		return fmt.Errorf("failed to create json string: %w", err)
+	var output any = results
+	if c.flagEnvironments {
+		output = items
 	}
 
-	return nil
+	if err := json.NewEncoder(c.Stdout()).Encode(output); err != nil {
+		return nil, fmt.Errorf("failed to create json string: %w", err)
+	}
+
+	return results, nil
+}
+
+// environmentNames returns the names of the environments declared for an
+// entrypoint directory.
+func (c *EntrypointsCommand) environmentNames(dir string) ([]string, error) {
+	if names, ok := c.deletedEnvironments[dir]; ok {
+		return names, nil
+	}
+
+	config, err := environments.Load(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load environments: %w", err)
+	}
+	return config.Names(), nil
 }
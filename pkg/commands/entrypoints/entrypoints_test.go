@@ -45,6 +45,8 @@ func TestEntrypointsProcess(t *testing.T) {
 		flagDestRef       string
 		flagSourceRef     string
 		flagDetectChanges bool
+		flagDetectDeleted bool
+		flagEnvironments  bool
 		flagMaxDepth      int
 		newGitClient      func(ctx context.Context, dir string) git.Git
 		platformClient    *platform.MockPlatform
@@ -159,6 +161,60 @@ func TestEntrypointsProcess(t *testing.T) {
 			},
 			expStdout: `["testdata/entrypoint1/project1","testdata/entrypoint1/project2"]`,
 		},
+		{
+			name:              "success_deleted_entrypoint",
+			flagDir:           []string{"testdata/entrypoint1"},
+			flagDestRef:       "main",
+			flagSourceRef:     "ldap/feature",
+			flagDetectChanges: true,
+			flagDetectDeleted: true,
+			newGitClient: func(ctx context.Context, dir string) git.Git {
+				return &git.MockGitClient{
+					DiffResp: []string{
+						filepath.Join(cwd, "testdata/entrypoint1/project1"),
+					},
+					DeletedDiffResp: []string{
+						filepath.Join(cwd, "testdata/entrypoint1/modules/bucket"),
+						filepath.Join(cwd, "testdata/entrypoint1/project3"),
+					},
+					ExtractFiles: map[string]string{
+						"modules/bucket/main.tf": `resource "google_storage_bucket" "bucket" {}`,
+						"project3/main.tf":       "terraform {\n  backend \"local\" {}\n}\n",
+					},
+				}
+			},
+			expStdout: `["testdata/entrypoint1/project1","testdata/entrypoint1/project3"]`,
+		},
+		{
+			name:             "success_environments",
+			flagDir:          []string{"testdata/entrypoint2"},
+			flagEnvironments: true,
+			expStdout:        `[{"dir":"testdata/entrypoint2/project3","environment":"dev"},{"dir":"testdata/entrypoint2/project3","environment":"prod"},{"dir":"testdata/entrypoint2/project4"}]`,
+		},
+		{
+			name:              "success_deleted_entrypoint_environments",
+			flagDir:           []string{"testdata/entrypoint1"},
+			flagDestRef:       "main",
+			flagSourceRef:     "ldap/feature",
+			flagDetectChanges: true,
+			flagDetectDeleted: true,
+			flagEnvironments:  true,
+			newGitClient: func(ctx context.Context, dir string) git.Git {
+				return &git.MockGitClient{
+					DiffResp: []string{
+						filepath.Join(cwd, "testdata/entrypoint1/project1"),
+					},
+					DeletedDiffResp: []string{
+						filepath.Join(cwd, "testdata/entrypoint1/project3"),
+					},
+					ExtractFiles: map[string]string{
+						"project3/main.tf":      "terraform {\n  backend \"local\" {}\n}\n",
+						"project3/guardian.hcl": "environment \"dev\" {}\n",
+					},
+				}
+			},
+			expStdout: `[{"dir":"testdata/entrypoint1/project1"},{"dir":"testdata/entrypoint1/project3","environment":"dev"}]`,
+		},
 		{
 			name:              "no_changes_without_add_entrypoint",
 			flagDir:           []string{"testdata/entrypoint1"},
@@ -231,6 +287,23 @@ func TestEntrypointsProcess(t *testing.T) {
 			},
 			err: "failed to find git diff directories: failed to run git diff",
 		},
+		{
+			name:              "errors_deleted_dirs",
+			flagDir:           []string{"testdata/entrypoint1"},
+			flagDestRef:       "main",
+			flagSourceRef:     "ldap/feature",
+			flagDetectChanges: true,
+			flagDetectDeleted: true,
+			newGitClient: func(ctx context.Context, dir string) git.Git {
+				return &git.MockGitClient{
+					DeletedDiffResp: []string{
+						filepath.Join(cwd, "testdata/entrypoint1/project3"),
+					},
+					ExtractErr: fmt.Errorf("failed to run git archive"),
+				}
+			},
+			err: "failed to find deleted entrypoints: failed to extract source ref: failed to run git archive",
+		},
 	}
 
 	for _, tc := range cases {
@@ -245,6 +318,8 @@ func TestEntrypointsProcess(t *testing.T) {
 				flagDestRef:       tc.flagDestRef,
 				flagSourceRef:     tc.flagSourceRef,
 				flagDetectChanges: tc.flagDetectChanges,
+				flagDetectDeleted: tc.flagDetectDeleted,
+				flagEnvironments:  tc.flagEnvironments,
 				flagMaxDepth:      tc.flagMaxDepth,
 				platformClient:    mockPlatformClient,
 				newGitClient:      tc.newGitClient,
@@ -280,6 +355,11 @@ func TestAfterParse(t *testing.T) {
 			args: []string{"-detect-changes", "-max-depth=0"},
 			err:  "invalid flag: source-ref and dest-ref are required to detect changes, to ignore changes set the detect-changes flag",
 		},
+		{
+			name: "validate_detect_deleted",
+			args: []string{"-detect-deleted"},
+			err:  "invalid flag: detect-changes is required to detect deleted entrypoints",
+		},
 	}
 
 	for _, tc := range cases {
@@ -0,0 +1,70 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package locks provides the commands to inspect and release apply locks.
+package locks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/posener/complete/v2"
+
+	"github.com/abcxyz/guardian/pkg/locks"
+	"github.com/abcxyz/guardian/pkg/platform"
+	"github.com/abcxyz/guardian/pkg/storage"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// LocksFlags are the flags shared by the locks commands.
+type LocksFlags struct {
+	Storage string
+}
+
+func (l *LocksFlags) Register(set *cli.FlagSet) {
+	f := set.NewSection("LOCKS OPTIONS")
+
+	f.StringVar(&cli.StringVar{
+		Name:    "storage",
+		Target:  &l.Storage,
+		Example: "gcs://my-guardian-state-bucket",
+		Usage:   fmt.Sprintf("The storage the apply locks are saved in, the same as used for Guardian plan files. Valid values are %q.", storage.SortedStorageTypes),
+		Predict: complete.PredictFunc(func(prefix string) []string {
+			return storage.SortedStorageTypes
+		}),
+	})
+
+	set.AfterParse(func(existingError error) (merr error) {
+		if l.Storage == "" {
+			merr = errors.Join(merr, fmt.Errorf("missing flag: storage is required"))
+		}
+		return merr
+	})
+}
+
+// newLockClient creates a lock client for the repository of the platform
+// configuration, using the storage from the flags.
+func newLockClient(ctx context.Context, cfg *platform.Config, flags *LocksFlags) (*locks.Client, error) {
+	repository, err := cfg.Repository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine repository: %w", err)
+	}
+
+	sc, err := storage.Parse(ctx, flags.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+	return locks.NewClient(sc, repository, 0), nil
+}
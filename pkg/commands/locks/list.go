@@ -0,0 +1,120 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locks
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/abcxyz/guardian/internal/metricswrap"
+	"github.com/abcxyz/guardian/pkg/locks"
+	"github.com/abcxyz/guardian/pkg/platform"
+	"github.com/abcxyz/pkg/cli"
+)
+
+var _ cli.Command = (*ListCommand)(nil)
+
+// ListCommand lists the apply locks of all entrypoints in a repository.
+type ListCommand struct {
+	cli.BaseCommand
+
+	platformConfig platform.Config
+
+	LocksFlags
+
+	lockClient *locks.Client
+	now        func() time.Time
+}
+
+func (c *ListCommand) Desc() string {
+	return `List the apply locks of all directories`
+}
+
+func (c *ListCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options]
+
+	List the apply locks of all directories in the repository, including the
+	change request holding the lock and when its lease expires.
+`
+}
+
+func (c *ListCommand) Flags() *cli.FlagSet {
+	set := c.NewFlagSet()
+
+	c.platformConfig.RegisterFlags(set)
+	c.LocksFlags.Register(set)
+
+	return set
+}
+
+func (c *ListCommand) Run(ctx context.Context, args []string) error {
+	metricswrap.WriteMetric(ctx, "command_locks_list", 1)
+
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	parsedArgs := f.Args()
+	if len(parsedArgs) > 0 {
+		return flag.ErrHelp
+	}
+
+	lockClient, err := newLockClient(ctx, &c.platformConfig, &c.LocksFlags)
+	if err != nil {
+		return err
+	}
+	c.lockClient = lockClient
+	c.now = time.Now
+
+	return c.Process(ctx)
+}
+
+// Process handles the main logic for listing the apply locks.
+func (c *ListCommand) Process(ctx context.Context) error {
+	all, err := c.lockClient.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list apply locks: %w", err)
+	}
+
+	if len(all) == 0 {
+		c.Outf("No apply locks found")
+		return nil
+	}
+
+	now := c.now()
+	for _, l := range all {
+		switch {
+		case l.Held(now):
+			c.Outf("%s\theld by #%d (%s) until %s", l.Entrypoint, l.Holder, l.CommitSHA, formatTime(l.ExpiresAt))
+		case l.Expired(now):
+			c.Outf("%s\texpired, held by #%d (%s) until %s", l.Entrypoint, l.Holder, l.CommitSHA, formatTime(l.ExpiresAt))
+		case l.LastHolder > 0:
+			c.Outf("%s\treleased by #%d at %s", l.Entrypoint, l.LastHolder, formatTime(l.ReleasedAt))
+		default:
+			c.Outf("%s\treleased", l.Entrypoint)
+		}
+	}
+
+	return nil
+}
+
+// formatTime formats a lock time for output.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abcxyz/guardian/pkg/github"
+	"github.com/abcxyz/guardian/pkg/locks"
+	"github.com/abcxyz/guardian/pkg/platform"
+	"github.com/abcxyz/guardian/pkg/storage"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+var testNow = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// testLockClient returns a lock client using a filesystem storage client with
+// the given locks, keyed by entrypoint.
+func testLockClient(tb testing.TB, existing map[string]string) *locks.Client {
+	tb.Helper()
+
+	ctx := tb.Context()
+	sc, err := storage.NewFilesystemStorage(ctx, tb.TempDir())
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	for entrypoint, data := range existing {
+		if err := sc.CreateObject(ctx, "guardian-locks/owner/repo/"+entrypoint+"/apply.lock", []byte(data)); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	return locks.NewClient(sc, "owner/repo", time.Hour)
+}
+
+func TestList_Process(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(t.Context(), logging.TestLogger(t))
+
+	cases := []struct {
+		name      string
+		existing  map[string]string
+		expStdout string
+	}{
+		{
+			name:      "no_locks",
+			expStdout: "No apply locks found",
+		},
+		{
+			name: "locks",
+			existing: map[string]string{
+				"a": `{"entrypoint":"a","holder":4,"commit_sha":"abc","expires_at":"2025-01-02T04:00:00Z","serial":1}`,
+				"b": `{"entrypoint":"b","holder":5,"commit_sha":"def","expires_at":"2025-01-02T03:00:00Z","serial":1}`,
+				"c": `{"entrypoint":"c","serial":2,"last_holder":3,"released_at":"2025-01-01T00:00:00Z"}`,
+			},
+			expStdout: "a\theld by #4 (abc) until 2025-01-02T04:00:00Z\n" +
+				"b\texpired, held by #5 (def) until 2025-01-02T03:00:00Z\n" +
+				"c\treleased by #3 at 2025-01-01T00:00:00Z",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &ListCommand{
+				lockClient: testLockClient(t, tc.existing),
+				now:        func() time.Time { return testNow },
+			}
+
+			_, stdout, _ := c.Pipe()
+
+			if err := c.Process(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := strings.TrimSpace(stdout.String()), tc.expStdout; got != want {
+				t.Errorf("expected stdout\n\n%s\n\nto be\n\n%s\n\n", got, want)
+			}
+		})
+	}
+}
+
+func TestRelease_Process(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(t.Context(), logging.TestLogger(t))
+
+	cases := []struct {
+		name      string
+		existing  map[string]string
+		expStdout string
+		expSerial int64
+	}{
+		{
+			name: "held",
+			existing: map[string]string{
+				"a": `{"entrypoint":"a","holder":4,"commit_sha":"abc","expires_at":"2100-01-01T00:00:00Z","serial":1}`,
+			},
+			expStdout: "Released a, held by #4 (abc)",
+			expSerial: 2,
+		},
+		{
+			name: "not_held",
+			existing: map[string]string{
+				"a": `{"entrypoint":"a","serial":1}`,
+			},
+			expStdout: "a is not locked",
+			expSerial: 1,
+		},
+		{
+			name:      "never_locked",
+			expStdout: "a is not locked",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &ReleaseCommand{
+				entrypoint: "a",
+				lockClient: testLockClient(t, tc.existing),
+			}
+
+			_, stdout, _ := c.Pipe()
+
+			if err := c.Process(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := strings.TrimSpace(stdout.String()), tc.expStdout; got != want {
+				t.Errorf("expected stdout %q to be %q", got, want)
+			}
+
+			l, err := c.lockClient.Get(ctx, "a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if l.Holder != 0 {
+				t.Errorf("expected lock to be released, held by #%d", l.Holder)
+			}
+			if got, want := l.Serial, tc.expSerial; got != want {
+				t.Errorf("expected serial %d to be %d", got, want)
+			}
+		})
+	}
+}
+
+func TestNewLockClient(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		cfg    *platform.Config
+		expErr string
+	}{
+		{
+			name: "github",
+			cfg: &platform.Config{
+				Type:   platform.TypeGitHub,
+				GitHub: github.Config{GitHubOwner: "owner", GitHubRepo: "repo"},
+			},
+		},
+		{
+			name:   "local",
+			cfg:    &platform.Config{Type: platform.TypeLocal},
+			expErr: `repository is required for platform "local"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			flags := &LocksFlags{Storage: "file://" + t.TempDir()}
+
+			_, err := newLockClient(t.Context(), tc.cfg, flags)
+			if diff := testutil.DiffErrString(err, tc.expErr); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
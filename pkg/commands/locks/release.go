@@ -0,0 +1,104 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locks
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/abcxyz/guardian/internal/metricswrap"
+	"github.com/abcxyz/guardian/pkg/locks"
+	"github.com/abcxyz/guardian/pkg/platform"
+	"github.com/abcxyz/pkg/cli"
+)
+
+var _ cli.Command = (*ReleaseCommand)(nil)
+
+// ReleaseCommand releases the apply lock of an entrypoint, regardless of the
+// change request holding it.
+type ReleaseCommand struct {
+	cli.BaseCommand
+
+	platformConfig platform.Config
+
+	LocksFlags
+
+	entrypoint string
+
+	lockClient *locks.Client
+}
+
+func (c *ReleaseCommand) Desc() string {
+	return `Release the apply lock of a directory`
+}
+
+func (c *ReleaseCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options] <directory>
+
+	Release the apply lock of a directory, regardless of the change request
+	holding it. The directory is relative to the repository root. Plans created
+	before the lock is released must be re-run before they can be applied.
+`
+}
+
+func (c *ReleaseCommand) Flags() *cli.FlagSet {
+	set := c.NewFlagSet()
+
+	c.platformConfig.RegisterFlags(set)
+	c.LocksFlags.Register(set)
+
+	return set
+}
+
+func (c *ReleaseCommand) Run(ctx context.Context, args []string) error {
+	metricswrap.WriteMetric(ctx, "command_locks_release", 1)
+
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	parsedArgs := f.Args()
+	if len(parsedArgs) != 1 {
+		return flag.ErrHelp
+	}
+	c.entrypoint = parsedArgs[0]
+
+	lockClient, err := newLockClient(ctx, &c.platformConfig, &c.LocksFlags)
+	if err != nil {
+		return err
+	}
+	c.lockClient = lockClient
+
+	return c.Process(ctx)
+}
+
+// Process handles the main logic for releasing an apply lock.
+func (c *ReleaseCommand) Process(ctx context.Context) error {
+	l, err := c.lockClient.ForceRelease(ctx, c.entrypoint)
+	if err != nil {
+		return fmt.Errorf("failed to release apply lock: %w", err)
+	}
+
+	if l.Holder == 0 {
+		c.Outf("%s is not locked", l.Entrypoint)
+		return nil
+	}
+
+	c.Outf("Released %s, held by #%d (%s)", l.Entrypoint, l.Holder, l.CommitSHA)
+	return nil
+}
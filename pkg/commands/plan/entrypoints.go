@@ -0,0 +1,238 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/abcxyz/guardian/pkg/environments"
+	"github.com/abcxyz/guardian/pkg/terraform"
+	"github.com/abcxyz/guardian/pkg/util"
+	"github.com/abcxyz/pkg/workerpool"
+)
+
+// target is an entrypoint directory and environment to plan.
+type target struct {
+	dir         string
+	environment string
+}
+
+// entrypointItem is an entry of the output of guardian entrypoints, either a
+// directory or, when run with environments, a directory and environment.
+type entrypointItem struct {
+	Dir         string `json:"dir"`
+	Environment string `json:"environment"`
+}
+
+// targets returns the entrypoints to plan, from the entrypoints file and the
+// dir flags. The current working directory is planned if neither is set.
+func (c *PlanCommand) targets(cwd string) (_ []*target, outErr error) {
+	var targets []*target
+
+	if c.flagEntrypointsFile != "" {
+		r := c.Stdin()
+		if c.flagEntrypointsFile != "-" {
+			f, err := os.Open(c.flagEntrypointsFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open entrypoints file: %w", err)
+			}
+			defer func() {
+				if closeErr := f.Close(); closeErr != nil {
+					outErr = errors.Join(outErr, fmt.Errorf("failed to close entrypoints file: %w", closeErr))
+				}
+			}()
+			r = f
+		}
+
+		parsed, err := parseEntrypoints(r, c.flagEnvironment)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, parsed...)
+	}
+
+	for _, dir := range c.flagDirs {
+		targets = append(targets, &target{dir: dir, environment: c.flagEnvironment})
+	}
+
+	if len(targets) == 0 && c.flagEntrypointsFile == "" {
+		targets = append(targets, &target{dir: cwd, environment: c.flagEnvironment})
+	}
+
+	return targets, nil
+}
+
+// parseEntrypoints parses the JSON output of guardian entrypoints. Directories
+// listed without an environment use the default environment.
+func parseEntrypoints(r io.Reader, defaultEnvironment string) ([]*target, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, fmt.Errorf("failed to parse entrypoints: %w", err)
+	}
+
+	targets := make([]*target, 0, len(items))
+	for _, raw := range items {
+		var item entrypointItem
+		if err := json.Unmarshal(raw, &item.Dir); err != nil {
+			if err := json.Unmarshal(raw, &item); err != nil {
+				return nil, fmt.Errorf("failed to parse entrypoint %s: %w", raw, err)
+			}
+		}
+
+		if item.Dir == "" {
+			return nil, fmt.Errorf("entrypoint %s is missing a directory", raw)
+		}
+		if item.Environment == "" {
+			item.Environment = defaultEnvironment
+		}
+		targets = append(targets, &target{dir: item.Dir, environment: item.Environment})
+	}
+	return targets, nil
+}
+
+// planTargets plans multiple entrypoints concurrently, each reporting its own
+// status. Environments of the same directory share its Terraform working
+// directory, so they are planned one after the other. Terraform init is run
+// for one entrypoint at a time, as the shared provider plugin cache is not
+// safe for concurrent use.
+func (c *PlanCommand) planTargets(ctx context.Context, cwd string, targets []*target) (merr error) {
+	c.initMu = &sync.Mutex{}
+	c.nestOutputDir = true
+
+	c.pluginCacheDir = c.flagPluginCacheDir
+	if c.pluginCacheDir == "" {
+		dir, err := os.MkdirTemp("", "guardian-plugin-cache-*")
+		if err != nil {
+			return fmt.Errorf("failed to create plugin cache directory: %w", err)
+		}
+		defer func() {
+			if err := os.RemoveAll(dir); err != nil {
+				merr = errors.Join(merr, fmt.Errorf("failed to delete plugin cache directory: %w", err))
+			}
+		}()
+		c.pluginCacheDir = dir
+	} else if err := os.MkdirAll(c.pluginCacheDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create plugin cache directory: %w", err)
+	}
+
+	// group the targets by directory, keeping the order they were given in
+	var dirs []string
+	byDir := make(map[string][]*target, len(targets))
+	for _, t := range targets {
+		if _, ok := byDir[t.dir]; !ok {
+			dirs = append(dirs, t.dir)
+		}
+		byDir[t.dir] = append(byDir[t.dir], t)
+	}
+
+	var outMu sync.Mutex
+	results := make(map[string]error, len(targets))
+
+	w := workerpool.New[*workerpool.Void](&workerpool.Config{
+		Concurrency: c.flagConcurrency,
+	})
+	for _, dir := range dirs {
+		dirTargets := byDir[dir]
+		if err := w.Do(ctx, func() (*workerpool.Void, error) {
+			for _, t := range dirTargets {
+				var out bytes.Buffer
+				err := c.forTarget(t, &out).planDirectory(ctx, cwd)
+
+				outMu.Lock()
+				util.Headerf(c.Stdout(), "Entrypoint %s", environments.Qualify(t.dir, t.environment))
+				fmt.Fprint(c.Stdout(), out.String())
+				results[environments.Qualify(t.dir, t.environment)] = err
+				outMu.Unlock()
+			}
+			return nil, nil
+		}); err != nil {
+			return fmt.Errorf("failed to queue plan: %w", err)
+		}
+	}
+	if _, err := w.Done(ctx); err != nil {
+		return fmt.Errorf("failed to run plans: %w", err)
+	}
+
+	return c.summarizeTargets(results)
+}
+
+// forTarget returns a copy of the command planning the target, writing its
+// output to the writer.
+func (c *PlanCommand) forTarget(t *target, out io.Writer) *PlanCommand {
+	child := *c
+	child.FlagDir = t.dir
+	child.flagEnvironment = t.environment
+	child.directory = ""
+	child.childPath = ""
+	child.destroyRef = ""
+	child.applyLock = nil
+	child.planModifiers = nil
+	child.SetStdout(out)
+	child.SetStderr(out)
+	return &child
+}
+
+// terraformInit runs terraform init, one entrypoint at a time when entrypoints
+// share the provider plugin cache. The init timeout starts once it is the turn
+// of the entrypoint.
+func (c *PlanCommand) terraformInit(ctx context.Context, stdout, stderr io.Writer, opts *terraform.InitOptions) (int, error) {
+	if c.initMu != nil {
+		c.initMu.Lock()
+		defer c.initMu.Unlock()
+	}
+	return terraform.RunStep(ctx, c.binaryName, "init", c.FlagInitTimeout, func(ctx context.Context) (int, error) { //nolint:wrapcheck // Want passthrough
+		return c.terraformClient.Init(ctx, stdout, stderr, opts) //nolint:wrapcheck // Want passthrough
+	})
+}
+
+// summarizeTargets writes the result of each entrypoint and returns an error
+// if any failed.
+func (c *PlanCommand) summarizeTargets(results map[string]error) error {
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	util.Headerf(c.Stdout(), "Plan Results")
+
+	var failed []string
+	var merr error
+	for _, name := range names {
+		err := results[name]
+		if err != nil {
+			failed = append(failed, name)
+			merr = errors.Join(merr, fmt.Errorf("%s: %w", name, err))
+			c.Outf("%s: failure", name)
+			continue
+		}
+		c.Outf("%s: success", name)
+	}
+
+	if merr != nil {
+		return fmt.Errorf("failed to plan %d of %d entrypoints (%s): %w",
+			len(failed), len(results), strings.Join(failed, ", "), merr)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/guardian/pkg/platform"
+	"github.com/abcxyz/guardian/pkg/storage"
+	"github.com/abcxyz/guardian/pkg/terraform"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestParseEntrypoints(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name               string
+		input              string
+		defaultEnvironment string
+		exp                []*target
+		err                string
+	}{
+		{
+			name:  "directories",
+			input: `["terraform/a","terraform/b"]`,
+			exp: []*target{
+				{dir: "terraform/a"},
+				{dir: "terraform/b"},
+			},
+		},
+		{
+			name:               "directories_default_environment",
+			input:              `["terraform/a"]`,
+			defaultEnvironment: "dev",
+			exp: []*target{
+				{dir: "terraform/a", environment: "dev"},
+			},
+		},
+		{
+			name:  "environments",
+			input: `[{"dir":"terraform/a","environment":"dev"},{"dir":"terraform/a","environment":"prod"},{"dir":"terraform/b"}]`,
+			exp: []*target{
+				{dir: "terraform/a", environment: "dev"},
+				{dir: "terraform/a", environment: "prod"},
+				{dir: "terraform/b"},
+			},
+		},
+		{
+			name:  "empty",
+			input: `[]`,
+			exp:   []*target{},
+		},
+		{
+			name:  "missing_dir",
+			input: `[{"environment":"dev"}]`,
+			err:   `entrypoint {"environment":"dev"} is missing a directory`,
+		},
+		{
+			name:  "invalid",
+			input: `{"dir":"terraform/a"}`,
+			err:   "failed to parse entrypoints",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseEntrypoints(strings.NewReader(tc.input), tc.defaultEnvironment)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(got, tc.exp, cmp.AllowUnexported(target{})); diff != "" {
+				t.Errorf("targets not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestPlan_planTargets(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(t.Context(), logging.TestLogger(t))
+
+	cases := []struct {
+		name                  string
+		targets               []*target
+		terraformClient       *terraform.MockTerraformClient
+		err                   string
+		expPlatformClientReqs []*platform.Request
+		expStdout             string
+	}{
+		{
+			name: "success",
+			targets: []*target{
+				{dir: "testdata"},
+				{dir: "testdata/environments", environment: "dev"},
+			},
+			terraformClient: terraformDiffMock,
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "Status",
+					Params: []any{platform.StatusSuccess, &platform.StatusParams{HasDiff: true, Details: "terraform show success with diff", Dir: "testdata", Operation: "plan"}},
+				},
+				{
+					Name:   "Status",
+					Params: []any{platform.StatusSuccess, &platform.StatusParams{HasDiff: true, Details: "terraform show success with diff", Dir: "testdata/environments@dev", Operation: "plan"}},
+				},
+			},
+			expStdout: "testdata: success\ntestdata/environments@dev: success",
+		},
+		{
+			name: "aggregates_failures",
+			targets: []*target{
+				{dir: "testdata"},
+				{dir: "testdata/environments"},
+			},
+			terraformClient: terraformNoDiffMock,
+			err:             "failed to plan 1 of 2 entrypoints (testdata/environments)",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "Status",
+					Params: []any{platform.StatusNoOperation, &platform.StatusParams{Dir: "testdata", Operation: "plan"}},
+				},
+				{
+					Name: "Status",
+					Params: []any{platform.StatusFailure, &platform.StatusParams{
+						Dir:          "testdata/environments",
+						Operation:    "plan",
+						ErrorMessage: `failed to select environment: an environment must be selected, declared environments are ["dev"]`,
+					}},
+				},
+			},
+			expStdout: "testdata: success\ntestdata/environments: failure",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cwd, err := (&PlanCommand{}).WorkingDir()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mockStorageClient := &storage.MockStorageClient{}
+			mockPlatformClient := &platform.MockPlatform{}
+
+			c := &PlanCommand{
+				flagOutputDir:      t.TempDir(),
+				flagLockTimeout:    10 * time.Minute,
+				flagConcurrency:    1,
+				flagPluginCacheDir: t.TempDir(),
+				terraformClient:    tc.terraformClient,
+				storageClient:      mockStorageClient,
+				platformClient:     mockPlatformClient,
+			}
+
+			_, stdout, _ := c.Pipe()
+
+			err = c.planTargets(ctx, cwd, tc.targets)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(mockPlatformClient.Reqs, tc.expPlatformClientReqs); diff != "" {
+				t.Errorf("Platform calls not as expected; (-got,+want): %s", diff)
+			}
+
+			if got, want := stdout.String(), tc.expStdout; !strings.Contains(got, want) {
+				t.Errorf("expected stdout\n\n%s\n\nto contain\n\n%s\n\n", got, want)
+			}
+		})
+	}
+}
@@ -21,18 +21,25 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/posener/complete/v2"
 
 	"github.com/abcxyz/guardian/internal/metricswrap"
 	"github.com/abcxyz/guardian/pkg/checkterraform"
+	"github.com/abcxyz/guardian/pkg/environments"
 	"github.com/abcxyz/guardian/pkg/flags"
+	"github.com/abcxyz/guardian/pkg/git"
+	"github.com/abcxyz/guardian/pkg/locks"
+	"github.com/abcxyz/guardian/pkg/modifiers"
 	"github.com/abcxyz/guardian/pkg/platform"
 	"github.com/abcxyz/guardian/pkg/storage"
 	"github.com/abcxyz/guardian/pkg/terraform"
@@ -46,6 +53,33 @@ const (
 	// plan file metadata key representing the exit code.
 	MetaKeyExitCode = "plan_exit_code"
 
+	// plan file metadata key representing the head commit SHA of the change
+	// request the plan was created for.
+	MetaKeyCommitSHA = "plan_commit_sha"
+
+	// plan file metadata key representing the entrypoint path, relative to the
+	// repository root.
+	MetaKeyEntrypoint = "plan_entrypoint"
+
+	// plan file metadata key representing the Terraform version used to plan.
+	MetaKeyTerraformVersion = "plan_terraform_version"
+
+	// plan file metadata key representing the Terraform compatible binary used
+	// to plan, "terraform" or "tofu".
+	MetaKeyTerraformBinary = "plan_terraform_binary"
+
+	// plan file metadata key representing the content hash of the entrypoint and
+	// its local modules.
+	MetaKeyContentHash = "plan_content_hash"
+
+	// plan file metadata key representing the serial of the apply lock of the
+	// entrypoint when the plan was created.
+	MetaKeyLockSerial = "plan_lock_serial"
+
+	// plan file metadata key representing the commit SHA of the configuration
+	// planned for destruction, only set when the entrypoint was deleted.
+	MetaKeyDestroyRef = "plan_destroy_ref"
+
 	ownerReadWritePerms = 0o600
 
 	planFilename     = "tfplan.binary"
@@ -58,6 +92,8 @@ var _ cli.Command = (*PlanCommand)(nil)
 type RunResult struct {
 	hasChanges     bool
 	commentDetails string
+	commentMessage string
+	annotations    []*platform.Annotation
 }
 
 type PlanCommand struct {
@@ -66,22 +102,51 @@ type PlanCommand struct {
 	directory     string
 	childPath     string
 	storagePrefix string
+	destroyRef    string
+	binaryName    string
 
 	platformConfig platform.Config
 
 	flags.CommonFlags
+	flags.EnvelopeFlags
+	flags.TerraformFlags
+	flags.RedactFlags
+	flags.TimeoutFlags
 
 	flagOutputDir              string
+	flagEnvironment            string
 	flagStorage                string
 	flagAllowLockfileChanges   bool
 	flagLockTimeout            time.Duration
+	flagPlanTimeout            time.Duration
 	flagSkipReporting          bool
+	flagUpsertStatus           bool
+	flagApplyLock              bool
+	flagDestroyBaseRef         string
 	flagReportStdout           bool
 	flagDisallowedProviders    []string
 	flagDisallowedProvisioners []string
 	flagAllowedProviders       []string
 	flagAllowedProvisioners    []string
+	flagSensitiveResourceTypes []string
 
+	flagDirs            []string
+	flagEntrypointsFile string
+	flagConcurrency     int64
+	flagPluginCacheDir  string
+
+	sensitiveResourceTypes []*regexp.Regexp
+	applyLock              *locks.Lock
+	redactor               *terraform.Redactor
+	metaValues             modifiers.MetaValues
+	planModifiers          *modifiers.PlanModifiers
+
+	// set when planning multiple entrypoints in one process
+	nestOutputDir  bool
+	pluginCacheDir string
+	initMu         *sync.Mutex
+
+	lockClient      *locks.Client
 	storageClient   storage.Storage
 	terraformClient terraform.Terraform
 	platformClient  platform.Platform
@@ -103,7 +168,22 @@ func (c *PlanCommand) Flags() *cli.FlagSet {
 	set := c.NewFlagSet()
 
 	c.platformConfig.RegisterFlags(set)
-	c.Register(set)
+
+	// plan accepts multiple directories, so it registers its own dir flag
+	// instead of the common one
+	cf := set.NewSection("COMMON OPTIONS")
+
+	cf.StringSliceVar(&cli.StringSliceVar{
+		Name:    "dir",
+		Target:  &c.flagDirs,
+		Example: "./terraform",
+		Usage:   "The location of the terraform directory. Repeat to plan multiple directories concurrently.",
+	})
+
+	c.EnvelopeFlags.Register(set)
+	c.TerraformFlags.Register(set)
+	c.RedactFlags.Register(set)
+	c.TimeoutFlags.Register(set)
 
 	f := set.NewSection("COMMAND OPTIONS")
 
@@ -117,6 +197,34 @@ func (c *PlanCommand) Flags() *cli.FlagSet {
 		}),
 	})
 
+	f.StringVar(&cli.StringVar{
+		Name:    "entrypoints-file",
+		Target:  &c.flagEntrypointsFile,
+		Example: "entrypoints.json",
+		Usage:   `The path to the JSON output of "guardian entrypoints" listing the directories to plan concurrently, or "-" to read it from stdin.`,
+	})
+
+	f.Int64Var(&cli.Int64Var{
+		Name:    "concurrency",
+		Target:  &c.flagConcurrency,
+		Example: "4",
+		Usage:   "The maximum number of directories to plan concurrently. Defaults to the number of CPUs.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "plugin-cache-dir",
+		Target:  &c.flagPluginCacheDir,
+		Example: "/path/to/plugin-cache",
+		Usage:   "The Terraform provider plugin cache directory shared by the directories planned together. Defaults to a temporary directory.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "environment",
+		Target:  &c.flagEnvironment,
+		Example: "dev",
+		Usage:   "The environment of the directory to plan, as declared in its guardian.hcl file. Required when the directory declares environments.",
+	})
+
 	f.BoolVar(&cli.BoolVar{
 		Name:    "allow-lockfile-changes",
 		Target:  &c.flagAllowLockfileChanges,
@@ -132,6 +240,14 @@ func (c *PlanCommand) Flags() *cli.FlagSet {
 		Usage:   "The duration Terraform should wait to obtain a lock when running commands that modify state.",
 	})
 
+	f.DurationVar(&cli.DurationVar{
+		Name:    "plan-timeout",
+		Target:  &c.flagPlanTimeout,
+		EnvVar:  "GUARDIAN_PLAN_TIMEOUT",
+		Example: "30m",
+		Usage:   "The maximum duration of terraform plan, unlimited if not set.",
+	})
+
 	f.StringVar(&cli.StringVar{
 		Name:    "output-dir",
 		Target:  &c.flagOutputDir,
@@ -147,6 +263,29 @@ func (c *PlanCommand) Flags() *cli.FlagSet {
 		Usage:   "Skips reporting of the plan status on the change request.",
 	})
 
+	f.BoolVar(&cli.BoolVar{
+		Name:    "upsert-status",
+		Target:  &c.flagUpsertStatus,
+		Default: false,
+		Example: "true",
+		Usage:   "Updates the previous plan status comment for the directory in place, keeping a short history, instead of posting a new comment.",
+	})
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "apply-lock",
+		Target:  &c.flagApplyLock,
+		Default: false,
+		Example: "true",
+		Usage:   "Records the apply lock of the directory in the plan file, so applying it fails if another change request applied the directory after it was planned.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "destroy-base-ref",
+		Target:  &c.flagDestroyBaseRef,
+		Example: "origin/main",
+		Usage:   "The base git ref of the change request. When the directory was deleted by the change request, its configuration at this ref is used to plan the destruction of its resources.",
+	})
+
 	f.BoolVar(&cli.BoolVar{
 		Name:    "report-stdout",
 		Target:  &c.flagReportStdout,
@@ -186,6 +325,14 @@ func (c *PlanCommand) Flags() *cli.FlagSet {
 		Example: "allowed-provisioner,another-allowed-provisioner",
 		Usage:   "The list of allowed Terraform provisioners. Setting this will override disallowed provisioners.",
 	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "sensitive-resource-types",
+		Target:  &c.flagSensitiveResourceTypes,
+		Default: terraform.DefaultSensitiveResourceTypes,
+		Example: "_iam_,^google_kms_",
+		Usage:   "The list of regular expressions matching resource types that are highlighted in the plan summary when deleted or replaced.",
+	})
 	return set
 }
 
@@ -207,15 +354,79 @@ func (c *PlanCommand) Run(ctx context.Context, args []string) error {
 		return fmt.Errorf("failed to get current working directory: %w", err)
 	}
 
-	if c.FlagDir == "" {
-		c.FlagDir = cwd
+	targets, err := c.targets(cwd)
+	if err != nil {
+		return err
 	}
 
 	if c.flagStorage == "" {
 		c.flagStorage = path.Join("file://", cwd)
 	}
 
+	for _, t := range c.flagSensitiveResourceTypes {
+		re, err := regexp.Compile(t)
+		if err != nil {
+			return fmt.Errorf("failed to parse sensitive resource type %q: %w", t, err)
+		}
+		c.sensitiveResourceTypes = append(c.sensitiveResourceTypes, re)
+	}
+
+	redactor, err := c.Redactor()
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+	c.redactor = redactor
+
+	platform, err := platform.NewPlatform(ctx, &c.platformConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create platform: %w", err)
+	}
+	c.platformClient = platform
+	c.metaValues = modifiers.ParseBodyMetaValues(ctx, c.platformConfig.ChangeRequestBody())
+
+	storagePrefix, err := c.platformClient.StoragePrefix(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to parse storage flag: %w", err)
+	}
+	c.storagePrefix = storagePrefix
+
+	sc, err := storage.Parse(ctx, c.flagStorage)
+	if err != nil {
+		return fmt.Errorf("failed to create storage client: %w", err)
+	}
+
+	// locks are shared by all change requests, so they are not stored in an
+	// envelope
+	if c.flagApplyLock {
+		repository, err := c.platformConfig.Repository()
+		if err != nil {
+			return fmt.Errorf("failed to determine repository for apply locks: %w", err)
+		}
+		c.lockClient = locks.NewClient(sc, repository, 0)
+	}
+
+	sc, err = c.WrapStorage(ctx, sc)
+	if err != nil {
+		return fmt.Errorf("failed to configure plan file envelope: %w", err)
+	}
+	c.storageClient = sc
+
+	if len(targets) == 1 && c.flagEntrypointsFile == "" {
+		c.FlagDir = targets[0].dir
+		c.flagEnvironment = targets[0].environment
+		return c.planDirectory(ctx, cwd)
+	}
+	return c.planTargets(ctx, cwd, targets)
+}
+
+// planDirectory resolves the directory to plan and runs the plan process for
+// it.
+func (c *PlanCommand) planDirectory(ctx context.Context, cwd string) (merr error) {
 	dirAbs, err := util.PathEvalAbs(c.FlagDir)
+	deleted := errors.Is(err, fs.ErrNotExist) && c.flagDestroyBaseRef != ""
+	if deleted {
+		dirAbs, err = filepath.Abs(c.FlagDir)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to absolute path for directory: %w", err)
 	}
@@ -227,35 +438,93 @@ func (c *PlanCommand) Run(ctx context.Context, args []string) error {
 	}
 	c.childPath = childPath
 
+	if deleted {
+		checkoutDir, err := os.MkdirTemp("", "guardian-destroy-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary checkout directory: %w", err)
+		}
+		defer func() {
+			if err := os.RemoveAll(checkoutDir); err != nil {
+				merr = errors.Join(merr, fmt.Errorf("failed to delete temporary checkout directory: %w", err))
+			}
+		}()
+
+		if err := c.checkoutDeletedDirectory(ctx, git.NewGitClient(cwd), checkoutDir); err != nil {
+			return fmt.Errorf("failed to checkout deleted directory: %w", err)
+		}
+	}
+
 	if c.flagOutputDir == "" {
-		c.flagOutputDir = childPath
+		// entrypoints planned together write the plan files of each environment
+		// to their own directory, next to the entrypoint directory
+		outputDir := childPath
+		if c.nestOutputDir {
+			outputDir = c.entrypoint()
+		}
+		c.flagOutputDir = filepath.Join(cwd, outputDir)
+
+		// a deleted directory no longer exists in the checkout, it is recreated
+		// so the plan files are not written to the temporary checkout directory.
+		if err := os.MkdirAll(c.flagOutputDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	} else if c.nestOutputDir {
+		// entrypoints planned together each write their plan files to their own
+		// directory
+		c.flagOutputDir = filepath.Join(c.flagOutputDir, c.entrypoint())
+		if err := os.MkdirAll(c.flagOutputDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
 	}
 	if c.flagOutputDir, err = filepath.Abs(c.flagOutputDir); err != nil {
 		return fmt.Errorf("failed to get absolute path for output directory: %w", err)
 	}
 
-	tfEnvVars := []string{"TF_IN_AUTOMATION=true"}
-	c.terraformClient = terraform.NewTerraformClient(c.directory, tfEnvVars)
+	// the client is only created when not already set, so tests can provide a
+	// mock client
+	if c.terraformClient == nil {
+		tfOpts, err := c.ClientOptions(ctx, c.directory)
+		if err != nil {
+			return fmt.Errorf("failed to configure terraform client: %w", err)
+		}
 
-	platform, err := platform.NewPlatform(ctx, &c.platformConfig)
-	if err != nil {
-		return fmt.Errorf("failed to create platform: %w", err)
+		tfEnvVars := []string{"TF_IN_AUTOMATION=true"}
+		if c.pluginCacheDir != "" {
+			tfEnvVars = append(tfEnvVars, "TF_PLUGIN_CACHE_DIR="+c.pluginCacheDir)
+		}
+		tfClient := terraform.NewTerraformClient(c.directory, tfEnvVars, tfOpts...)
+		c.terraformClient = tfClient
+		c.binaryName = terraform.BinaryName(tfClient.Binary())
 	}
-	c.platformClient = platform
 
-	storagePrefix, err := c.platformClient.StoragePrefix(ctx)
+	return c.Process(ctx)
+}
+
+// checkoutDeletedDirectory writes the repository at the destroy base ref to
+// the checkout directory, and plans the destruction of the deleted directory
+// from there.
+func (c *PlanCommand) checkoutDeletedDirectory(ctx context.Context, gitClient git.Git, checkoutDir string) error {
+	util.Headerf(c.Stdout(), "Checking out deleted directory")
+
+	sha, err := gitClient.ResolveRef(ctx, c.flagDestroyBaseRef)
 	if err != nil {
-		return fmt.Errorf("failed to parse storage flag: %w", err)
+		return fmt.Errorf("failed to resolve destroy base ref %s: %w", c.flagDestroyBaseRef, err)
 	}
-	c.storagePrefix = storagePrefix
 
-	sc, err := storage.Parse(ctx, c.flagStorage)
-	if err != nil {
-		return fmt.Errorf("failed to create storage client: %w", err)
+	if err := gitClient.ExtractRef(ctx, sha, checkoutDir); err != nil {
+		return fmt.Errorf("failed to extract destroy base ref %s: %w", c.flagDestroyBaseRef, err)
 	}
-	c.storageClient = sc
 
-	return c.Process(ctx)
+	dir := filepath.Join(checkoutDir, filepath.FromSlash(c.childPath))
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("directory %s does not exist at %s: %w", c.childPath, c.flagDestroyBaseRef, err)
+	}
+
+	c.Outf("Planning destruction of %s using the configuration at %s (%s)", c.childPath, c.flagDestroyBaseRef, sha)
+
+	c.directory = dir
+	c.destroyRef = sha
+	return nil
 }
 
 // Process handles the main logic for the Guardian plan run process.
@@ -272,55 +541,254 @@ func (c *PlanCommand) Process(ctx context.Context) error {
 
 	sp := &platform.StatusParams{
 		Operation: operation,
-		Dir:       c.childPath,
+		Dir:       c.entrypoint(),
 	}
 
 	status := platform.StatusNoOperation
 
+	// the lock is read before planning, so an apply that completes while
+	// planning makes the plan stale
+	if c.lockClient != nil {
+		lock, err := c.lockClient.Get(ctx, c.entrypoint())
+		if err != nil {
+			return fmt.Errorf("failed to get apply lock: %w", err)
+		}
+		c.applyLock = lock
+	}
+
 	result, err := c.terraformPlan(ctx)
 	if err != nil {
 		merr = errors.Join(merr, fmt.Errorf("failed to run Guardian plan: %w", err))
 		status = platform.StatusFailure
 		sp.ErrorMessage = err.Error()
 		sp.Details = result.commentDetails
+		sp.Annotations = result.annotations
 	}
 
 	if result.hasChanges && err == nil {
 		status = platform.StatusSuccess
 		sp.Details = result.commentDetails
+		sp.Message = result.commentMessage
 		sp.HasDiff = true
+
+		if c.destroyRef != "" {
+			status = platform.StatusDestroy
+			sp.Message = strings.TrimSpace(destroyMessage + "\n\n" + sp.Message)
+		}
+	}
+
+	if message := TimeoutMessage(err); message != "" {
+		sp.Message = strings.TrimSpace(message + "\n\n" + sp.Message)
+	}
+
+	if message := modifiersMessage(c.planModifiers); message != "" {
+		sp.Message = strings.TrimSpace(message + "\n\n" + sp.Message)
+	}
+
+	if message := c.applyLockMessage(time.Now()); message != "" {
+		sp.Message = strings.TrimSpace(message + "\n\n" + sp.Message)
 	}
 
 	if c.flagSkipReporting {
 		return merr
 	}
 
-	if err := c.platformClient.ReportStatus(ctx, status, sp); err != nil {
+	if err := c.reportStatus(ctx, status, sp); err != nil {
 		merr = errors.Join(merr, fmt.Errorf("failed to report status: %w", err))
 	}
 
 	return merr
 }
 
+// entrypoint returns the directory qualified with the selected environment,
+// which identifies the plan file, apply lock and status reports of the run.
+func (c *PlanCommand) entrypoint() string {
+	return environments.Qualify(c.childPath, c.flagEnvironment)
+}
+
+// destroyMessage is the warning reported for a plan of a deleted directory.
+const destroyMessage = "> ⚠️ **This directory was deleted by this change request. Applying this plan will destroy all of its resources.**"
+
+// TimeoutMessage returns a warning naming the Terraform step that timed out and
+// whether the state lock was released, or an empty string if no step timed out.
+func TimeoutMessage(err error) string {
+	var timeoutErr *terraform.StepTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		return ""
+	}
+
+	binary := timeoutErr.BinaryName()
+	if timeoutErr.Killed {
+		return fmt.Sprintf("> ⚠️ **%s %s timed out after %s and was killed before it exited.** "+
+			"The state lock may not have been released, in which case it must be released using `%s force-unlock`.",
+			binary, timeoutErr.Step, timeoutErr.Timeout, binary)
+	}
+	return fmt.Sprintf("> ⚠️ **%s %s timed out after %s.** It was interrupted and exited, releasing the state lock.",
+		binary, timeoutErr.Step, timeoutErr.Timeout)
+}
+
+// modifiersMessage returns a warning listing the plan options requested in the
+// change request body, as the plan may not include all changes.
+func modifiersMessage(m *modifiers.PlanModifiers) string {
+	if m.IsEmpty() {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("> ⚠️ **This plan was modified by the change request body and may not include all changes.**\n>")
+	if len(m.Targets) > 0 {
+		fmt.Fprintf(&b, "\n> - Targets: %s", util.CodeList(m.Targets, 0))
+	}
+	if len(m.Replaces) > 0 {
+		fmt.Fprintf(&b, "\n> - Replaces: %s", util.CodeList(m.Replaces, 0))
+	}
+	if m.RefreshOnly {
+		b.WriteString("\n> - Refresh only, no changes to infrastructure are planned")
+	}
+	return b.String()
+}
+
+// applyLockMessage returns a warning if the directory is locked by another
+// change request being applied, as the plan will be stale once it completes.
+func (c *PlanCommand) applyLockMessage(now time.Time) string {
+	l := c.applyLock
+	if l == nil || !l.Held(now) || l.Holder == c.platformConfig.ChangeRequestNumber() {
+		return ""
+	}
+
+	return fmt.Sprintf("> ⚠️ **This directory is locked by #%d while it is being applied, until %s at the latest.** This plan will need to be re-run once the apply has completed.",
+		l.Holder, l.ExpiresAt.UTC().Format(time.RFC3339))
+}
+
+// formatAnnotations returns annotations for the lines that are not formatted
+// correctly, from the output of terraform fmt.
+func (c *PlanCommand) formatAnnotations(output string) []*platform.Annotation {
+	var annotations []*platform.Annotation
+	for _, d := range terraform.ParseFormatDiff(output) {
+		annotations = append(annotations, &platform.Annotation{
+			Path:      path.Join(c.childPath, d.Filename),
+			StartLine: d.StartLine,
+			EndLine:   d.EndLine,
+			Level:     platform.AnnotationLevelFailure,
+			Title:     "Terraform format",
+			Message:   "This file is not formatted correctly, run `terraform fmt` to fix it.",
+		})
+	}
+	return annotations
+}
+
+// diagnosticAnnotations returns annotations for the diagnostics in the output
+// of a Terraform command.
+func (c *PlanCommand) diagnosticAnnotations(output string) []*platform.Annotation {
+	var annotations []*platform.Annotation
+	for _, d := range terraform.ParseDiagnostics(output) {
+		message := d.Detail
+		if message == "" {
+			message = d.Summary
+		}
+
+		level := platform.AnnotationLevelFailure
+		if d.Severity == "warning" {
+			level = platform.AnnotationLevelWarning
+		}
+
+		annotations = append(annotations, &platform.Annotation{
+			Path:      path.Join(c.childPath, d.Filename),
+			StartLine: d.Line,
+			EndLine:   d.Line,
+			Level:     level,
+			Title:     d.Summary,
+			Message:   message,
+		})
+	}
+	return annotations
+}
+
+// reportStatus reports the status of the run with sensitive values masked,
+// updating the previous status report in place when requested.
+func (c *PlanCommand) reportStatus(ctx context.Context, st platform.Status, p *platform.StatusParams) error {
+	p.Details = c.redactor.Redact(p.Details)
+	p.Message = c.redactor.Redact(p.Message)
+	p.ErrorMessage = c.redactor.Redact(p.ErrorMessage)
+
+	if c.flagUpsertStatus {
+		return c.platformClient.UpsertStatus(ctx, st, p) //nolint:wrapcheck // Want passthrough
+	}
+	return c.platformClient.ReportStatus(ctx, st, p) //nolint:wrapcheck // Want passthrough
+}
+
 // terraformPlan runs the required Terraform commands for a full run of
 // a Guardian plan using the Terraform CLI.
 func (c *PlanCommand) terraformPlan(ctx context.Context) (*RunResult, error) {
+	// output is masked as it is written, only values matching the redaction
+	// rules are known until the plan is shown
+	out := c.redactor.Writer(c.Stdout())
+	errOut := c.redactor.Writer(c.Stderr())
+	defer func() {
+		if err := errors.Join(out.Flush(), errOut.Flush()); err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "failed to flush output", "error", err)
+		}
+	}()
+
 	var stdout, stderr strings.Builder
-	multiStdout := io.MultiWriter(c.Stdout(), &stdout)
-	multiStderr := io.MultiWriter(c.Stderr(), &stderr)
-
-	util.Headerf(c.Stdout(), "Check Terraform Format")
-	if _, err := c.terraformClient.Format(ctx, multiStdout, multiStderr, &terraform.FormatOptions{
-		Check:     pointer.To(true),
-		Diff:      pointer.To(true),
-		Recursive: pointer.To(true),
-		NoColor:   pointer.To(true),
-	}); err != nil {
-		commentDetails := stderr.String()
-		if commentDetails == "" {
-			commentDetails = stdout.String()
+	multiStdout := io.MultiWriter(out, &stdout)
+	multiStderr := io.MultiWriter(errOut, &stderr)
+
+	// The content hash is computed before running any Terraform commands, as
+	// they can write files to the entrypoint directory. A deleted directory has
+	// no content to verify when applying, the destroy ref is verified instead.
+	var contentHash string
+	if c.destroyRef == "" {
+		cwd, err := c.WorkingDir()
+		if err != nil {
+			return &RunResult{}, fmt.Errorf("failed to get current working directory: %w", err)
+		}
+
+		contentHash, err = terraform.EntrypointContentHash(ctx, cwd, c.directory)
+		if err != nil {
+			return &RunResult{}, fmt.Errorf("failed to compute entrypoint content hash: %w", err)
+		}
+	}
+
+	config, err := environments.Load(c.directory)
+	if err != nil {
+		return &RunResult{}, fmt.Errorf("failed to load environments: %w", err)
+	}
+
+	env, err := config.Select(c.flagEnvironment)
+	if err != nil {
+		return &RunResult{}, fmt.Errorf("failed to select environment: %w", err)
+	}
+
+	mods, err := c.metaValues.PlanModifiersFor(ctx, c.childPath, c.entrypoint())
+	if err != nil {
+		return &RunResult{}, fmt.Errorf("failed to parse plan modifiers: %w", err)
+	}
+	c.planModifiers = mods
+
+	terraformVersion, err := terraform.GetVersion(ctx, c.terraformClient)
+	if err != nil {
+		return &RunResult{}, fmt.Errorf("failed to get terraform version: %w", err)
+	}
+
+	// the formatting of a deleted directory can no longer be fixed
+	if c.destroyRef == "" {
+		util.Headerf(out, "Check Terraform Format")
+		if _, err := c.terraformClient.Format(ctx, multiStdout, multiStderr, &terraform.FormatOptions{
+			Check:     pointer.To(true),
+			Diff:      pointer.To(true),
+			Recursive: pointer.To(true),
+			NoColor:   pointer.To(true),
+		}); err != nil {
+			commentDetails := stderr.String()
+			if commentDetails == "" {
+				commentDetails = stdout.String()
+			}
+			return &RunResult{
+				commentDetails: commentDetails,
+				annotations:    c.formatAnnotations(stdout.String()),
+			}, fmt.Errorf("failed to check formatting: %w", err)
 		}
-		return &RunResult{commentDetails: commentDetails}, fmt.Errorf("failed to check formatting: %w", err)
 	}
 
 	stdout.Reset()
@@ -331,12 +799,13 @@ func (c *PlanCommand) terraformPlan(ctx context.Context) (*RunResult, error) {
 		lockfileMode = "readonly"
 	}
 
-	util.Headerf(c.Stdout(), "Initializing Terraform")
-	if _, err := c.terraformClient.Init(ctx, multiStdout, multiStderr, &terraform.InitOptions{
-		Input:       pointer.To(false),
-		NoColor:     pointer.To(true),
-		Lockfile:    pointer.To(lockfileMode),
-		LockTimeout: pointer.To(c.flagLockTimeout.String()),
+	util.Headerf(out, "Initializing Terraform")
+	if _, err := c.terraformInit(ctx, multiStdout, multiStderr, &terraform.InitOptions{
+		BackendConfigs: env.BackendConfigs,
+		Input:          pointer.To(false),
+		NoColor:        pointer.To(true),
+		Lockfile:       pointer.To(lockfileMode),
+		LockTimeout:    pointer.To(c.flagLockTimeout.String()),
 	}); err != nil {
 		commentDetails := stderr.String()
 		if commentDetails == "" {
@@ -348,15 +817,37 @@ func (c *PlanCommand) terraformPlan(ctx context.Context) (*RunResult, error) {
 	stdout.Reset()
 	stderr.Reset()
 
-	util.Headerf(c.Stdout(), "Validating Terraform")
-	if _, err := c.terraformClient.Validate(ctx, multiStdout, multiStderr, &terraform.ValidateOptions{
-		NoColor: pointer.To(true),
+	if env.Workspace != "" {
+		util.Headerf(out, "Selecting Terraform Workspace")
+		if _, err := c.terraformClient.WorkspaceSelect(ctx, multiStdout, multiStderr, &terraform.WorkspaceSelectOptions{
+			Name:     pointer.To(env.Workspace),
+			OrCreate: pointer.To(true),
+		}); err != nil {
+			commentDetails := stderr.String()
+			if commentDetails == "" {
+				commentDetails = stdout.String()
+			}
+			return &RunResult{commentDetails: commentDetails}, fmt.Errorf("failed to select workspace: %w", err)
+		}
+
+		stdout.Reset()
+		stderr.Reset()
+	}
+
+	util.Headerf(out, "Validating Terraform")
+	if _, err := terraform.RunStep(ctx, c.binaryName, "validate", c.FlagValidateTimeout, func(ctx context.Context) (int, error) {
+		return c.terraformClient.Validate(ctx, multiStdout, multiStderr, &terraform.ValidateOptions{ //nolint:wrapcheck // Want passthrough
+			NoColor: pointer.To(true),
+		})
 	}); err != nil {
 		commentDetails := stderr.String()
 		if commentDetails == "" {
 			commentDetails = stdout.String()
 		}
-		return &RunResult{commentDetails: commentDetails}, fmt.Errorf("failed to validate: %w", err)
+		return &RunResult{
+			commentDetails: commentDetails,
+			annotations:    c.diagnosticAnnotations(stderr.String() + stdout.String()),
+		}, fmt.Errorf("failed to validate: %w", err)
 	}
 
 	stdout.Reset()
@@ -365,24 +856,43 @@ func (c *PlanCommand) terraformPlan(ctx context.Context) (*RunResult, error) {
 	var hasChanges bool
 	var planExitCode int
 
-	util.Headerf(c.Stdout(), "Planning Terraform")
+	util.Headerf(out, "Planning Terraform")
 
 	// create a separate writer for plan output
 	// this output will be sent back for reporting status
 	var planOut strings.Builder
-	multiPlanOut := io.MultiWriter(c.Stdout(), &planOut)
+
+	// the plan output is buffered and only written once the plan is shown, so
+	// values only known to be sensitive from the plan JSON are masked
+	var planOutWritten bool
+	writePlanOut := func(s string) {
+		if planOutWritten {
+			return
+		}
+		planOutWritten = true
+		if _, err := io.WriteString(out, c.redactor.Redact(s)); err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "failed to write plan output", "error", err)
+		}
+	}
 
 	planAbsFilepath := path.Join(c.flagOutputDir, planFilename)
-	exitCode, err := c.terraformClient.Plan(ctx, multiPlanOut, multiStderr, &terraform.PlanOptions{
-		Out:                    pointer.To(planAbsFilepath),
-		Input:                  pointer.To(false),
-		NoColor:                pointer.To(true),
-		DetailedExitcode:       pointer.To(true),
-		LockTimeout:            pointer.To(c.flagLockTimeout.String()),
-		DisallowedProviders:    c.flagDisallowedProviders,
-		DisallowedProvisioners: c.flagDisallowedProvisioners,
-		AllowedProviders:       c.flagAllowedProviders,
-		AllowedProvisioners:    c.flagAllowedProvisioners,
+	exitCode, err := terraform.RunStep(ctx, c.binaryName, "plan", c.flagPlanTimeout, func(ctx context.Context) (int, error) {
+		return c.terraformClient.Plan(ctx, &planOut, multiStderr, &terraform.PlanOptions{ //nolint:wrapcheck // Want passthrough
+			Out:                    pointer.To(planAbsFilepath),
+			Input:                  pointer.To(false),
+			NoColor:                pointer.To(true),
+			DetailedExitcode:       pointer.To(true),
+			LockTimeout:            pointer.To(c.flagLockTimeout.String()),
+			Destroy:                pointer.To(c.destroyRef != ""),
+			RefreshOnly:            pointer.To(mods.RefreshOnly),
+			Replaces:               mods.Replaces,
+			Targets:                mods.Targets,
+			VarFiles:               env.VarFiles,
+			DisallowedProviders:    c.flagDisallowedProviders,
+			DisallowedProvisioners: c.flagDisallowedProvisioners,
+			AllowedProviders:       c.flagAllowedProviders,
+			AllowedProvisioners:    c.flagAllowedProvisioners,
+		})
 	})
 
 	planExitCode = exitCode
@@ -391,6 +901,8 @@ func (c *PlanCommand) terraformPlan(ctx context.Context) (*RunResult, error) {
 	hasChanges = planExitCode == 2
 
 	if err != nil && !hasChanges {
+		writePlanOut(planOut.String())
+
 		commentDetails := stderr.String()
 		if commentDetails == "" {
 			commentDetails = planOut.String()
@@ -402,6 +914,9 @@ func (c *PlanCommand) terraformPlan(ctx context.Context) (*RunResult, error) {
 	planOut.Reset()
 	stderr.Reset()
 
+	// written with the redaction rules known so far if the plan JSON is not read
+	defer writePlanOut(planOutOriginal.String())
+
 	// Produces a cleaner output of the planned changes for writing to comment.
 	// This will exclude extra lines from the plan, e.g. "refreshing state" and
 	// only contain details of the planned changes.
@@ -418,7 +933,7 @@ func (c *PlanCommand) terraformPlan(ctx context.Context) (*RunResult, error) {
 
 	stderr.Reset()
 
-	util.Headerf(c.Stdout(), "Writing Plan to Local JSON File")
+	util.Headerf(out, "Writing Plan to Local JSON File")
 
 	// we dont need to write the contents of the plan json to stdout,
 	// instead capture the output in memory for writing to file in output-dir
@@ -440,6 +955,23 @@ func (c *PlanCommand) terraformPlan(ctx context.Context) (*RunResult, error) {
 	}
 	c.Outf("Plan JSON file path: %s", planJSONAbsFilepath)
 
+	// values that are sensitive in the plan are masked in the reported status
+	if r, err := c.redactor.WithPlanJSON([]byte(jsonOut.String())); err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "failed to read sensitive values from plan", "error", err)
+	} else {
+		c.redactor = r
+	}
+	writePlanOut(planOutOriginal.String())
+
+	// the summary is best effort, as the plan details are still reported
+	var commentMessage string
+	summary, err := terraform.ParsePlanSummary([]byte(jsonOut.String()))
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "failed to summarize plan", "error", err)
+	} else if summary.HasChanges() {
+		commentMessage = planSummaryMessage(summary, c.sensitiveResourceTypes)
+	}
+
 	stderr.Reset()
 
 	planData, err := os.ReadFile(planAbsFilepath)
@@ -447,10 +979,31 @@ func (c *PlanCommand) terraformPlan(ctx context.Context) (*RunResult, error) {
 		return &RunResult{hasChanges: hasChanges}, fmt.Errorf("failed to read plan binary: %w", err)
 	}
 
-	util.Headerf(c.Stdout(), "Saving Plan File")
+	util.Headerf(out, "Saving Plan File")
+
+	metadata := map[string]string{
+		MetaKeyExitCode:         strconv.Itoa(planExitCode),
+		MetaKeyEntrypoint:       c.entrypoint(),
+		MetaKeyTerraformVersion: terraformVersion,
+	}
+	if contentHash != "" {
+		metadata[MetaKeyContentHash] = contentHash
+	}
+	if c.binaryName != "" {
+		metadata[MetaKeyTerraformBinary] = c.binaryName
+	}
+	if c.destroyRef != "" {
+		metadata[MetaKeyDestroyRef] = c.destroyRef
+	}
+	if sha := c.platformConfig.ChangeRequestSHA(); sha != "" {
+		metadata[MetaKeyCommitSHA] = sha
+	}
+	if c.applyLock != nil {
+		metadata[MetaKeyLockSerial] = strconv.FormatInt(c.applyLock.CurrentSerial(time.Now()), 10)
+	}
 
-	planFileLocalPath := path.Join(c.childPath, planFilename)
-	if err := c.saveGuardianPlan(ctx, planFileLocalPath, planData, planExitCode); err != nil {
+	planFileLocalPath := path.Join(c.entrypoint(), planFilename)
+	if err := c.saveGuardianPlan(ctx, planFileLocalPath, planData, metadata); err != nil {
 		return &RunResult{hasChanges: hasChanges}, fmt.Errorf("failed to upload plan data: %w", err)
 	}
 
@@ -459,21 +1012,21 @@ func (c *PlanCommand) terraformPlan(ctx context.Context) (*RunResult, error) {
 	if c.flagReportStdout {
 		return &RunResult{
 			commentDetails: planOutOriginal.String(),
+			commentMessage: commentMessage,
 			hasChanges:     hasChanges,
 		}, nil
 	}
 
 	return &RunResult{
 		commentDetails: planOut.String(),
+		commentMessage: commentMessage,
 		hasChanges:     hasChanges,
 	}, nil
 }
 
-// saveGuardianPlan uploads the Guardian plan binary to the configured Guardian storage client.
-func (c *PlanCommand) saveGuardianPlan(ctx context.Context, p string, data []byte, exitCode int) error {
-	metadata := make(map[string]string)
-	metadata[MetaKeyExitCode] = strconv.Itoa(exitCode)
-
+// saveGuardianPlan uploads the Guardian plan binary and its metadata to the
+// configured Guardian storage client.
+func (c *PlanCommand) saveGuardianPlan(ctx context.Context, p string, data []byte, metadata map[string]string) error {
 	objectPath := path.Join(c.storagePrefix, p)
 
 	c.Outf("Plan file path: %s %s", c.storageClient.Parent(), objectPath)
@@ -15,13 +15,18 @@
 package plan
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 
+	"github.com/abcxyz/guardian/pkg/child"
+	"github.com/abcxyz/guardian/pkg/locks"
+	"github.com/abcxyz/guardian/pkg/modifiers"
 	"github.com/abcxyz/guardian/pkg/platform"
 	"github.com/abcxyz/guardian/pkg/storage"
 	"github.com/abcxyz/guardian/pkg/terraform"
@@ -31,6 +36,10 @@ import (
 
 var terraformNoDiffMock = &terraform.MockTerraformClient{
 	PlanBody: []byte("this is a plan binary"),
+	VersionResponse: &terraform.MockTerraformResponse{
+		Stdout:   `{"terraform_version":"1.5.7"}`,
+		ExitCode: 0,
+	},
 	FormatResponse: &terraform.MockTerraformResponse{
 		Stdout:   "terraform format success",
 		ExitCode: 0,
@@ -59,6 +68,10 @@ var terraformNoDiffMock = &terraform.MockTerraformClient{
 
 var terraformDiffMock = &terraform.MockTerraformClient{
 	PlanBody: []byte("this is a plan binary"),
+	VersionResponse: &terraform.MockTerraformResponse{
+		Stdout:   `{"terraform_version":"1.5.7"}`,
+		ExitCode: 0,
+	},
 	FormatResponse: &terraform.MockTerraformResponse{
 		Stdout:   "terraform format success",
 		ExitCode: 0,
@@ -67,6 +80,10 @@ var terraformDiffMock = &terraform.MockTerraformClient{
 		Stdout:   "terraform init success with diff",
 		ExitCode: 0,
 	},
+	WorkspaceSelectResponse: &terraform.MockTerraformResponse{
+		Stdout:   "terraform workspace select success",
+		ExitCode: 0,
+	},
 	ValidateResponse: &terraform.MockTerraformResponse{
 		Stdout:   "terraform validate success with diff",
 		ExitCode: 0,
@@ -87,6 +104,10 @@ var terraformDiffMock = &terraform.MockTerraformClient{
 
 var terraformErrorMock = &terraform.MockTerraformClient{
 	PlanBody: []byte("this is a plan binary"),
+	VersionResponse: &terraform.MockTerraformResponse{
+		Stdout:   `{"terraform_version":"1.5.7"}`,
+		ExitCode: 0,
+	},
 	FormatResponse: &terraform.MockTerraformResponse{
 		Stdout:   "terraform format success",
 		ExitCode: 0,
@@ -115,6 +136,33 @@ var terraformErrorMock = &terraform.MockTerraformClient{
 	},
 }
 
+var terraformFormatErrorMock = &terraform.MockTerraformClient{
+	VersionResponse: &terraform.MockTerraformResponse{
+		Stdout:   `{"terraform_version":"1.5.7"}`,
+		ExitCode: 0,
+	},
+	FormatResponse: &terraform.MockTerraformResponse{
+		Stdout:   "main.tf\n--- old/main.tf\n+++ new/main.tf\n@@ -1,3 +1,3 @@\n",
+		ExitCode: 3,
+		Err:      fmt.Errorf("failed to run terraform fmt"),
+	},
+}
+
+// hangingPlanClient is a Terraform client whose plan only ends when the context
+// is canceled, killing Terraform if killed is set.
+type hangingPlanClient struct {
+	*terraform.MockTerraformClient
+	killed bool
+}
+
+func (c *hangingPlanClient) Plan(ctx context.Context, stdout, stderr io.Writer, opts *terraform.PlanOptions) (int, error) {
+	<-ctx.Done()
+	if c.killed {
+		return -1, fmt.Errorf("failed to run command: %w of 30s: signal: killed", child.ErrKilled)
+	}
+	return -1, fmt.Errorf("failed to run command: %w", ctx.Err())
+}
+
 func TestPlan_Process(t *testing.T) {
 	t.Parallel()
 
@@ -128,11 +176,18 @@ func TestPlan_Process(t *testing.T) {
 		flagAllowLockfileChanges bool
 		flagLockTimeout          time.Duration
 		flagReportStdout         bool
-		terraformClient          *terraform.MockTerraformClient
+		flagUpsertStatus         bool
+		applyLock                string
+		destroyRef               string
+		flagEnvironment          string
+		body                     string
+		flagPlanTimeout          time.Duration
+		terraformClient          terraform.Terraform
 		err                      string
 		expPlatformClientReqs    []*platform.Request
 		expStorageClientReqs     []*storage.Request
 		expStdout                string
+		expStdoutExcludes        string
 		expStderr                string
 	}{
 		{
@@ -158,6 +213,225 @@ func TestPlan_Process(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:                     "success_with_diff_modifiers",
+			directory:                "testdata",
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			body: `Replace the VM
+GUARDIAN_TARGET=testdata:module.network
+GUARDIAN_REPLACE=testdata:google_compute_instance.vm
+GUARDIAN_TARGET=other:module.other`,
+			terraformClient: terraformDiffMock,
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusSuccess, &platform.StatusParams{
+						HasDiff:   true,
+						Details:   "terraform show success with diff",
+						Dir:       "testdata",
+						Operation: "plan",
+						Message: "> ⚠️ **This plan was modified by the change request body and may not include all changes.**\n>" +
+							"\n> - Targets: `module.network`\n> - Replaces: `google_compute_instance.vm`",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "CreateObject",
+					Params: []any{
+						"testdata/tfplan.binary",
+						"this is a plan binary",
+					},
+				},
+			},
+		},
+		{
+			name:                     "handles_invalid_modifiers",
+			directory:                "testdata",
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			body:                     "GUARDIAN_TARGET=module.network",
+			terraformClient:          terraformDiffMock,
+			err:                      "failed to parse plan modifiers",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusFailure, &platform.StatusParams{
+						Dir:          "testdata",
+						Operation:    "plan",
+						ErrorMessage: `failed to parse plan modifiers: invalid GUARDIAN_TARGET value "module.network", must be in the format <entrypoint>:<address>`,
+					}},
+				},
+			},
+		},
+		{
+			name:                     "success_with_diff_redacts_sensitive_values",
+			directory:                "testdata",
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			terraformClient: &terraform.MockTerraformClient{
+				PlanBody: []byte("this is a plan binary"),
+				VersionResponse: &terraform.MockTerraformResponse{
+					Stdout: `{"terraform_version":"1.5.7"}`,
+				},
+				PlanResponse: &terraform.MockTerraformResponse{
+					Stdout:   "+ token = output-token-value\n",
+					ExitCode: 2,
+				},
+				ShowResponse: &terraform.MockTerraformResponse{
+					Stdout: "+ token = output-token-value",
+				},
+				ShowJSONResponse: &terraform.MockTerraformResponse{
+					Stdout: `{"output_changes": {"token": {"after": "output-token-value", "after_sensitive": true}}}`,
+				},
+			},
+			expStdout:         "+ token = (redacted)",
+			expStdoutExcludes: "output-token-value",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "Status",
+					Params: []any{platform.StatusSuccess, &platform.StatusParams{HasDiff: true, Details: "+ token = (redacted)", Dir: "testdata", Operation: "plan"}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "CreateObject",
+					Params: []any{
+						"testdata/tfplan.binary",
+						"this is a plan binary",
+					},
+				},
+			},
+		},
+		{
+			name:                     "success_with_diff_upsert_status",
+			directory:                "testdata",
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			flagUpsertStatus:         true,
+			terraformClient:          terraformDiffMock,
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "UpsertStatus",
+					Params: []any{platform.StatusSuccess, &platform.StatusParams{HasDiff: true, Details: "terraform show success with diff", Dir: "testdata", Operation: "plan"}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "CreateObject",
+					Params: []any{
+						"testdata/tfplan.binary",
+						"this is a plan binary",
+					},
+				},
+			},
+		},
+		{
+			name:                     "success_with_diff_locked_by_other",
+			directory:                "testdata",
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			applyLock:                `{"entrypoint":"testdata","holder":4,"expires_at":"2100-01-01T00:00:00Z","serial":2}`,
+			terraformClient:          terraformDiffMock,
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusSuccess, &platform.StatusParams{
+						HasDiff:   true,
+						Details:   "terraform show success with diff",
+						Message:   "> ⚠️ **This directory is locked by #4 while it is being applied, until 2100-01-01T00:00:00Z at the latest.** This plan will need to be re-run once the apply has completed.",
+						Dir:       "testdata",
+						Operation: "plan",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "CreateObject",
+					Params: []any{
+						"testdata/tfplan.binary",
+						"this is a plan binary",
+					},
+				},
+			},
+		},
+		{
+			name:                     "success_with_diff_destroy",
+			directory:                "testdata",
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			destroyRef:               "abc123",
+			terraformClient:          terraformDiffMock,
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusDestroy, &platform.StatusParams{
+						HasDiff:   true,
+						Details:   "terraform show success with diff",
+						Message:   "> ⚠️ **This directory was deleted by this change request. Applying this plan will destroy all of its resources.**",
+						Dir:       "testdata",
+						Operation: "plan",
+					}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "CreateObject",
+					Params: []any{
+						"testdata/tfplan.binary",
+						"this is a plan binary",
+					},
+				},
+			},
+		},
+		{
+			name:                     "success_with_diff_environment",
+			directory:                "testdata/environments",
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			flagEnvironment:          "dev",
+			terraformClient:          terraformDiffMock,
+			expStdout:                "terraform workspace select success",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "Status",
+					Params: []any{platform.StatusSuccess, &platform.StatusParams{HasDiff: true, Details: "terraform show success with diff", Dir: "testdata/environments@dev", Operation: "plan"}},
+				},
+			},
+			expStorageClientReqs: []*storage.Request{
+				{
+					Name: "CreateObject",
+					Params: []any{
+						"testdata/environments@dev/tfplan.binary",
+						"this is a plan binary",
+					},
+				},
+			},
+		},
+		{
+			name:                     "handles_undeclared_environment",
+			directory:                "testdata/environments",
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			flagEnvironment:          "prod",
+			terraformClient:          terraformDiffMock,
+			err:                      `failed to run Guardian plan: failed to select environment: environment "prod" is not declared in guardian.hcl`,
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name:   "Status",
+					Params: []any{platform.StatusFailure, &platform.StatusParams{ErrorMessage: `failed to select environment: environment "prod" is not declared in guardian.hcl`, Dir: "testdata/environments@prod", Operation: "plan"}},
+				},
+			},
+		},
 		{
 			name:                     "success_with_diff_and_report_stdout",
 			directory:                "testdata",
@@ -205,6 +479,37 @@ func TestPlan_Process(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:                     "handles_format_error",
+			directory:                "testdata",
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			terraformClient:          terraformFormatErrorMock,
+			err:                      "failed to run Guardian plan: failed to check formatting: failed to run terraform fmt",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusFailure, &platform.StatusParams{
+						HasDiff:      false,
+						Details:      "main.tf\n--- old/main.tf\n+++ new/main.tf\n@@ -1,3 +1,3 @@\n",
+						ErrorMessage: "failed to check formatting: failed to run terraform fmt",
+						Dir:          "testdata",
+						Operation:    "plan",
+						Annotations: []*platform.Annotation{
+							{
+								Path:      "testdata/main.tf",
+								StartLine: 1,
+								EndLine:   3,
+								Level:     platform.AnnotationLevelFailure,
+								Title:     "Terraform format",
+								Message:   "This file is not formatted correctly, run `terraform fmt` to fix it.",
+							},
+						},
+					}},
+				},
+			},
+		},
 		{
 			name:                     "handles_error",
 			directory:                "testdata",
@@ -222,6 +527,50 @@ func TestPlan_Process(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:                     "handles_plan_timeout",
+			directory:                "testdata",
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			flagPlanTimeout:          time.Millisecond,
+			terraformClient:          &hangingPlanClient{MockTerraformClient: terraformDiffMock},
+			err:                      "failed to run Guardian plan: failed to plan: terraform plan timed out after 1ms",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusFailure, &platform.StatusParams{
+						Dir:          "testdata",
+						Operation:    "plan",
+						Message:      "> ⚠️ **terraform plan timed out after 1ms.** It was interrupted and exited, releasing the state lock.",
+						ErrorMessage: "failed to plan: terraform plan timed out after 1ms: failed to run command: context deadline exceeded",
+					}},
+				},
+			},
+		},
+		{
+			name:                     "handles_plan_timeout_killed",
+			directory:                "testdata",
+			storagePrefix:            "",
+			flagAllowLockfileChanges: true,
+			flagLockTimeout:          10 * time.Minute,
+			flagPlanTimeout:          time.Millisecond,
+			terraformClient:          &hangingPlanClient{MockTerraformClient: terraformDiffMock, killed: true},
+			err:                      "failed to run Guardian plan: failed to plan: terraform plan timed out after 1ms and was killed before releasing the state lock",
+			expPlatformClientReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusFailure, &platform.StatusParams{
+						Dir:       "testdata",
+						Operation: "plan",
+						Message: "> ⚠️ **terraform plan timed out after 1ms and was killed before it exited.** " +
+							"The state lock may not have been released, in which case it must be released using `terraform force-unlock`.",
+						ErrorMessage: "failed to plan: terraform plan timed out after 1ms and was killed before releasing the state lock: " +
+							"failed to run command: process was killed after the grace period of 30s: signal: killed",
+					}},
+				},
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -235,15 +584,24 @@ func TestPlan_Process(t *testing.T) {
 				directory:                tc.directory,
 				childPath:                tc.directory,
 				storagePrefix:            tc.storagePrefix,
+				destroyRef:               tc.destroyRef,
+				flagEnvironment:          tc.flagEnvironment,
+				metaValues:               modifiers.ParseBodyMetaValues(ctx, tc.body),
 				flagOutputDir:            t.TempDir(),
 				flagAllowLockfileChanges: tc.flagAllowLockfileChanges,
 				flagReportStdout:         tc.flagReportStdout,
+				flagUpsertStatus:         tc.flagUpsertStatus,
 				flagLockTimeout:          tc.flagLockTimeout,
+				flagPlanTimeout:          tc.flagPlanTimeout,
 				terraformClient:          tc.terraformClient,
 				storageClient:            mockStorageClient,
 				platformClient:           mockPlatformClient,
 			}
 
+			if tc.applyLock != "" {
+				c.lockClient = locks.NewClient(&storage.MockStorageClient{DownloadData: tc.applyLock}, "owner/repo", 0)
+			}
+
 			_, stdout, stderr := c.Pipe()
 
 			err := c.Process(ctx)
@@ -262,6 +620,9 @@ func TestPlan_Process(t *testing.T) {
 			if got, want := strings.TrimSpace(stdout.String()), strings.TrimSpace(tc.expStdout); !strings.Contains(got, want) {
 				t.Errorf("expected stdout\n\n%s\n\nto contain\n\n%s\n\n", got, want)
 			}
+			if tc.expStdoutExcludes != "" && strings.Contains(stdout.String(), tc.expStdoutExcludes) {
+				t.Errorf("expected stdout\n\n%s\n\nto not contain\n\n%s\n\n", stdout.String(), tc.expStdoutExcludes)
+			}
 			if got, want := strings.TrimSpace(stderr.String()), strings.TrimSpace(tc.expStderr); !strings.Contains(got, want) {
 				t.Errorf("expected stderr\n\n%s\n\nto contain\n\n%s\n\n", got, want)
 			}
@@ -0,0 +1,122 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/abcxyz/guardian/pkg/terraform"
+)
+
+// maxSummaryDestroyedRows is the maximum number of destroyed resources listed
+// in the plan summary.
+const maxSummaryDestroyedRows = 50
+
+var actionText = map[string]string{
+	terraform.ActionCreate:  "🟩 create",
+	terraform.ActionUpdate:  "🟨 update",
+	terraform.ActionReplace: "🟧 replace",
+	terraform.ActionDelete:  "🟥 delete",
+}
+
+// planSummaryMessage renders the plan summary as markdown for the status
+// comment, highlighting deleted resources with a type matching any of the
+// sensitive patterns.
+func planSummaryMessage(s *terraform.PlanSummary, sensitive []*regexp.Regexp) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "| Create | Update | Replace | Delete |\n")
+	fmt.Fprintf(&b, "| ---: | ---: | ---: | ---: |\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d |", s.Create, s.Update, s.Replace, s.Delete)
+
+	if resources := s.SensitiveDestroyed(sensitive); len(resources) > 0 {
+		addresses := make([]string, 0, len(resources))
+		for _, r := range resources {
+			addresses = append(addresses, fmt.Sprintf("`%s`", r.Address))
+		}
+		fmt.Fprintf(&b, "\n\n> ⚠️ **Sensitive resources will be deleted:** %s", strings.Join(addresses, ", "))
+	}
+
+	if destroyed := s.Destroyed(); len(destroyed) > 0 {
+		fmt.Fprintf(&b, "\n\n**Resources being destroyed or replaced**\n\n")
+		fmt.Fprintf(&b, "| Resource | Action |\n")
+		fmt.Fprintf(&b, "| --- | --- |")
+		for i, r := range destroyed {
+			if i == maxSummaryDestroyedRows {
+				fmt.Fprintf(&b, "\n| _and %d more_ | |", len(destroyed)-i)
+				break
+			}
+			fmt.Fprintf(&b, "\n| `%s` | %s |", r.Address, actionText[r.Action])
+		}
+	}
+
+	if rows := summaryGroups(s); len(rows) > 0 {
+		fmt.Fprintf(&b, "\n\n<details>\n<summary>Resources by module and provider</summary>\n\n")
+		fmt.Fprintf(&b, "| Module | Provider | Create | Update | Replace | Delete |\n")
+		fmt.Fprintf(&b, "| --- | --- | ---: | ---: | ---: | ---: |")
+		for _, r := range rows {
+			fmt.Fprintf(&b, "\n| %s | %s | %d | %d | %d | %d |",
+				r.module, r.provider, r.counts[terraform.ActionCreate], r.counts[terraform.ActionUpdate],
+				r.counts[terraform.ActionReplace], r.counts[terraform.ActionDelete])
+		}
+		fmt.Fprintf(&b, "\n</details>")
+	}
+
+	return b.String()
+}
+
+// summaryGroup is the number of resource changes per action for a module and
+// provider.
+type summaryGroup struct {
+	module   string
+	provider string
+	counts   map[string]int
+}
+
+// summaryGroups groups the resource changes by module and provider, sorted by
+// module and provider.
+func summaryGroups(s *terraform.PlanSummary) []*summaryGroup {
+	groups := make(map[[2]string]*summaryGroup)
+	for _, r := range s.Resources {
+		module := r.Module
+		if module == "" {
+			module = "_root_"
+		}
+		provider := strings.TrimPrefix(r.Provider, "registry.terraform.io/")
+
+		key := [2]string{module, provider}
+		g, ok := groups[key]
+		if !ok {
+			g = &summaryGroup{module: module, provider: provider, counts: make(map[string]int)}
+			groups[key] = g
+		}
+		g.counts[r.Action]++
+	}
+
+	rows := make([]*summaryGroup, 0, len(groups))
+	for _, g := range groups {
+		rows = append(rows, g)
+	}
+	slices.SortFunc(rows, func(a, b *summaryGroup) int {
+		if c := strings.Compare(a.module, b.module); c != 0 {
+			return c
+		}
+		return strings.Compare(a.provider, b.provider)
+	})
+	return rows
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/guardian/pkg/terraform"
+)
+
+func TestPlanSummaryMessage(t *testing.T) {
+	t.Parallel()
+
+	summary := &terraform.PlanSummary{
+		Create:  1,
+		Replace: 1,
+		Delete:  1,
+		Resources: []*terraform.PlanResourceChange{
+			{Address: "google_storage_bucket.new", Type: "google_storage_bucket", Provider: "registry.terraform.io/hashicorp/google", Action: terraform.ActionCreate},
+			{Address: "module.kms.google_kms_crypto_key.key", Module: "module.kms", Type: "google_kms_crypto_key", Provider: "registry.terraform.io/hashicorp/google", Action: terraform.ActionReplace},
+			{Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Provider: "registry.terraform.io/hashicorp/aws", Action: terraform.ActionDelete},
+		},
+	}
+
+	cases := []struct {
+		name      string
+		sensitive []*regexp.Regexp
+		exp       string
+	}{
+		{
+			name: "without_sensitive",
+			exp: "| Create | Update | Replace | Delete |\n" +
+				"| ---: | ---: | ---: | ---: |\n" +
+				"| 1 | 0 | 1 | 1 |\n\n" +
+				"**Resources being destroyed or replaced**\n\n" +
+				"| Resource | Action |\n" +
+				"| --- | --- |\n" +
+				"| `module.kms.google_kms_crypto_key.key` | 🟧 replace |\n" +
+				"| `aws_s3_bucket.logs` | 🟥 delete |\n\n" +
+				"<details>\n<summary>Resources by module and provider</summary>\n\n" +
+				"| Module | Provider | Create | Update | Replace | Delete |\n" +
+				"| --- | --- | ---: | ---: | ---: | ---: |\n" +
+				"| _root_ | hashicorp/aws | 0 | 0 | 0 | 1 |\n" +
+				"| _root_ | hashicorp/google | 1 | 0 | 0 | 0 |\n" +
+				"| module.kms | hashicorp/google | 0 | 0 | 1 | 0 |\n" +
+				"</details>",
+		},
+		{
+			name:      "with_sensitive",
+			sensitive: []*regexp.Regexp{regexp.MustCompile(`_kms_`)},
+			exp: "| Create | Update | Replace | Delete |\n" +
+				"| ---: | ---: | ---: | ---: |\n" +
+				"| 1 | 0 | 1 | 1 |\n\n" +
+				"> ⚠️ **Sensitive resources will be deleted:** `module.kms.google_kms_crypto_key.key`\n\n" +
+				"**Resources being destroyed or replaced**\n\n" +
+				"| Resource | Action |\n" +
+				"| --- | --- |\n" +
+				"| `module.kms.google_kms_crypto_key.key` | 🟧 replace |\n" +
+				"| `aws_s3_bucket.logs` | 🟥 delete |\n\n" +
+				"<details>\n<summary>Resources by module and provider</summary>\n\n" +
+				"| Module | Provider | Create | Update | Replace | Delete |\n" +
+				"| --- | --- | ---: | ---: | ---: | ---: |\n" +
+				"| _root_ | hashicorp/aws | 0 | 0 | 0 | 1 |\n" +
+				"| _root_ | hashicorp/google | 1 | 0 | 0 | 0 |\n" +
+				"| module.kms | hashicorp/google | 0 | 0 | 1 | 0 |\n" +
+				"</details>",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := planSummaryMessage(summary, tc.sensitive)
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("message not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
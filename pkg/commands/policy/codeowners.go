@@ -0,0 +1,220 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/abcxyz/guardian/pkg/codeowners"
+	"github.com/abcxyz/guardian/pkg/git"
+	"github.com/abcxyz/guardian/pkg/platform"
+	"github.com/abcxyz/pkg/logging"
+)
+
+// codeOwnersPolicyName is the name the results of the built-in codeowners
+// policy are reported with.
+const codeOwnersPolicyName = "guardian_codeowners"
+
+// codeOwnersPolicy is a built-in policy that requires an approval from the code
+// owners of the changed entrypoints and modules, and of the entrypoints using a
+// changed module. The CODEOWNERS file is read from the base branch, so a change
+// request cannot change its own owners.
+type codeOwnersPolicy struct {
+	// file is the path of the CODEOWNERS file, relative to the repository root.
+	file          string
+	repositoryDir string
+	gitClient     git.Git
+}
+
+// newCodeOwnersPolicy returns the codeowners policy, nil if no CODEOWNERS file
+// is configured.
+func newCodeOwnersPolicy(f *CodeOwnersFlags) (*codeOwnersPolicy, error) {
+	if f.CodeOwnersFile == "" {
+		return nil, nil
+	}
+
+	repositoryDir, err := filepath.Abs(f.CodeOwnersRepositoryDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for %s: %w", f.CodeOwnersRepositoryDir, err)
+	}
+	file, err := filepath.Abs(f.CodeOwnersFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for %s: %w", f.CodeOwnersFile, err)
+	}
+
+	rel, err := filepath.Rel(repositoryDir, file)
+	if err != nil || !filepath.IsLocal(rel) {
+		return nil, fmt.Errorf("codeowners file %s must be in the repository %s", f.CodeOwnersFile, f.CodeOwnersRepositoryDir)
+	}
+
+	return &codeOwnersPolicy{
+		file:          filepath.ToSlash(rel),
+		repositoryDir: f.CodeOwnersRepositoryDir,
+		gitClient:     git.NewGitClient(f.CodeOwnersRepositoryDir),
+	}, nil
+}
+
+// evaluate returns the missing approvals for the change request. Owners are
+// satisfied by an approval from any one of them, email owners are ignored as
+// they cannot be matched to approvers.
+func (p *codeOwnersPolicy) evaluate(ctx context.Context, plat platform.Platform) (*Result, error) {
+	logger := logging.FromContext(ctx)
+
+	data, err := plat.GetPolicyData(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy data: %w", err)
+	}
+
+	cr := data.ChangeRequest()
+	if cr == nil {
+		logger.DebugContext(ctx, "skipped codeowners policy, not a change request")
+		return &Result{}, nil
+	}
+
+	base := newBaseTree(p.gitClient, cr)
+	if base == nil {
+		return nil, fmt.Errorf("the base branch of the change request is required to read the codeowners file")
+	}
+
+	contents, err := base.readFile(ctx, p.file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read codeowners file: %w", err)
+	}
+
+	file, err := codeowners.Parse(bytes.NewReader(contents))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse codeowners file: %w", err)
+	}
+
+	changes, err := changedPaths(ctx, p.repositoryDir, base, cr.ChangedFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find changed entrypoints and modules: %w", err)
+	}
+
+	approvers, err := plat.GetLatestApprovers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest approvers: %w", err)
+	}
+
+	// only GitLab reports the full path of the approving groups
+	fullTeamPaths := data.GitLab != nil
+
+	var result Result
+
+	// the owners of the CODEOWNERS file must approve changes to the owners
+	if slices.Contains(cr.ChangedFiles, p.file) {
+		owners := file.Owners(p.file)
+		users, teams := ownerHandles(owners)
+		if (len(users) > 0 || len(teams) > 0) && !approvedByOwner(approvers, users, teams, fullTeamPaths) {
+			result.MissingApprovals = append(result.MissingApprovals, &MissingApproval{
+				AssignUsers: users,
+				AssignTeams: teams,
+				Message: fmt.Sprintf("changes to the CODEOWNERS file `%s` require an approval from its code owners: %s",
+					p.file, strings.Join(owners, ", ")),
+			})
+		}
+	}
+
+	for _, c := range changes {
+		owners := file.Owners(c.path + "/")
+
+		users, teams := ownerHandles(owners)
+		if len(users) == 0 && len(teams) == 0 {
+			logger.DebugContext(ctx, "no code owners for changed path",
+				"path", c.path)
+			continue
+		}
+
+		if approvedByOwner(approvers, users, teams, fullTeamPaths) {
+			continue
+		}
+
+		result.MissingApprovals = append(result.MissingApprovals, &MissingApproval{
+			AssignUsers: users,
+			AssignTeams: teams,
+			Message:     c.approvalMessage(owners),
+		})
+	}
+
+	return &result, nil
+}
+
+// ownerHandles returns the users and teams of the owners, without the leading
+// @. Email owners are ignored.
+func ownerHandles(owners []string) (users, teams []string) {
+	for _, o := range owners {
+		name, ok := strings.CutPrefix(o, "@")
+		switch {
+		case !ok:
+			continue
+		case strings.Contains(name, "/"):
+			teams = append(teams, name)
+		default:
+			users = append(users, name)
+		}
+	}
+	return users, teams
+}
+
+// approvalMessage returns the reason the code owners of the changed path are
+// required to approve.
+func (c *changedPath) approvalMessage(owners []string) string {
+	switch {
+	case c.module:
+		return fmt.Sprintf("changes to module `%s` require an approval from its code owners: %s",
+			c.path, strings.Join(owners, ", "))
+	case c.direct:
+		return fmt.Sprintf("changes to entrypoint `%s` require an approval from its code owners: %s",
+			c.path, strings.Join(owners, ", "))
+	default:
+		return fmt.Sprintf("changes to module(s) `%s` used by entrypoint `%s` require an approval from the entrypoint code owners: %s",
+			strings.Join(c.modules, "`, `"), c.path, strings.Join(owners, ", "))
+	}
+}
+
+// approvedByOwner returns true if one of the users or teams approved. Teams are
+// qualified by their organization or parent group, e.g. org/team. When the
+// platform reports the full paths of the approving teams they are compared with
+// the full path. Otherwise the platform returns the names of the approving
+// teams, so the last segment is compared with the team name in lower case with
+// spaces replaced by dashes.
+func approvedByOwner(approvers *platform.GetLatestApproversResult, users, teams []string, fullTeamPaths bool) bool {
+	for _, u := range users {
+		if slices.ContainsFunc(approvers.Users, func(a string) bool {
+			return strings.EqualFold(a, u)
+		}) {
+			return true
+		}
+	}
+
+	for _, t := range teams {
+		slug := t[strings.LastIndex(t, "/")+1:]
+		if slices.ContainsFunc(approvers.Teams, func(a string) bool {
+			if fullTeamPaths {
+				return strings.EqualFold(a, t)
+			}
+			return strings.EqualFold(a, t) || strings.EqualFold(strings.ReplaceAll(a, " ", "-"), slug)
+		}) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,352 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/guardian/pkg/git"
+	"github.com/abcxyz/guardian/pkg/platform"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+const testCodeOwners = `
+*                   @org/platform
+/entrypoints/dev/   @dev-owner @org/dev-team
+/entrypoints/prod/  prod@example.com @org/prod-team
+/modules/           @module-owner
+/README.md
+/.github/CODEOWNERS @org/security
+`
+
+func testCodeOwnersPolicy() *codeOwnersPolicy {
+	return &codeOwnersPolicy{
+		file:          ".github/CODEOWNERS",
+		repositoryDir: "testdata/fetch_data/repository",
+		gitClient: &git.MockGitClient{
+			ShowFiles: map[string]string{".github/CODEOWNERS": testCodeOwners},
+			// entrypoints/old is deleted by the change request
+			ExtractFiles: map[string]string{
+				"entrypoints/old/main.tf": `terraform {
+  backend "gcs" {
+    bucket = "my-bucket"
+    prefix = "old"
+  }
+}`,
+			},
+		},
+	}
+}
+
+func TestCodeOwnersPolicy_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(t.Context(), logging.TestLogger(t))
+
+	cases := []struct {
+		name              string
+		isPullRequest     bool
+		includeTeams      bool
+		missingBaseBranch bool
+		changedFiles      []string
+		users             []string
+		teams             []string
+		want              *Result
+		wantErr           string
+	}{
+		{
+			name:          "module_change_requires_entrypoint_owners",
+			isPullRequest: true,
+			changedFiles:  []string{"modules/bucket/main.tf"},
+			want: &Result{
+				MissingApprovals: []*MissingApproval{
+					{
+						AssignUsers: []string{"dev-owner"},
+						AssignTeams: []string{"org/dev-team"},
+						Message:     "changes to module(s) `modules/bucket` used by entrypoint `entrypoints/dev` require an approval from the entrypoint code owners: @dev-owner, @org/dev-team",
+					},
+					{
+						AssignUsers: []string{"module-owner"},
+						Message:     "changes to module `modules/bucket` require an approval from its code owners: @module-owner",
+					},
+				},
+			},
+		},
+		{
+			name:          "approved_by_owners",
+			isPullRequest: true,
+			includeTeams:  true,
+			changedFiles:  []string{"modules/bucket/main.tf"},
+			users:         []string{"Module-Owner"},
+			teams:         []string{"Dev Team"},
+			want:          &Result{},
+		},
+		{
+			name:          "team_approval_requires_teams",
+			isPullRequest: true,
+			changedFiles:  []string{"entrypoints/dev/main.tf"},
+			teams:         []string{"dev-team"},
+			want: &Result{
+				MissingApprovals: []*MissingApproval{
+					{
+						AssignUsers: []string{"dev-owner"},
+						AssignTeams: []string{"org/dev-team"},
+						Message:     "changes to entrypoint `entrypoints/dev` require an approval from its code owners: @dev-owner, @org/dev-team",
+					},
+				},
+			},
+		},
+		{
+			name:          "email_owners_not_assigned",
+			isPullRequest: true,
+			changedFiles:  []string{"entrypoints/prod/main.tf"},
+			users:         []string{"dev-owner"},
+			want: &Result{
+				MissingApprovals: []*MissingApproval{
+					{
+						AssignTeams: []string{"org/prod-team"},
+						Message:     "changes to entrypoint `entrypoints/prod` require an approval from its code owners: prod@example.com, @org/prod-team",
+					},
+				},
+			},
+		},
+		{
+			name:          "subdirectory_change_requires_entrypoint_owners",
+			isPullRequest: true,
+			changedFiles:  []string{"entrypoints/prod/files/startup.sh"},
+			want: &Result{
+				MissingApprovals: []*MissingApproval{
+					{
+						AssignTeams: []string{"org/prod-team"},
+						Message:     "changes to entrypoint `entrypoints/prod` require an approval from its code owners: prod@example.com, @org/prod-team",
+					},
+				},
+			},
+		},
+		{
+			name:          "deleted_entrypoint_requires_owners",
+			isPullRequest: true,
+			changedFiles:  []string{"entrypoints/old/main.tf"},
+			want: &Result{
+				MissingApprovals: []*MissingApproval{
+					{
+						AssignTeams: []string{"org/platform"},
+						Message:     "changes to entrypoint `entrypoints/old` require an approval from its code owners: @org/platform",
+					},
+				},
+			},
+		},
+		{
+			name:          "codeowners_change_requires_codeowners_owners",
+			isPullRequest: true,
+			changedFiles:  []string{".github/CODEOWNERS"},
+			want: &Result{
+				MissingApprovals: []*MissingApproval{
+					{
+						AssignTeams: []string{"org/security"},
+						Message:     "changes to the CODEOWNERS file `.github/CODEOWNERS` require an approval from its code owners: @org/security",
+					},
+				},
+			},
+		},
+		{
+			name:          "codeowners_change_approved",
+			isPullRequest: true,
+			includeTeams:  true,
+			changedFiles:  []string{".github/CODEOWNERS"},
+			teams:         []string{"security"},
+			want:          &Result{},
+		},
+		{
+			name:          "no_entrypoints_changed",
+			isPullRequest: true,
+			changedFiles:  []string{"README.md"},
+			want:          &Result{},
+		},
+		{
+			name: "not_change_request",
+			want: &Result{},
+		},
+		{
+			name:              "missing_base_branch",
+			isPullRequest:     true,
+			missingBaseBranch: true,
+			changedFiles:      []string{"entrypoints/prod/main.tf"},
+			wantErr:           "the base branch of the change request is required to read the codeowners file",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			baseBranch := "main"
+			if tc.missingBaseBranch {
+				baseBranch = ""
+			}
+
+			mockPlatform := &platform.MockPlatform{
+				IsPullRequest: tc.isPullRequest,
+				IncludeTeams:  tc.includeTeams,
+				UserApprovers: tc.users,
+				TeamApprovers: tc.teams,
+				ChangeRequest: &platform.ChangeRequestData{
+					BaseBranch:   baseBranch,
+					ChangedFiles: tc.changedFiles,
+				},
+			}
+
+			got, err := testCodeOwnersPolicy().evaluate(ctx, mockPlatform)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("result not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestApprovedByOwner(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name          string
+		approvers     *platform.GetLatestApproversResult
+		users         []string
+		teams         []string
+		fullTeamPaths bool
+		want          bool
+	}{
+		{
+			name:      "user",
+			approvers: &platform.GetLatestApproversResult{Users: []string{"Dev-Owner"}},
+			users:     []string{"dev-owner"},
+			want:      true,
+		},
+		{
+			name:      "team_name",
+			approvers: &platform.GetLatestApproversResult{Teams: []string{"Dev Team"}},
+			teams:     []string{"org/dev-team"},
+			want:      true,
+		},
+		{
+			name:          "full_team_path",
+			approvers:     &platform.GetLatestApproversResult{Teams: []string{"Infra/Dev-Team"}},
+			teams:         []string{"infra/dev-team"},
+			fullTeamPaths: true,
+			want:          true,
+		},
+		{
+			name:          "full_team_path_of_other_parent",
+			approvers:     &platform.GetLatestApproversResult{Teams: []string{"other/dev-team"}},
+			teams:         []string{"infra/dev-team"},
+			fullTeamPaths: true,
+			want:          false,
+		},
+		{
+			name:      "not_approved",
+			approvers: &platform.GetLatestApproversResult{Users: []string{"other"}, Teams: []string{"other-team"}},
+			users:     []string{"dev-owner"},
+			teams:     []string{"org/dev-team"},
+			want:      false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := approvedByOwner(tc.approvers, tc.users, tc.teams, tc.fullTeamPaths); got != tc.want {
+				t.Errorf("expected approved to be %t, got %t", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestEnforce_CodeOwners(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(t.Context(), logging.TestLogger(t))
+
+	cases := []struct {
+		name      string
+		results   Results
+		err       string
+		expStatus platform.Status
+	}{
+		{
+			name:      "reports_missing_approvals",
+			results:   Results{},
+			err:       "failed: \"guardian_codeowners\" - changes to entrypoint `entrypoints/prod` require an approval from its code owners: prod@example.com, @org/prod-team",
+			expStatus: platform.StatusPolicyViolation,
+		},
+		{
+			name: "reserved_policy_name",
+			results: Results{
+				codeOwnersPolicyName: {},
+			},
+			err: "policy name \"guardian_codeowners\" is reserved for the built-in codeowners policy",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockPlatform := &platform.MockPlatform{
+				IsPullRequest: true,
+				ChangeRequest: &platform.ChangeRequestData{
+					BaseBranch:   "main",
+					ChangedFiles: []string{"entrypoints/prod/main.tf"},
+				},
+			}
+			c := &EnforceCommand{
+				directory:  "dir",
+				platform:   mockPlatform,
+				codeOwners: testCodeOwnersPolicy(),
+			}
+
+			err := c.Enforce(ctx, tc.results)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			var assigned *platform.AssignReviewersInput
+			var status platform.Status
+			for _, req := range mockPlatform.Reqs {
+				switch req.Name {
+				case "AssignReviewers":
+					assigned = req.Params[0].(*platform.AssignReviewersInput)
+				case "Status":
+					status = req.Params[0].(platform.Status)
+				}
+			}
+			if status != tc.expStatus {
+				t.Errorf("expected status %q to be %q", status, tc.expStatus)
+			}
+
+			if tc.expStatus == "" {
+				return
+			}
+			if diff := cmp.Diff(assigned, &platform.AssignReviewersInput{Users: []string{}, Teams: []string{"org/prod-team"}}); diff != "" {
+				t.Errorf("assigned reviewers not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
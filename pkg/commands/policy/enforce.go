@@ -20,7 +20,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/abcxyz/guardian/internal/metricswrap"
@@ -31,10 +33,34 @@ import (
 	"github.com/abcxyz/pkg/sets"
 )
 
+// Severity is the severity the missing_approvals and deny results of a policy
+// are enforced with.
+type Severity string
+
+const (
+	// SeverityEnforce fails the run for missing approvals and deny violations.
+	// This is the default severity.
+	SeverityEnforce Severity = "enforce"
+
+	// SeverityWarn reports missing approvals and deny violations as warnings
+	// without failing the run, so new policies can be staged before they are
+	// enforced.
+	SeverityWarn Severity = "warn"
+)
+
 // Result defines the expected structure of the OPA policy evaluation result.
 type Result struct {
 	MissingApprovals []*MissingApproval `json:"missing_approvals"`
 	Deny             []*Deny            `json:"deny"`
+
+	// Warn and Info are advisory results, reported on the change request
+	// without failing the run.
+	Warn []*Advisory `json:"warn,omitempty"`
+	Info []*Advisory `json:"info,omitempty"`
+
+	// Severity overrides the severity of the policy, defaults to
+	// SeverityEnforce.
+	Severity Severity `json:"severity,omitempty"`
 }
 
 // MissingApproval defines the missing approvals determined from the policy
@@ -58,6 +84,8 @@ type EnforceCommand struct {
 	flags          EnforceFlags
 	commonFlags    flags.CommonFlags
 	platform       platform.Platform
+	exemptions     *exemptionRequests
+	codeOwners     *codeOwnersPolicy
 }
 
 // Desc implements cli.Command.
@@ -98,6 +126,18 @@ func (c *EnforceCommand) Run(ctx context.Context, args []string) error {
 	}
 	c.platform = platform
 
+	exemptions, err := newExemptionRequests(ctx, &c.platformConfig, &c.flags.ExemptionFlags)
+	if err != nil {
+		return err
+	}
+	c.exemptions = exemptions
+
+	codeOwners, err := newCodeOwnersPolicy(&c.flags.CodeOwnersFlags)
+	if err != nil {
+		return err
+	}
+	c.codeOwners = codeOwners
+
 	cwd, err := c.WorkingDir()
 	if err != nil {
 		return fmt.Errorf("failed to get current working directory: %w", err)
@@ -128,28 +168,98 @@ func (c *EnforceCommand) Process(ctx context.Context) error {
 		return fmt.Errorf("failed to unmarshal json: %w", err)
 	}
 
+	return c.Enforce(ctx, *results)
+}
+
+// Enforce enforces the results of the policy evaluation, reporting any
+// violations, warnings and notes on the change request.
+func (c *EnforceCommand) Enforce(ctx context.Context, results Results) error {
+	logger := logging.FromContext(ctx)
+
+	if c.codeOwners != nil {
+		if _, ok := results[codeOwnersPolicyName]; ok {
+			return fmt.Errorf("policy name %q is reserved for the built-in codeowners policy", codeOwnersPolicyName)
+		}
+
+		r, err := c.codeOwners.evaluate(ctx, c.platform)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate codeowners policy: %w", err)
+		}
+		results = maps.Clone(results)
+		results[codeOwnersPolicyName] = r
+	}
+
+	names := make([]string, 0, len(results))
+	for k := range results {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	exempted, notExempted, err := c.applyExemptions(ctx, results)
+	if err != nil {
+		return err
+	}
+
 	var merr error
+	var advisory bool
 	var b strings.Builder
-	for k, v := range *results {
+	var annotations []*platform.Annotation
+	for _, k := range names {
+		v := results[k]
+		if v == nil {
+			continue
+		}
+
 		logger.DebugContext(ctx, "processing policy decision",
-			"policy_name", k)
+			"policy_name", k,
+			"severity", v.Severity)
 
 		var violation error
 		var st strings.Builder
-		if err := c.EnforceMissingApprovals(ctx, &st, k, v); err != nil {
-			violation = errors.Join(violation, err)
+		if reason, ok := notExempted[k]; ok {
+			fmt.Fprintf(&st, "- **Exemption not applied**: %s\n", reason)
 		}
 
-		if err := c.EnforceDeny(ctx, &st, k, v); err != nil {
-			violation = errors.Join(violation, err)
+		switch e := exempted[k]; {
+		case e != nil:
+			writeExempted(&st, v, e)
+			advisory = true
+			annotations = append(annotations, advisoryAnnotations(k, stagedDenies(v), platform.AnnotationLevelWarning)...)
+		case v.Severity == "" || v.Severity == SeverityEnforce:
+			if err := c.EnforceMissingApprovals(ctx, &st, k, v); err != nil {
+				violation = errors.Join(violation, err)
+			}
+
+			if err := c.EnforceDeny(ctx, &st, k, v); err != nil {
+				violation = errors.Join(violation, err)
+				annotations = append(annotations, denyAnnotations(k, v)...)
+			}
+		case v.Severity == SeverityWarn:
+			if writeSuppressed(&st, "Policy in warn mode, not enforced", v) {
+				advisory = true
+				annotations = append(annotations, advisoryAnnotations(k, stagedDenies(v), platform.AnnotationLevelWarning)...)
+			}
+		default:
+			violation = fmt.Errorf("failed: \"%s\" - invalid severity %q, must be %q or %q", k, v.Severity, SeverityEnforce, SeverityWarn)
+			fmt.Fprintf(&st, "- **Invalid severity**: `%s`\n", v.Severity)
 		}
 
-		if violation != nil {
-			// Prints policy name followed by the violations found.
+		if writeAdvisories(&st, "Warning(s)", v.Warn) {
+			advisory = true
+			annotations = append(annotations, advisoryAnnotations(k, v.Warn, platform.AnnotationLevelWarning)...)
+		}
+		if writeAdvisories(&st, "Note(s)", v.Info) {
+			advisory = true
+			annotations = append(annotations, advisoryAnnotations(k, v.Info, platform.AnnotationLevelNotice)...)
+		}
+
+		if st.Len() > 0 {
+			// Prints policy name followed by the violations, warnings and notes
+			// found.
 			fmt.Fprintf(&b, "#### Policy: `%s`\n", k)
 			fmt.Fprintf(&b, "%s\n", st.String())
-			merr = errors.Join(merr, violation)
 		}
+		merr = errors.Join(merr, violation)
 	}
 
 	if c.flags.SkipReporting {
@@ -158,14 +268,27 @@ func (c *EnforceCommand) Process(ctx context.Context) error {
 
 	if merr != nil {
 		if err := c.platform.ReportStatus(ctx, platform.StatusPolicyViolation, &platform.StatusParams{
-			Operation: "Policy Violation",
-			Dir:       c.directory,
-			Message:   fmt.Sprintf("The planned resource changes raised policy violations that will need to be addressed:\n\n%s", b.String()),
+			Operation:   "Policy Violation",
+			Dir:         c.directory,
+			Message:     fmt.Sprintf("The planned resource changes raised policy violations that will need to be addressed:\n\n%s", b.String()),
+			Annotations: annotations,
 		}); err != nil {
 			return fmt.Errorf("failed to report status: %w", err)
 		}
+		return merr
 	}
-	return merr
+
+	if advisory {
+		if err := c.platform.ReportStatus(ctx, platform.StatusPolicyAdvisory, &platform.StatusParams{
+			Operation:   "Policy Advisory",
+			Dir:         c.directory,
+			Message:     fmt.Sprintf("The planned resource changes raised policy warnings, notes or exemptions that do not block the changes:\n\n%s", b.String()),
+			Annotations: annotations,
+		}); err != nil {
+			return fmt.Errorf("failed to report status: %w", err)
+		}
+	}
+	return nil
 }
 
 // EnforceMissingApprovals checks for any missing_approvals violations attempts
@@ -230,6 +353,11 @@ func (c *EnforceCommand) EnforceMissingApprovals(ctx context.Context, b *strings
 // evaluation result.
 type Deny struct {
 	Message string `json:"msg"`
+
+	// Path and Line optionally locate the violation in a file, relative to the
+	// repository root, for platforms that support annotations.
+	Path string `json:"path,omitempty"`
+	Line int    `json:"line,omitempty"`
 }
 
 // EnforceDeny blocks the action if a deny violation is found and reports
@@ -251,3 +379,109 @@ func (c *EnforceCommand) EnforceDeny(ctx context.Context, b *strings.Builder, po
 	}
 	return merr
 }
+
+// denyAnnotations returns annotations for the deny violations that include a
+// file location.
+func denyAnnotations(policyName string, r *Result) []*platform.Annotation {
+	var annotations []*platform.Annotation
+	for _, m := range r.Deny {
+		if m.Path == "" {
+			continue
+		}
+
+		annotations = append(annotations, &platform.Annotation{
+			Path:      m.Path,
+			StartLine: m.Line,
+			EndLine:   m.Line,
+			Level:     platform.AnnotationLevelFailure,
+			Title:     fmt.Sprintf("Policy: %s", policyName),
+			Message:   m.Message,
+		})
+	}
+	return annotations
+}
+
+// Advisory defines the expected structure of the warn and info results from
+// the policy evaluation result.
+type Advisory struct {
+	Message string `json:"msg"`
+
+	// Path and Line optionally locate the result in a file, relative to the
+	// repository root, for platforms that support annotations.
+	Path string `json:"path,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// writeAdvisories writes the advisory results under the heading and returns
+// true if there were any.
+func writeAdvisories(b *strings.Builder, heading string, advisories []*Advisory) bool {
+	if len(advisories) == 0 {
+		return false
+	}
+
+	fmt.Fprintf(b, "- **%s**:\n", heading)
+	for _, a := range advisories {
+		fmt.Fprintf(b, "\t - %s\n", a.Message)
+	}
+	return true
+}
+
+// writeSuppressed writes the missing approvals and deny violations of a policy
+// in warn mode or exempted, which are reported under the heading without
+// assigning reviewers or failing the run, and returns true if there were any.
+func writeSuppressed(b *strings.Builder, heading string, r *Result) bool {
+	if len(r.MissingApprovals) == 0 && len(r.Deny) == 0 {
+		return false
+	}
+
+	fmt.Fprintf(b, "- **%s**:\n", heading)
+	for _, m := range r.MissingApprovals {
+		fmt.Fprintf(b, "\t - Requires approval: %s\n", m.Message)
+
+		var from []string
+		if len(m.AssignUsers) > 0 {
+			from = append(from, "Users: "+strings.Join(m.AssignUsers, ", "))
+		}
+		if len(m.AssignTeams) > 0 {
+			from = append(from, "Teams: "+strings.Join(m.AssignTeams, ", "))
+		}
+		if len(from) > 0 {
+			fmt.Fprintf(b, "\t\t - Would request approvals from %s\n", strings.Join(from, "; "))
+		}
+	}
+	for _, m := range r.Deny {
+		fmt.Fprintf(b, "\t - Not allowed: %s\n", m.Message)
+	}
+	return true
+}
+
+// stagedDenies returns the deny violations of a policy in warn mode or
+// exempted as advisory results.
+func stagedDenies(r *Result) []*Advisory {
+	advisories := make([]*Advisory, 0, len(r.Deny))
+	for _, m := range r.Deny {
+		advisories = append(advisories, &Advisory{Message: m.Message, Path: m.Path, Line: m.Line})
+	}
+	return advisories
+}
+
+// advisoryAnnotations returns annotations at the level for the advisory results
+// that include a file location.
+func advisoryAnnotations(policyName string, advisories []*Advisory, level platform.AnnotationLevel) []*platform.Annotation {
+	var annotations []*platform.Annotation
+	for _, a := range advisories {
+		if a.Path == "" {
+			continue
+		}
+
+		annotations = append(annotations, &platform.Annotation{
+			Path:      a.Path,
+			StartLine: a.Line,
+			EndLine:   a.Line,
+			Level:     level,
+			Title:     fmt.Sprintf("Policy: %s", policyName),
+			Message:   a.Message,
+		})
+	}
+	return annotations
+}
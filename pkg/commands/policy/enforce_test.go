@@ -15,10 +15,18 @@
 package policy
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/guardian/pkg/exemptions"
+	"github.com/abcxyz/guardian/pkg/modifiers"
 	"github.com/abcxyz/guardian/pkg/platform"
+	"github.com/abcxyz/guardian/pkg/storage"
 	"github.com/abcxyz/pkg/logging"
 	"github.com/abcxyz/pkg/testutil"
 )
@@ -93,3 +101,440 @@ func TestEnforce_Process(t *testing.T) {
 		})
 	}
 }
+
+func TestEnforce_Enforce(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(t.Context(), logging.TestLogger(t))
+
+	cases := []struct {
+		name            string
+		results         Results
+		expPlatformReqs []*platform.Request
+		err             string
+	}{
+		{
+			name: "reports_warnings_and_notes",
+			results: Results{
+				"notes": {
+					Info: []*Advisory{{Message: "this change touches production"}},
+				},
+				"warnings": {
+					Warn: []*Advisory{{Message: "bucket is missing labels", Path: "terraform/main.tf", Line: 3}},
+				},
+			},
+			expPlatformReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusPolicyAdvisory, &platform.StatusParams{
+						Operation: "Policy Advisory",
+						Dir:       "dir",
+						Message: "The planned resource changes raised policy warnings, notes or exemptions that do not block the changes:\n\n" +
+							"#### Policy: `notes`\n- **Note(s)**:\n\t - this change touches production\n\n" +
+							"#### Policy: `warnings`\n- **Warning(s)**:\n\t - bucket is missing labels\n\n",
+						Annotations: []*platform.Annotation{
+							{
+								Path:      "terraform/main.tf",
+								StartLine: 3,
+								EndLine:   3,
+								Level:     platform.AnnotationLevelWarning,
+								Title:     "Policy: warnings",
+								Message:   "bucket is missing labels",
+							},
+						},
+					}},
+				},
+			},
+		},
+		{
+			name: "stages_policy_in_warn_mode",
+			results: Results{
+				"staged": {
+					Severity: SeverityWarn,
+					MissingApprovals: []*MissingApproval{
+						{AssignTeams: []string{"iam-admins"}, Message: "IAM changes require approval"},
+					},
+					Deny: []*Deny{{Message: "public buckets are not allowed"}},
+				},
+			},
+			expPlatformReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusPolicyAdvisory, &platform.StatusParams{
+						Operation: "Policy Advisory",
+						Dir:       "dir",
+						Message: "The planned resource changes raised policy warnings, notes or exemptions that do not block the changes:\n\n" +
+							"#### Policy: `staged`\n- **Policy in warn mode, not enforced**:\n" +
+							"\t - Requires approval: IAM changes require approval\n" +
+							"\t\t - Would request approvals from Teams: iam-admins\n" +
+							"\t - Not allowed: public buckets are not allowed\n\n",
+					}},
+				},
+			},
+		},
+		{
+			name: "reports_warnings_with_violations",
+			results: Results{
+				"deny_policy": {
+					Deny: []*Deny{{Message: "public buckets are not allowed"}},
+					Warn: []*Advisory{{Message: "bucket is missing labels"}},
+				},
+			},
+			expPlatformReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusPolicyViolation, &platform.StatusParams{
+						Operation: "Policy Violation",
+						Dir:       "dir",
+						Message: "The planned resource changes raised policy violations that will need to be addressed:\n\n" +
+							"#### Policy: `deny_policy`\n- **Action(s) not allowed**:\n\t - Reason: public buckets are not allowed\n" +
+							"- **Warning(s)**:\n\t - bucket is missing labels\n\n",
+					}},
+				},
+			},
+			err: `failed: "deny_policy" - public buckets are not allowed`,
+		},
+		{
+			name: "fails_with_invalid_severity",
+			results: Results{
+				"typo": {Severity: "warning"},
+			},
+			expPlatformReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusPolicyViolation, &platform.StatusParams{
+						Operation: "Policy Violation",
+						Dir:       "dir",
+						Message: "The planned resource changes raised policy violations that will need to be addressed:\n\n" +
+							"#### Policy: `typo`\n- **Invalid severity**: `warning`\n\n",
+					}},
+				},
+			},
+			err: `failed: "typo" - invalid severity "warning", must be "enforce" or "warn"`,
+		},
+		{
+			name: "no_results",
+			results: Results{
+				"empty": {},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockPlatform := &platform.MockPlatform{}
+			c := &EnforceCommand{
+				directory: "dir",
+				platform:  mockPlatform,
+			}
+
+			err := c.Enforce(ctx, tc.results)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(mockPlatform.Reqs, tc.expPlatformReqs); diff != "" {
+				t.Errorf("platform calls not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestEnforce_Exemptions(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(t.Context(), logging.TestLogger(t))
+
+	results := Results{
+		"iam": {
+			Deny: []*Deny{{Message: "owner role is not allowed"}},
+		},
+		"labels": {
+			Warn: []*Advisory{{Message: "bucket is missing labels"}},
+		},
+	}
+
+	cases := []struct {
+		name            string
+		existing        *exemptions.Exemption
+		accessLevel     string
+		accessLevels    map[string]string
+		userTeams       []string
+		exemptionTeams  []string
+		editors         *platform.ChangeRequestEditors
+		source          string
+		expStatus       platform.Status
+		expMessage      string
+		expAuditActions []string
+		err             string
+	}{
+		{
+			name:            "applies_exemption",
+			accessLevel:     "admin",
+			expStatus:       platform.StatusPolicyAdvisory,
+			expMessage:      "- **Exempted by admin-user until ",
+			expAuditActions: []string{exemptions.ActionGranted},
+		},
+		{
+			name:            "authorized_by_team",
+			accessLevel:     "write",
+			userTeams:       []string{"platform-admins"},
+			exemptionTeams:  []string{"platform-admins"},
+			expStatus:       platform.StatusPolicyAdvisory,
+			expMessage:      ", reason: incident INC-123**:\n\t - Not allowed: owner role is not allowed\n",
+			expAuditActions: []string{exemptions.ActionGranted},
+		},
+		{
+			name:            "rejects_unauthorized",
+			accessLevel:     "write",
+			userTeams:       []string{"developers"},
+			exemptionTeams:  []string{"platform-admins"},
+			expStatus:       platform.StatusPolicyViolation,
+			expMessage:      "- **Exemption not applied**: admin-user is not authorized to grant policy exemptions\n",
+			expAuditActions: []string{exemptions.ActionRejected},
+			err:             `failed: "iam" - owner role is not allowed`,
+		},
+		{
+			name:         "rejects_unauthorized_commenter",
+			accessLevels: map[string]string{"admin-user": "admin", "dev-user": "write"},
+			editors: &platform.ChangeRequestEditors{
+				Comments: []*platform.ChangeRequestComment{
+					{Author: "admin-user", Body: "GUARDIAN_EXEMPT=iam:incident INC-123"},
+					{Author: "dev-user", Body: "GUARDIAN_EXEMPT=iam:incident INC-123"},
+				},
+			},
+			expStatus:       platform.StatusPolicyViolation,
+			expMessage:      "- **Exemption not applied**: dev-user is not authorized to grant policy exemptions\n",
+			expAuditActions: []string{exemptions.ActionRejected},
+			err:             `failed: "iam" - owner role is not allowed`,
+		},
+		{
+			name:        "skips_invalid_comment",
+			accessLevel: "admin",
+			editors: &platform.ChangeRequestEditors{
+				Comments: []*platform.ChangeRequestComment{
+					{Author: "admin-user", Body: "GUARDIAN_EXEMPT=iam:incident INC-123"},
+					{Author: "dev-user", Body: "GUARDIAN_EXEMPT=iam"},
+				},
+			},
+			expStatus:       platform.StatusPolicyAdvisory,
+			expMessage:      "- **Exempted by admin-user until ",
+			expAuditActions: []string{exemptions.ActionGranted},
+		},
+		{
+			name:        "rejects_edited_comment",
+			accessLevel: "admin",
+			editors: &platform.ChangeRequestEditors{
+				Comments: []*platform.ChangeRequestComment{
+					{Author: "admin-user", Body: "GUARDIAN_EXEMPT=iam:incident INC-123", Edited: true},
+				},
+			},
+			expStatus:       platform.StatusPolicyViolation,
+			expMessage:      "- **Exemption not applied**: the comment requesting the exemption was edited, request it in a new comment\n",
+			expAuditActions: []string{},
+			err:             `failed: "iam" - owner role is not allowed`,
+		},
+		{
+			name:            "rejects_body_request",
+			accessLevel:     "admin",
+			editors:         &platform.ChangeRequestEditors{},
+			source:          modifiers.ExemptionSourceModifier,
+			expStatus:       platform.StatusPolicyViolation,
+			expMessage:      "- **Exemption not applied**: exemptions requested in the change request body cannot be attributed to a user, request it in a comment instead\n",
+			expAuditActions: []string{},
+			err:             `failed: "iam" - owner role is not allowed`,
+		},
+		{
+			name:            "comment_overrides_body_request",
+			accessLevel:     "admin",
+			source:          modifiers.ExemptionSourceModifier,
+			expStatus:       platform.StatusPolicyAdvisory,
+			expMessage:      "- **Exempted by admin-user until ",
+			expAuditActions: []string{exemptions.ActionGranted},
+		},
+		{
+			name:         "authorized_by_label_requester",
+			accessLevels: map[string]string{"admin-user": "admin", "dev-user": "write"},
+			editors: &platform.ChangeRequestEditors{
+				Labels: map[string]string{"guardian-exempt:iam": "admin-user"},
+			},
+			source:          modifiers.ExemptionSourceLabel,
+			expStatus:       platform.StatusPolicyAdvisory,
+			expMessage:      "- **Exempted by admin-user until ",
+			expAuditActions: []string{exemptions.ActionGranted},
+		},
+		{
+			name:         "rejects_unauthorized_label_requester",
+			accessLevels: map[string]string{"admin-user": "admin", "dev-user": "write"},
+			editors: &platform.ChangeRequestEditors{
+				Labels: map[string]string{"guardian-exempt:iam": "dev-user"},
+			},
+			source:          modifiers.ExemptionSourceLabel,
+			expStatus:       platform.StatusPolicyViolation,
+			expMessage:      "- **Exemption not applied**: dev-user is not authorized to grant policy exemptions\n",
+			expAuditActions: []string{exemptions.ActionRejected},
+			err:             `failed: "iam" - owner role is not allowed`,
+		},
+		{
+			name:            "rejects_unknown_label_requester",
+			accessLevel:     "admin",
+			editors:         &platform.ChangeRequestEditors{},
+			source:          modifiers.ExemptionSourceLabel,
+			expStatus:       platform.StatusPolicyViolation,
+			expMessage:      "- **Exemption not applied**: the user that requested the exemption could not be determined\n",
+			expAuditActions: []string{},
+			err:             `failed: "iam" - owner role is not allowed`,
+		},
+		{
+			name: "rejects_expired",
+			existing: &exemptions.Exemption{
+				Policy:        "iam",
+				ChangeRequest: 5,
+				Reason:        "incident INC-100",
+				GrantedBy:     "admin-user",
+				GrantedAt:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				ExpiresAt:     time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+			},
+			accessLevel:     "admin",
+			expStatus:       platform.StatusPolicyViolation,
+			expMessage:      "- **Exemption not applied**: the exemption expired at 2025-01-02T00:00:00Z\n",
+			expAuditActions: []string{exemptions.ActionExpired},
+			err:             `failed: "iam" - owner role is not allowed`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := storage.NewFilesystemStorage(ctx, t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.existing != nil {
+				b, err := json.Marshal(tc.existing)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := s.CreateObject(ctx, "guardian-exemptions/owner/repo/5/iam.json", b); err != nil {
+					t.Fatal(err)
+				}
+			}
+			client := exemptions.NewClient(s, "owner/repo", time.Hour)
+
+			editors := tc.editors
+			if editors == nil {
+				editors = &platform.ChangeRequestEditors{
+					Comments: []*platform.ChangeRequestComment{
+						{Author: "admin-user", Body: "looks good"},
+						{Author: "admin-user", Body: "GUARDIAN_EXEMPT=iam:incident INC-123"},
+					},
+				}
+			}
+
+			requested := map[string]*modifiers.Exemption{
+				"labels": {Reason: "no violations", Source: modifiers.ExemptionSourceModifier},
+			}
+			if tc.source != "" {
+				requested["iam"] = &modifiers.Exemption{Reason: "incident INC-123", Source: tc.source}
+			}
+
+			mockPlatform := &platform.MockPlatform{
+				UserAccessLevel:  tc.accessLevel,
+				UserAccessLevels: tc.accessLevels,
+				UserTeams:        tc.userTeams,
+				Editors:          editors,
+			}
+			c := &EnforceCommand{
+				directory: "dir",
+				platform:  mockPlatform,
+				flags: EnforceFlags{
+					ExemptionFlags: ExemptionFlags{
+						ExemptionPermissions: []string{"admin"},
+						ExemptionTeams:       tc.exemptionTeams,
+					},
+				},
+				exemptions: &exemptionRequests{
+					client:        client,
+					changeRequest: 5,
+					commitSHA:     "sha",
+					requested:     requested,
+				},
+			}
+
+			err = c.Enforce(ctx, results)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			var got *platform.StatusParams
+			for _, req := range mockPlatform.Reqs {
+				if req.Name != "Status" {
+					continue
+				}
+				if st := req.Params[0].(platform.Status); st != tc.expStatus {
+					t.Errorf("expected status %q to be %q", st, tc.expStatus)
+				}
+				got = req.Params[1].(*platform.StatusParams)
+			}
+			if got == nil {
+				t.Fatal("expected status to be reported")
+			}
+			if !strings.Contains(got.Message, tc.expMessage) {
+				t.Errorf("expected message %q to contain %q", got.Message, tc.expMessage)
+			}
+			if !strings.Contains(got.Message, "bucket is missing labels") {
+				t.Errorf("expected message %q to contain the warnings", got.Message)
+			}
+
+			stored, err := client.Get(ctx, 5, "iam")
+			if err != nil {
+				t.Fatal(err)
+			}
+			actions := make([]string, 0, len(stored.Audit))
+			for _, e := range stored.Audit {
+				actions = append(actions, e.Action)
+			}
+			if diff := cmp.Diff(actions, tc.expAuditActions); diff != "" {
+				t.Errorf("audit trail not as expected; (-got,+want): %s", diff)
+			}
+
+			// policies without violations are not exempted
+			unused, err := client.Get(ctx, 5, "labels")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(unused.Audit) > 0 {
+				t.Errorf("expected exemption for policy without violations not to be recorded, got %d events", len(unused.Audit))
+			}
+		})
+	}
+}
+
+func TestDenyAnnotations(t *testing.T) {
+	t.Parallel()
+
+	r := &Result{
+		Deny: []*Deny{
+			{Message: "no location"},
+			{Message: "bucket must not be public", Path: "terraform/main.tf", Line: 12},
+		},
+	}
+
+	got := denyAnnotations("deny_policy", r)
+	exp := []*platform.Annotation{
+		{
+			Path:      "terraform/main.tf",
+			StartLine: 12,
+			EndLine:   12,
+			Level:     platform.AnnotationLevelFailure,
+			Title:     "Policy: deny_policy",
+			Message:   "bucket must not be public",
+		},
+	}
+	if diff := cmp.Diff(got, exp); diff != "" {
+		t.Errorf("annotations not as expected; (-got,+want): %s", diff)
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright 2024 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/loader"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/storage/inmem"
+
+	"github.com/abcxyz/guardian/internal/metricswrap"
+	"github.com/abcxyz/guardian/pkg/flags"
+	"github.com/abcxyz/guardian/pkg/platform"
+	"github.com/abcxyz/guardian/pkg/util"
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+)
+
+var _ cli.Command = (*EvalCommand)(nil)
+
+// EvalCommand implements cli.Command. It evaluates the Guardian policies using
+// the embedded OPA engine.
+type EvalCommand struct {
+	cli.BaseCommand
+
+	directory      string
+	platformConfig platform.Config
+	flags          EvalFlags
+	commonFlags    flags.CommonFlags
+	platform       platform.Platform
+	exemptions     *exemptionRequests
+	codeOwners     *codeOwnersPolicy
+}
+
+// Desc implements cli.Command.
+func (c *EvalCommand) Desc() string {
+	return "Evaluate a set of Guardian policies"
+}
+
+// Help implements cli.Command.
+func (c *EvalCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options]
+
+  Evaluate every policy package under the root of a Rego policy bundle, using
+  the Terraform plan and workflow inputs as input and the data from policy
+  fetch-data, and write the results read by policy enforce.
+`
+}
+
+// Flags returns the list of flags that are defined on the command.
+func (c *EvalCommand) Flags() *cli.FlagSet {
+	set := cli.NewFlagSet()
+	c.commonFlags.Register(set)
+	c.platformConfig.RegisterFlags(set)
+	c.flags.Register(set)
+	return set
+}
+
+// Run implements cli.Command.
+func (c *EvalCommand) Run(ctx context.Context, args []string) error {
+	metricswrap.WriteMetric(ctx, "command_policy_eval", 1)
+
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	// the platform is only used to enforce the results
+	if c.flags.Enforce {
+		platform, err := platform.NewPlatform(ctx, &c.platformConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create platform: %w", err)
+		}
+		c.platform = platform
+
+		exemptions, err := newExemptionRequests(ctx, &c.platformConfig, &c.flags.ExemptionFlags)
+		if err != nil {
+			return err
+		}
+		c.exemptions = exemptions
+
+		codeOwners, err := newCodeOwnersPolicy(&c.flags.CodeOwnersFlags)
+		if err != nil {
+			return err
+		}
+		c.codeOwners = codeOwners
+	}
+
+	cwd, err := c.WorkingDir()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	if c.commonFlags.FlagDir == "" {
+		c.commonFlags.FlagDir = cwd
+	}
+	c.directory = c.commonFlags.FlagDir
+
+	return c.Process(ctx)
+}
+
+// Process handles the main logic for evaluating the policies, writing the
+// results and enforcing them when requested.
+func (c *EvalCommand) Process(ctx context.Context) error {
+	input, err := c.input()
+	if err != nil {
+		return err
+	}
+
+	data, err := readJSONObject(c.flags.PolicyDataFile)
+	if err != nil {
+		return fmt.Errorf("failed to read policy data file: %w", err)
+	}
+
+	regoVersion := ast.RegoV1
+	if c.flags.V0Compatible {
+		regoVersion = ast.RegoV0
+	}
+
+	results, err := Evaluate(ctx, &EvalInput{
+		BundleDir:   c.flags.BundleDir,
+		Root:        c.flags.Root,
+		Input:       input,
+		Data:        data,
+		RegoVersion: regoVersion,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to evaluate policies: %w", err)
+	}
+
+	b, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("failed to marshal policy results: %w", err)
+	}
+	if err := os.WriteFile(c.flags.ResultsFile, b, ownerReadWritePerms); err != nil {
+		return fmt.Errorf("failed to write policy results to json file: %w", err)
+	}
+
+	absFilepath, err := util.PathEvalAbs(c.flags.ResultsFile)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate absolute filepath: %w", err)
+	}
+	c.Outf("Saved policy results to local file: %s", absFilepath)
+
+	if !c.flags.Enforce {
+		return nil
+	}
+
+	enforce := &EnforceCommand{
+		directory:  c.directory,
+		platform:   c.platform,
+		exemptions: c.exemptions,
+		codeOwners: c.codeOwners,
+		flags: EnforceFlags{
+			ExemptionFlags:  c.flags.ExemptionFlags,
+			CodeOwnersFlags: c.flags.CodeOwnersFlags,
+			Silent:          c.flags.Silent,
+			SkipReporting:   c.flags.SkipReporting,
+		},
+	}
+	return enforce.Enforce(ctx, results)
+}
+
+// input returns the policy input, the Terraform plan with the workflow inputs
+// merged in. A workflow input cannot replace a field of the plan.
+func (c *EvalCommand) input() (map[string]any, error) {
+	input, err := readJSONObject(c.flags.PlanFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+
+	inputs, err := readJSONObject(c.flags.InputsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inputs file: %w", err)
+	}
+
+	for k, v := range inputs {
+		if _, ok := input[k]; ok {
+			return nil, fmt.Errorf("workflow input %q conflicts with a plan field", k)
+		}
+		input[k] = v
+	}
+	return input, nil
+}
+
+// readJSONObject reads a file containing a JSON object. An empty object is
+// returned if the path is empty.
+func readJSONObject(pth string) (map[string]any, error) {
+	obj := make(map[string]any)
+	if pth == "" {
+		return obj, nil
+	}
+
+	b, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, fmt.Errorf("failed to parse json object: %w", err)
+	}
+	return obj, nil
+}
+
+// EvalInput are the inputs for evaluating the policies of a bundle.
+type EvalInput struct {
+	// BundleDir is the directory of Rego policies and JSON or YAML data files.
+	BundleDir string
+
+	// Root is the package root, e.g. "guardian". Every package under the root
+	// is evaluated as a policy, keyed by its path relative to the root.
+	Root string
+
+	// Input is the input document.
+	Input map[string]any
+
+	// Data is merged into the data documents of the bundle.
+	Data map[string]any
+
+	// RegoVersion is the Rego syntax version of the policies.
+	RegoVersion ast.RegoVersion
+}
+
+// Evaluate evaluates every package under the root of the policy bundle and
+// returns the results by package path relative to the root, e.g. the results
+// of package guardian.iam with the root guardian are keyed by "iam".
+func Evaluate(ctx context.Context, in *EvalInput) (Results, error) {
+	logger := logging.FromContext(ctx)
+
+	loaded, err := loader.NewFileLoader().
+		WithRegoVersion(in.RegoVersion).
+		Filtered([]string{in.BundleDir}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policy bundle: %w", err)
+	}
+
+	documents := loaded.Documents
+	if documents == nil {
+		documents = make(map[string]any)
+	}
+	for k, v := range in.Data {
+		if _, ok := documents[k]; ok {
+			return nil, fmt.Errorf("policy data %q conflicts with a data document of the bundle", k)
+		}
+		documents[k] = v
+	}
+
+	rootRef, err := ast.ParseRef("data." + in.Root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse root %q: %w", in.Root, err)
+	}
+
+	opts := []func(*rego.Rego){
+		rego.Query(rootRef.String()),
+		rego.Store(inmem.NewFromObject(documents)),
+		rego.Input(in.Input),
+		rego.SetRegoVersion(in.RegoVersion),
+	}
+
+	// packages are keyed by their path relative to the root
+	packages := make(map[string][]string)
+	for _, m := range loaded.ParsedModules() {
+		opts = append(opts, rego.ParsedModule(m))
+
+		pkg := m.Package.Path
+		if !pkg.HasPrefix(rootRef) || pkg.Equal(rootRef) {
+			continue
+		}
+
+		segments := make([]string, 0, len(pkg)-len(rootRef))
+		for _, term := range pkg[len(rootRef):] {
+			str, ok := term.Value.(ast.String)
+			if !ok {
+				return nil, fmt.Errorf("invalid package path %s", pkg)
+			}
+			segments = append(segments, string(str))
+		}
+		packages[strings.Join(segments, ".")] = segments
+	}
+
+	rs, err := rego.New(opts...).Eval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate %s: %w", rootRef, err)
+	}
+
+	results := make(Results, len(packages))
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return results, nil
+	}
+	value := rs[0].Expressions[0].Value
+
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		logger.DebugContext(ctx, "reading policy result", "policy_name", name)
+
+		r, err := packageResult(value, packages[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read result of policy %q: %w", name, err)
+		}
+		results[name] = r
+	}
+
+	return results, nil
+}
+
+// packageResult returns the result of the package at the path of the
+// evaluated root document.
+func packageResult(value any, pth []string) (*Result, error) {
+	for _, k := range pth {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return &Result{}, nil
+		}
+		value = obj[k]
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	var r Result
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse result: %w", err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2024 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/guardian/pkg/platform"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestEval_Process(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(t.Context(), logging.TestLogger(t))
+
+	cases := []struct {
+		name               string
+		flags              EvalFlags
+		expResults         Results
+		expPlatformReqs    []*platform.Request
+		err                string
+		expNoResultsOutput bool
+	}{
+		{
+			name: "evaluates_plan",
+			flags: EvalFlags{
+				BundleDir:      "testdata/eval/bundle",
+				Root:           "guardian",
+				PlanFile:       "testdata/eval/tfplan.json",
+				PolicyDataFile: "testdata/eval/guardian_policy_context.json",
+			},
+			expResults: Results{
+				"admin": {Deny: []*Deny{}},
+				"iam": {
+					Deny: []*Deny{
+						{Message: "google_project_iam_member.owner grants roles/owner, which is not allowed"},
+					},
+					MissingApprovals: []*MissingApproval{
+						{AssignTeams: []string{"iam-admins"}, Message: "IAM changes require an approval from iam-admins"},
+					},
+				},
+			},
+		},
+		{
+			name: "evaluates_nested_root",
+			flags: EvalFlags{
+				BundleDir:  "testdata/eval/bundle",
+				Root:       "guardian.admin",
+				InputsFile: "testdata/eval/inputs.json",
+			},
+			expResults: Results{},
+		},
+		{
+			name: "evaluates_workflow_inputs",
+			flags: EvalFlags{
+				BundleDir:  "testdata/eval/bundle/admin",
+				Root:       "guardian",
+				InputsFile: "testdata/eval/inputs.json",
+			},
+			expResults: Results{
+				"admin": {
+					Deny: []*Deny{{Message: "destroy is not allowed"}},
+				},
+			},
+		},
+		{
+			name: "evaluates_v0_policies",
+			flags: EvalFlags{
+				BundleDir:    "testdata/eval/v0",
+				Root:         "guardian",
+				InputsFile:   "testdata/eval/inputs.json",
+				V0Compatible: true,
+			},
+			expResults: Results{
+				"legacy": {
+					Deny: []*Deny{{Message: "legacy policy"}},
+				},
+			},
+		},
+		{
+			name: "rejects_v0_policies",
+			flags: EvalFlags{
+				BundleDir: "testdata/eval/v0",
+				Root:      "guardian",
+			},
+			err:                "failed to load policy bundle",
+			expNoResultsOutput: true,
+		},
+		{
+			name: "rejects_conflicting_inputs",
+			flags: EvalFlags{
+				BundleDir:  "testdata/eval/bundle",
+				Root:       "guardian",
+				PlanFile:   "testdata/eval/tfplan.json",
+				InputsFile: "testdata/eval/conflicting_inputs.json",
+			},
+			err:                `workflow input "resource_changes" conflicts with a plan field`,
+			expNoResultsOutput: true,
+		},
+		{
+			name: "enforces_results",
+			flags: EvalFlags{
+				BundleDir:  "testdata/eval/bundle/admin",
+				Root:       "guardian",
+				InputsFile: "testdata/eval/inputs.json",
+				Enforce:    true,
+			},
+			expResults: Results{
+				"admin": {
+					Deny: []*Deny{{Message: "destroy is not allowed"}},
+				},
+			},
+			expPlatformReqs: []*platform.Request{
+				{
+					Name: "Status",
+					Params: []any{platform.StatusPolicyViolation, &platform.StatusParams{
+						Operation: "Policy Violation",
+						Dir:       "dir",
+						Message: "The planned resource changes raised policy violations that will need to be addressed:\n\n" +
+							"#### Policy: `admin`\n- **Action(s) not allowed**:\n\t - Reason: destroy is not allowed\n\n",
+					}},
+				},
+			},
+			err: `failed: "admin" - destroy is not allowed`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resultsFile := filepath.Join(t.TempDir(), "policy_results.json")
+			tc.flags.ResultsFile = resultsFile
+
+			mockPlatform := &platform.MockPlatform{}
+			c := &EvalCommand{
+				directory: "dir",
+				flags:     tc.flags,
+				platform:  mockPlatform,
+			}
+
+			err := c.Process(ctx)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(mockPlatform.Reqs, tc.expPlatformReqs); diff != "" {
+				t.Errorf("platform calls not as expected; (-got,+want): %s", diff)
+			}
+
+			b, err := os.ReadFile(resultsFile)
+			if tc.expNoResultsOutput {
+				if err == nil {
+					t.Errorf("expected results file not to be written")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got Results
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tc.expResults); diff != "" {
+				t.Errorf("results not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
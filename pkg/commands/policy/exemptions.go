@@ -0,0 +1,274 @@
+// Copyright 2024 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abcxyz/guardian/pkg/exemptions"
+	"github.com/abcxyz/guardian/pkg/modifiers"
+	"github.com/abcxyz/guardian/pkg/platform"
+	"github.com/abcxyz/guardian/pkg/storage"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/sets"
+)
+
+// exemptionRequests are the policy exemptions requested for the change request
+// the current run was triggered for. Exemptions requested in comments are only
+// known once the comments are retrieved when the exemptions are applied.
+type exemptionRequests struct {
+	client        *exemptions.Client
+	changeRequest int
+	commitSHA     string
+	requested     map[string]*modifiers.Exemption
+}
+
+// newExemptionRequests returns the exemptions requested in the change request
+// body or labels. A label takes precedence over the body for the same policy,
+// as exemptions requested in the body are never applied. Nil is returned if
+// exemptions are not enabled.
+func newExemptionRequests(ctx context.Context, cfg *platform.Config, f *ExemptionFlags) (*exemptionRequests, error) {
+	if f.ExemptionStorage == "" {
+		return nil, nil
+	}
+
+	requested, err := modifiers.ParseBodyMetaValues(ctx, cfg.ChangeRequestBody()).Exemptions(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse policy exemptions: %w", err)
+	}
+
+	labeled, err := modifiers.MetaValues{}.Exemptions(cfg.ChangeRequestLabels())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse policy exemptions: %w", err)
+	}
+	maps.Copy(requested, labeled)
+
+	repository, err := cfg.Repository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine repository: %w", err)
+	}
+
+	sc, err := storage.Parse(ctx, f.ExemptionStorage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+
+	return &exemptionRequests{
+		client:        exemptions.NewClient(sc, repository, f.ExemptionTTL),
+		changeRequest: cfg.ChangeRequestNumber(),
+		commitSHA:     cfg.CommitSHA(),
+		requested:     requested,
+	}, nil
+}
+
+// applyExemptions applies the exemptions requested for policies with missing
+// approvals or deny violations. The applied exemptions are returned by policy
+// name, along with the reasons requested exemptions were not applied.
+func (c *EnforceCommand) applyExemptions(ctx context.Context, results Results) (map[string]*exemptions.Exemption, map[string]string, error) {
+	logger := logging.FromContext(ctx)
+
+	if c.exemptions == nil {
+		return nil, nil, nil
+	}
+
+	var violated []string
+	for name, r := range results {
+		if r != nil && (len(r.MissingApprovals) > 0 || len(r.Deny) > 0) {
+			violated = append(violated, name)
+		}
+	}
+	sort.Strings(violated)
+
+	requested := make(map[string]*requestedExemption, len(c.exemptions.requested))
+	for name, req := range c.exemptions.requested {
+		requested[name] = &requestedExemption{Exemption: req}
+	}
+
+	applied := make(map[string]*exemptions.Exemption)
+	notApplied := make(map[string]string)
+
+	if len(violated) == 0 {
+		return applied, notApplied, nil
+	}
+
+	if c.exemptions.changeRequest <= 0 {
+		for _, name := range violated {
+			if _, ok := requested[name]; ok {
+				notApplied[name] = "exemptions can only be applied to change requests"
+			}
+		}
+		return applied, notApplied, nil
+	}
+
+	editors, err := c.platform.GetChangeRequestEditors(ctx, c.exemptions.changeRequest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get change request editors: %w", err)
+	}
+	addCommentExemptions(ctx, requested, editors.Comments)
+
+	authorized := make(map[string]bool)
+	for _, name := range violated {
+		req, ok := requested[name]
+		if !ok {
+			continue
+		}
+
+		requester, reason := exemptionRequester(editors, name, req)
+		if requester == "" {
+			notApplied[name] = reason
+			continue
+		}
+
+		ok, found := authorized[requester]
+		if !found {
+			var err error
+			ok, err = c.authorizedForExemptions(ctx, requester)
+			if err != nil {
+				return nil, nil, err
+			}
+			authorized[requester] = ok
+		}
+
+		e, err := c.exemptions.client.Apply(ctx, &exemptions.Request{
+			ChangeRequest: c.exemptions.changeRequest,
+			Policy:        name,
+			Reason:        req.Reason,
+			Source:        req.Source,
+			Actor:         requester,
+			Authorized:    ok,
+			CommitSHA:     c.exemptions.commitSHA,
+		})
+		switch {
+		case errors.Is(err, exemptions.ErrUnauthorized):
+			notApplied[name] = fmt.Sprintf("%s is not authorized to grant policy exemptions", requester)
+		case errors.Is(err, exemptions.ErrExpired):
+			notApplied[name] = fmt.Sprintf("the exemption expired at %s", formatTime(e.ExpiresAt))
+		case err != nil:
+			return nil, nil, fmt.Errorf("failed to apply exemption for policy %q: %w", name, err)
+		default:
+			logger.InfoContext(ctx, "applied policy exemption",
+				"policy_name", name,
+				"granted_by", e.GrantedBy,
+				"expires_at", e.ExpiresAt)
+			applied[name] = e
+		}
+	}
+
+	return applied, notApplied, nil
+}
+
+// requestedExemption is an exemption request with the comment it was
+// requested in, if any.
+type requestedExemption struct {
+	*modifiers.Exemption
+
+	// comment is the comment the exemption was requested in.
+	comment *platform.ChangeRequestComment
+}
+
+// addCommentExemptions adds the exemptions requested with GUARDIAN_EXEMPT lines
+// in the comments, which take precedence over the body and labels. Comments are
+// oldest first, so the last comment requesting an exemption for a policy wins.
+// Comments with invalid requests are skipped, so a comment by any user cannot
+// fail the enforcement.
+func addCommentExemptions(ctx context.Context, requested map[string]*requestedExemption, comments []*platform.ChangeRequestComment) {
+	logger := logging.FromContext(ctx)
+
+	for _, comment := range comments {
+		exempt, err := modifiers.ParseBodyMetaValues(ctx, comment.Body).Exemptions(nil)
+		if err != nil {
+			logger.WarnContext(ctx, "skipped invalid policy exemption comment",
+				"author", comment.Author,
+				"error", err)
+			continue
+		}
+
+		for name, e := range exempt {
+			e.Source = modifiers.ExemptionSourceComment
+			requested[name] = &requestedExemption{Exemption: e, comment: comment}
+		}
+	}
+}
+
+// exemptionRequester returns the user that requested an exemption, the author
+// of the comment for a comment or the user that last added the guardian-exempt
+// label for a label. An empty user is returned with the reason if the requester
+// cannot be trusted. Exemptions requested in the change request body are never
+// attributed, as the platforms do not report which user added a line, and the
+// author of an edited comment may not have written the request.
+func exemptionRequester(editors *platform.ChangeRequestEditors, policy string, req *requestedExemption) (string, string) {
+	switch req.Source {
+	case modifiers.ExemptionSourceComment:
+		if req.comment.Edited {
+			return "", "the comment requesting the exemption was edited, request it in a new comment"
+		}
+		if req.comment.Author == "" {
+			return "", "the user that requested the exemption could not be determined"
+		}
+		return req.comment.Author, ""
+	case modifiers.ExemptionSourceLabel:
+		for label, user := range editors.Labels {
+			p, ok := strings.CutPrefix(strings.TrimSpace(label), modifiers.LabelPrefixGuardianExempt)
+			if ok && strings.TrimSpace(p) == policy && user != "" {
+				return user, ""
+			}
+		}
+		return "", "the user that requested the exemption could not be determined"
+	default:
+		return "", "exemptions requested in the change request body cannot be attributed to a user, request it in a comment instead"
+	}
+}
+
+// authorizedForExemptions returns true if the user has one of the repository
+// permissions or is a member of one of the teams authorized to grant policy
+// exemptions.
+func (c *EnforceCommand) authorizedForExemptions(ctx context.Context, username string) (bool, error) {
+	permission, err := c.platform.GetRepoPermissionsForUser(ctx, username)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user repo permissions: %w", err)
+	}
+	if slices.Contains(c.flags.ExemptionPermissions, permission) {
+		return true, nil
+	}
+
+	if len(c.flags.ExemptionTeams) == 0 {
+		return false, nil
+	}
+
+	teams, err := c.platform.GetUserTeamMemberships(ctx, username)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user team memberships: %w", err)
+	}
+	return len(sets.Intersect(teams, c.flags.ExemptionTeams)) > 0, nil
+}
+
+// writeExempted writes the missing approvals and deny violations of an
+// exempted policy.
+func writeExempted(b *strings.Builder, r *Result, e *exemptions.Exemption) {
+	heading := fmt.Sprintf("Exempted by %s until %s, reason: %s", e.GrantedBy, formatTime(e.ExpiresAt), e.Reason)
+	writeSuppressed(b, heading, r)
+}
+
+// formatTime formats a time for reports.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -17,12 +17,20 @@ package policy
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/abcxyz/guardian/internal/metricswrap"
+	"github.com/abcxyz/guardian/pkg/git"
+	"github.com/abcxyz/guardian/pkg/modifiers"
 	"github.com/abcxyz/guardian/pkg/platform"
+	"github.com/abcxyz/guardian/pkg/terraform"
 	"github.com/abcxyz/guardian/pkg/util"
 	"github.com/abcxyz/pkg/cli"
 )
@@ -41,7 +49,11 @@ type FetchDataCommand struct {
 
 	platformConfig platform.Config
 	platform       platform.Platform
+	gitClient      git.Git
 	flags          FetchDataFlags
+
+	changeRequestBody   string
+	changeRequestLabels []string
 }
 
 // Desc implements cli.Command.
@@ -82,6 +94,9 @@ func (c *FetchDataCommand) Run(ctx context.Context, args []string) error {
 		return fmt.Errorf("failed to create platform: %w", err)
 	}
 	c.platform = platform
+	c.gitClient = git.NewGitClient(c.flags.flagRepositoryDir)
+	c.changeRequestBody = c.platformConfig.ChangeRequestBody()
+	c.changeRequestLabels = c.platformConfig.ChangeRequestLabels()
 
 	return c.Process(ctx)
 }
@@ -92,6 +107,44 @@ func (c *FetchDataCommand) Process(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to get policy data: %w", err)
 	}
+	data.SchemaVersion = platform.PolicyDataSchemaVersion
+
+	// plan modifiers are exposed so policies can require additional approvals
+	// for targeted or replace plans
+	metaValues := modifiers.ParseBodyMetaValues(ctx, c.changeRequestBody)
+	mods, err := metaValues.PlanModifiers()
+	if err != nil {
+		return fmt.Errorf("failed to parse plan modifiers: %w", err)
+	}
+	if len(mods) > 0 {
+		data.Modifiers = mods
+	}
+
+	// requested exemptions are exposed so policies can take them into account,
+	// they are only applied by policy enforce once authorized
+	exemptions, err := metaValues.Exemptions(c.changeRequestLabels)
+	if err != nil {
+		return fmt.Errorf("failed to parse policy exemptions: %w", err)
+	}
+	if len(exemptions) > 0 {
+		data.Exemptions = exemptions
+	}
+
+	if cr := data.ChangeRequest(); cr != nil && c.flags.flagRepositoryDir != "" {
+		entrypoints, err := changedEntrypoints(ctx, c.flags.flagRepositoryDir, newBaseTree(c.gitClient, cr), cr.ChangedFiles)
+		if err != nil {
+			return fmt.Errorf("failed to find changed entrypoints: %w", err)
+		}
+		data.ChangedEntrypoints = entrypoints
+	}
+
+	if c.flags.flagPlanFile != "" {
+		summary, err := planSummaryData(c.flags.flagPlanFile)
+		if err != nil {
+			return err
+		}
+		data.PlanSummary = summary
+	}
 
 	d, err := json.Marshal(data)
 	if err != nil {
@@ -110,3 +163,294 @@ func (c *FetchDataCommand) Process(ctx context.Context) error {
 	c.Outf("Saved policy data to local file: %s", absFilepath)
 	return nil
 }
+
+// planSummaryData returns the summary of the resource changes of the plan JSON
+// file at the given path.
+func planSummaryData(planFile string) (*platform.PlanSummaryData, error) {
+	b, err := os.ReadFile(planFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+
+	s, err := terraform.ParsePlanSummary(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize plan: %w", err)
+	}
+
+	res := &platform.PlanSummaryData{
+		Create:    s.Create,
+		Update:    s.Update,
+		Replace:   s.Replace,
+		Delete:    s.Delete,
+		Resources: make([]*platform.PlanResourceChangeData, 0, len(s.Resources)),
+	}
+	for _, r := range s.Resources {
+		res.Resources = append(res.Resources, &platform.PlanResourceChangeData{
+			Address:  r.Address,
+			Module:   r.Module,
+			Type:     r.Type,
+			Provider: r.Provider,
+			Action:   r.Action,
+		})
+	}
+	return res, nil
+}
+
+// changedEntrypoints returns the entrypoints that contain a changed file or use
+// a module that contains a changed file. Changed files and the returned
+// entrypoints are relative to the repository root.
+func changedEntrypoints(ctx context.Context, repositoryDir string, base *baseTree, changedFiles []string) ([]string, error) {
+	changes, err := changedPaths(ctx, repositoryDir, base, changedFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]string, 0, len(changes))
+	for _, c := range changes {
+		if !c.module {
+			res = append(res, c.path)
+		}
+	}
+	return res, nil
+}
+
+// changedPath is an entrypoint or local module affected by the changed files of
+// a change request.
+type changedPath struct {
+	// path is the directory of the entrypoint or module, relative to the
+	// repository root.
+	path string
+
+	// module is true if the path is a module, an entrypoint otherwise.
+	module bool
+
+	// direct is true if the path contains a changed file.
+	direct bool
+
+	// modules are the changed modules used by the entrypoint.
+	modules []string
+}
+
+// changedPaths returns the entrypoints and modules that contain a changed file,
+// and the entrypoints that use a module that contains a changed file, sorted by
+// path. A changed file belongs to the closest entrypoint or module directory
+// containing it. Files of directories that no longer exist are resolved against
+// the base tree, if any. Changed files and the returned paths are relative to
+// the repository root.
+func changedPaths(ctx context.Context, repositoryDir string, base *baseTree, changedFiles []string) (_ []*changedPath, outErr error) {
+	rootAbs, err := util.PathEvalAbs(repositoryDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate absolute path for %s: %w", repositoryDir, err)
+	}
+
+	moduleUsageGraph, err := terraform.ModuleUsage(ctx, rootAbs, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get module usage for %s: %w", rootAbs, err)
+	}
+
+	// the base tree is only extracted when a changed directory was deleted
+	var tempDir, baseRootAbs string
+	var baseModuleUsageGraph *terraform.ModuleUsageGraph
+	defer func() {
+		if tempDir == "" {
+			return
+		}
+		if err := os.RemoveAll(tempDir); err != nil {
+			outErr = errors.Join(outErr, fmt.Errorf("failed to delete temporary directory: %w", err))
+		}
+	}()
+
+	// a directory can be both an entrypoint and a module used by others
+	type key struct {
+		path   string
+		module bool
+	}
+	changes := make(map[key]*changedPath)
+	get := func(root, dir string, module bool) (*changedPath, error) {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get relative path for %s: %w", dir, err)
+		}
+		rel = filepath.ToSlash(rel)
+
+		k := key{path: rel, module: module}
+		c, ok := changes[k]
+		if !ok {
+			c = &changedPath{path: rel, module: module}
+			changes[k] = c
+		}
+		return c, nil
+	}
+
+	for _, f := range changedFiles {
+		relDir := filepath.Dir(filepath.FromSlash(f))
+		root, graph := rootAbs, moduleUsageGraph
+
+		if _, err := os.Stat(filepath.Join(rootAbs, relDir)); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("failed to check directory %s: %w", relDir, err)
+			}
+
+			if base != nil {
+				if baseModuleUsageGraph == nil {
+					if tempDir, err = os.MkdirTemp("", "guardian-policy-*"); err != nil {
+						return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+					}
+
+					if err := base.extract(ctx, tempDir); err != nil {
+						return nil, err
+					}
+
+					if baseRootAbs, err = util.PathEvalAbs(tempDir); err != nil {
+						return nil, fmt.Errorf("failed to evaluate absolute path for %s: %w", tempDir, err)
+					}
+
+					if baseModuleUsageGraph, err = terraform.ModuleUsage(ctx, baseRootAbs, nil, true); err != nil {
+						return nil, fmt.Errorf("failed to get module usage for base branch %s: %w", base.branch, err)
+					}
+				}
+				root, graph = baseRootAbs, baseModuleUsageGraph
+			}
+		}
+
+		changedDir, ok := closestModuleOrEntrypoint(graph, root, filepath.Join(root, relDir))
+		if !ok {
+			continue
+		}
+
+		if entrypoints, ok := graph.ModulesToEntrypoints[changedDir]; ok {
+			m, err := get(root, changedDir, true)
+			if err != nil {
+				return nil, err
+			}
+			m.direct = true
+
+			for entrypoint := range entrypoints {
+				e, err := get(root, entrypoint, false)
+				if err != nil {
+					return nil, err
+				}
+				if !slices.Contains(e.modules, m.path) {
+					e.modules = append(e.modules, m.path)
+				}
+			}
+		}
+		if _, ok := graph.EntrypointToModules[changedDir]; ok {
+			e, err := get(root, changedDir, false)
+			if err != nil {
+				return nil, err
+			}
+			e.direct = true
+		}
+	}
+
+	res := make([]*changedPath, 0, len(changes))
+	for _, c := range changes {
+		slices.Sort(c.modules)
+		res = append(res, c)
+	}
+	slices.SortFunc(res, func(a, b *changedPath) int {
+		if c := strings.Compare(a.path, b.path); c != 0 {
+			return c
+		}
+		// entrypoints first
+		if a.module == b.module {
+			return 0
+		}
+		if a.module {
+			return 1
+		}
+		return -1
+	})
+
+	return res, nil
+}
+
+// closestModuleOrEntrypoint returns the closest directory containing dir, dir
+// included, that is a module or an entrypoint, stopping at the root directory.
+func closestModuleOrEntrypoint(graph *terraform.ModuleUsageGraph, root, dir string) (string, bool) {
+	for {
+		if _, ok := graph.ModulesToEntrypoints[dir]; ok {
+			return dir, true
+		}
+		if _, ok := graph.EntrypointToModules[dir]; ok {
+			return dir, true
+		}
+
+		if dir == root {
+			return "", false
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// baseTree reads the base branch of a change request from the repository,
+// without changing the current checkout. The base branch must be fetched.
+type baseTree struct {
+	git    git.Git
+	branch string
+
+	// ref is the resolved commit of the base branch.
+	ref string
+}
+
+// newBaseTree returns the base tree of the change request, nil if the
+// platform does not report a base branch.
+func newBaseTree(gitClient git.Git, cr *platform.ChangeRequestData) *baseTree {
+	if gitClient == nil || cr.BaseBranch == "" {
+		return nil
+	}
+	return &baseTree{git: gitClient, branch: cr.BaseBranch}
+}
+
+// resolve returns the commit of the base branch, preferring the remote
+// tracking branch over a local branch of the same name.
+func (b *baseTree) resolve(ctx context.Context) (string, error) {
+	if b.ref != "" {
+		return b.ref, nil
+	}
+
+	var merr error
+	for _, ref := range []string{"origin/" + b.branch, b.branch} {
+		sha, err := b.git.ResolveRef(ctx, ref)
+		if err != nil {
+			merr = errors.Join(merr, err)
+			continue
+		}
+		b.ref = sha
+		return sha, nil
+	}
+	return "", fmt.Errorf("failed to resolve base branch %s, it must be fetched: %w", b.branch, merr)
+}
+
+// readFile returns the contents of a file of the base branch, relative to the
+// repository root.
+func (b *baseTree) readFile(ctx context.Context, pth string) ([]byte, error) {
+	ref, err := b.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := b.git.ShowFile(ctx, ref, pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from base branch %s: %w", pth, b.branch, err)
+	}
+	return contents, nil
+}
+
+// extract writes the files of the base branch to the dest directory.
+func (b *baseTree) extract(ctx context.Context, dest string) error {
+	ref, err := b.resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.git.ExtractRef(ctx, ref, dest); err != nil {
+		return fmt.Errorf("failed to extract base branch %s: %w", b.branch, err)
+	}
+	return nil
+}
@@ -23,6 +23,8 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 
+	"github.com/abcxyz/guardian/pkg/git"
+	"github.com/abcxyz/guardian/pkg/modifiers"
 	"github.com/abcxyz/guardian/pkg/platform"
 	"github.com/abcxyz/pkg/logging"
 	"github.com/abcxyz/pkg/testutil"
@@ -35,6 +37,8 @@ func TestFetchData_Process(t *testing.T) {
 
 	cases := []struct {
 		name             string
+		body             string
+		labels           []string
 		isPullRequest    bool
 		includeTeams     bool
 		getPolicyDataErr error
@@ -44,6 +48,10 @@ func TestFetchData_Process(t *testing.T) {
 		users            []string
 		userAccessLevel  string
 		userTeams        []string
+		changeRequest    *platform.ChangeRequestData
+		repositoryDir    string
+		gitClient        git.Git
+		planFile         string
 		want             platform.GetPolicyDataResult
 	}{
 		{
@@ -55,6 +63,7 @@ func TestFetchData_Process(t *testing.T) {
 			username:      "test-username",
 			userTeams:     []string{"team1"},
 			want: platform.GetPolicyDataResult{
+				SchemaVersion: platform.PolicyDataSchemaVersion,
 				Mock: &platform.MockPolicyData{
 					Approvers: &platform.GetLatestApproversResult{
 						Teams: []string{"team1", "team2"},
@@ -74,6 +83,7 @@ func TestFetchData_Process(t *testing.T) {
 			userAccessLevel: "admin",
 			username:        "test-username",
 			want: platform.GetPolicyDataResult{
+				SchemaVersion: platform.PolicyDataSchemaVersion,
 				Mock: &platform.MockPolicyData{
 					Approvers: &platform.GetLatestApproversResult{},
 					Actor: &platform.MockActorData{
@@ -89,6 +99,7 @@ func TestFetchData_Process(t *testing.T) {
 			userTeams:    []string{"team1", "team2"},
 			username:     "test-username",
 			want: platform.GetPolicyDataResult{
+				SchemaVersion: platform.PolicyDataSchemaVersion,
 				Mock: &platform.MockPolicyData{
 					Actor: &platform.MockActorData{
 						Username: "test-username",
@@ -105,6 +116,7 @@ func TestFetchData_Process(t *testing.T) {
 			users:         []string{},
 			username:      "test-username",
 			want: platform.GetPolicyDataResult{
+				SchemaVersion: platform.PolicyDataSchemaVersion,
 				Mock: &platform.MockPolicyData{
 					Approvers: &platform.GetLatestApproversResult{
 						Users: []string{},
@@ -124,6 +136,7 @@ func TestFetchData_Process(t *testing.T) {
 			userAccessLevel: "read_only",
 			username:        "test-username",
 			want: platform.GetPolicyDataResult{
+				SchemaVersion: platform.PolicyDataSchemaVersion,
 				Mock: &platform.MockPolicyData{
 					Actor: &platform.MockActorData{
 						Username:    "test-username",
@@ -141,6 +154,7 @@ func TestFetchData_Process(t *testing.T) {
 			username:      "test-username",
 			userTeams:     []string{"team1"},
 			want: platform.GetPolicyDataResult{
+				SchemaVersion: platform.PolicyDataSchemaVersion,
 				Mock: &platform.MockPolicyData{
 					Approvers: &platform.GetLatestApproversResult{
 						Users: []string{"user1", "user2"},
@@ -151,6 +165,163 @@ func TestFetchData_Process(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "includes_plan_modifiers",
+			body:          "Replace the VM\nGUARDIAN_REPLACE=terraform/app:google_compute_instance.vm\nGUARDIAN_TARGET=terraform/app:module.network",
+			isPullRequest: true,
+			username:      "test-username",
+			want: platform.GetPolicyDataResult{
+				SchemaVersion: platform.PolicyDataSchemaVersion,
+				Mock: &platform.MockPolicyData{
+					Approvers: &platform.GetLatestApproversResult{},
+					Actor: &platform.MockActorData{
+						Username: "test-username",
+					},
+				},
+				Modifiers: map[string]*modifiers.PlanModifiers{
+					"terraform/app": {
+						Targets:  []string{"module.network"},
+						Replaces: []string{"google_compute_instance.vm"},
+					},
+				},
+			},
+		},
+		{
+			name:          "includes_exemptions",
+			body:          "GUARDIAN_EXEMPT=iam:incident INC-123",
+			labels:        []string{"guardian-exempt:buckets"},
+			isPullRequest: true,
+			username:      "test-username",
+			want: platform.GetPolicyDataResult{
+				SchemaVersion: platform.PolicyDataSchemaVersion,
+				Mock: &platform.MockPolicyData{
+					Approvers: &platform.GetLatestApproversResult{},
+					Actor: &platform.MockActorData{
+						Username: "test-username",
+					},
+				},
+				Exemptions: map[string]*modifiers.Exemption{
+					"iam":     {Reason: "incident INC-123", Source: modifiers.ExemptionSourceModifier},
+					"buckets": {Reason: `requested with the "guardian-exempt:buckets" label`, Source: modifiers.ExemptionSourceLabel},
+				},
+			},
+		},
+		{
+			name:          "includes_changed_entrypoints",
+			isPullRequest: true,
+			changeRequest: &platform.ChangeRequestData{
+				ChangedFiles: []string{"modules/bucket/main.tf", "entrypoints/prod/main.tf", "README.md"},
+			},
+			repositoryDir: "testdata/fetch_data/repository",
+			want: platform.GetPolicyDataResult{
+				SchemaVersion: platform.PolicyDataSchemaVersion,
+				Mock: &platform.MockPolicyData{
+					Approvers: &platform.GetLatestApproversResult{},
+					Actor:     &platform.MockActorData{},
+					ChangeRequest: &platform.ChangeRequestData{
+						ChangedFiles: []string{"modules/bucket/main.tf", "entrypoints/prod/main.tf", "README.md"},
+					},
+				},
+				ChangedEntrypoints: []string{"entrypoints/dev", "entrypoints/prod"},
+			},
+		},
+		{
+			name:          "includes_changed_entrypoints_of_subdirectories_and_deleted_directories",
+			isPullRequest: true,
+			changeRequest: &platform.ChangeRequestData{
+				BaseBranch:   "main",
+				ChangedFiles: []string{"entrypoints/prod/files/startup.sh", "entrypoints/old/main.tf"},
+			},
+			repositoryDir: "testdata/fetch_data/repository",
+			gitClient: &git.MockGitClient{
+				ExtractFiles: map[string]string{
+					"entrypoints/old/main.tf": `terraform {
+  backend "gcs" {
+    bucket = "my-bucket"
+    prefix = "old"
+  }
+}`,
+				},
+			},
+			want: platform.GetPolicyDataResult{
+				SchemaVersion: platform.PolicyDataSchemaVersion,
+				Mock: &platform.MockPolicyData{
+					Approvers: &platform.GetLatestApproversResult{},
+					Actor:     &platform.MockActorData{},
+					ChangeRequest: &platform.ChangeRequestData{
+						BaseBranch:   "main",
+						ChangedFiles: []string{"entrypoints/prod/files/startup.sh", "entrypoints/old/main.tf"},
+					},
+				},
+				ChangedEntrypoints: []string{"entrypoints/old", "entrypoints/prod"},
+			},
+		},
+		{
+			name:          "skips_changed_entrypoints_without_repository_dir",
+			isPullRequest: true,
+			changeRequest: &platform.ChangeRequestData{
+				ChangedFiles: []string{"entrypoints/prod/main.tf"},
+			},
+			want: platform.GetPolicyDataResult{
+				SchemaVersion: platform.PolicyDataSchemaVersion,
+				Mock: &platform.MockPolicyData{
+					Approvers: &platform.GetLatestApproversResult{},
+					Actor:     &platform.MockActorData{},
+					ChangeRequest: &platform.ChangeRequestData{
+						ChangedFiles: []string{"entrypoints/prod/main.tf"},
+					},
+				},
+			},
+		},
+		{
+			name:          "includes_plan_summary",
+			isPullRequest: true,
+			planFile:      "testdata/fetch_data/tfplan.json",
+			want: platform.GetPolicyDataResult{
+				SchemaVersion: platform.PolicyDataSchemaVersion,
+				Mock: &platform.MockPolicyData{
+					Approvers: &platform.GetLatestApproversResult{},
+					Actor:     &platform.MockActorData{},
+				},
+				PlanSummary: &platform.PlanSummaryData{
+					Create:  1,
+					Replace: 1,
+					Resources: []*platform.PlanResourceChangeData{
+						{
+							Address:  "google_project_iam_member.owner",
+							Type:     "google_project_iam_member",
+							Provider: "registry.terraform.io/hashicorp/google",
+							Action:   "create",
+						},
+						{
+							Address:  "module.bucket.google_storage_bucket.logs",
+							Module:   "module.bucket",
+							Type:     "google_storage_bucket",
+							Provider: "registry.terraform.io/hashicorp/google",
+							Action:   "replace",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:          "fails_with_missing_plan_file",
+			isPullRequest: true,
+			planFile:      "testdata/fetch_data/missing.json",
+			wantErr:       "failed to read plan file",
+		},
+		{
+			name:          "fails_with_invalid_exemption",
+			body:          "GUARDIAN_EXEMPT=iam",
+			isPullRequest: true,
+			wantErr:       "failed to parse policy exemptions",
+		},
+		{
+			name:          "fails_with_invalid_modifier",
+			body:          "GUARDIAN_TARGET=module.network",
+			isPullRequest: true,
+			wantErr:       "failed to parse plan modifiers",
+		},
 		{
 			name:             "fails_with_error",
 			getPolicyDataErr: fmt.Errorf("failed to get latest approvers"),
@@ -165,8 +336,13 @@ func TestFetchData_Process(t *testing.T) {
 			outDir := t.TempDir()
 			c := &FetchDataCommand{
 				flags: FetchDataFlags{
-					flagOutputDir: outDir,
+					flagOutputDir:     outDir,
+					flagRepositoryDir: tc.repositoryDir,
+					flagPlanFile:      tc.planFile,
 				},
+				gitClient:           tc.gitClient,
+				changeRequestBody:   tc.body,
+				changeRequestLabels: tc.labels,
 				platform: &platform.MockPlatform{
 					ActorUsername:    tc.username,
 					GetPolicyDataErr: tc.getPolicyDataErr,
@@ -176,6 +352,7 @@ func TestFetchData_Process(t *testing.T) {
 					IsPullRequest:    tc.isPullRequest,
 					IncludeTeams:     tc.includeTeams,
 					UserTeams:        tc.userTeams,
+					ChangeRequest:    tc.changeRequest,
 				},
 			}
 			outFilepath := path.Join(outDir, policyDataFilename)
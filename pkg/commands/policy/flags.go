@@ -17,17 +17,28 @@ package policy
 import (
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/posener/complete/v2"
+
+	"github.com/abcxyz/guardian/pkg/exemptions"
+	"github.com/abcxyz/guardian/pkg/storage"
 	"github.com/abcxyz/pkg/cli"
 )
 
 type EnforceFlags struct {
+	ExemptionFlags
+	CodeOwnersFlags
+
 	Silent        bool
 	ResultsFile   string
 	SkipReporting bool
 }
 
 func (e *EnforceFlags) Register(set *cli.FlagSet) {
+	e.ExemptionFlags.Register(set)
+	e.CodeOwnersFlags.Register(set)
+
 	f := set.NewSection("ENFORCE OPTIONS")
 
 	f.BoolVar(&cli.BoolVar{
@@ -61,7 +72,9 @@ func (e *EnforceFlags) Register(set *cli.FlagSet) {
 }
 
 type FetchDataFlags struct {
-	flagOutputDir string
+	flagOutputDir     string
+	flagRepositoryDir string
+	flagPlanFile      string
 }
 
 func (fd *FetchDataFlags) Register(set *cli.FlagSet) {
@@ -73,4 +86,203 @@ func (fd *FetchDataFlags) Register(set *cli.FlagSet) {
 		Target:  &fd.flagOutputDir,
 		Usage:   "Write the policy data JSON file to a target local directory.",
 	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "repository-dir",
+		Example: ".",
+		Target:  &fd.flagRepositoryDir,
+		Usage: "The root directory of the repository checkout. When set, the " +
+			"entrypoints affected by the changed files of the change request " +
+			"are included in the policy data.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "plan-file",
+		Example: "tfplan.json",
+		Target:  &fd.flagPlanFile,
+		Usage: "The path to the Terraform plan JSON file. When set, a summary " +
+			"of the planned resource changes is included in the policy data.",
+	})
+}
+
+type EvalFlags struct {
+	ExemptionFlags
+	CodeOwnersFlags
+
+	BundleDir      string
+	Root           string
+	PlanFile       string
+	PolicyDataFile string
+	InputsFile     string
+	ResultsFile    string
+	V0Compatible   bool
+	Enforce        bool
+	Silent         bool
+	SkipReporting  bool
+}
+
+func (e *EvalFlags) Register(set *cli.FlagSet) {
+	e.ExemptionFlags.Register(set)
+	e.CodeOwnersFlags.Register(set)
+
+	f := set.NewSection("EVAL OPTIONS")
+
+	f.StringVar(&cli.StringVar{
+		Name:    "bundle",
+		Example: "./policy",
+		Target:  &e.BundleDir,
+		Usage:   "The path to the directory of Rego policies and data files to evaluate.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "root",
+		Default: "guardian",
+		Example: "guardian.admin",
+		Target:  &e.Root,
+		Usage:   "The package root, every package under the root is evaluated as a policy.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "plan-file",
+		Example: "tfplan.json",
+		Target:  &e.PlanFile,
+		Usage:   "The path to the Terraform plan JSON file, used as the policy input.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "policy-data-file",
+		Example: "guardian_policy_context.json",
+		Target:  &e.PolicyDataFile,
+		Usage:   "The path to the policy data JSON file written by policy fetch-data, loaded as policy data.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "inputs-file",
+		Example: "workflow_dispatch_inputs.json",
+		Target:  &e.InputsFile,
+		Usage:   "The path to a JSON file of workflow inputs, merged into the policy input.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "results-file",
+		Default: "policy_results.json",
+		Example: "policy_results.json",
+		Target:  &e.ResultsFile,
+		Usage:   "The path to write the policy results to, in the format read by policy enforce.",
+	})
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "v0-compatible",
+		Default: false,
+		Target:  &e.V0Compatible,
+		Usage:   "Parse the policies using the Rego v0 syntax.",
+	})
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "enforce",
+		Default: false,
+		Target:  &e.Enforce,
+		Usage:   "Enforce the policy results, as policy enforce does.",
+	})
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "silent",
+		Default: false,
+		Target:  &e.Silent,
+		Usage:   "Skips any actions and only reports the violations found, when enforcing.",
+	})
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "skip-reporting",
+		Target:  &e.SkipReporting,
+		Default: false,
+		Example: "true",
+		Usage:   "Skips reporting of the status of any policy violations on the change request, when enforcing.",
+	})
+
+	set.AfterParse(func(existingError error) (merr error) {
+		if e.BundleDir == "" {
+			merr = errors.Join(merr, fmt.Errorf("missing flag: bundle is required"))
+		}
+		if e.Root == "" {
+			merr = errors.Join(merr, fmt.Errorf("missing flag: root is required"))
+		}
+		return merr
+	})
+}
+
+// ExemptionFlags are the flags for applying policy exemptions, shared by the
+// commands that enforce policy results.
+type ExemptionFlags struct {
+	ExemptionStorage     string
+	ExemptionTTL         time.Duration
+	ExemptionPermissions []string
+	ExemptionTeams       []string
+}
+
+func (e *ExemptionFlags) Register(set *cli.FlagSet) {
+	f := set.NewSection("EXEMPTION OPTIONS")
+
+	f.StringVar(&cli.StringVar{
+		Name:    "exemption-storage",
+		Target:  &e.ExemptionStorage,
+		Example: "gcs://my-guardian-state-bucket",
+		Usage: fmt.Sprintf("The storage the policy exemptions and their audit trail are saved in, "+
+			"exemptions are only applied if set. Valid values are %q.", storage.SortedStorageTypes),
+		Predict: complete.PredictFunc(func(prefix string) []string {
+			return storage.SortedStorageTypes
+		}),
+	})
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "exemption-ttl",
+		Target:  &e.ExemptionTTL,
+		Default: exemptions.DefaultTTL,
+		Example: "4h",
+		Usage:   "The duration a policy exemption applies for after it is granted.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "exemption-permissions",
+		Target:  &e.ExemptionPermissions,
+		Default: []string{"admin"},
+		Example: "admin,maintain",
+		Usage:   "The repository permissions of users authorized to grant policy exemptions.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "exemption-teams",
+		Target:  &e.ExemptionTeams,
+		Example: "platform-admins",
+		Usage:   "The teams whose members are authorized to grant policy exemptions.",
+	})
+}
+
+// CodeOwnersFlags are the flags for the built-in codeowners policy, shared by
+// the commands that enforce policy results.
+type CodeOwnersFlags struct {
+	CodeOwnersFile          string
+	CodeOwnersRepositoryDir string
+}
+
+func (c *CodeOwnersFlags) Register(set *cli.FlagSet) {
+	f := set.NewSection("CODEOWNERS OPTIONS")
+
+	f.StringVar(&cli.StringVar{
+		Name:    "codeowners-file",
+		Target:  &c.CodeOwnersFile,
+		Example: ".github/CODEOWNERS",
+		Usage: "The path to a CODEOWNERS file, read from the base branch of the " +
+			"change request. When set, the built-in " +
+			"guardian_codeowners policy requires an approval from the owners of the " +
+			"changed entrypoints and modules, and of the entrypoints using a changed module.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "codeowners-repository-dir",
+		Target:  &c.CodeOwnersRepositoryDir,
+		Default: ".",
+		Example: "path/to/repository",
+		Usage:   "The root directory of the repository checkout the CODEOWNERS patterns are relative to.",
+	})
 }
@@ -0,0 +1,5 @@
+package guardian.admin
+
+deny contains {"msg": sprintf("%s is not allowed", [input.command])} if {
+	input.command == "destroy"
+}
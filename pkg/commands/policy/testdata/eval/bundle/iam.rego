@@ -0,0 +1,23 @@
+package guardian.iam
+
+import data.guardian.config
+
+deny contains {"msg": sprintf("%s grants %s, which is not allowed", [rc.address, rc.change.after.role])} if {
+	some rc in input.resource_changes
+	rc.type == "google_project_iam_member"
+	rc.change.after.role in config.denied_roles
+}
+
+missing_approvals contains {
+	"assign_team_reviewers": ["iam-admins"],
+	"msg": "IAM changes require an approval from iam-admins",
+} if {
+	some rc in input.resource_changes
+	startswith(rc.type, "google_project_iam_")
+	not approved
+}
+
+approved if {
+	some approver in data.github.pull_request_approvers.teams
+	approver == "iam-admins"
+}
@@ -0,0 +1,5 @@
+package guardian.legacy
+
+deny[{"msg": "legacy policy"}] {
+	input.command == "destroy"
+}
@@ -0,0 +1,10 @@
+terraform {
+  backend "gcs" {
+    bucket = "my-bucket"
+    prefix = "dev"
+  }
+}
+
+module "bucket" {
+  source = "../../modules/bucket"
+}
@@ -43,6 +43,7 @@ type RunCommand struct {
 	terraformArgs    []string
 
 	flags.CommonFlags
+	flags.TerraformFlags
 
 	flagAllowedTerraformCommands []string
 	flagAllowLockfileChanges     bool
@@ -70,7 +71,8 @@ Usage: {{ COMMAND }} [options]
 func (c *RunCommand) Flags() *cli.FlagSet {
 	set := c.NewFlagSet()
 
-	c.Register(set)
+	c.CommonFlags.Register(set)
+	c.TerraformFlags.Register(set)
 
 	f := set.NewSection("COMMAND OPTIONS")
 
@@ -162,8 +164,13 @@ func (c *RunCommand) Run(ctx context.Context, args []string) error {
 	}
 	c.childPath = childPath
 
+	tfOpts, err := c.ClientOptions(ctx, c.directory)
+	if err != nil {
+		return fmt.Errorf("failed to configure terraform client: %w", err)
+	}
+
 	tfEnvVars := []string{"TF_IN_AUTOMATION=true"}
-	c.terraformClient = terraform.NewTerraformClient(c.directory, tfEnvVars)
+	c.terraformClient = terraform.NewTerraformClient(c.directory, tfEnvVars, tfOpts...)
 
 	return c.Process(ctx)
 }
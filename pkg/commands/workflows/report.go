@@ -25,10 +25,13 @@ import (
 	"strings"
 	"text/template"
 
+	"code.gitea.io/sdk/gitea"
 	"github.com/google/go-github/v53/github"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
 
 	"github.com/abcxyz/guardian/internal/metricswrap"
 	"github.com/abcxyz/guardian/pkg/platform"
+	"github.com/abcxyz/guardian/pkg/terraform"
 	"github.com/abcxyz/pkg/cli"
 )
 
@@ -130,24 +133,26 @@ func (c *ReportCommand) Run(ctx context.Context, args []string) error {
 func (c *ReportCommand) Process(ctx context.Context) error {
 	var prNumber int
 	if c.flagType == "plan" {
-		prNumber = c.platformConfig.GitHub.GitHubPullRequestNumber
+		prNumber = c.platformConfig.ChangeRequestNumber()
 	} else {
 		// For apply (on push event), look up PR number associated with the commit SHA
-		resp, err := c.platformClient.ListChangeRequestsByCommit(ctx, c.platformConfig.GitHub.GitHubSHA, nil)
+		sha := c.platformConfig.CommitSHA()
+		resp, err := c.platformClient.ListChangeRequestsByCommit(ctx, sha, nil)
 		if err != nil {
-			return fmt.Errorf("failed to list pull requests for commit [%s]: %w", c.platformConfig.GitHub.GitHubSHA, err)
+			return fmt.Errorf("failed to list pull requests for commit [%s]: %w", sha, err)
 		}
 		if len(resp.PullRequests) == 0 {
-			c.Outf("no pull requests found for commit [%s], skipping reporting", c.platformConfig.GitHub.GitHubSHA)
+			c.Outf("no pull requests found for commit [%s], skipping reporting", sha)
 			return nil
 		}
 		prNumber = resp.PullRequests[0].Number
 	}
 
 	// Fetch all Jobs for current run ID
-	jobs, err := c.platformClient.ListJobs(ctx, c.platformConfig.GitHub.GitHubRunID)
+	runID := c.platformConfig.RunID()
+	jobs, err := c.platformClient.ListJobs(ctx, runID)
 	if err != nil {
-		return fmt.Errorf("failed to list jobs for run [%d]: %w", c.platformConfig.GitHub.GitHubRunID, err)
+		return fmt.Errorf("failed to list jobs for run [%d]: %w", runID, err)
 	}
 
 	// Parse entrypoints
@@ -325,6 +330,17 @@ func (c *ReportCommand) Process(ctx context.Context) error {
 				PerPage: 100,
 			},
 		},
+		GitLab: &gitlab.ListMergeRequestNotesOptions{
+			ListOptions: gitlab.ListOptions{
+				PerPage: 100,
+			},
+		},
+		Gitea: &gitea.ListIssueCommentOptions{
+			ListOptions: gitea.ListOptions{
+				Page:     1,
+				PageSize: 50,
+			},
+		},
 	}
 
 	var allReports []*platform.Report
@@ -338,6 +354,8 @@ func (c *ReportCommand) Process(ctx context.Context) error {
 			break
 		}
 		listOpts.GitHub.Page = res.Pagination.NextPage
+		listOpts.GitLab.Page = res.Pagination.NextPage
+		listOpts.Gitea.Page = res.Pagination.NextPage
 	}
 
 	for _, r := range allReports {
@@ -357,19 +375,6 @@ func (c *ReportCommand) Process(ctx context.Context) error {
 	return nil
 }
 
-type planJSON struct {
-	ResourceChanges []resourceChange `json:"resource_changes"`
-}
-
-type resourceChange struct {
-	Address string `json:"address"`
-	Change  change `json:"change"`
-}
-
-type change struct {
-	Actions []string `json:"actions"`
-}
-
 type planStat struct {
 	Added   int
 	Changed int
@@ -377,51 +382,20 @@ type planStat struct {
 	Err     error
 }
 
+// parsePlanFile returns the number of resources added, changed and deleted by
+// the JSON plan file, where replaced resources are both added and deleted.
 func parsePlanFile(p string) (int, int, int, error) {
 	data, err := os.ReadFile(p)
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	var plan planJSON
-	if err := json.Unmarshal(data, &plan); err != nil {
+	s, err := terraform.ParsePlanSummary(data)
+	if err != nil {
 		return 0, 0, 0, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
-	var added, changed, deleted int
-	for _, rc := range plan.ResourceChanges {
-		actions := rc.Change.Actions
-		if len(actions) == 0 {
-			continue
-		}
-
-		isDelete := false
-		isCreate := false
-		isUpdate := false
-		for _, a := range actions {
-			switch a {
-			case "create":
-				isCreate = true
-			case "delete":
-				isDelete = true
-			case "update":
-				isUpdate = true
-			}
-		}
-
-		if isCreate && isDelete {
-			added++
-			deleted++
-		} else if isCreate {
-			added++
-		} else if isDelete {
-			deleted++
-		} else if isUpdate {
-			changed++
-		}
-	}
-
-	return added, changed, deleted, nil
+	return s.Create + s.Replace, s.Update, s.Delete + s.Replace, nil
 }
 
 func findPlanFile(artifactsDir, entrypoint string) (string, error) {
@@ -20,8 +20,10 @@ import (
 	"strings"
 	"testing"
 
+	"code.gitea.io/sdk/gitea"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/v53/github"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
 
 	"github.com/abcxyz/guardian/pkg/platform"
 	"github.com/abcxyz/pkg/testutil"
@@ -166,6 +168,17 @@ func TestReportCommandProcess(t *testing.T) {
 									PerPage: 100,
 								},
 							},
+							GitLab: &gitlab.ListMergeRequestNotesOptions{
+								ListOptions: gitlab.ListOptions{
+									PerPage: 100,
+								},
+							},
+							Gitea: &gitea.ListIssueCommentOptions{
+								ListOptions: gitea.ListOptions{
+									Page:     1,
+									PageSize: 50,
+								},
+							},
 						},
 					},
 				},
@@ -227,6 +240,17 @@ func TestReportCommandProcess(t *testing.T) {
 									PerPage: 100,
 								},
 							},
+							GitLab: &gitlab.ListMergeRequestNotesOptions{
+								ListOptions: gitlab.ListOptions{
+									PerPage: 100,
+								},
+							},
+							Gitea: &gitea.ListIssueCommentOptions{
+								ListOptions: gitea.ListOptions{
+									Page:     1,
+									PageSize: 50,
+								},
+							},
 						},
 					},
 				},
@@ -284,6 +308,17 @@ func TestReportCommandProcess(t *testing.T) {
 									PerPage: 100,
 								},
 							},
+							GitLab: &gitlab.ListMergeRequestNotesOptions{
+								ListOptions: gitlab.ListOptions{
+									PerPage: 100,
+								},
+							},
+							Gitea: &gitea.ListIssueCommentOptions{
+								ListOptions: gitea.ListOptions{
+									Page:     1,
+									PageSize: 50,
+								},
+							},
 						},
 					},
 				},
@@ -341,6 +376,17 @@ func TestReportCommandProcess(t *testing.T) {
 									PerPage: 100,
 								},
 							},
+							GitLab: &gitlab.ListMergeRequestNotesOptions{
+								ListOptions: gitlab.ListOptions{
+									PerPage: 100,
+								},
+							},
+							Gitea: &gitea.ListIssueCommentOptions{
+								ListOptions: gitea.ListOptions{
+									Page:     1,
+									PageSize: 50,
+								},
+							},
 						},
 					},
 				},
@@ -385,6 +431,17 @@ func TestReportCommandProcess(t *testing.T) {
 									PerPage: 100,
 								},
 							},
+							GitLab: &gitlab.ListMergeRequestNotesOptions{
+								ListOptions: gitlab.ListOptions{
+									PerPage: 100,
+								},
+							},
+							Gitea: &gitea.ListIssueCommentOptions{
+								ListOptions: gitea.ListOptions{
+									Page:     1,
+									PageSize: 50,
+								},
+							},
 						},
 					},
 				},
@@ -427,6 +484,17 @@ func TestReportCommandProcess(t *testing.T) {
 									PerPage: 100,
 								},
 							},
+							GitLab: &gitlab.ListMergeRequestNotesOptions{
+								ListOptions: gitlab.ListOptions{
+									PerPage: 100,
+								},
+							},
+							Gitea: &gitea.ListIssueCommentOptions{
+								ListOptions: gitea.ListOptions{
+									Page:     1,
+									PageSize: 50,
+								},
+							},
 						},
 					},
 				},
@@ -475,6 +543,17 @@ func TestReportCommandProcess(t *testing.T) {
 									PerPage: 100,
 								},
 							},
+							GitLab: &gitlab.ListMergeRequestNotesOptions{
+								ListOptions: gitlab.ListOptions{
+									PerPage: 100,
+								},
+							},
+							Gitea: &gitea.ListIssueCommentOptions{
+								ListOptions: gitea.ListOptions{
+									Page:     1,
+									PageSize: 50,
+								},
+							},
 						},
 					},
 				},
@@ -517,6 +596,7 @@ func TestReportCommandProcess(t *testing.T) {
 				flagArtifactsDir: artifactsDir,
 				platformClient:   mockPlatformClient,
 			}
+			c.platformConfig.Type = platform.TypeGitHub
 			c.platformConfig.GitHub.GitHubPullRequestNumber = tc.githubPullRequestNumber
 			c.platformConfig.GitHub.GitHubSHA = tc.githubSHA
 			c.platformConfig.GitHub.GitHubRunID = tc.githubRunID
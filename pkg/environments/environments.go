@@ -0,0 +1,139 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package environments reads the environments an entrypoint is deployed to,
+// declared in the Guardian config file of the entrypoint directory.
+package environments
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+// ConfigFilename is the name of the Guardian config file in an entrypoint
+// directory.
+const ConfigFilename = "guardian.hcl"
+
+// validName matches environment names, which are used in storage paths.
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Config is the Guardian config of an entrypoint.
+type Config struct {
+	Environments []*Environment `hcl:"environment,block"`
+}
+
+// Environment is a deployment of an entrypoint. Paths are relative to the
+// entrypoint directory.
+type Environment struct {
+	// Name is the name of the environment.
+	Name string `hcl:"name,label"`
+
+	// Workspace is the Terraform workspace selected for the environment.
+	Workspace string `hcl:"workspace,optional"`
+
+	// VarFiles are the variable files passed to terraform plan.
+	VarFiles []string `hcl:"var_files,optional"`
+
+	// BackendConfigs are the backend config files passed to terraform init.
+	BackendConfigs []string `hcl:"backend_configs,optional"`
+}
+
+// Load reads the Guardian config of an entrypoint directory. An empty config
+// is returned if the directory does not have a config file.
+func Load(dir string) (*Config, error) {
+	pth := filepath.Join(dir, ConfigFilename)
+
+	b, err := os.ReadFile(pth)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	return parse(b, pth)
+}
+
+// parse parses and validates the contents of a Guardian config file.
+func parse(contents []byte, filename string) (*Config, error) {
+	parser := hclparse.NewParser()
+	file, diags := parser.ParseHCL(contents, filename)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse config file: %w", diags)
+	}
+
+	var c Config
+	if diags := gohcl.DecodeBody(file.Body, nil, &c); diags.HasErrors() {
+		return nil, fmt.Errorf("failed to decode config file: %w", diags)
+	}
+
+	seen := make(map[string]struct{}, len(c.Environments))
+	for _, e := range c.Environments {
+		if !validName.MatchString(e.Name) {
+			return nil, fmt.Errorf("invalid environment name %q, must match %s", e.Name, validName)
+		}
+		if _, ok := seen[e.Name]; ok {
+			return nil, fmt.Errorf("environment %q is declared more than once", e.Name)
+		}
+		seen[e.Name] = struct{}{}
+	}
+
+	return &c, nil
+}
+
+// Names returns the names of the environments in the order they are declared.
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Environments))
+	for _, e := range c.Environments {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+// Select returns the environment with the given name. An entrypoint without
+// environments can only be run without selecting one, in which case an empty
+// environment is returned, and an entrypoint with environments must have one
+// selected.
+func (c *Config) Select(name string) (*Environment, error) {
+	if name == "" {
+		if len(c.Environments) > 0 {
+			return nil, fmt.Errorf("an environment must be selected, declared environments are %q", c.Names())
+		}
+		return &Environment{}, nil
+	}
+
+	for _, e := range c.Environments {
+		if e.Name == name {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("environment %q is not declared in %s", name, ConfigFilename)
+}
+
+// Qualify returns the entrypoint path qualified with the environment name,
+// which identifies the plan files, apply locks and status reports of an
+// entrypoint environment. The path is returned as is without an environment.
+func Qualify(entrypoint, environment string) string {
+	if environment == "" {
+		return entrypoint
+	}
+	return entrypoint + "@" + environment
+}
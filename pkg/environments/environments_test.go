@@ -0,0 +1,193 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package environments
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		dir  string
+		exp  *Config
+		err  string
+	}{
+		{
+			name: "success",
+			dir:  "testdata/environments",
+			exp: &Config{
+				Environments: []*Environment{
+					{
+						Name:           "dev",
+						Workspace:      "dev",
+						VarFiles:       []string{"env/dev.tfvars"},
+						BackendConfigs: []string{"env/dev.backend.hcl"},
+					},
+					{
+						Name:     "prod",
+						VarFiles: []string{"env/common.tfvars", "env/prod.tfvars"},
+					},
+				},
+			},
+		},
+		{
+			name: "missing_config",
+			dir:  "testdata/missing",
+			exp:  &Config{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Load(tc.dir)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("config not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		contents string
+		err      string
+	}{
+		{
+			name: "invalid_name",
+			contents: `
+environment "dev/us" {}
+`,
+			err: `invalid environment name "dev/us"`,
+		},
+		{
+			name: "duplicate_name",
+			contents: `
+environment "dev" {}
+environment "dev" {}
+`,
+			err: `environment "dev" is declared more than once`,
+		},
+		{
+			name: "unknown_attribute",
+			contents: `
+environment "dev" {
+  var_file = "dev.tfvars"
+}
+`,
+			err: "failed to decode config file",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parse([]byte(tc.contents), ConfigFilename)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestConfig_Select(t *testing.T) {
+	t.Parallel()
+
+	withEnvironments := &Config{
+		Environments: []*Environment{
+			{Name: "dev", Workspace: "dev"},
+			{Name: "prod", Workspace: "prod"},
+		},
+	}
+
+	cases := []struct {
+		name        string
+		config      *Config
+		environment string
+		exp         *Environment
+		err         string
+	}{
+		{
+			name:        "selects_environment",
+			config:      withEnvironments,
+			environment: "prod",
+			exp:         &Environment{Name: "prod", Workspace: "prod"},
+		},
+		{
+			name:   "no_environments",
+			config: &Config{},
+			exp:    &Environment{},
+		},
+		{
+			name:   "requires_environment",
+			config: withEnvironments,
+			err:    `an environment must be selected, declared environments are ["dev" "prod"]`,
+		},
+		{
+			name:        "unknown_environment",
+			config:      withEnvironments,
+			environment: "staging",
+			err:         `environment "staging" is not declared in guardian.hcl`,
+		},
+		{
+			name:        "no_environments_selected",
+			config:      &Config{},
+			environment: "dev",
+			err:         `environment "dev" is not declared in guardian.hcl`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tc.config.Select(tc.environment)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("environment not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestQualify(t *testing.T) {
+	t.Parallel()
+
+	if got, want := Qualify("terraform/app", ""), "terraform/app"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if got, want := Qualify("terraform/app", "dev"), "terraform/app@dev"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+}
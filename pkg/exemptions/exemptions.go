@@ -0,0 +1,293 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package exemptions provides time bound policy exemptions for change
+// requests, recorded with an audit trail using a Guardian storage client.
+package exemptions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/abcxyz/guardian/pkg/storage"
+)
+
+const (
+	// DefaultTTL is the default duration an exemption applies for after it is
+	// granted.
+	DefaultTTL = 24 * time.Hour
+
+	exemptionRoot = "guardian-exemptions"
+)
+
+// Actions recorded in the audit trail of an exemption.
+const (
+	ActionGranted  = "granted"
+	ActionApplied  = "applied"
+	ActionExpired  = "expired"
+	ActionRejected = "rejected"
+)
+
+var (
+	// ErrUnauthorized is returned when the user requesting an exemption is not
+	// authorized to grant it.
+	ErrUnauthorized = errors.New("user is not authorized to grant policy exemptions")
+
+	// ErrExpired is returned when the exemption expired.
+	ErrExpired = errors.New("exemption expired")
+)
+
+// Exemption is the exemption of a change request from a policy. An exemption
+// is granted the first time it is requested by an authorized user and applies
+// until it expires. Every request is recorded in the audit trail, including
+// rejected requests.
+type Exemption struct {
+	// Policy is the name of the policy the change request is exempted from.
+	Policy string `json:"policy"`
+
+	// ChangeRequest is the number of the exempted change request.
+	ChangeRequest int `json:"change_request"`
+
+	// Reason is the reason the exemption was granted for.
+	Reason string `json:"reason,omitempty"`
+
+	// Source is how the exemption was requested, e.g. with a modifier or label.
+	Source string `json:"source,omitempty"`
+
+	// GrantedBy is the user that granted the exemption.
+	GrantedBy string `json:"granted_by,omitempty"`
+
+	// GrantedAt is the time the exemption was granted.
+	GrantedAt time.Time `json:"granted_at,omitzero"`
+
+	// ExpiresAt is the time the exemption expires.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+
+	// Audit is the audit trail of the exemption, oldest first.
+	Audit []*Event `json:"audit"`
+
+	version string
+}
+
+// Event is an entry in the audit trail of an exemption.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor,omitempty"`
+	CommitSHA string    `json:"commit_sha,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// Granted returns true if the exemption was granted.
+func (e *Exemption) Granted() bool {
+	return !e.GrantedAt.IsZero()
+}
+
+// Active returns true if the exemption was granted and has not expired at the
+// given time.
+func (e *Exemption) Active(now time.Time) bool {
+	return e.Granted() && now.Before(e.ExpiresAt)
+}
+
+// Request is a request to exempt a change request from a policy.
+type Request struct {
+	// ChangeRequest is the number of the change request.
+	ChangeRequest int
+
+	// Policy is the name of the policy.
+	Policy string
+
+	// Reason is the reason the exemption is requested.
+	Reason string
+
+	// Source is how the exemption was requested.
+	Source string
+
+	// Actor is the user requesting the exemption.
+	Actor string
+
+	// Authorized is true if the actor is authorized to grant exemptions.
+	Authorized bool
+
+	// CommitSHA is the commit being evaluated.
+	CommitSHA string
+}
+
+// Client reads and writes the policy exemptions of a repository.
+type Client struct {
+	storage storage.Storage
+	prefix  string
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewClient creates a new Client storing the policy exemptions for a
+// repository using the storage client. Granted exemptions expire after the ttl,
+// which defaults to DefaultTTL.
+func NewClient(s storage.Storage, repository string, ttl time.Duration) *Client {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &Client{
+		storage: s,
+		prefix:  path.Join(exemptionRoot, repository),
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// exemptionPath returns the storage path of the exemption of a change request
+// from a policy.
+func (c *Client) exemptionPath(changeRequest int, policy string) string {
+	return path.Join(c.prefix, strconv.Itoa(changeRequest), policy+".json")
+}
+
+// Get returns the exemption of a change request from a policy. An exemption
+// that is not granted is returned if it was never requested.
+func (c *Client) Get(ctx context.Context, changeRequest int, policy string) (*Exemption, error) {
+	e, err := c.get(ctx, c.exemptionPath(changeRequest, policy))
+	if err != nil {
+		return nil, err
+	}
+	if e.Policy == "" {
+		e.Policy = policy
+		e.ChangeRequest = changeRequest
+	}
+	return e, nil
+}
+
+// get reads the exemption stored at the storage path.
+func (c *Client) get(ctx context.Context, name string) (_ *Exemption, outErr error) {
+	// the version is read before the contents, so an update in between makes
+	// the next write fail instead of overwriting the newer audit trail
+	version, err := c.storage.ObjectVersion(ctx, name)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return &Exemption{}, nil
+		}
+		return nil, fmt.Errorf("failed to get exemption version: %w", err)
+	}
+
+	rc, _, err := c.storage.GetObject(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exemption: %w", err)
+	}
+	defer func() {
+		if closeErr := rc.Close(); closeErr != nil {
+			outErr = errors.Join(outErr, fmt.Errorf("failed to close exemption reader: %w", closeErr))
+		}
+	}()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exemption: %w", err)
+	}
+
+	var e Exemption
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, fmt.Errorf("failed to parse exemption: %w", err)
+	}
+	e.version = version
+
+	return &e, nil
+}
+
+// write stores the exemption at the storage path, if the stored exemption
+// still matches the version it was read with.
+func (c *Client) write(ctx context.Context, name string, e *Exemption) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal exemption: %w", err)
+	}
+
+	opts := []storage.CreateOption{
+		storage.WithContentType("application/json"),
+		storage.WithCacheMaxAgeSeconds(0),
+	}
+	// an empty version means the exemption did not exist when read, in which
+	// case it is only created if it still does not exist
+	if e.version != "" {
+		opts = append(opts, storage.WithIfVersionMatch(e.version))
+	}
+
+	if err := c.storage.CreateObject(ctx, name, b, opts...); err != nil {
+		return fmt.Errorf("failed to write exemption: %w", err)
+	}
+	return nil
+}
+
+// Apply applies a requested exemption and records the request in the audit
+// trail. The exemption is granted the first time it is requested by an
+// authorized user and applies until it expires. An expired exemption cannot be
+// granted again for the same change request. The exemption is returned with
+// ErrUnauthorized if it is not granted and the actor is not authorized, or
+// ErrExpired if it expired.
+func (c *Client) Apply(ctx context.Context, req *Request) (*Exemption, error) {
+	if req.ChangeRequest <= 0 {
+		return nil, fmt.Errorf("change request number is required to apply an exemption")
+	}
+	if req.Policy == "" {
+		return nil, fmt.Errorf("policy is required to apply an exemption")
+	}
+
+	name := c.exemptionPath(req.ChangeRequest, req.Policy)
+
+	e, err := c.get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	e.Policy = req.Policy
+	e.ChangeRequest = req.ChangeRequest
+
+	now := c.now()
+	event := &Event{
+		Time:      now,
+		Actor:     req.Actor,
+		CommitSHA: req.CommitSHA,
+	}
+
+	var result error
+	switch {
+	case !e.Granted() && !req.Authorized:
+		event.Action = ActionRejected
+		event.Reason = req.Reason
+		result = fmt.Errorf("%w: %s", ErrUnauthorized, req.Actor)
+	case !e.Granted():
+		event.Action = ActionGranted
+		event.Reason = req.Reason
+		e.Reason = req.Reason
+		e.Source = req.Source
+		e.GrantedBy = req.Actor
+		e.GrantedAt = now
+		e.ExpiresAt = now.Add(c.ttl)
+	case !e.Active(now):
+		event.Action = ActionExpired
+		result = fmt.Errorf("%w at %s", ErrExpired, e.ExpiresAt.UTC().Format(time.RFC3339))
+	default:
+		event.Action = ActionApplied
+	}
+	e.Audit = append(e.Audit, event)
+
+	if err := c.write(ctx, name, e); err != nil {
+		return nil, err
+	}
+	return e, result
+}
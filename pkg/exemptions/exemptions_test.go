@@ -0,0 +1,228 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exemptions
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/abcxyz/guardian/pkg/storage"
+)
+
+var testNow = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// testClient returns an exemption client for the owner/repo repository using
+// a filesystem storage client in a temporary directory.
+func testClient(tb testing.TB) (*Client, storage.Storage) {
+	tb.Helper()
+
+	s, err := storage.NewFilesystemStorage(tb.Context(), tb.TempDir())
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	c := NewClient(s, "owner/repo", time.Hour)
+	c.now = func() time.Time { return testNow }
+	return c, s
+}
+
+// writeExemption writes an exemption directly to the storage client.
+func writeExemption(tb testing.TB, s storage.Storage, name string, e *Exemption) {
+	tb.Helper()
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := s.CreateObject(tb.Context(), name, b, storage.WithAllowOverwrite(true)); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func TestClient_Apply(t *testing.T) {
+	t.Parallel()
+
+	granted := &Exemption{
+		Policy:        "iam",
+		ChangeRequest: 5,
+		Reason:        "incident",
+		Source:        "modifier",
+		GrantedBy:     "admin",
+		GrantedAt:     testNow.Add(-30 * time.Minute),
+		ExpiresAt:     testNow.Add(30 * time.Minute),
+		Audit: []*Event{
+			{Time: testNow.Add(-30 * time.Minute), Action: ActionGranted, Actor: "admin", Reason: "incident"},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		existing *Exemption
+		req      *Request
+		exp      *Exemption
+		err      error
+	}{
+		{
+			name: "grants_exemption",
+			req: &Request{
+				ChangeRequest: 5,
+				Policy:        "iam",
+				Reason:        "incident",
+				Source:        "modifier",
+				Actor:         "admin",
+				Authorized:    true,
+				CommitSHA:     "sha",
+			},
+			exp: &Exemption{
+				Policy:        "iam",
+				ChangeRequest: 5,
+				Reason:        "incident",
+				Source:        "modifier",
+				GrantedBy:     "admin",
+				GrantedAt:     testNow,
+				ExpiresAt:     testNow.Add(time.Hour),
+				Audit: []*Event{
+					{Time: testNow, Action: ActionGranted, Actor: "admin", CommitSHA: "sha", Reason: "incident"},
+				},
+			},
+		},
+		{
+			name: "rejects_unauthorized",
+			req: &Request{
+				ChangeRequest: 5,
+				Policy:        "iam",
+				Reason:        "incident",
+				Actor:         "author",
+				CommitSHA:     "sha",
+			},
+			exp: &Exemption{
+				Policy:        "iam",
+				ChangeRequest: 5,
+				Audit: []*Event{
+					{Time: testNow, Action: ActionRejected, Actor: "author", CommitSHA: "sha", Reason: "incident"},
+				},
+			},
+			err: ErrUnauthorized,
+		},
+		{
+			name:     "applies_granted",
+			existing: granted,
+			req: &Request{
+				ChangeRequest: 5,
+				Policy:        "iam",
+				Reason:        "another reason",
+				Actor:         "author",
+				CommitSHA:     "sha2",
+			},
+			exp: &Exemption{
+				Policy:        "iam",
+				ChangeRequest: 5,
+				Reason:        "incident",
+				Source:        "modifier",
+				GrantedBy:     "admin",
+				GrantedAt:     testNow.Add(-30 * time.Minute),
+				ExpiresAt:     testNow.Add(30 * time.Minute),
+				Audit: []*Event{
+					{Time: testNow.Add(-30 * time.Minute), Action: ActionGranted, Actor: "admin", Reason: "incident"},
+					{Time: testNow, Action: ActionApplied, Actor: "author", CommitSHA: "sha2"},
+				},
+			},
+		},
+		{
+			name: "expired",
+			existing: &Exemption{
+				Policy:        "iam",
+				ChangeRequest: 5,
+				Reason:        "incident",
+				GrantedBy:     "admin",
+				GrantedAt:     testNow.Add(-2 * time.Hour),
+				ExpiresAt:     testNow.Add(-time.Hour),
+			},
+			req: &Request{
+				ChangeRequest: 5,
+				Policy:        "iam",
+				Reason:        "incident",
+				Actor:         "admin",
+				Authorized:    true,
+			},
+			exp: &Exemption{
+				Policy:        "iam",
+				ChangeRequest: 5,
+				Reason:        "incident",
+				GrantedBy:     "admin",
+				GrantedAt:     testNow.Add(-2 * time.Hour),
+				ExpiresAt:     testNow.Add(-time.Hour),
+				Audit: []*Event{
+					{Time: testNow, Action: ActionExpired, Actor: "admin"},
+				},
+			},
+			err: ErrExpired,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+			c, s := testClient(t)
+
+			if tc.existing != nil {
+				writeExemption(t, s, "guardian-exemptions/owner/repo/5/iam.json", tc.existing)
+			}
+
+			got, err := c.Apply(ctx, tc.req)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v to be %v", err, tc.err)
+			}
+
+			if diff := cmp.Diff(got, tc.exp, cmpopts.IgnoreUnexported(Exemption{})); diff != "" {
+				t.Errorf("exemption not as expected; (-got,+want): %s", diff)
+			}
+
+			stored, err := c.Get(ctx, 5, "iam")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(stored, tc.exp, cmpopts.IgnoreUnexported(Exemption{})); diff != "" {
+				t.Errorf("stored exemption not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestClient_Get(t *testing.T) {
+	t.Parallel()
+
+	c, _ := testClient(t)
+
+	got, err := c.Get(t.Context(), 5, "iam")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := &Exemption{Policy: "iam", ChangeRequest: 5}
+	if diff := cmp.Diff(got, exp, cmpopts.IgnoreUnexported(Exemption{})); diff != "" {
+		t.Errorf("exemption not as expected; (-got,+want): %s", diff)
+	}
+	if got.Active(testNow) {
+		t.Errorf("expected exemption that was never requested not to be active")
+	}
+}
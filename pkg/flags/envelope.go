@@ -0,0 +1,130 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/abcxyz/guardian/pkg/storage"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// EnvelopeFlags represent the shared plan file signing and encryption flags.
+// Embed this struct into any commands that read or write plan files.
+type EnvelopeFlags struct {
+	FlagPlanSigningKey        string
+	FlagPlanSigningKeyFile    string
+	FlagPlanEncryptionKey     string
+	FlagPlanEncryptionKeyFile string
+}
+
+func (e *EnvelopeFlags) Register(set *cli.FlagSet) {
+	f := set.NewSection("PLAN FILE OPTIONS")
+
+	f.StringVar(&cli.StringVar{
+		Name:    "plan-signing-key",
+		Target:  &e.FlagPlanSigningKey,
+		EnvVar:  "GUARDIAN_PLAN_SIGNING_KEY",
+		Example: "c2lnbmluZy1rZXk=",
+		Usage: "The base64 encoded key used to sign plan files with HMAC-SHA256. " +
+			"When set, plan files are signed when saved and verified before they are applied.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "plan-signing-key-file",
+		Target:  &e.FlagPlanSigningKeyFile,
+		Example: "/path/to/signing.key",
+		Usage:   "The path to a file containing the base64 encoded plan signing key.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "plan-encryption-key",
+		Target:  &e.FlagPlanEncryptionKey,
+		EnvVar:  "GUARDIAN_PLAN_ENCRYPTION_KEY",
+		Example: "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+		Usage: "The base64 encoded 16, 24 or 32 byte key used to encrypt plan files with AES-GCM. " +
+			"Requires a plan signing key.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "plan-encryption-key-file",
+		Target:  &e.FlagPlanEncryptionKeyFile,
+		Example: "/path/to/encryption.key",
+		Usage:   "The path to a file containing the base64 encoded plan encryption key.",
+	})
+
+	set.AfterParse(func(merr error) error {
+		if e.FlagPlanSigningKey != "" && e.FlagPlanSigningKeyFile != "" {
+			merr = errors.Join(merr, fmt.Errorf("only one of plan-signing-key or plan-signing-key-file can be set"))
+		}
+
+		if e.FlagPlanEncryptionKey != "" && e.FlagPlanEncryptionKeyFile != "" {
+			merr = errors.Join(merr, fmt.Errorf("only one of plan-encryption-key or plan-encryption-key-file can be set"))
+		}
+
+		hasSigningKey := e.FlagPlanSigningKey != "" || e.FlagPlanSigningKeyFile != ""
+		hasEncryptionKey := e.FlagPlanEncryptionKey != "" || e.FlagPlanEncryptionKeyFile != ""
+		if hasEncryptionKey && !hasSigningKey {
+			merr = errors.Join(merr, fmt.Errorf("plan-encryption-key requires a plan signing key"))
+		}
+
+		return merr
+	})
+}
+
+// WrapStorage wraps the storage client in a storage.EnvelopeStorage when a plan
+// signing key is configured, otherwise the storage client is returned as is.
+func (e *EnvelopeFlags) WrapStorage(ctx context.Context, s storage.Storage) (storage.Storage, error) {
+	signingKey, err := readKey(e.FlagPlanSigningKey, e.FlagPlanSigningKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan signing key: %w", err)
+	}
+
+	if len(signingKey) == 0 {
+		return s, nil
+	}
+
+	encryptionKey, err := readKey(e.FlagPlanEncryptionKey, e.FlagPlanEncryptionKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan encryption key: %w", err)
+	}
+
+	es, err := storage.NewEnvelopeStorage(ctx, s, signingKey, encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create envelope storage: %w", err)
+	}
+	return es, nil
+}
+
+// readKey parses a base64 encoded key from the value or, if set, the contents
+// of the file.
+func readKey(value, file string) ([]byte, error) {
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		value = string(b)
+	}
+
+	key, err := storage.ParseEnvelopeKey(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key: %w", err)
+	}
+	return key, nil
+}
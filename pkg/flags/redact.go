@@ -0,0 +1,60 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"fmt"
+
+	"github.com/abcxyz/guardian/pkg/terraform"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// RedactFlags represent the shared flags configuring how sensitive values are
+// masked in Terraform output. Embed this struct into any commands that report
+// Terraform output.
+type RedactFlags struct {
+	FlagRedactPatterns   []string
+	FlagRedactAttributes []string
+}
+
+func (r *RedactFlags) Register(set *cli.FlagSet) {
+	f := set.NewSection("REDACTION OPTIONS")
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "redact-pattern",
+		Target:  &r.FlagRedactPatterns,
+		EnvVar:  "GUARDIAN_REDACT_PATTERNS",
+		Example: `ghp_[A-Za-z0-9]{36}`,
+		Usage:   "The regular expressions matching values that are masked in Terraform output, in addition to the values marked as sensitive in the plan.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "redact-attribute",
+		Target:  &r.FlagRedactAttributes,
+		EnvVar:  "GUARDIAN_REDACT_ATTRIBUTES",
+		Default: terraform.DefaultRedactAttributes,
+		Example: "password,token",
+		Usage:   "The names of attributes whose values are masked in Terraform output, even if the provider does not mark them as sensitive.",
+	})
+}
+
+// Redactor returns the redactor masking sensitive values in Terraform output.
+func (r *RedactFlags) Redactor() (*terraform.Redactor, error) {
+	redactor, err := terraform.NewRedactor(r.FlagRedactPatterns, r.FlagRedactAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure redaction: %w", err)
+	}
+	return redactor, nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/posener/complete/v2"
+
+	"github.com/abcxyz/guardian/pkg/terraform"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// TerraformFlags represent the shared flags selecting the Terraform compatible
+// executable. Embed this struct into any commands that run Terraform.
+type TerraformFlags struct {
+	FlagTerraformBinary    string
+	FlagTofuEncryption     string
+	FlagTofuEncryptionFile string
+
+	FlagTerraformVersionMirror  string
+	FlagTerraformVersionCache   string
+	FlagTerraformVersionKeyFile string
+
+	FlagTerraformGracePeriod time.Duration
+}
+
+func (t *TerraformFlags) Register(set *cli.FlagSet) {
+	f := set.NewSection("TERRAFORM OPTIONS")
+
+	f.StringVar(&cli.StringVar{
+		Name:    "terraform-binary",
+		Target:  &t.FlagTerraformBinary,
+		EnvVar:  "GUARDIAN_TERRAFORM_BINARY",
+		Default: terraform.BinaryTerraform,
+		Example: terraform.BinaryOpenTofu,
+		Usage: fmt.Sprintf("The Terraform compatible executable to run, %q, %q, the path to an executable, or %q "+
+			"to use OpenTofu when the directory contains OpenTofu files or only OpenTofu is installed.",
+			terraform.BinaryTerraform, terraform.BinaryOpenTofu, terraform.BinaryAuto),
+		Predict: complete.PredictFunc(func(prefix string) []string {
+			return []string{terraform.BinaryTerraform, terraform.BinaryOpenTofu, terraform.BinaryAuto}
+		}),
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "tofu-encryption",
+		Target:  &t.FlagTofuEncryption,
+		EnvVar:  "GUARDIAN_TOFU_ENCRYPTION",
+		Example: `key_provider "pbkdf2" "key" { passphrase = "..." }`,
+		Usage: "The OpenTofu state and plan encryption configuration, passed to OpenTofu using the " +
+			"TF_ENCRYPTION environment variable. Requires OpenTofu.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "tofu-encryption-file",
+		Target:  &t.FlagTofuEncryptionFile,
+		Example: "/path/to/encryption.hcl",
+		Usage:   "The path to a file containing the OpenTofu state and plan encryption configuration.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "terraform-version-mirror",
+		Target:  &t.FlagTerraformVersionMirror,
+		EnvVar:  "GUARDIAN_TERRAFORM_VERSION_MIRROR",
+		Example: "https://releases.hashicorp.com/terraform",
+		Usage: "The release mirror to install the version matching the required_version of the entrypoint " +
+			"and its local modules from, an HTTP URL, a file:// URL or a local directory. Releases are verified using the " +
+			"terraform-version-key-file.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "terraform-version-cache",
+		Target:  &t.FlagTerraformVersionCache,
+		EnvVar:  "GUARDIAN_TERRAFORM_VERSION_CACHE",
+		Example: "/path/to/cache",
+		Usage: "The directory installed releases are cached in. Without a terraform-version-mirror, " +
+			"only releases in the cache are used. Cached releases are checked against the checksum " +
+			"recorded when they were installed. Defaults to the user cache directory when a " +
+			"terraform-version-mirror is set.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "terraform-version-key-file",
+		Target:  &t.FlagTerraformVersionKeyFile,
+		EnvVar:  "GUARDIAN_TERRAFORM_VERSION_KEY_FILE",
+		Example: "/path/to/hashicorp.asc",
+		Usage:   "The path to the armored PGP public key the release checksums are signed with.",
+	})
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "terraform-grace-period",
+		Target:  &t.FlagTerraformGracePeriod,
+		EnvVar:  "GUARDIAN_TERRAFORM_GRACE_PERIOD",
+		Default: terraform.DefaultGracePeriod,
+		Example: "1m",
+		Usage: "The time Terraform is given to exit and release the state lock after it is interrupted " +
+			"because a step timed out or the run was canceled, before it is killed.",
+	})
+
+	set.AfterParse(func(merr error) error {
+		if t.FlagTofuEncryption != "" && t.FlagTofuEncryptionFile != "" {
+			merr = errors.Join(merr, fmt.Errorf("only one of tofu-encryption or tofu-encryption-file can be set"))
+		}
+		if t.FlagTerraformVersionMirror != "" && t.FlagTerraformVersionKeyFile == "" {
+			merr = errors.Join(merr, fmt.Errorf("terraform-version-key-file is required with terraform-version-mirror"))
+		}
+		return merr
+	})
+}
+
+// ClientOptions returns the options for creating a Terraform client for the
+// entrypoint directory, resolving the executable to run. When a version mirror
+// or cache is configured, the release matching the required version of the
+// entrypoint is installed and run instead of the executable on PATH.
+func (t *TerraformFlags) ClientOptions(ctx context.Context, dir string) ([]terraform.ClientOption, error) {
+	binary, err := terraform.ResolveBinary(dir, t.FlagTerraformBinary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve terraform binary: %w", err)
+	}
+
+	binary, err = t.installVersion(ctx, dir, binary)
+	if err != nil {
+		return nil, err
+	}
+
+	encryption := t.FlagTofuEncryption
+	if t.FlagTofuEncryptionFile != "" {
+		b, err := os.ReadFile(t.FlagTofuEncryptionFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tofu encryption file: %w", err)
+		}
+		encryption = string(b)
+	}
+
+	if encryption != "" && !terraform.IsOpenTofu(binary) {
+		return nil, fmt.Errorf("tofu-encryption requires OpenTofu, but the terraform binary is %q", binary)
+	}
+
+	return []terraform.ClientOption{
+		terraform.WithBinary(binary),
+		terraform.WithEncryption(encryption),
+		terraform.WithGracePeriod(t.FlagTerraformGracePeriod),
+	}, nil
+}
+
+// installVersion installs the release of the binary matching the required
+// version of the entrypoint directory and its local modules, returning the path to the installed
+// executable. The binary is returned as is if version management is not
+// configured, the binary is a path, or the entrypoint does not declare a
+// required version.
+func (t *TerraformFlags) installVersion(ctx context.Context, dir, binary string) (_ string, outErr error) {
+	if t.FlagTerraformVersionMirror == "" && t.FlagTerraformVersionCache == "" {
+		return binary, nil
+	}
+	if binary != terraform.BinaryTerraform && binary != terraform.BinaryOpenTofu {
+		return binary, nil
+	}
+
+	cacheDir := t.FlagTerraformVersionCache
+	if cacheDir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user cache directory: %w", err)
+		}
+		cacheDir = filepath.Join(userCache, "guardian", "versions")
+	}
+
+	var keyring io.Reader
+	if t.FlagTerraformVersionKeyFile != "" {
+		f, err := os.Open(t.FlagTerraformVersionKeyFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to open terraform version key file: %w", err)
+		}
+		defer func() {
+			if closeErr := f.Close(); closeErr != nil {
+				outErr = errors.Join(outErr, fmt.Errorf("failed to close terraform version key file: %w", closeErr))
+			}
+		}()
+		keyring = f
+	}
+
+	m, err := terraform.NewVersionManager(binary, t.FlagTerraformVersionMirror, cacheDir, keyring)
+	if err != nil {
+		return "", fmt.Errorf("failed to create version manager: %w", err)
+	}
+
+	// The current directory is the root of the repository, which the local
+	// modules of the entrypoint are resolved against.
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	pth, err := m.Install(ctx, cwd, dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to install %s version: %w", binary, err)
+	}
+	if pth == "" {
+		return binary, nil
+	}
+	return pth, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"time"
+
+	"github.com/abcxyz/pkg/cli"
+)
+
+// TimeoutFlags represent the shared flags limiting how long the Terraform steps
+// common to all commands can run. Embed this struct into any commands that
+// initialize and validate Terraform.
+type TimeoutFlags struct {
+	FlagInitTimeout     time.Duration
+	FlagValidateTimeout time.Duration
+}
+
+func (t *TimeoutFlags) Register(set *cli.FlagSet) {
+	f := set.NewSection("TIMEOUT OPTIONS")
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "init-timeout",
+		Target:  &t.FlagInitTimeout,
+		EnvVar:  "GUARDIAN_INIT_TIMEOUT",
+		Example: "10m",
+		Usage:   "The maximum duration of terraform init, unlimited if not set.",
+	})
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "validate-timeout",
+		Target:  &t.FlagValidateTimeout,
+		EnvVar:  "GUARDIAN_VALIDATE_TIMEOUT",
+		Example: "5m",
+		Usage:   "The maximum duration of terraform validate, unlimited if not set.",
+	})
+}
@@ -16,14 +16,18 @@
 package git
 
 import (
+	"archive/tar"
 	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strings"
 
 	"golang.org/x/exp/maps"
 
@@ -41,8 +45,18 @@ var newline = regexp.MustCompile("\r?\n")
 type Git interface {
 	// DiffDirsAbs returns the directories changed using the git diff command
 	DiffDirsAbs(ctx context.Context, baseRef, headRef string) ([]string, error)
+	// DiffDeletedDirsAbs returns the directories deleted using the git diff
+	// command
+	DiffDeletedDirsAbs(ctx context.Context, baseRef, headRef string) ([]string, error)
 	// CloneRepository clones the repository to the workingDir.
 	CloneRepository(ctx context.Context, githubToken, owner, repo string) error
+	// ResolveRef returns the commit SHA of a ref.
+	ResolveRef(ctx context.Context, ref string) (string, error)
+	// ExtractRef writes the files of the workingDir at a ref to a directory.
+	ExtractRef(ctx context.Context, ref, dest string) error
+	// ShowFile returns the contents of a file at a ref, relative to the
+	// workingDir.
+	ShowFile(ctx context.Context, ref, pth string) ([]byte, error)
 }
 
 // GitClient implements the git interface.
@@ -80,6 +94,32 @@ func (g *GitClient) DiffDirsAbs(ctx context.Context, sourceRef, destRef string)
 	return parseSortedDiffDirsAbs(ctx, stdout.String())
 }
 
+// DiffDeletedDirsAbs runs a git diff between two revisions and returns the
+// sorted list of absolute directory paths that had files deleted and no longer
+// exist.
+func (g *GitClient) DiffDeletedDirsAbs(ctx context.Context, sourceRef, destRef string) ([]string, error) {
+	logger := logging.FromContext(ctx).With("working_dir", g.workingDir)
+
+	var stdout, stderr bytes.Buffer
+
+	// renames are reported as a deletion and an addition, so moved directories
+	// are destroyed at their previous location
+	_, err := child.Run(ctx, &child.RunConfig{
+		Stdout:     &stdout,
+		Stderr:     &stderr,
+		WorkingDir: g.workingDir,
+		Command:    "git",
+		Args:       []string{"diff", fmt.Sprintf("%s..%s", sourceRef, destRef), "--name-only", "--no-renames", "--diff-filter=D"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git diff command: %w\n\n%s", err, stderr.String())
+	}
+
+	logger.DebugContext(ctx, "DiffDeletedDirsAbs git diff output", "output", stdout.String())
+
+	return parseSortedDeletedDirsAbs(ctx, stdout.String())
+}
+
 // CloneRepository clones the repository to the workingDir.
 func (g *GitClient) CloneRepository(ctx context.Context, githubToken, owner, repo string) error {
 	logger := logging.FromContext(ctx).With("working_dir", g.workingDir)
@@ -102,6 +142,183 @@ func (g *GitClient) CloneRepository(ctx context.Context, githubToken, owner, rep
 	return nil
 }
 
+// ResolveRef returns the commit SHA of a ref.
+func (g *GitClient) ResolveRef(ctx context.Context, ref string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	_, err := child.Run(ctx, &child.RunConfig{
+		Stdout:     &stdout,
+		Stderr:     &stderr,
+		WorkingDir: g.workingDir,
+		Command:    "git",
+		Args:       []string{"rev-parse", "--verify", ref + "^{commit}"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to run git rev-parse command: %w\n\n%s", err, stderr.String())
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// ExtractRef writes the files of the workingDir at a ref to the dest directory,
+// without changing the current checkout. When the workingDir is a subdirectory
+// of the repository, only the files of that subdirectory are written.
+func (g *GitClient) ExtractRef(ctx context.Context, ref, dest string) error {
+	logger := logging.FromContext(ctx).With("working_dir", g.workingDir)
+
+	var stdout, stderr bytes.Buffer
+
+	_, err := child.Run(ctx, &child.RunConfig{
+		Stdout:     &stdout,
+		Stderr:     &stderr,
+		WorkingDir: g.workingDir,
+		Command:    "git",
+		Args:       []string{"archive", "--format=tar", ref},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to run git archive command: %w\n\n%s", err, stderr.String())
+	}
+
+	logger.DebugContext(ctx, "ExtractRef git archive output", "bytes", stdout.Len())
+
+	if err := extractTar(&stdout, dest); err != nil {
+		return fmt.Errorf("failed to extract git archive: %w", err)
+	}
+
+	return nil
+}
+
+// ShowFile returns the contents of a file at a ref, without changing the
+// current checkout. The path is relative to the workingDir.
+func (g *GitClient) ShowFile(ctx context.Context, ref, pth string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	_, err := child.Run(ctx, &child.RunConfig{
+		Stdout:     &stdout,
+		Stderr:     &stderr,
+		WorkingDir: g.workingDir,
+		Command:    "git",
+		Args:       []string{"show", ref + ":./" + filepath.ToSlash(pth)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git show command: %w\n\n%s", err, stderr.String())
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// extractTar writes the directories, files and symlinks of a tar archive to the
+// dest directory.
+func extractTar(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		// git archive adds a global header with the commit sha
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		name := filepath.FromSlash(strings.TrimSuffix(hdr.Name, "/"))
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("archive contains invalid path %q", hdr.Name)
+		}
+		pth := filepath.Join(dest, name)
+
+		// entries must not be written through a symlink created by an earlier
+		// entry, which could resolve to a directory outside of dest.
+		parent := filepath.Dir(name)
+		if hdr.Typeflag == tar.TypeDir {
+			parent = name
+		}
+		if err := checkNoSymlinks(dest, parent); err != nil {
+			return fmt.Errorf("archive contains invalid path %q: %w", hdr.Name, err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(pth, 0o700); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", pth, err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(pth), 0o700); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(pth), err)
+			}
+			if err := writeFile(pth, tr, hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(pth), 0o700); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(pth), err)
+			}
+			if err := os.Symlink(hdr.Linkname, pth); err != nil {
+				return fmt.Errorf("failed to create symlink %s: %w", pth, err)
+			}
+		}
+	}
+}
+
+// checkNoSymlinks returns an error if any existing directory on the relative
+// path from dest is a symlink or resolves outside of dest.
+func checkNoSymlinks(dest, rel string) error {
+	pth := dest
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if part == "." {
+			continue
+		}
+		pth = filepath.Join(pth, part)
+
+		fi, err := os.Lstat(pth)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", pth, err)
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink", pth)
+		}
+	}
+
+	resolved, err := filepath.EvalSymlinks(pth)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", pth, err)
+	}
+	resolvedDest, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", dest, err)
+	}
+	if rel, err := filepath.Rel(resolvedDest, resolved); err != nil || !filepath.IsLocal(rel) {
+		return fmt.Errorf("%s resolves outside of %s", pth, dest)
+	}
+
+	return nil
+}
+
+// writeFile writes the contents of the reader to a new file.
+func writeFile(pth string, r io.Reader, perm fs.FileMode) (outErr error) {
+	f, err := os.OpenFile(pth, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", pth, err)
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil {
+			outErr = errors.Join(outErr, fmt.Errorf("failed to close file %s: %w", pth, closeErr))
+		}
+	}()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", pth, err)
+	}
+	return nil
+}
+
 // parseSortedDiffDirs splits a string at newlines and returns the sorted set of
 // absolute directory paths.
 func parseSortedDiffDirsAbs(ctx context.Context, stdout string) ([]string, error) {
@@ -134,3 +351,38 @@ func parseSortedDiffDirsAbs(ctx context.Context, stdout string) ([]string, error
 
 	return dirs, nil
 }
+
+// parseSortedDeletedDirsAbs splits a string at newlines and returns the sorted
+// set of absolute directory paths that do not exist.
+func parseSortedDeletedDirsAbs(ctx context.Context, stdout string) ([]string, error) {
+	logger := logging.FromContext(ctx)
+
+	matches := make(map[string]struct{})
+
+	for _, line := range newline.Split(stdout, -1) {
+		if len(line) > 0 {
+			dir := filepath.Dir(line)
+
+			if _, err := os.Stat(dir); err == nil {
+				continue
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("failed to check directory %s: %w", dir, err)
+			}
+
+			path, err := filepath.Abs(dir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get absolute path for directory %s: %w", dir, err)
+			}
+
+			matches[path] = struct{}{}
+		}
+	}
+
+	dirs := maps.Keys(matches)
+
+	sort.Strings(dirs)
+
+	logger.DebugContext(ctx, "parseSortedDeletedDirsAbs result", "dirs", dirs)
+
+	return dirs, nil
+}
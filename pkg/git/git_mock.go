@@ -16,13 +16,29 @@ package git
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 )
 
 // MockGitClient implements the git interface.
 type MockGitClient struct {
-	DiffResp []string
-	DiffErr  error
-	CloneErr error
+	DiffResp        []string
+	DiffErr         error
+	DeletedDiffResp []string
+	DeletedDiffErr  error
+	CloneErr        error
+	ResolveRefResp  string
+	ResolveRefErr   error
+
+	// ExtractFiles are the files written by ExtractRef, keyed by their path
+	// relative to the destination directory.
+	ExtractFiles map[string]string
+	ExtractErr   error
+
+	// ShowFiles are the files returned by ShowFile, keyed by their path.
+	ShowFiles map[string]string
+	ShowErr   error
 }
 
 // DiffDirsAbs runs a git diff between two revisions and returns the list of directories with changes.
@@ -30,7 +46,48 @@ func (m *MockGitClient) DiffDirsAbs(ctx context.Context, baseRef, headRef string
 	return m.DiffResp, m.DiffErr
 }
 
+// DiffDeletedDirsAbs runs a git diff between two revisions and returns the list of deleted directories.
+func (m *MockGitClient) DiffDeletedDirsAbs(ctx context.Context, baseRef, headRef string) ([]string, error) {
+	return m.DeletedDiffResp, m.DeletedDiffErr
+}
+
 // CloneRepository clones the repository to the workingDir.
 func (m *MockGitClient) CloneRepository(ctx context.Context, githubToken, owner, repo string) error {
 	return m.CloneErr
 }
+
+// ResolveRef returns the commit SHA of a ref.
+func (m *MockGitClient) ResolveRef(ctx context.Context, ref string) (string, error) {
+	return m.ResolveRefResp, m.ResolveRefErr
+}
+
+// ExtractRef writes the files of the workingDir at a ref to a directory.
+func (m *MockGitClient) ExtractRef(ctx context.Context, ref, dest string) error {
+	if m.ExtractErr != nil {
+		return m.ExtractErr
+	}
+
+	for name, contents := range m.ExtractFiles {
+		pth := filepath.Join(dest, name)
+		if err := os.MkdirAll(filepath.Dir(pth), 0o700); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(pth, []byte(contents), 0o600); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+	}
+	return nil
+}
+
+// ShowFile returns the contents of a file at a ref.
+func (m *MockGitClient) ShowFile(ctx context.Context, ref, pth string) ([]byte, error) {
+	if m.ShowErr != nil {
+		return nil, m.ShowErr
+	}
+
+	contents, ok := m.ShowFiles[pth]
+	if !ok {
+		return nil, fmt.Errorf("file %s does not exist at %s", pth, ref)
+	}
+	return []byte(contents), nil
+}
@@ -15,6 +15,8 @@
 package git
 
 import (
+	"archive/tar"
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
@@ -99,3 +101,160 @@ testdata/third`,
 		})
 	}
 }
+
+func TestParseSortedDeletedDirsAbs(t *testing.T) {
+	t.Parallel()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		value string
+		exp   []string
+		err   string
+	}{
+		{
+			name: "success",
+			value: `testdata/fourth/main.tf
+testdata/fourth/test.txt
+testdata/fifth/main.tf`,
+			exp: []string{
+				filepath.Join(cwd, "testdata/fifth"),
+				filepath.Join(cwd, "testdata/fourth"),
+			},
+		},
+		{
+			name: "ignores_existing_dir",
+			value: `testdata/first/deleted.txt
+testdata/fourth/main.tf`,
+			exp: []string{
+				filepath.Join(cwd, "testdata/fourth"),
+			},
+		},
+		{
+			name:  "handles_empty",
+			value: "",
+			exp:   []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dirs, err := parseSortedDeletedDirsAbs(t.Context(), tc.value)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(dirs, tc.exp); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestExtractTar(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		headers  []*tar.Header
+		expFiles map[string]string
+		err      string
+	}{
+		{
+			name: "success",
+			headers: []*tar.Header{
+				{Typeflag: tar.TypeXGlobalHeader, Name: "pax_global_header"},
+				{Typeflag: tar.TypeDir, Name: "entrypoint/", Mode: 0o755},
+				{Typeflag: tar.TypeReg, Name: "entrypoint/main.tf", Mode: 0o644, Size: 4},
+				{Typeflag: tar.TypeReg, Name: "modules/bucket/main.tf", Mode: 0o644, Size: 4},
+			},
+			expFiles: map[string]string{
+				"entrypoint/main.tf":     "data",
+				"modules/bucket/main.tf": "data",
+			},
+		},
+		{
+			name: "rejects_path_outside_dest",
+			headers: []*tar.Header{
+				{Typeflag: tar.TypeReg, Name: "../main.tf", Mode: 0o644, Size: 4},
+			},
+			expFiles: map[string]string{},
+			err:      `archive contains invalid path "../main.tf"`,
+		},
+		{
+			name: "rejects_file_inside_symlink",
+			headers: []*tar.Header{
+				{Typeflag: tar.TypeSymlink, Name: "link", Linkname: ".."},
+				{Typeflag: tar.TypeReg, Name: "link/main.tf", Mode: 0o644, Size: 4},
+			},
+			expFiles: map[string]string{},
+			err:      `archive contains invalid path "link/main.tf"`,
+		},
+		{
+			name: "rejects_directory_inside_symlink",
+			headers: []*tar.Header{
+				{Typeflag: tar.TypeSymlink, Name: "link", Linkname: ".."},
+				{Typeflag: tar.TypeDir, Name: "link/", Mode: 0o755},
+			},
+			expFiles: map[string]string{},
+			err:      `archive contains invalid path "link/"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			for _, hdr := range tc.headers {
+				if err := tw.WriteHeader(hdr); err != nil {
+					t.Fatal(err)
+				}
+				if hdr.Size > 0 {
+					if _, err := tw.Write([]byte("data")); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if err := tw.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			dest := t.TempDir()
+			err := extractTar(&buf, dest)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			got := make(map[string]string)
+			if err := filepath.WalkDir(dest, func(pth string, d os.DirEntry, err error) error {
+				if err != nil || !d.Type().IsRegular() {
+					return err
+				}
+				b, err := os.ReadFile(pth)
+				if err != nil {
+					return err //nolint:wrapcheck // Want passthrough
+				}
+				rel, err := filepath.Rel(dest, pth)
+				if err != nil {
+					return err //nolint:wrapcheck // Want passthrough
+				}
+				got[filepath.ToSlash(rel)] = string(b)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(got, tc.expFiles); diff != "" {
+				t.Errorf("files not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
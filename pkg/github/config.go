@@ -16,6 +16,7 @@ package github
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/go-github/v53/github"
@@ -53,18 +54,33 @@ type Config struct {
 	GitHubJobName           string
 	GitHubPullRequestNumber int
 	GitHubPullRequestBody   string
+	GitHubPullRequestSHA    string
+	GitHubPullRequestLabels []string
 	GitHubSHA               string
 	GitHubActor             string
 
 	// Policy
 	IncludeTeams bool
+
+	// Reporting
+	ReportMode string
 }
 
+const (
+	// ReportModeComment reports statuses as pull request comments.
+	ReportModeComment = "comment"
+
+	// ReportModeCheck reports statuses as check runs on the head commit.
+	ReportModeCheck = "check"
+)
+
 type configDefaults struct {
 	Owner             string
 	Repo              string
 	PullRequestNumber int
 	PullRequestBody   string
+	PullRequestSHA    string
+	PullRequestLabels []string
 }
 
 func (c *Config) RegisterFlags(set *cli.FlagSet) {
@@ -83,6 +99,8 @@ func (c *Config) RegisterFlags(set *cli.FlagSet) {
 		if err := json.Unmarshal(data, &event); err == nil {
 			d.PullRequestNumber = event.GetNumber()
 			d.PullRequestBody = event.GetPullRequest().GetBody()
+			d.PullRequestSHA = event.GetPullRequest().GetHead().GetSHA()
+			d.PullRequestLabels = labelNames(event.GetPullRequest().Labels)
 		}
 	}
 	if githubContext.EventName == "pull_request_target" {
@@ -90,6 +108,8 @@ func (c *Config) RegisterFlags(set *cli.FlagSet) {
 		if err := json.Unmarshal(data, &event); err == nil {
 			d.PullRequestNumber = event.GetNumber()
 			d.PullRequestBody = event.GetPullRequest().GetBody()
+			d.PullRequestSHA = event.GetPullRequest().GetHead().GetSHA()
+			d.PullRequestLabels = labelNames(event.GetPullRequest().Labels)
 		}
 	}
 
@@ -241,6 +261,24 @@ GITHUB_TOKEN.`,
 		Hidden:  true,
 	})
 
+	f.StringVar(&cli.StringVar{
+		Name:    "github-pull-request-sha",
+		EnvVar:  "GITHUB_PULL_REQUEST_SHA",
+		Target:  &c.GitHubPullRequestSHA,
+		Default: d.PullRequestSHA,
+		Usage:   "The head commit SHA of the GitHub pull request.",
+		Hidden:  true,
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "github-pull-request-labels",
+		EnvVar:  "GITHUB_PULL_REQUEST_LABELS",
+		Target:  &c.GitHubPullRequestLabels,
+		Default: d.PullRequestLabels,
+		Usage:   "The labels of the GitHub pull request.",
+		Hidden:  true,
+	})
+
 	f.StringVar(&cli.StringVar{
 		Name:   "github-commit-sha",
 		EnvVar: "GITHUB_SHA",
@@ -264,4 +302,23 @@ GITHUB_TOKEN.`,
 		Usage:   "If true, includes team data in payload. Requires 'members: read' token permissions.",
 		Hidden:  true,
 	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "github-report-mode",
+		Target:  &c.ReportMode,
+		Default: ReportModeComment,
+		Example: ReportModeCheck,
+		Usage: fmt.Sprintf(`How statuses are reported on pull requests. Allowed values are %q.
+The %q mode creates a check run per entrypoint and operation, with annotations,
+which requires 'checks: write' token permissions.`, []string{ReportModeComment, ReportModeCheck}, ReportModeCheck),
+	})
+}
+
+// labelNames returns the names of the labels.
+func labelNames(labels []*github.Label) []string {
+	names := make([]string, 0, len(labels))
+	for _, l := range labels {
+		names = append(names, l.GetName())
+	}
+	return names
 }
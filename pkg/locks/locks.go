@@ -0,0 +1,346 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package locks provides lease based apply locks for entrypoints, shared by all
+// change requests of a repository and stored using a Guardian storage client.
+package locks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abcxyz/guardian/pkg/storage"
+)
+
+const (
+	// DefaultTTL is the default lease duration of an apply lock.
+	DefaultTTL = 1 * time.Hour
+
+	// AnySerial skips verifying the serial when acquiring a lock.
+	AnySerial int64 = -1
+
+	lockRoot     = "guardian-locks"
+	lockFilename = "apply.lock"
+)
+
+var (
+	// ErrLocked is returned when the lock is held by another change request.
+	ErrLocked = errors.New("entrypoint is locked")
+
+	// ErrStale is returned when the entrypoint was applied after the serial being
+	// acquired was read.
+	ErrStale = errors.New("entrypoint was applied since the plan was created")
+
+	// ErrNotHeld is returned when releasing a lock that is not held by the change
+	// request.
+	ErrNotHeld = errors.New("lock is not held")
+)
+
+// Lock is the apply lock of an entrypoint. The lock is held while Holder is set
+// and the lease has not expired. Released locks are kept so the serial, which
+// is incremented every time the entrypoint is applied, can be used to detect
+// plans that were created before another change request was applied.
+type Lock struct {
+	// Entrypoint is the entrypoint path, relative to the repository root.
+	Entrypoint string `json:"entrypoint"`
+
+	// Holder is the number of the change request holding the lock, zero if the
+	// lock is released.
+	Holder int `json:"holder,omitempty"`
+
+	// CommitSHA is the commit being applied by the holder.
+	CommitSHA string `json:"commit_sha,omitempty"`
+
+	// AcquiredAt is the time the lock was acquired.
+	AcquiredAt time.Time `json:"acquired_at,omitzero"`
+
+	// ExpiresAt is the time the lease expires, after which the lock can be taken
+	// over by another change request.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+
+	// Serial is the number of times the entrypoint was applied.
+	Serial int64 `json:"serial"`
+
+	// LastHolder is the number of the change request that last released the
+	// lock.
+	LastHolder int `json:"last_holder,omitempty"`
+
+	// ReleasedAt is the time the lock was last released.
+	ReleasedAt time.Time `json:"released_at,omitzero"`
+
+	version string
+}
+
+// Held returns true if the lock is held by a change request at the given time.
+func (l *Lock) Held(now time.Time) bool {
+	return l.Holder > 0 && now.Before(l.ExpiresAt)
+}
+
+// Expired returns true if the lease of the lock expired before it was
+// released.
+func (l *Lock) Expired(now time.Time) bool {
+	return l.Holder > 0 && !now.Before(l.ExpiresAt)
+}
+
+// CurrentSerial returns the serial plans should be compared against at the
+// given time. A lock that expired without being released may have been
+// partially applied, so it counts as an apply.
+func (l *Lock) CurrentSerial(now time.Time) int64 {
+	if l.Expired(now) {
+		return l.Serial + 1
+	}
+	return l.Serial
+}
+
+// Client reads and writes the apply locks of a repository.
+type Client struct {
+	storage storage.Storage
+	prefix  string
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewClient creates a new Client storing the apply locks for a repository
+// using the storage client. Acquired locks expire after the ttl, which defaults
+// to DefaultTTL.
+func NewClient(s storage.Storage, repository string, ttl time.Duration) *Client {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &Client{
+		storage: s,
+		prefix:  path.Join(lockRoot, repository),
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// lockPath returns the storage path of the lock for an entrypoint.
+func (c *Client) lockPath(entrypoint string) string {
+	return path.Join(c.prefix, entrypoint, lockFilename)
+}
+
+// Get returns the apply lock of an entrypoint. A released lock is returned if
+// the entrypoint was never locked.
+func (c *Client) Get(ctx context.Context, entrypoint string) (*Lock, error) {
+	l, err := c.get(ctx, c.lockPath(entrypoint))
+	if err != nil {
+		return nil, err
+	}
+	if l.Entrypoint == "" {
+		l.Entrypoint = entrypoint
+	}
+	return l, nil
+}
+
+// get reads the lock stored at the storage path.
+func (c *Client) get(ctx context.Context, name string) (_ *Lock, outErr error) {
+	// the version is read before the contents, so an update in between makes
+	// the next write fail instead of overwriting the newer lock
+	version, err := c.storage.ObjectVersion(ctx, name)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return &Lock{}, nil
+		}
+		return nil, fmt.Errorf("failed to get lock version: %w", err)
+	}
+
+	rc, _, err := c.storage.GetObject(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock: %w", err)
+	}
+	defer func() {
+		if closeErr := rc.Close(); closeErr != nil {
+			outErr = errors.Join(outErr, fmt.Errorf("failed to close lock reader: %w", closeErr))
+		}
+	}()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock: %w", err)
+	}
+
+	var l Lock
+	if err := json.Unmarshal(b, &l); err != nil {
+		return nil, fmt.Errorf("failed to parse lock: %w", err)
+	}
+	l.version = version
+
+	return &l, nil
+}
+
+// write stores the lock at the storage path, if the stored lock still matches
+// the version it was read with.
+func (c *Client) write(ctx context.Context, name string, l *Lock, version string) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lock: %w", err)
+	}
+
+	opts := []storage.CreateOption{
+		storage.WithContentType("application/json"),
+		storage.WithCacheMaxAgeSeconds(0),
+	}
+	// an empty version means the lock did not exist when read, in which case it
+	// is only created if it still does not exist
+	if version != "" {
+		opts = append(opts, storage.WithIfVersionMatch(version))
+	}
+
+	if err := c.storage.CreateObject(ctx, name, b, opts...); err != nil {
+		return fmt.Errorf("failed to write lock: %w", err)
+	}
+	return nil
+}
+
+// Acquire acquires the apply lock of an entrypoint for a change request. The
+// serial is the current serial of the lock when the plan being applied was
+// created, or AnySerial to skip the check. The current lock is returned with
+// ErrLocked if it is held by another change request, or ErrStale if the
+// entrypoint was applied after the serial was read. A change request can
+// re-acquire a lock it already holds, which renews the lease.
+func (c *Client) Acquire(ctx context.Context, entrypoint string, holder int, sha string, serial int64) (*Lock, error) {
+	if holder <= 0 {
+		return nil, fmt.Errorf("change request number is required to acquire the lock")
+	}
+
+	name := c.lockPath(entrypoint)
+
+	current, err := c.get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.now()
+	if current.Held(now) && current.Holder != holder {
+		return current, fmt.Errorf("%w by change request #%d", ErrLocked, current.Holder)
+	}
+
+	currentSerial := current.CurrentSerial(now)
+	if serial != AnySerial && serial != currentSerial {
+		return current, fmt.Errorf("%w: plan serial %d, current serial %d", ErrStale, serial, currentSerial)
+	}
+
+	next := &Lock{
+		Entrypoint: entrypoint,
+		Holder:     holder,
+		CommitSHA:  sha,
+		AcquiredAt: now,
+		ExpiresAt:  now.Add(c.ttl),
+		Serial:     currentSerial,
+		LastHolder: current.LastHolder,
+		ReleasedAt: current.ReleasedAt,
+	}
+
+	if err := c.write(ctx, name, next, current.version); err != nil {
+		if errors.Is(err, storage.ErrPreconditionFailed) {
+			return current, fmt.Errorf("%w by another change request: %w", ErrLocked, err)
+		}
+		return nil, err
+	}
+	return next, nil
+}
+
+// Release releases the apply lock of an entrypoint held by a change request and
+// increments the serial. ErrNotHeld is returned if the lock is no longer held by
+// the change request, e.g. because the lease expired and was taken over.
+func (c *Client) Release(ctx context.Context, entrypoint string, holder int) error {
+	name := c.lockPath(entrypoint)
+
+	current, err := c.get(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if current.Holder != holder {
+		return fmt.Errorf("%w by change request #%d", ErrNotHeld, holder)
+	}
+
+	return c.release(ctx, name, current)
+}
+
+// ForceRelease releases the apply lock of an entrypoint regardless of the
+// change request holding it and returns the lock as it was before being
+// released. Nothing is written if the lock is not held.
+func (c *Client) ForceRelease(ctx context.Context, entrypoint string) (*Lock, error) {
+	name := c.lockPath(entrypoint)
+
+	current, err := c.get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Entrypoint == "" {
+		current.Entrypoint = entrypoint
+	}
+
+	if current.Holder == 0 {
+		return current, nil
+	}
+
+	if err := c.release(ctx, name, current); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// release writes the released lock for the currently held lock.
+func (c *Client) release(ctx context.Context, name string, current *Lock) error {
+	next := &Lock{
+		Entrypoint: current.Entrypoint,
+		Serial:     current.Serial + 1,
+		LastHolder: current.Holder,
+		ReleasedAt: c.now(),
+	}
+	return c.write(ctx, name, next, current.version)
+}
+
+// List returns the apply locks of all entrypoints in the repository, sorted by
+// entrypoint.
+func (c *Client) List(ctx context.Context) ([]*Lock, error) {
+	uris, err := c.storage.ObjectsWithName(ctx, lockFilename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locks: %w", err)
+	}
+
+	locks := make([]*Lock, 0, len(uris))
+	for _, uri := range uris {
+		// storage clients return the full path of objects, the lock path is the
+		// part relative to the storage parent
+		i := strings.Index(uri, c.prefix+"/")
+		if i < 0 {
+			continue
+		}
+
+		l, err := c.get(ctx, uri[i:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get lock %s: %w", uri, err)
+		}
+		locks = append(locks, l)
+	}
+
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].Entrypoint < locks[j].Entrypoint
+	})
+
+	return locks, nil
+}
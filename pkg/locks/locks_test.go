@@ -0,0 +1,392 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/abcxyz/guardian/pkg/storage"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+var testNow = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// testClient returns a lock client for the owner/repo repository using a
+// filesystem storage client in a temporary directory.
+func testClient(tb testing.TB) (*Client, storage.Storage) {
+	tb.Helper()
+
+	s, err := storage.NewFilesystemStorage(tb.Context(), tb.TempDir())
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	c := NewClient(s, "owner/repo", time.Hour)
+	c.now = func() time.Time { return testNow }
+	return c, s
+}
+
+// writeLock writes a lock directly to the storage client.
+func writeLock(tb testing.TB, s storage.Storage, name string, l *Lock) {
+	tb.Helper()
+
+	b, err := json.Marshal(l)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := s.CreateObject(tb.Context(), name, b, storage.WithAllowOverwrite(true)); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func TestClient_Acquire(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		existing *Lock
+		holder   int
+		serial   int64
+		exp      *Lock
+		err      error
+	}{
+		{
+			name:   "never_locked",
+			holder: 5,
+			serial: AnySerial,
+			exp: &Lock{
+				Entrypoint: "dir",
+				Holder:     5,
+				CommitSHA:  "sha",
+				AcquiredAt: testNow,
+				ExpiresAt:  testNow.Add(time.Hour),
+			},
+		},
+		{
+			name: "released_serial_matches",
+			existing: &Lock{
+				Entrypoint: "dir",
+				Serial:     2,
+				LastHolder: 4,
+				ReleasedAt: testNow.Add(-time.Hour),
+			},
+			holder: 5,
+			serial: 2,
+			exp: &Lock{
+				Entrypoint: "dir",
+				Holder:     5,
+				CommitSHA:  "sha",
+				AcquiredAt: testNow,
+				ExpiresAt:  testNow.Add(time.Hour),
+				Serial:     2,
+				LastHolder: 4,
+				ReleasedAt: testNow.Add(-time.Hour),
+			},
+		},
+		{
+			name: "released_serial_stale",
+			existing: &Lock{
+				Entrypoint: "dir",
+				Serial:     3,
+				LastHolder: 4,
+			},
+			holder: 5,
+			serial: 2,
+			err:    ErrStale,
+		},
+		{
+			name: "held_by_other",
+			existing: &Lock{
+				Entrypoint: "dir",
+				Holder:     4,
+				ExpiresAt:  testNow.Add(time.Minute),
+				Serial:     2,
+			},
+			holder: 5,
+			serial: 2,
+			err:    ErrLocked,
+		},
+		{
+			name: "held_by_self",
+			existing: &Lock{
+				Entrypoint: "dir",
+				Holder:     5,
+				AcquiredAt: testNow.Add(-time.Minute),
+				ExpiresAt:  testNow.Add(time.Minute),
+				Serial:     2,
+			},
+			holder: 5,
+			serial: 2,
+			exp: &Lock{
+				Entrypoint: "dir",
+				Holder:     5,
+				CommitSHA:  "sha",
+				AcquiredAt: testNow,
+				ExpiresAt:  testNow.Add(time.Hour),
+				Serial:     2,
+			},
+		},
+		{
+			name: "expired_counts_as_apply",
+			existing: &Lock{
+				Entrypoint: "dir",
+				Holder:     4,
+				ExpiresAt:  testNow.Add(-time.Minute),
+				Serial:     2,
+			},
+			holder: 5,
+			serial: 3,
+			exp: &Lock{
+				Entrypoint: "dir",
+				Holder:     5,
+				CommitSHA:  "sha",
+				AcquiredAt: testNow,
+				ExpiresAt:  testNow.Add(time.Hour),
+				Serial:     3,
+			},
+		},
+		{
+			name: "expired_serial_stale",
+			existing: &Lock{
+				Entrypoint: "dir",
+				Holder:     4,
+				ExpiresAt:  testNow.Add(-time.Minute),
+				Serial:     2,
+			},
+			holder: 5,
+			serial: 2,
+			err:    ErrStale,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+			c, s := testClient(t)
+
+			if tc.existing != nil {
+				writeLock(t, s, "guardian-locks/owner/repo/dir/apply.lock", tc.existing)
+			}
+
+			got, err := c.Acquire(ctx, "dir", tc.holder, "sha", tc.serial)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v to be %v", err, tc.err)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(got, tc.exp, cmpopts.IgnoreUnexported(Lock{})); diff != "" {
+				t.Errorf("lock not as expected; (-got,+want): %s", diff)
+			}
+
+			stored, err := c.Get(ctx, "dir")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(stored, tc.exp, cmpopts.IgnoreUnexported(Lock{})); diff != "" {
+				t.Errorf("stored lock not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+// racingStorage updates the lock after its version is read, simulating another
+// change request acquiring the lock concurrently.
+type racingStorage struct {
+	storage.Storage
+	race func(ctx context.Context)
+}
+
+func (s *racingStorage) ObjectVersion(ctx context.Context, name string) (string, error) {
+	version, err := s.Storage.ObjectVersion(ctx, name)
+	s.race(ctx)
+	return version, err //nolint:wrapcheck // Want passthrough
+}
+
+func TestClient_Acquire_concurrent(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	c, s := testClient(t)
+	writeLock(t, s, "guardian-locks/owner/repo/dir/apply.lock", &Lock{Entrypoint: "dir", Serial: 2})
+
+	c.storage = &racingStorage{
+		Storage: s,
+		race: func(ctx context.Context) {
+			writeLock(t, s, "guardian-locks/owner/repo/dir/apply.lock", &Lock{
+				Entrypoint: "dir",
+				Holder:     4,
+				ExpiresAt:  testNow.Add(time.Hour),
+				Serial:     2,
+			})
+		},
+	}
+
+	if _, err := c.Acquire(ctx, "dir", 5, "sha", 2); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected error %v to be %v", err, ErrLocked)
+	}
+}
+
+func TestClient_Release(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		existing *Lock
+		holder   int
+		exp      *Lock
+		err      string
+	}{
+		{
+			name: "held_by_self",
+			existing: &Lock{
+				Entrypoint: "dir",
+				Holder:     5,
+				ExpiresAt:  testNow.Add(time.Minute),
+				Serial:     2,
+			},
+			holder: 5,
+			exp: &Lock{
+				Entrypoint: "dir",
+				Serial:     3,
+				LastHolder: 5,
+				ReleasedAt: testNow,
+			},
+		},
+		{
+			name: "held_by_other",
+			existing: &Lock{
+				Entrypoint: "dir",
+				Holder:     4,
+				ExpiresAt:  testNow.Add(time.Minute),
+				Serial:     2,
+			},
+			holder: 5,
+			exp: &Lock{
+				Entrypoint: "dir",
+				Holder:     4,
+				ExpiresAt:  testNow.Add(time.Minute),
+				Serial:     2,
+			},
+			err: "lock is not held by change request #5",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+			c, s := testClient(t)
+			writeLock(t, s, "guardian-locks/owner/repo/dir/apply.lock", tc.existing)
+
+			err := c.Release(ctx, "dir", tc.holder)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			got, err := c.Get(ctx, "dir")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tc.exp, cmpopts.IgnoreUnexported(Lock{})); diff != "" {
+				t.Errorf("lock not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestClient_ForceRelease(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	c, s := testClient(t)
+
+	held := &Lock{
+		Entrypoint: "dir",
+		Holder:     4,
+		ExpiresAt:  testNow.Add(time.Minute),
+		Serial:     2,
+	}
+	writeLock(t, s, "guardian-locks/owner/repo/dir/apply.lock", held)
+
+	got, err := c.ForceRelease(ctx, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got, held, cmpopts.IgnoreUnexported(Lock{})); diff != "" {
+		t.Errorf("released lock not as expected; (-got,+want): %s", diff)
+	}
+
+	stored, err := c.Get(ctx, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := &Lock{
+		Entrypoint: "dir",
+		Serial:     3,
+		LastHolder: 4,
+		ReleasedAt: testNow,
+	}
+	if diff := cmp.Diff(stored, exp, cmpopts.IgnoreUnexported(Lock{})); diff != "" {
+		t.Errorf("stored lock not as expected; (-got,+want): %s", diff)
+	}
+
+	// releasing a lock that is not held does not change it
+	if _, err := c.ForceRelease(ctx, "dir"); err != nil {
+		t.Fatal(err)
+	}
+	stored, err = c.Get(ctx, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(stored, exp, cmpopts.IgnoreUnexported(Lock{})); diff != "" {
+		t.Errorf("stored lock not as expected; (-got,+want): %s", diff)
+	}
+}
+
+func TestClient_List(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	c, s := testClient(t)
+
+	writeLock(t, s, "guardian-locks/owner/repo/b/apply.lock", &Lock{Entrypoint: "b", Serial: 1})
+	writeLock(t, s, "guardian-locks/owner/repo/a/c/apply.lock", &Lock{Entrypoint: "a/c", Holder: 4})
+	writeLock(t, s, "guardian-locks/owner/other/a/apply.lock", &Lock{Entrypoint: "a"})
+
+	got, err := c.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []*Lock{
+		{Entrypoint: "a/c", Holder: 4},
+		{Entrypoint: "b", Serial: 1},
+	}
+	if diff := cmp.Diff(got, exp, cmpopts.IgnoreUnexported(Lock{})); diff != "" {
+		t.Errorf("locks not as expected; (-got,+want): %s", diff)
+	}
+}
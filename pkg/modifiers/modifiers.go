@@ -17,11 +17,42 @@ package modifiers
 
 import (
 	"context"
+	"fmt"
+	"path"
 	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/abcxyz/pkg/logging"
 )
 
 const (
 	MetaKeyGuardianDir = "GUARDIAN_DIR"
+
+	// MetaKeyGuardianTarget limits the plan of an entrypoint to a resource
+	// address, in the format <entrypoint>:<address>.
+	MetaKeyGuardianTarget = "GUARDIAN_TARGET"
+
+	// MetaKeyGuardianReplace forces the replacement of a resource address in the
+	// plan of an entrypoint, in the format <entrypoint>:<address>.
+	MetaKeyGuardianReplace = "GUARDIAN_REPLACE"
+
+	// MetaKeyGuardianRefreshOnly creates a refresh only plan for an entrypoint.
+	MetaKeyGuardianRefreshOnly = "GUARDIAN_REFRESH_ONLY"
+
+	// MetaKeyGuardianExempt requests an exemption from a policy for the change
+	// request, in the format <policy>:<reason>.
+	MetaKeyGuardianExempt = "GUARDIAN_EXEMPT"
+
+	// LabelPrefixGuardianExempt is the prefix of change request labels that
+	// request an exemption from a policy, e.g. guardian-exempt:<policy>.
+	LabelPrefixGuardianExempt = "guardian-exempt:"
+
+	// ExemptionSourceModifier, ExemptionSourceLabel and ExemptionSourceComment
+	// are the sources an exemption can be requested with.
+	ExemptionSourceModifier = "modifier"
+	ExemptionSourceLabel    = "label"
+	ExemptionSourceComment  = "comment"
 )
 
 var (
@@ -49,3 +80,201 @@ func ParseBodyMetaValues(ctx context.Context, contents string) MetaValues {
 	}
 	return metaValues
 }
+
+// PlanModifiers are the Terraform plan options requested for an entrypoint.
+type PlanModifiers struct {
+	// Targets are the resource addresses the plan is limited to.
+	Targets []string `json:"targets,omitempty"`
+
+	// Replaces are the resource addresses forced to be replaced.
+	Replaces []string `json:"replaces,omitempty"`
+
+	// RefreshOnly is true if the plan only refreshes the state.
+	RefreshOnly bool `json:"refresh_only,omitempty"`
+}
+
+// IsEmpty returns true if no plan options are requested.
+func (p *PlanModifiers) IsEmpty() bool {
+	return p == nil || (len(p.Targets) == 0 && len(p.Replaces) == 0 && !p.RefreshOnly)
+}
+
+// PlanModifiers returns the plan modifiers keyed by the entrypoint they apply
+// to. An entrypoint is a directory, which applies to all of its environments,
+// or a directory qualified with an environment name.
+func (m MetaValues) PlanModifiers() (map[string]*PlanModifiers, error) {
+	result := make(map[string]*PlanModifiers)
+	get := func(entrypoint string) *PlanModifiers {
+		if _, ok := result[entrypoint]; !ok {
+			result[entrypoint] = &PlanModifiers{}
+		}
+		return result[entrypoint]
+	}
+
+	for _, v := range m[MetaKeyGuardianTarget] {
+		entrypoint, address, err := splitAddress(MetaKeyGuardianTarget, v)
+		if err != nil {
+			return nil, err
+		}
+		p := get(entrypoint)
+		p.Targets = append(p.Targets, address)
+	}
+
+	for _, v := range m[MetaKeyGuardianReplace] {
+		entrypoint, address, err := splitAddress(MetaKeyGuardianReplace, v)
+		if err != nil {
+			return nil, err
+		}
+		p := get(entrypoint)
+		p.Replaces = append(p.Replaces, address)
+	}
+
+	for _, v := range m[MetaKeyGuardianRefreshOnly] {
+		entrypoint := normalizeEntrypoint(v)
+		if entrypoint == "" {
+			return nil, fmt.Errorf("%s requires an entrypoint", MetaKeyGuardianRefreshOnly)
+		}
+		get(entrypoint).RefreshOnly = true
+	}
+
+	return result, nil
+}
+
+// PlanModifiersFor returns the plan modifiers that apply to any of the
+// entrypoints, e.g. an entrypoint directory and the directory qualified with
+// an environment name. Modifiers for other entrypoints are skipped, so an
+// invalid modifier only fails the plan of the entrypoint it is for.
+func (m MetaValues) PlanModifiersFor(ctx context.Context, entrypoints ...string) (*PlanModifiers, error) {
+	logger := logging.FromContext(ctx)
+
+	wanted := make(map[string]struct{}, len(entrypoints))
+	for _, e := range entrypoints {
+		wanted[normalizeEntrypoint(e)] = struct{}{}
+	}
+
+	filtered := make(MetaValues)
+	for _, key := range []string{MetaKeyGuardianTarget, MetaKeyGuardianReplace, MetaKeyGuardianRefreshOnly} {
+		for _, v := range m[key] {
+			// a modifier that does not name an entrypoint could be meant for any,
+			// so it fails every plan.
+			entrypoint := modifierEntrypoint(key, v)
+			if _, ok := wanted[entrypoint]; ok || entrypoint == "" {
+				filtered[key] = append(filtered[key], v)
+				continue
+			}
+
+			if _, err := (MetaValues{key: {v}}).PlanModifiers(); err != nil {
+				logger.WarnContext(ctx, "skipping invalid plan modifier for another entrypoint",
+					"key", key,
+					"value", v,
+					"error", err)
+			}
+		}
+	}
+
+	all, err := filtered.PlanModifiers()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := &PlanModifiers{}
+	for _, k := range keys {
+		p := all[k]
+		result.Targets = append(result.Targets, p.Targets...)
+		result.Replaces = append(result.Replaces, p.Replaces...)
+		result.RefreshOnly = result.RefreshOnly || p.RefreshOnly
+	}
+	return result, nil
+}
+
+// modifierEntrypoint returns the normalized entrypoint a plan modifier value is
+// for, or an empty string if the value does not name one.
+func modifierEntrypoint(key, value string) string {
+	if key == MetaKeyGuardianRefreshOnly {
+		return normalizeEntrypoint(value)
+	}
+
+	entrypoint, _, ok := strings.Cut(value, ":")
+	if !ok {
+		return ""
+	}
+	return normalizeEntrypoint(entrypoint)
+}
+
+// splitAddress splits a modifier value in the format <entrypoint>:<address>.
+// Resource addresses can contain colons in index keys, so the value is split
+// at the first colon.
+func splitAddress(key, value string) (string, string, error) {
+	entrypoint, address, ok := strings.Cut(value, ":")
+	entrypoint = normalizeEntrypoint(entrypoint)
+	address = strings.TrimSpace(address)
+	if !ok || entrypoint == "" || address == "" {
+		return "", "", fmt.Errorf("invalid %s value %q, must be in the format <entrypoint>:<address>", key, value)
+	}
+	return entrypoint, address, nil
+}
+
+// normalizeEntrypoint cleans an entrypoint path, so paths written with a
+// leading ./ or a trailing slash match.
+func normalizeEntrypoint(entrypoint string) string {
+	entrypoint = strings.TrimSpace(entrypoint)
+	if entrypoint == "" {
+		return ""
+	}
+	return strings.Trim(path.Clean(entrypoint), "/")
+}
+
+// Exemption is a request to exempt the change request from a policy.
+type Exemption struct {
+	// Reason is the reason the exemption is requested.
+	Reason string `json:"reason"`
+
+	// Source is how the exemption was requested, ExemptionSourceModifier,
+	// ExemptionSourceLabel or ExemptionSourceComment.
+	Source string `json:"source"`
+}
+
+// Exemptions returns the policy exemptions requested with GUARDIAN_EXEMPT
+// modifiers or guardian-exempt labels, keyed by policy name. A modifier takes
+// precedence over a label for the same policy, as it records a reason.
+func (m MetaValues) Exemptions(labels []string) (map[string]*Exemption, error) {
+	result := make(map[string]*Exemption)
+
+	for _, v := range m[MetaKeyGuardianExempt] {
+		policy, reason, ok := strings.Cut(v, ":")
+		policy = strings.TrimSpace(policy)
+		reason = strings.TrimSpace(reason)
+		if !ok || policy == "" || reason == "" || strings.ContainsAny(policy, " \t") {
+			return nil, fmt.Errorf("invalid %s value %q, must be in the format <policy>:<reason>", MetaKeyGuardianExempt, v)
+		}
+		if _, ok := result[policy]; ok {
+			return nil, fmt.Errorf("%s is requested more than once for policy %q", MetaKeyGuardianExempt, policy)
+		}
+		result[policy] = &Exemption{
+			Reason: reason,
+			Source: ExemptionSourceModifier,
+		}
+	}
+
+	for _, label := range labels {
+		policy, ok := strings.CutPrefix(strings.TrimSpace(label), LabelPrefixGuardianExempt)
+		policy = strings.TrimSpace(policy)
+		if !ok || policy == "" {
+			continue
+		}
+		if _, ok := result[policy]; ok {
+			continue
+		}
+		result[policy] = &Exemption{
+			Reason: fmt.Sprintf("requested with the %q label", label),
+			Source: ExemptionSourceLabel,
+		}
+	}
+
+	return result, nil
+}
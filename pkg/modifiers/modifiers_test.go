@@ -18,6 +18,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
 )
 
 func TestParseMetaValues(t *testing.T) {
@@ -81,3 +84,173 @@ no kv pairs
 		})
 	}
 }
+
+func TestMetaValues_PlanModifiers(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		contents string
+		exp      map[string]*PlanModifiers
+		err      string
+	}{
+		{
+			name: "success",
+			contents: `Fix the bucket
+GUARDIAN_TARGET=terraform/a:google_storage_bucket.b["a:b"]
+GUARDIAN_TARGET=./terraform/a/:module.network
+GUARDIAN_REPLACE=terraform/b@dev:google_compute_instance.vm
+GUARDIAN_REFRESH_ONLY=terraform/c`,
+			exp: map[string]*PlanModifiers{
+				"terraform/a": {
+					Targets: []string{`google_storage_bucket.b["a:b"]`, "module.network"},
+				},
+				"terraform/b@dev": {
+					Replaces: []string{"google_compute_instance.vm"},
+				},
+				"terraform/c": {
+					RefreshOnly: true,
+				},
+			},
+		},
+		{
+			name:     "no_modifiers",
+			contents: "GUARDIAN_DIR=terraform/a",
+			exp:      map[string]*PlanModifiers{},
+		},
+		{
+			name:     "missing_address",
+			contents: "GUARDIAN_TARGET=terraform/a",
+			err:      `invalid GUARDIAN_TARGET value "terraform/a", must be in the format <entrypoint>:<address>`,
+		},
+		{
+			name:     "missing_entrypoint",
+			contents: "GUARDIAN_REPLACE=:google_compute_instance.vm",
+			err:      "invalid GUARDIAN_REPLACE value",
+		},
+		{
+			name:     "empty_refresh_only",
+			contents: "GUARDIAN_REFRESH_ONLY=",
+			err:      "GUARDIAN_REFRESH_ONLY requires an entrypoint",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseBodyMetaValues(t.Context(), tc.contents).PlanModifiers()
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("modifiers not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestMetaValues_PlanModifiersFor(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(t.Context(), logging.TestLogger(t))
+
+	m := ParseBodyMetaValues(ctx, `GUARDIAN_TARGET=terraform/a:module.all
+GUARDIAN_TARGET=terraform/a@dev:module.dev
+GUARDIAN_TARGET=terraform/a@prod:module.prod
+GUARDIAN_REFRESH_ONLY=terraform/a@dev
+GUARDIAN_REPLACE=terraform/b:google_compute_instance.vm
+GUARDIAN_REPLACE=terraform/b:`)
+
+	got, err := m.PlanModifiersFor(ctx, "terraform/a", "terraform/a@dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := &PlanModifiers{
+		Targets:     []string{"module.all", "module.dev"},
+		RefreshOnly: true,
+	}
+	if diff := cmp.Diff(got, exp); diff != "" {
+		t.Errorf("modifiers not as expected; (-got,+want): %s", diff)
+	}
+
+	if got, err := m.PlanModifiersFor(ctx, "terraform/c"); err != nil || !got.IsEmpty() {
+		t.Errorf("expected no modifiers for terraform/c, got %#v, %v", got, err)
+	}
+
+	_, err = m.PlanModifiersFor(ctx, "terraform/b")
+	if diff := testutil.DiffErrString(err, `invalid GUARDIAN_REPLACE value "terraform/b:"`); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestMetaValues_Exemptions(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		contents string
+		labels   []string
+		exp      map[string]*Exemption
+		err      string
+	}{
+		{
+			name: "no_exemptions",
+			contents: `GUARDIAN_DIR=terraform/app
+GUARDIAN_TARGET=terraform/app:module.network`,
+			labels: []string{"bug"},
+			exp:    map[string]*Exemption{},
+		},
+		{
+			name: "modifiers",
+			contents: `GUARDIAN_EXEMPT=iam:incident INC-123, owner access needed
+GUARDIAN_EXEMPT= buckets : public bucket for the status page `,
+			exp: map[string]*Exemption{
+				"iam":     {Reason: "incident INC-123, owner access needed", Source: ExemptionSourceModifier},
+				"buckets": {Reason: "public bucket for the status page", Source: ExemptionSourceModifier},
+			},
+		},
+		{
+			name:     "labels",
+			contents: "GUARDIAN_EXEMPT=iam:incident INC-123",
+			labels:   []string{"guardian-exempt:iam", "guardian-exempt:buckets", "guardian-exempt:", "bug"},
+			exp: map[string]*Exemption{
+				"iam":     {Reason: "incident INC-123", Source: ExemptionSourceModifier},
+				"buckets": {Reason: `requested with the "guardian-exempt:buckets" label`, Source: ExemptionSourceLabel},
+			},
+		},
+		{
+			name:     "missing_reason",
+			contents: "GUARDIAN_EXEMPT=iam",
+			err:      `invalid GUARDIAN_EXEMPT value "iam", must be in the format <policy>:<reason>`,
+		},
+		{
+			name:     "invalid_policy",
+			contents: "GUARDIAN_EXEMPT=the iam policy:incident",
+			err:      `invalid GUARDIAN_EXEMPT value "the iam policy:incident"`,
+		},
+		{
+			name: "duplicate_policy",
+			contents: `GUARDIAN_EXEMPT=iam:incident
+GUARDIAN_EXEMPT=iam:another incident`,
+			err: `GUARDIAN_EXEMPT is requested more than once for policy "iam"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseBodyMetaValues(t.Context(), tc.contents).Exemptions(tc.labels)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("exemptions not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
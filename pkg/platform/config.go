@@ -17,6 +17,7 @@ package platform
 import (
 	"errors"
 	"fmt"
+	"path"
 	"strconv"
 	"strings"
 
@@ -32,6 +33,7 @@ type Config struct {
 
 	GitHub gh.Config
 	GitLab gitLabConfig
+	Gitea  giteaConfig
 	Local  localConfig
 }
 
@@ -55,6 +57,7 @@ func (c *Config) RegisterFlags(set *cli.FlagSet) {
 	// leave last to put help under platform options
 	c.GitHub.RegisterFlags(set)
 	c.GitLab.RegisterFlags(set)
+	c.Gitea.RegisterFlags(set)
 
 	set.AfterParse(func(merr error) error {
 		c.Type = strings.ToLower(strings.TrimSpace(c.Type))
@@ -71,8 +74,137 @@ func (c *Config) RegisterFlags(set *cli.FlagSet) {
 			if v, _ := strconv.ParseBool(set.GetEnv("GITLAB_CI")); v {
 				c.Type = TypeGitLab
 			}
+			// Gitea Actions also sets GITHUB_ACTIONS for compatibility.
+			if v, _ := strconv.ParseBool(set.GetEnv("GITEA_ACTIONS")); v {
+				c.Type = TypeGitea
+			}
 		}
 
 		return merr
 	})
 }
+
+// CommitSHA returns the commit SHA the current run was triggered for, if the
+// platform provides one.
+func (c *Config) CommitSHA() string {
+	if strings.EqualFold(c.Type, TypeGitHub) {
+		return c.GitHub.GitHubSHA
+	}
+	if strings.EqualFold(c.Type, TypeGitLab) {
+		return c.GitLab.GitLabCommitSHA
+	}
+	if strings.EqualFold(c.Type, TypeGitea) {
+		return c.Gitea.GiteaSHA
+	}
+	return ""
+}
+
+// ChangeRequestSHA returns the head commit SHA of the change request the
+// current run was triggered for, if the platform provides one.
+func (c *Config) ChangeRequestSHA() string {
+	if strings.EqualFold(c.Type, TypeGitHub) {
+		return c.GitHub.GitHubPullRequestSHA
+	}
+	if strings.EqualFold(c.Type, TypeGitLab) && c.GitLab.GitLabMergeRequestIID > 0 {
+		if c.GitLab.GitLabMergeRequestSHA != "" {
+			return c.GitLab.GitLabMergeRequestSHA
+		}
+		return c.GitLab.GitLabCommitSHA
+	}
+	if strings.EqualFold(c.Type, TypeGitea) {
+		return c.Gitea.GiteaPullRequestSHA
+	}
+	return ""
+}
+
+// ChangeRequestBody returns the body of the change request the current run was
+// triggered for, if the platform provides one.
+func (c *Config) ChangeRequestBody() string {
+	if strings.EqualFold(c.Type, TypeGitHub) {
+		return c.GitHub.GitHubPullRequestBody
+	}
+	if strings.EqualFold(c.Type, TypeGitLab) {
+		return c.GitLab.GitLabMergeRequestDescription
+	}
+	if strings.EqualFold(c.Type, TypeGitea) {
+		return c.Gitea.GiteaPullRequestBody
+	}
+	return ""
+}
+
+// ChangeRequestLabels returns the labels of the change request the current run
+// was triggered for, if the platform provides them.
+func (c *Config) ChangeRequestLabels() []string {
+	if strings.EqualFold(c.Type, TypeGitHub) {
+		return c.GitHub.GitHubPullRequestLabels
+	}
+	if strings.EqualFold(c.Type, TypeGitLab) {
+		return c.GitLab.GitLabMergeRequestLabels
+	}
+	if strings.EqualFold(c.Type, TypeGitea) {
+		return c.Gitea.GiteaPullRequestLabels
+	}
+	return nil
+}
+
+// Actor returns the username of the user that triggered the current run, if
+// the platform provides one.
+func (c *Config) Actor() string {
+	if strings.EqualFold(c.Type, TypeGitHub) {
+		return c.GitHub.GitHubActor
+	}
+	if strings.EqualFold(c.Type, TypeGitLab) {
+		return c.GitLab.GitLabUserLogin
+	}
+	if strings.EqualFold(c.Type, TypeGitea) {
+		return c.Gitea.GiteaActor
+	}
+	return ""
+}
+
+// ChangeRequestNumber returns the number of the change request the current run
+// was triggered for, if the platform provides one.
+func (c *Config) ChangeRequestNumber() int {
+	if strings.EqualFold(c.Type, TypeGitHub) {
+		return c.GitHub.GitHubPullRequestNumber
+	}
+	if strings.EqualFold(c.Type, TypeGitLab) {
+		return c.GitLab.GitLabMergeRequestIID
+	}
+	if strings.EqualFold(c.Type, TypeGitea) {
+		return c.Gitea.GiteaPullRequestNumber
+	}
+	return 0
+}
+
+// RunID returns the ID of the workflow run or pipeline the current run is part
+// of, if the platform provides one.
+func (c *Config) RunID() int64 {
+	if strings.EqualFold(c.Type, TypeGitHub) {
+		return c.GitHub.GitHubRunID
+	}
+	if strings.EqualFold(c.Type, TypeGitLab) {
+		return int64(c.GitLab.GitLabPipelineID)
+	}
+	if strings.EqualFold(c.Type, TypeGitea) {
+		return c.Gitea.GiteaRunID
+	}
+	return 0
+}
+
+// Repository returns the path identifying the repository the current run is
+// for, matching the repository segment of the storage prefix. An error is
+// returned if the platform does not provide one, so callers never operate on
+// the data of every repository.
+func (c *Config) Repository() (string, error) {
+	if strings.EqualFold(c.Type, TypeGitHub) && c.GitHub.GitHubOwner != "" && c.GitHub.GitHubRepo != "" {
+		return path.Join(c.GitHub.GitHubOwner, c.GitHub.GitHubRepo), nil
+	}
+	if strings.EqualFold(c.Type, TypeGitLab) && c.GitLab.GitLabProjectID > 0 {
+		return strconv.Itoa(c.GitLab.GitLabProjectID), nil
+	}
+	if strings.EqualFold(c.Type, TypeGitea) && c.Gitea.GiteaOwner != "" && c.Gitea.GiteaRepo != "" {
+		return path.Join(c.Gitea.GiteaOwner, c.Gitea.GiteaRepo), nil
+	}
+	return "", fmt.Errorf("repository is required for platform %q", c.Type)
+}
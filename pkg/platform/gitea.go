@@ -0,0 +1,1355 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/sethvargo/go-githubactions"
+	"github.com/sethvargo/go-retry"
+
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+)
+
+var (
+	_ Platform = (*Gitea)(nil)
+
+	// giteaIgnoredStatusCodes are status codes that should not be retried.
+	giteaIgnoredStatusCodes = map[int]struct{}{
+		403: {},
+		404: {},
+		405: {},
+		422: {},
+	}
+)
+
+// Gitea does not limit the size of comments, this matches the GitHub limit to
+// keep comments readable.
+const giteaMaxCommentLength = 65536
+
+// Gitea implements the Platform interface.
+type Gitea struct {
+	cfg        *giteaConfig
+	client     *gitea.Client
+	httpClient *http.Client
+
+	logURL string
+}
+
+type giteaConfig struct {
+	// Retry
+	MaxRetries        uint64
+	InitialRetryDelay time.Duration
+	MaxRetryDelay     time.Duration
+
+	GuardianGiteaToken string
+	GiteaServerURL     string
+
+	GiteaOwner             string
+	GiteaRepo              string
+	GiteaPullRequestNumber int
+	GiteaPullRequestSHA    string
+	GiteaPullRequestBody   string
+	GiteaPullRequestLabels []string
+	GiteaEventName         string
+	GiteaSHA               string
+	GiteaRunID             int64
+	GiteaRunNumber         int64
+	GiteaActor             string
+
+	// Policy
+	IncludeTeams bool
+}
+
+type giteaPredefinedConfig struct {
+	Token             string
+	ServerURL         string
+	Owner             string
+	Repo              string
+	PullRequestNumber int
+	PullRequestSHA    string
+	PullRequestBody   string
+	PullRequestLabels []string
+	EventName         string
+	SHA               string
+	RunID             int64
+	RunNumber         int64
+	Actor             string
+}
+
+// Load retrieves the predefined Gitea Actions variables from environment.
+// Gitea Actions is compatible with GitHub Actions and provides the same
+// variables and event payloads. See
+// https://docs.gitea.com/usage/actions/comparison.
+func (c *giteaPredefinedConfig) Load() {
+	if v := os.Getenv("GITEA_TOKEN"); v != "" {
+		c.Token = v
+	}
+
+	githubContext, err := githubactions.New().Context()
+	if err != nil {
+		return
+	}
+
+	c.ServerURL = githubContext.ServerURL
+	c.Owner, c.Repo = githubContext.Repo()
+	c.EventName = githubContext.EventName
+	c.SHA = githubContext.SHA
+	c.RunID = githubContext.RunID
+	c.RunNumber = githubContext.RunNumber
+	c.Actor = githubContext.Actor
+
+	if c.EventName == "pull_request" || c.EventName == "pull_request_target" {
+		// ignore err because we have no way of returning an error via the flags.Register function.
+		// this is ok beause this is just for defaulting values from the environment.
+		data, _ := json.Marshal(githubContext.Event) //nolint:errchkjson // Shouldnt affect defaults
+
+		var event struct {
+			Number      int `json:"number"`
+			PullRequest struct {
+				Body string `json:"body"`
+				Head struct {
+					SHA string `json:"sha"`
+				} `json:"head"`
+				Labels []struct {
+					Name string `json:"name"`
+				} `json:"labels"`
+			} `json:"pull_request"`
+		}
+		if err := json.Unmarshal(data, &event); err == nil {
+			c.PullRequestNumber = event.Number
+			c.PullRequestSHA = event.PullRequest.Head.SHA
+			c.PullRequestBody = event.PullRequest.Body
+			for _, l := range event.PullRequest.Labels {
+				c.PullRequestLabels = append(c.PullRequestLabels, l.Name)
+			}
+		}
+	}
+}
+
+func (c *giteaConfig) RegisterFlags(set *cli.FlagSet) {
+	f := set.NewSection("GITEA OPTIONS")
+
+	cfgDefaults := &giteaPredefinedConfig{}
+	cfgDefaults.Load()
+
+	f.StringVar(&cli.StringVar{
+		Name:    "guardian-gitea-token",
+		EnvVar:  "GUARDIAN_GITEA_TOKEN",
+		Target:  &c.GuardianGiteaToken,
+		Default: cfgDefaults.Token,
+		Usage:   "The Gitea access token to make Gitea API calls. If not supplied this will default to GITEA_TOKEN.",
+		Hidden:  true,
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "gitea-server-url",
+		EnvVar:  "GITEA_SERVER_URL",
+		Target:  &c.GiteaServerURL,
+		Example: "https://gitea.mydomain.com",
+		Default: cfgDefaults.ServerURL,
+		Usage:   "The URL of the Gitea instance.",
+		Hidden:  true,
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "gitea-owner",
+		Target:  &c.GiteaOwner,
+		Default: cfgDefaults.Owner,
+		Example: "organization-name",
+		Usage:   "The Gitea repository owner.",
+		Hidden:  true,
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "gitea-repo",
+		Target:  &c.GiteaRepo,
+		Default: cfgDefaults.Repo,
+		Example: "repository-name",
+		Usage:   "The Gitea repository name.",
+		Hidden:  true,
+	})
+
+	f.IntVar(&cli.IntVar{
+		Name:    "gitea-pull-request-number",
+		EnvVar:  "GITEA_PULL_REQUEST_NUMBER",
+		Target:  &c.GiteaPullRequestNumber,
+		Default: cfgDefaults.PullRequestNumber,
+		Usage:   "The Gitea pull request number.",
+		Hidden:  true,
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "gitea-pull-request-sha",
+		EnvVar:  "GITEA_PULL_REQUEST_SHA",
+		Target:  &c.GiteaPullRequestSHA,
+		Default: cfgDefaults.PullRequestSHA,
+		Usage:   "The head commit SHA of the Gitea pull request.",
+		Hidden:  true,
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "gitea-pull-request-body",
+		EnvVar:  "GITEA_PULL_REQUEST_BODY",
+		Target:  &c.GiteaPullRequestBody,
+		Default: cfgDefaults.PullRequestBody,
+		Usage:   "The Gitea pull request body.",
+		Hidden:  true,
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "gitea-pull-request-labels",
+		EnvVar:  "GITEA_PULL_REQUEST_LABELS",
+		Target:  &c.GiteaPullRequestLabels,
+		Default: cfgDefaults.PullRequestLabels,
+		Usage:   "The labels of the Gitea pull request.",
+		Hidden:  true,
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "gitea-event-name",
+		EnvVar:  "GITEA_EVENT_NAME",
+		Target:  &c.GiteaEventName,
+		Default: cfgDefaults.EventName,
+		Example: "pull_request",
+		Usage:   "The name of the event that triggered the Gitea workflow.",
+		Hidden:  true,
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "gitea-commit-sha",
+		EnvVar:  "GITEA_SHA",
+		Target:  &c.GiteaSHA,
+		Default: cfgDefaults.SHA,
+		Usage:   "The commit SHA the Gitea workflow was triggered for.",
+		Hidden:  true,
+	})
+
+	f.Int64Var(&cli.Int64Var{
+		Name:    "gitea-run-id",
+		EnvVar:  "GITEA_RUN_ID",
+		Target:  &c.GiteaRunID,
+		Default: cfgDefaults.RunID,
+		Usage:   "The Gitea workflow run ID.",
+		Hidden:  true,
+	})
+
+	f.Int64Var(&cli.Int64Var{
+		Name:    "gitea-run-number",
+		EnvVar:  "GITEA_RUN_NUMBER",
+		Target:  &c.GiteaRunNumber,
+		Default: cfgDefaults.RunNumber,
+		Usage:   "The Gitea workflow run number, used to link to the run logs.",
+		Hidden:  true,
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "gitea-actor",
+		EnvVar:  "GITEA_ACTOR",
+		Target:  &c.GiteaActor,
+		Default: cfgDefaults.Actor,
+		Usage:   "The Gitea login for the user that triggered the workflow.",
+		Hidden:  true,
+	})
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "gitea-include-teams",
+		Default: false,
+		Target:  &c.IncludeTeams,
+		Usage:   "If true, includes team data in payload. Requires a token that can read organization teams.",
+		Hidden:  true,
+	})
+}
+
+// NewGitea creates a new Gitea client.
+func NewGitea(ctx context.Context, cfg *giteaConfig) (*Gitea, error) {
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.InitialRetryDelay <= 0 {
+		cfg.InitialRetryDelay = 1 * time.Second
+	}
+	if cfg.MaxRetryDelay <= 0 {
+		cfg.MaxRetryDelay = 20 * time.Second
+	}
+
+	if cfg.GiteaServerURL == "" {
+		return nil, fmt.Errorf("gitea server url is required")
+	}
+
+	httpClient := &http.Client{}
+
+	// The server version is not checked up front to avoid making API calls
+	// when creating the client, all supported endpoints are available in the
+	// Gitea versions that support Gitea Actions.
+	c, err := gitea.NewClient(cfg.GiteaServerURL,
+		gitea.SetToken(cfg.GuardianGiteaToken),
+		gitea.SetHTTPClient(httpClient),
+		gitea.SetContext(ctx),
+		gitea.SetGiteaVersion(""),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gitea client: %w", err)
+	}
+
+	g := &Gitea{
+		cfg:        cfg,
+		client:     c,
+		httpClient: httpClient,
+	}
+
+	if cfg.GiteaOwner != "" && cfg.GiteaRepo != "" && cfg.GiteaRunNumber > 0 {
+		g.logURL = fmt.Sprintf("%s/%s/%s/actions/runs/%d",
+			strings.TrimSuffix(cfg.GiteaServerURL, "/"), cfg.GiteaOwner, cfg.GiteaRepo, cfg.GiteaRunNumber)
+	}
+
+	return g, nil
+}
+
+// AssignReviewers assigns a list of users and teams as reviewers of the target
+// Pull Request. Principals are requested one at a time so that a single invalid
+// principal does not fail the whole request.
+func (g *Gitea) AssignReviewers(ctx context.Context, inputs *AssignReviewersInput) (*AssignReviewersResult, error) {
+	logger := logging.FromContext(ctx)
+	if inputs == nil {
+		return nil, fmt.Errorf("inputs cannot be nil")
+	}
+
+	if err := validateGiteaReporterInputs(g.cfg); err != nil {
+		return nil, fmt.Errorf("failed to validate gitea inputs: %w", err)
+	}
+
+	var result AssignReviewersResult
+	for _, u := range inputs.Users {
+		if err := g.requestReviewers(ctx, gitea.PullReviewRequestOptions{Reviewers: []string{u}}); err != nil {
+			logger.ErrorContext(ctx, "failed to assign reviewer for pull request",
+				"user", u,
+				"error", err,
+			)
+			continue
+		}
+		result.Users = append(result.Users, u)
+	}
+	for _, t := range inputs.Teams {
+		// teams may be qualified by the organization, as in CODEOWNERS files
+		name := strings.TrimPrefix(t, g.cfg.GiteaOwner+"/")
+		if err := g.requestReviewers(ctx, gitea.PullReviewRequestOptions{TeamReviewers: []string{name}}); err != nil {
+			logger.ErrorContext(ctx, "failed to assign reviewer for pull request",
+				"team", t,
+				"error", err,
+			)
+			continue
+		}
+		result.Teams = append(result.Teams, t)
+	}
+
+	if len(result.Users) == 0 && len(result.Teams) == 0 {
+		return nil, fmt.Errorf("failed to assign all requested reviewers to pull request")
+	}
+
+	return &result, nil
+}
+
+func (g *Gitea) requestReviewers(ctx context.Context, opts gitea.PullReviewRequestOptions) error {
+	return g.withRetries(ctx, func(ctx context.Context) error {
+		if resp, err := g.client.CreateReviewRequests(g.cfg.GiteaOwner, g.cfg.GiteaRepo, int64(g.cfg.GiteaPullRequestNumber), opts); err != nil {
+			return maybeGiteaRetryable(resp, fmt.Errorf("failed to request reviewers: %w", err))
+		}
+		return nil
+	})
+}
+
+// GetLatestApprovers retrieves the users whose latest review for a pull request
+// is an approval. Comments following an approval by the same user keep the
+// approval, while dismissed reviews are not counted. When teams are included,
+// it also returns the teams that the approvers are members of.
+func (g *Gitea) GetLatestApprovers(ctx context.Context) (*GetLatestApproversResult, error) {
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "querying latest approvers")
+
+	if err := validateGiteaReporterInputs(g.cfg); err != nil {
+		return nil, fmt.Errorf("failed to validate gitea inputs: %w", err)
+	}
+
+	reviews, err := g.latestReviews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest approvers: %w", err)
+	}
+
+	// Explicitly sets the default Users to an empty slice. If these are not
+	// explicitly provided to OPA, then the policy result may be incorrect.
+	result := &GetLatestApproversResult{
+		Users: []string{},
+	}
+	for _, r := range reviews {
+		if r.State == gitea.ReviewStateApproved {
+			result.Users = append(result.Users, r.Reviewer.UserName)
+		}
+	}
+
+	logger.DebugContext(ctx, "found latest approvers from",
+		"users", result.Users,
+	)
+	if !g.cfg.IncludeTeams {
+		logger.DebugContext(ctx, "skipped fetching team approvers")
+		return result, nil
+	}
+
+	found := make(map[string]struct{})
+	for _, username := range result.Users {
+		teams, err := g.GetUserTeamMemberships(ctx, username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get team memberships for approvers: %w", err)
+		}
+		for _, t := range teams {
+			if _, ok := found[t]; !ok {
+				result.Teams = append(result.Teams, t)
+				found[t] = struct{}{}
+			}
+		}
+	}
+	logger.DebugContext(ctx, "found latest approvers from",
+		"teams", result.Teams,
+	)
+
+	return result, nil
+}
+
+// latestReviews returns the latest approval or change request review of each
+// reviewer of the pull request, in the order the reviewers first reviewed.
+// Comments following an approval by the same user keep the approval, while
+// dismissed reviews are not counted.
+func (g *Gitea) latestReviews(ctx context.Context) ([]*gitea.PullReview, error) {
+	// Reviews are returned in the order they were submitted, so later reviews
+	// overwrite earlier reviews by the same user.
+	var reviewers []string
+	latest := make(map[string]*gitea.PullReview)
+
+	opts := gitea.ListPullReviewsOptions{ListOptions: gitea.ListOptions{Page: 1, PageSize: 50}}
+	for {
+		var reviews []*gitea.PullReview
+		var nextPage int
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			var resp *gitea.Response
+			var err error
+			reviews, resp, err = g.client.ListPullReviews(g.cfg.GiteaOwner, g.cfg.GiteaRepo, int64(g.cfg.GiteaPullRequestNumber), opts)
+			if err != nil {
+				return maybeGiteaRetryable(resp, fmt.Errorf("failed to list pull request reviews: %w", err))
+			}
+			nextPage = resp.NextPage
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+
+		for _, r := range reviews {
+			if r.Reviewer == nil || r.Dismissed {
+				continue
+			}
+
+			login := r.Reviewer.UserName
+			switch r.State {
+			case gitea.ReviewStateApproved, gitea.ReviewStateRequestChanges:
+			default:
+				continue
+			}
+
+			if _, ok := latest[login]; !ok {
+				reviewers = append(reviewers, login)
+			}
+			latest[login] = r
+		}
+
+		if nextPage == 0 {
+			break
+		}
+		opts.Page = nextPage
+	}
+
+	res := make([]*gitea.PullReview, 0, len(reviewers))
+	for _, login := range reviewers {
+		res = append(res, latest[login])
+	}
+	return res, nil
+}
+
+// GetUserRepoPermissions returns the repo permission for the user that
+// triggered the workflow.
+func (g *Gitea) GetUserRepoPermissions(ctx context.Context) (string, error) {
+	if g.cfg.GiteaActor == "" {
+		return "", fmt.Errorf("gitea-actor is required")
+	}
+	return g.GetRepoPermissionsForUser(ctx, g.cfg.GiteaActor)
+}
+
+// GetRepoPermissionsForUser returns the repo permission for the given user.
+func (g *Gitea) GetRepoPermissionsForUser(ctx context.Context, username string) (string, error) {
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "querying user repo permissions",
+		"username", username)
+
+	var result string
+	return result, g.withRetries(ctx, func(ctx context.Context) error {
+		permission, resp, err := g.client.CollaboratorPermission(g.cfg.GiteaOwner, g.cfg.GiteaRepo, username)
+		if err != nil {
+			if resp != nil && resp.Response != nil && resp.StatusCode == http.StatusNotFound {
+				result = string(gitea.AccessModeNone)
+				return nil
+			}
+			return maybeGiteaRetryable(resp, fmt.Errorf("failed to get user repo permissions: %w", err))
+		}
+		if permission == nil {
+			result = string(gitea.AccessModeNone)
+			return nil
+		}
+		result = string(permission.Permission)
+		return nil
+	})
+}
+
+// giteaTimelineComment is an entry of the Gitea issue timeline API. The SDK
+// decodes the label of an entry as a list, while Gitea returns a single label,
+// so the request is made directly.
+type giteaTimelineComment struct {
+	Type      string    `json:"type"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	User      *struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Label *struct {
+		Name string `json:"name"`
+	} `json:"label"`
+}
+
+// GetChangeRequestEditors retrieves the users that last added the labels of a
+// pull request and the comments of the pull request from the issue timeline.
+func (g *Gitea) GetChangeRequestEditors(ctx context.Context, changeRequestID int) (*ChangeRequestEditors, error) {
+	const pageSize = 50
+
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "querying pull request editors",
+		"pull_request_number", changeRequestID)
+
+	result := &ChangeRequestEditors{
+		Labels: make(map[string]string),
+	}
+
+	for page := 1; ; page++ {
+		u := fmt.Sprintf("%s/api/v1/repos/%s/%s/issues/%d/timeline?page=%d&limit=%d",
+			strings.TrimSuffix(g.cfg.GiteaServerURL, "/"),
+			url.PathEscape(g.cfg.GiteaOwner), url.PathEscape(g.cfg.GiteaRepo), changeRequestID, page, pageSize)
+
+		var comments []*giteaTimelineComment
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			return g.getJSON(ctx, u, &comments)
+		}); err != nil {
+			return nil, fmt.Errorf("failed to list timeline for pull request [%d]: %w", changeRequestID, err)
+		}
+
+		// comments are listed oldest first, so the last event for a label wins,
+		// a body of "1" marks an added label
+		for _, c := range comments {
+			var login string
+			if c.User != nil {
+				login = c.User.Login
+			}
+
+			switch {
+			case c.Type == "comment":
+				result.Comments = append(result.Comments, &ChangeRequestComment{
+					Author: login,
+					Body:   c.Body,
+					Edited: c.UpdatedAt.After(c.CreatedAt),
+				})
+			case c.Type != "label" || c.Label == nil:
+				continue
+			case c.Body != "1":
+				delete(result.Labels, c.Label.Name)
+			default:
+				result.Labels[c.Label.Name] = login
+			}
+		}
+
+		if len(comments) < pageSize {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// GetUserTeamMemberships returns a list of teams that a user is a member of,
+// within the organization that owns the repository.
+func (g *Gitea) GetUserTeamMemberships(ctx context.Context, username string) ([]string, error) {
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "querying user team memberships",
+		"org", g.cfg.GiteaOwner,
+		"user", username)
+
+	if username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+
+	var teams []*gitea.Team
+	opts := gitea.ListTeamsOptions{ListOptions: gitea.ListOptions{Page: 1, PageSize: 50}}
+	for {
+		var page []*gitea.Team
+		var nextPage int
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			var resp *gitea.Response
+			var err error
+			page, resp, err = g.client.ListOrgTeams(g.cfg.GiteaOwner, opts)
+			if err != nil {
+				return maybeGiteaRetryable(resp, fmt.Errorf("failed to list organization teams: %w", err))
+			}
+			nextPage = resp.NextPage
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to query user team memberships: %w", err)
+		}
+
+		teams = append(teams, page...)
+		if nextPage == 0 {
+			break
+		}
+		opts.Page = nextPage
+	}
+
+	res := make([]string, 0)
+	for _, t := range teams {
+		var isMember bool
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			_, resp, err := g.client.GetTeamMember(t.ID, username)
+			if err != nil {
+				if resp != nil && resp.Response != nil && resp.StatusCode == http.StatusNotFound {
+					return nil
+				}
+				return maybeGiteaRetryable(resp, fmt.Errorf("failed to get team member: %w", err))
+			}
+			isMember = true
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to query user team memberships: %w", err)
+		}
+
+		if isMember {
+			res = append(res, t.Name)
+		}
+	}
+
+	return res, nil
+}
+
+// GiteaActorData defines the payload of the actor used for policy evaluation.
+type GiteaActorData struct {
+	Username    string   `json:"username"`
+	AccessLevel string   `json:"access_level"`
+	Teams       []string `json:"teams,omitempty"`
+}
+
+// GiteaPolicyData defines the payload of Gitea contextual data used for policy
+// evaluation.
+type GiteaPolicyData struct {
+	PullRequestApprovers *GetLatestApproversResult `json:"pull_request_approvers"`
+	Actor                *GiteaActorData           `json:"actor"`
+	PullRequest          *ChangeRequestData        `json:"pull_request,omitempty"`
+}
+
+// GetPolicyData aggregates data from Gitea into a payload used for policy
+// evaluation.
+func (g *Gitea) GetPolicyData(ctx context.Context) (*GetPolicyDataResult, error) {
+	p, err := g.GetUserRepoPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user repo permissions: %w", err)
+	}
+
+	var actorTeams []string
+	if g.cfg.IncludeTeams {
+		actorTeams, err = g.GetUserTeamMemberships(ctx, g.cfg.GiteaActor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user teams: %w", err)
+		}
+	}
+
+	actor := &GiteaActorData{
+		Username:    g.cfg.GiteaActor,
+		AccessLevel: p,
+		Teams:       actorTeams,
+	}
+
+	var approvers *GetLatestApproversResult
+	var pullRequest *ChangeRequestData
+	// Skip, if the command is not running in the context of a pull request.
+	if g.cfg.GiteaPullRequestNumber > 0 {
+		approvers, err = g.GetLatestApprovers(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest approvers: %w", err)
+		}
+
+		pullRequest, err = g.pullRequestData(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pull request data: %w", err)
+		}
+	}
+
+	return &GetPolicyDataResult{
+		Gitea: &GiteaPolicyData{
+			PullRequestApprovers: approvers,
+			Actor:                actor,
+			PullRequest:          pullRequest,
+		},
+	}, nil
+}
+
+// pullRequestData retrieves the pull request context used for policy
+// evaluation.
+func (g *Gitea) pullRequestData(ctx context.Context) (*ChangeRequestData, error) {
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "querying pull request data")
+
+	number := int64(g.cfg.GiteaPullRequestNumber)
+
+	var pr *gitea.PullRequest
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		var resp *gitea.Response
+		var err error
+		pr, resp, err = g.client.GetPullRequest(g.cfg.GiteaOwner, g.cfg.GiteaRepo, number)
+		if err != nil {
+			return maybeGiteaRetryable(resp, fmt.Errorf("failed to get pull request: %w", err))
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to query pull request: %w", err)
+	}
+
+	result := &ChangeRequestData{
+		Number:       int(pr.Index),
+		Author:       &ChangeRequestAuthor{},
+		Draft:        pr.Draft,
+		Labels:       make([]string, 0, len(pr.Labels)),
+		ChangedFiles: make([]string, 0),
+		Commits:      make([]*ChangeRequestCommit, 0),
+	}
+	if pr.Poster != nil {
+		result.Author.Username = pr.Poster.UserName
+	}
+	if pr.Base != nil {
+		result.BaseBranch = pr.Base.Ref
+	}
+	if pr.Head != nil {
+		result.HeadBranch = pr.Head.Ref
+		result.HeadSHA = pr.Head.Sha
+	}
+	for _, l := range pr.Labels {
+		result.Labels = append(result.Labels, l.Name)
+	}
+
+	seen := make(map[string]struct{})
+	filesOpts := gitea.ListPullRequestFilesOptions{ListOptions: gitea.ListOptions{Page: 1, PageSize: 50}}
+	for {
+		var files []*gitea.ChangedFile
+		var nextPage int
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			var resp *gitea.Response
+			var err error
+			files, resp, err = g.client.ListPullRequestFiles(g.cfg.GiteaOwner, g.cfg.GiteaRepo, number, filesOpts)
+			if err != nil {
+				return maybeGiteaRetryable(resp, fmt.Errorf("failed to list pull request files: %w", err))
+			}
+			nextPage = resp.NextPage
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to query pull request changed files: %w", err)
+		}
+
+		for _, f := range files {
+			for _, p := range []string{f.Filename, f.PreviousFilename} {
+				if _, ok := seen[p]; p == "" || ok {
+					continue
+				}
+				seen[p] = struct{}{}
+				result.ChangedFiles = append(result.ChangedFiles, p)
+			}
+		}
+
+		if nextPage == 0 {
+			break
+		}
+		filesOpts.Page = nextPage
+	}
+
+	commitsOpts := gitea.ListPullRequestCommitsOptions{ListOptions: gitea.ListOptions{Page: 1, PageSize: 50}}
+	for {
+		var commits []*gitea.Commit
+		var nextPage int
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			var resp *gitea.Response
+			var err error
+			commits, resp, err = g.client.ListPullRequestCommits(g.cfg.GiteaOwner, g.cfg.GiteaRepo, number, commitsOpts)
+			if err != nil {
+				return maybeGiteaRetryable(resp, fmt.Errorf("failed to list pull request commits: %w", err))
+			}
+			nextPage = resp.NextPage
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to query pull request commits: %w", err)
+		}
+
+		for _, c := range commits {
+			commit := &ChangeRequestCommit{}
+			if c.CommitMeta != nil {
+				commit.SHA = c.SHA
+			}
+			if c.Author != nil {
+				commit.Author = c.Author.UserName
+			}
+			if c.RepoCommit != nil && c.RepoCommit.Author != nil {
+				commit.AuthorName = c.RepoCommit.Author.Name
+				if t, err := time.Parse(time.RFC3339, c.RepoCommit.Author.Date); err == nil {
+					commit.CommittedAt = t.UTC()
+				}
+			}
+			result.Commits = append(result.Commits, commit)
+		}
+
+		if nextPage == 0 {
+			break
+		}
+		commitsOpts.Page = nextPage
+	}
+
+	// Gitea lists the commits newest first.
+	slices.Reverse(result.Commits)
+
+	// reviews are given for the head commit at the time, so an approval for
+	// another commit was given before the latest push
+	reviews, err := g.latestReviews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pull request reviews: %w", err)
+	}
+	stale := make([]string, 0)
+	for _, r := range reviews {
+		if r.State == gitea.ReviewStateApproved && r.CommitID != "" && r.CommitID != result.HeadSHA {
+			stale = append(stale, r.Reviewer.UserName)
+		}
+	}
+	predates := len(stale) > 0
+	result.StaleApprovers = stale
+	result.ApprovalsPredateLatestPush = &predates
+
+	if g.cfg.IncludeTeams && result.Author.Username != "" {
+		teams, err := g.GetUserTeamMemberships(ctx, result.Author.Username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get author teams: %w", err)
+		}
+		result.Author.Teams = teams
+	}
+
+	return result, nil
+}
+
+// StoragePrefix generates the unique storage prefix for the Gitea platform
+// type. For push events, the pull request is looked up from the commit SHA.
+func (g *Gitea) StoragePrefix(ctx context.Context) (string, error) {
+	logger := logging.FromContext(ctx)
+
+	pullRequestEvents := []string{"pull_request", "pull_request_target"}
+	if slices.Contains(pullRequestEvents, g.cfg.GiteaEventName) {
+		var merr error
+		if g.cfg.GiteaOwner == "" {
+			merr = errors.Join(merr, fmt.Errorf("gitea owner is required for storage"))
+		}
+		if g.cfg.GiteaRepo == "" {
+			merr = errors.Join(merr, fmt.Errorf("gitea repo is required for storage"))
+		}
+		if g.cfg.GiteaPullRequestNumber <= 0 {
+			merr = errors.Join(merr, fmt.Errorf("gitea pull request number is required for storage"))
+		}
+
+		if merr != nil {
+			return "", merr
+		}
+
+		logger.DebugContext(ctx, "storage prefix from pull request event data",
+			"gitea_pull_request_number", g.cfg.GiteaPullRequestNumber)
+
+		return fmt.Sprintf("guardian-plans/%s/%s/%d", g.cfg.GiteaOwner, g.cfg.GiteaRepo, g.cfg.GiteaPullRequestNumber), nil
+	}
+
+	if g.cfg.GiteaEventName == "push" {
+		logger.DebugContext(ctx, "looking up pull request from commit sha",
+			"owner", g.cfg.GiteaOwner,
+			"repo", g.cfg.GiteaRepo,
+			"commit_sha", g.cfg.GiteaSHA)
+
+		resp, err := g.ListChangeRequestsByCommit(ctx, g.cfg.GiteaSHA, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to list pull requests: %w", err)
+		}
+
+		if len(resp.PullRequests) == 0 {
+			return "", fmt.Errorf("no pull requests found for commit sha: %s", g.cfg.GiteaSHA)
+		}
+
+		number := resp.PullRequests[0].Number
+		logger.DebugContext(ctx, "computed pull request number from commit sha",
+			"gitea_pull_request_number", number)
+
+		return fmt.Sprintf("guardian-plans/%s/%s/%d", g.cfg.GiteaOwner, g.cfg.GiteaRepo, number), nil
+	}
+
+	logger.DebugContext(ctx, "returning no storage prefix")
+	return "", nil
+}
+
+// ReportStatus reports the status of a run.
+func (g *Gitea) ReportStatus(ctx context.Context, st Status, p *StatusParams) error {
+	if err := validateGiteaReporterInputs(g.cfg); err != nil {
+		return fmt.Errorf("failed to validate reporter inputs: %w", err)
+	}
+
+	msgs, err := statusMessageParts(st, p, g.logURL, giteaMaxCommentLength)
+	if err != nil {
+		return fmt.Errorf("failed to generate status message: %w", err)
+	}
+
+	for _, msg := range msgs {
+		if err := g.CreateReport(ctx, g.cfg.GiteaPullRequestNumber, msg); err != nil {
+			return fmt.Errorf("failed to report status: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpsertStatus reports the status of a run, editing the previous status comment
+// for the same operation and entrypoint in place if one exists.
+func (g *Gitea) UpsertStatus(ctx context.Context, st Status, p *StatusParams) error {
+	if err := validateGiteaReporterInputs(g.cfg); err != nil {
+		return fmt.Errorf("failed to validate reporter inputs: %w", err)
+	}
+
+	previous, previousParts, err := g.findStatusReports(ctx, p)
+	if err != nil {
+		return fmt.Errorf("failed to find previous status: %w", err)
+	}
+
+	var previousBody string
+	if previous != nil {
+		previousBody = previous.Body
+	}
+
+	msgs, err := upsertStatusMessages(st, p, g.logURL, giteaMaxCommentLength, previousBody)
+	if err != nil {
+		return fmt.Errorf("failed to generate status message: %w", err)
+	}
+
+	if err := upsertStatusReports(ctx, msgs, previous, previousParts, &statusReportOps{
+		create: func(ctx context.Context, body string) error {
+			return g.CreateReport(ctx, g.cfg.GiteaPullRequestNumber, body)
+		},
+		edit: func(ctx context.Context, r *Report, body string) error {
+			commentID, ok := r.ID.(int64)
+			if !ok {
+				return fmt.Errorf("expected comment id of type int64")
+			}
+			return g.withRetries(ctx, func(ctx context.Context) error {
+				if _, resp, err := g.client.EditIssueComment(g.cfg.GiteaOwner, g.cfg.GiteaRepo, commentID, gitea.EditIssueCommentOption{
+					Body: body,
+				}); err != nil {
+					return maybeGiteaRetryable(resp, fmt.Errorf("failed to edit pull request comment: %w", err))
+				}
+				return nil
+			})
+		},
+		delete: func(ctx context.Context, r *Report) error {
+			return g.DeleteReport(ctx, r.ID)
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to report status: %w", err)
+	}
+
+	return nil
+}
+
+// findStatusReports returns the most recent status comment for the operation
+// and entrypoint, or nil if there is none, and its continuation comments in
+// order.
+func (g *Gitea) findStatusReports(ctx context.Context, p *StatusParams) (*Report, []*Report, error) {
+	marker, partMarker := statusMarker(p), statusPartMarker(p)
+
+	listOpts := &ListReportsOptions{
+		Gitea: &gitea.ListIssueCommentOptions{
+			ListOptions: gitea.ListOptions{Page: 1, PageSize: 50},
+		},
+	}
+
+	var found *Report
+	var parts []*Report
+	for {
+		response, err := g.ListReports(ctx, g.cfg.GiteaPullRequestNumber, listOpts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list comments: %w", err)
+		}
+
+		for _, c := range response.Reports {
+			if !strings.HasPrefix(c.Body, commentPrefix) {
+				continue
+			}
+			if strings.Contains(c.Body, marker) {
+				found = c
+			} else if strings.Contains(c.Body, partMarker) {
+				parts = append(parts, c)
+			}
+		}
+
+		if response.Pagination == nil {
+			return found, parts, nil
+		}
+		listOpts.Gitea.Page = response.Pagination.NextPage
+	}
+}
+
+// ReportEntrypointsSummary reports the summary for the entrypoints command.
+func (g *Gitea) ReportEntrypointsSummary(ctx context.Context, p *EntrypointsSummaryParams) error {
+	if err := validateGiteaReporterInputs(g.cfg); err != nil {
+		return fmt.Errorf("failed to validate reporter inputs: %w", err)
+	}
+
+	msg, err := entrypointsSummaryMessage(p, g.logURL)
+	if err != nil {
+		return fmt.Errorf("failed to generate summary message: %w", err)
+	}
+
+	if err := g.CreateReport(ctx, g.cfg.GiteaPullRequestNumber, msg.String()); err != nil {
+		return fmt.Errorf("failed to report entrypoints summary: %w", err)
+	}
+	return nil
+}
+
+// ClearReports clears any existing reports that can be removed.
+func (g *Gitea) ClearReports(ctx context.Context, changeRequestID int) error {
+	listOpts := &ListReportsOptions{
+		Gitea: &gitea.ListIssueCommentOptions{
+			ListOptions: gitea.ListOptions{Page: 1, PageSize: 50},
+		},
+	}
+
+	for {
+		response, err := g.ListReports(ctx, changeRequestID, listOpts)
+		if err != nil {
+			return fmt.Errorf("failed to list comments: %w", err)
+		}
+
+		for _, c := range response.Reports {
+			// prefix is not found, skip
+			if !strings.HasPrefix(c.Body, commentPrefix) {
+				continue
+			}
+
+			// found the prefix, delete the comment
+			if err := g.DeleteReport(ctx, c.ID); err != nil {
+				return fmt.Errorf("failed to delete comment: %w", err)
+			}
+		}
+
+		if response.Pagination == nil {
+			return nil
+		}
+		listOpts.Gitea.Page = response.Pagination.NextPage
+	}
+}
+
+// ListReports lists existing reports for an issue or change request.
+func (g *Gitea) ListReports(ctx context.Context, changeRequestID int, opts *ListReportsOptions) (*ListReportsResult, error) {
+	number := changeRequestID
+	if number <= 0 {
+		number = g.cfg.GiteaPullRequestNumber
+	}
+
+	if err := validateGiteaPullRequestInputs(g.cfg, number); err != nil {
+		return nil, fmt.Errorf("failed to validate reporter inputs: %w", err)
+	}
+
+	var listOpts gitea.ListIssueCommentOptions
+	if opts != nil && opts.Gitea != nil {
+		listOpts = *opts.Gitea
+	}
+
+	var reports []*Report
+	var pagination *Pagination
+
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		comments, resp, err := g.client.ListIssueComments(g.cfg.GiteaOwner, g.cfg.GiteaRepo, int64(number), listOpts)
+		if err != nil {
+			return maybeGiteaRetryable(resp, fmt.Errorf("failed to list pull request comments: %w", err))
+		}
+
+		for _, c := range comments {
+			reports = append(reports, &Report{ID: c.ID, Body: c.Body})
+		}
+
+		if resp.NextPage != 0 {
+			pagination = &Pagination{NextPage: resp.NextPage}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+
+	return &ListReportsResult{Reports: reports, Pagination: pagination}, nil
+}
+
+// DeleteReport deletes an existing comment from an issue or change request.
+func (g *Gitea) DeleteReport(ctx context.Context, id any) error {
+	commentID, ok := id.(int64)
+	if !ok {
+		return fmt.Errorf("expected comment id of type int64")
+	}
+
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		if resp, err := g.client.DeleteIssueComment(g.cfg.GiteaOwner, g.cfg.GiteaRepo, commentID); err != nil {
+			return maybeGiteaRetryable(resp, fmt.Errorf("failed to delete pull request comment: %w", err))
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to delete report: %w", err)
+	}
+
+	return nil
+}
+
+// CreateReport posts a comment on an issue or change request.
+func (g *Gitea) CreateReport(ctx context.Context, changeRequestID int, body string) error {
+	number := changeRequestID
+	if number <= 0 {
+		number = g.cfg.GiteaPullRequestNumber
+	}
+
+	if err := validateGiteaPullRequestInputs(g.cfg, number); err != nil {
+		return fmt.Errorf("failed to validate reporter inputs: %w", err)
+	}
+
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "creating pull request comment",
+		"owner", g.cfg.GiteaOwner,
+		"repo", g.cfg.GiteaRepo,
+		"number", number)
+
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		if _, resp, err := g.client.CreateIssueComment(g.cfg.GiteaOwner, g.cfg.GiteaRepo, int64(number), gitea.CreateIssueCommentOption{
+			Body: body,
+		}); err != nil {
+			return maybeGiteaRetryable(resp, fmt.Errorf("failed to create pull request comment: %w", err))
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+
+	return nil
+}
+
+// ListChangeRequestsByCommit lists the pull requests associated with a commit
+// SHA, either as the head commit or the merge commit of the pull request. Gitea
+// does not provide an API to search pull requests by commit, so the most
+// recently updated pull requests are searched.
+func (g *Gitea) ListChangeRequestsByCommit(ctx context.Context, sha string, opts *ListChangeRequestsByCommitOptions) (*ListChangeRequestsByCommitResponse, error) {
+	if sha == "" {
+		return nil, fmt.Errorf("commit sha is required")
+	}
+
+	listOpts := gitea.ListPullRequestsOptions{
+		ListOptions: gitea.ListOptions{Page: 1, PageSize: 50},
+		State:       gitea.StateAll,
+		Sort:        "recentupdate",
+	}
+	if opts != nil && opts.Gitea != nil {
+		listOpts = *opts.Gitea
+	}
+
+	var pullRequests []*PullRequest
+	var pagination *Pagination
+
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		prs, resp, err := g.client.ListRepoPullRequests(g.cfg.GiteaOwner, g.cfg.GiteaRepo, listOpts)
+		if err != nil {
+			return maybeGiteaRetryable(resp, fmt.Errorf("failed to list pull requests: %w", err))
+		}
+
+		for _, pr := range prs {
+			var headSHA string
+			if pr.Head != nil {
+				headSHA = pr.Head.Sha
+			}
+
+			if headSHA != sha && (pr.MergedCommitID == nil || *pr.MergedCommitID != sha) {
+				continue
+			}
+			pullRequests = append(pullRequests, &PullRequest{ID: pr.ID, Number: int(pr.Index), Body: pr.Body, HeadSHA: headSHA})
+		}
+
+		if resp.NextPage != 0 {
+			pagination = &Pagination{NextPage: resp.NextPage}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list pull requests for commit sha [%s]: %w", sha, err)
+	}
+
+	return &ListChangeRequestsByCommitResponse{PullRequests: pullRequests, Pagination: pagination}, nil
+}
+
+// giteaJobs is the response of the Gitea Actions list jobs API.
+type giteaJobs struct {
+	Jobs []struct {
+		ID         int64  `json:"id"`
+		Name       string `json:"name"`
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+		HTMLURL    string `json:"html_url"`
+	} `json:"jobs"`
+}
+
+// ListJobs lists all jobs running as part of a Gitea Actions workflow run with
+// pagination. The Gitea SDK does not support the Actions jobs API, so the
+// request is made directly.
+func (g *Gitea) ListJobs(ctx context.Context, runID int64) ([]*Job, error) {
+	const pageSize = 50
+
+	var jobs []*Job
+	for page := 1; ; page++ {
+		u := fmt.Sprintf("%s/api/v1/repos/%s/%s/actions/runs/%d/jobs?page=%d&limit=%d",
+			strings.TrimSuffix(g.cfg.GiteaServerURL, "/"),
+			url.PathEscape(g.cfg.GiteaOwner), url.PathEscape(g.cfg.GiteaRepo), runID, page, pageSize)
+
+		var res giteaJobs
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			return g.getJSON(ctx, u, &res)
+		}); err != nil {
+			return nil, fmt.Errorf("failed to list jobs for workflow run [%d]: %w", runID, err)
+		}
+
+		for _, j := range res.Jobs {
+			jobs = append(jobs, &Job{
+				ID:         j.ID,
+				Name:       j.Name,
+				URL:        j.HTMLURL,
+				Status:     j.Status,
+				Conclusion: j.Conclusion,
+			})
+		}
+
+		if len(res.Jobs) < pageSize {
+			break
+		}
+	}
+
+	return jobs, nil
+}
+
+// getJSON makes an authenticated GET request to the Gitea API and decodes the
+// JSON response.
+func (g *Gitea) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if g.cfg.GuardianGiteaToken != "" {
+		req.Header.Set("Authorization", "token "+g.cfg.GuardianGiteaToken)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return retry.RetryableError(fmt.Errorf("failed to make request: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("unexpected response status: %s", resp.Status)
+		if _, ok := giteaIgnoredStatusCodes[resp.StatusCode]; ok {
+			return err
+		}
+		return retry.RetryableError(err)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func validateGiteaReporterInputs(cfg *giteaConfig) error {
+	return validateGiteaPullRequestInputs(cfg, cfg.GiteaPullRequestNumber)
+}
+
+// validateGiteaPullRequestInputs validates the inputs required to interact with
+// a specific pull request, which may not be the pull request of the current
+// workflow run.
+func validateGiteaPullRequestInputs(cfg *giteaConfig, number int) error {
+	var merr error
+	if cfg.GiteaOwner == "" {
+		merr = errors.Join(merr, fmt.Errorf("gitea owner is required"))
+	}
+
+	if cfg.GiteaRepo == "" {
+		merr = errors.Join(merr, fmt.Errorf("gitea repo is required"))
+	}
+
+	if number <= 0 {
+		merr = errors.Join(merr, fmt.Errorf("gitea pull request number is required"))
+	}
+
+	if cfg.GuardianGiteaToken == "" {
+		merr = errors.Join(merr, fmt.Errorf("gitea token is required"))
+	}
+
+	return merr
+}
+
+func (g *Gitea) withRetries(ctx context.Context, retryFunc retry.RetryFunc) error {
+	backoff := retry.NewFibonacci(g.cfg.InitialRetryDelay)
+	backoff = retry.WithMaxRetries(g.cfg.MaxRetries, backoff)
+	backoff = retry.WithCappedDuration(g.cfg.MaxRetryDelay, backoff)
+
+	if err := retry.Do(ctx, backoff, retryFunc); err != nil {
+		return fmt.Errorf("failed to execute retriable function: %w", err)
+	}
+	return nil
+}
+
+func maybeGiteaRetryable(resp *gitea.Response, err error) error {
+	if resp != nil && resp.Response != nil {
+		if _, ok := giteaIgnoredStatusCodes[resp.StatusCode]; ok {
+			return err
+		}
+	}
+	return retry.RetryableError(err)
+}
@@ -0,0 +1,786 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+// fakeGitea is a minimal in-memory implementation of the Gitea REST API for a
+// single repository.
+type fakeGitea struct {
+	mu sync.Mutex
+
+	// collaborators maps usernames to their repository permission.
+	collaborators map[string]string
+	// teams maps team names to their members.
+	teams map[string][]string
+	// reviews are the pull request reviews in the order they were submitted.
+	reviews []map[string]any
+	// pullRequests are the repository pull requests.
+	pullRequests []map[string]any
+	// timeline is the issue timeline of pull request 1, oldest first.
+	timeline []map[string]any
+	// files are the changed files of pull request 1.
+	files []map[string]any
+	// commits are the commits of pull request 1, newest first.
+	commits []map[string]any
+	// jobs are the workflow run jobs.
+	jobs []map[string]any
+
+	comments         map[int64]string
+	nextCommentID    int64
+	reviewRequests   []string
+	teamReviewers    []string
+	invalidReviewers []string
+}
+
+func (f *fakeGitea) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /api/v1/repos/owner/repo/pulls/1/requested_reviewers", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		var body struct {
+			Reviewers     []string `json:"reviewers"`
+			TeamReviewers []string `json:"team_reviewers"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"message": err.Error()})
+			return
+		}
+		for _, u := range append(body.Reviewers, body.TeamReviewers...) {
+			for _, invalid := range f.invalidReviewers {
+				if u == invalid {
+					writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"message": "reviewer does not exist"})
+					return
+				}
+			}
+		}
+		f.reviewRequests = append(f.reviewRequests, body.Reviewers...)
+		f.teamReviewers = append(f.teamReviewers, body.TeamReviewers...)
+		writeJSON(w, http.StatusCreated, []any{})
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, f.reviews)
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, f.pullRequests)
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/pulls/{number}", func(w http.ResponseWriter, r *http.Request) {
+		for _, pr := range f.pullRequests {
+			if fmt.Sprint(pr["number"]) == r.PathValue("number") {
+				writeJSON(w, http.StatusOK, pr)
+				return
+			}
+		}
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "pull request not found"})
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/pulls/1/files", func(w http.ResponseWriter, r *http.Request) {
+		res := f.files
+		if res == nil {
+			res = []map[string]any{}
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/pulls/1/commits", func(w http.ResponseWriter, r *http.Request) {
+		res := f.commits
+		if res == nil {
+			res = []map[string]any{}
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+		res := f.timeline
+		if res == nil {
+			res = []map[string]any{}
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/collaborators/{user}/permission", func(w http.ResponseWriter, r *http.Request) {
+		permission, ok := f.collaborators[r.PathValue("user")]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]any{"message": "user not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"permission": permission})
+	})
+
+	mux.HandleFunc("GET /api/v1/orgs/owner/teams", func(w http.ResponseWriter, r *http.Request) {
+		res := []map[string]any{}
+		for i, name := range slices.Sorted(maps.Keys(f.teams)) {
+			res = append(res, map[string]any{"id": i + 1, "name": name})
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	mux.HandleFunc("GET /api/v1/teams/{team}/members/{user}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("team"))
+		name := slices.Sorted(maps.Keys(f.teams))[id-1]
+		for _, m := range f.teams[name] {
+			if m == r.PathValue("user") {
+				writeJSON(w, http.StatusOK, map[string]any{"login": m})
+				return
+			}
+		}
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "not found"})
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/issues/{number}/comments", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		res := []map[string]any{}
+		for id := int64(1); id < f.nextCommentID; id++ {
+			if body, ok := f.comments[id]; ok {
+				res = append(res, map[string]any{"id": id, "body": body})
+			}
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	mux.HandleFunc("POST /api/v1/repos/owner/repo/issues/{number}/comments", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		var body struct {
+			Body string `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"message": err.Error()})
+			return
+		}
+		id := f.nextCommentID
+		f.comments[id] = body.Body
+		f.nextCommentID++
+		writeJSON(w, http.StatusCreated, map[string]any{"id": id, "body": body.Body})
+	})
+
+	mux.HandleFunc("PATCH /api/v1/repos/owner/repo/issues/comments/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if _, ok := f.comments[id]; !ok {
+			writeJSON(w, http.StatusNotFound, map[string]any{"message": "comment not found"})
+			return
+		}
+
+		var body struct {
+			Body string `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"message": err.Error()})
+			return
+		}
+		f.comments[id] = body.Body
+		writeJSON(w, http.StatusOK, map[string]any{"id": id, "body": body.Body})
+	})
+
+	mux.HandleFunc("DELETE /api/v1/repos/owner/repo/issues/comments/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		delete(f.comments, id)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/actions/runs/{run}/jobs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"jobs": f.jobs, "total_count": len(f.jobs)})
+	})
+
+	return mux
+}
+
+func testGitea(tb testing.TB, fake *fakeGitea, cfg *giteaConfig) *Gitea {
+	tb.Helper()
+
+	srv := httptest.NewServer(fake.handler())
+	tb.Cleanup(srv.Close)
+
+	cfg.GuardianGiteaToken = "token"
+	cfg.GiteaServerURL = srv.URL
+	cfg.GiteaOwner = "owner"
+	cfg.GiteaRepo = "repo"
+	cfg.InitialRetryDelay = time.Millisecond
+	cfg.MaxRetryDelay = time.Millisecond
+	cfg.MaxRetries = 1
+
+	g, err := NewGitea(tb.Context(), cfg)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return g
+}
+
+func TestGitea_AssignReviewers(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name             string
+		input            *AssignReviewersInput
+		want             *AssignReviewersResult
+		wantUsers        []string
+		wantTeams        []string
+		wantErr          string
+		invalidReviewers []string
+	}{
+		{
+			name:      "users_and_teams",
+			input:     &AssignReviewersInput{Users: []string{"alice", "bob"}, Teams: []string{"platform"}},
+			want:      &AssignReviewersResult{Users: []string{"alice", "bob"}, Teams: []string{"platform"}},
+			wantUsers: []string{"alice", "bob"},
+			wantTeams: []string{"platform"},
+		},
+		{
+			name:             "skips_invalid_user",
+			input:            &AssignReviewersInput{Users: []string{"alice", "unknown"}},
+			want:             &AssignReviewersResult{Users: []string{"alice"}},
+			wantUsers:        []string{"alice"},
+			invalidReviewers: []string{"unknown"},
+		},
+		{
+			name:             "all_failed",
+			input:            &AssignReviewersInput{Users: []string{"unknown"}},
+			wantErr:          "failed to assign all requested reviewers to pull request",
+			invalidReviewers: []string{"unknown"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeGitea{invalidReviewers: tc.invalidReviewers}
+			g := testGitea(t, fake, &giteaConfig{GiteaPullRequestNumber: 1})
+
+			got, err := g.AssignReviewers(t.Context(), tc.input)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("result not as expected; (-got,+want): %s", diff)
+			}
+			if diff := cmp.Diff(fake.reviewRequests, tc.wantUsers); diff != "" {
+				t.Errorf("requested users not as expected; (-got,+want): %s", diff)
+			}
+			if diff := cmp.Diff(fake.teamReviewers, tc.wantTeams); diff != "" {
+				t.Errorf("requested teams not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestGitea_GetLatestApprovers(t *testing.T) {
+	t.Parallel()
+
+	review := func(user, state string, dismissed bool) map[string]any {
+		return map[string]any{"user": map[string]any{"login": user}, "state": state, "dismissed": dismissed}
+	}
+
+	cases := []struct {
+		name         string
+		includeTeams bool
+		reviews      []map[string]any
+		want         *GetLatestApproversResult
+	}{
+		{
+			name: "no_reviews",
+			want: &GetLatestApproversResult{Users: []string{}},
+		},
+		{
+			name: "latest_review_state",
+			reviews: []map[string]any{
+				review("alice", "APPROVED", false),
+				review("bob", "APPROVED", false),
+				review("alice", "COMMENT", false),
+				review("bob", "REQUEST_CHANGES", false),
+				review("carol", "REQUEST_CHANGES", false),
+				review("carol", "APPROVED", false),
+				review("dave", "APPROVED", true),
+			},
+			want: &GetLatestApproversResult{Users: []string{"alice", "carol"}},
+		},
+		{
+			name:         "with_teams",
+			includeTeams: true,
+			reviews: []map[string]any{
+				review("alice", "APPROVED", false),
+				review("carol", "APPROVED", false),
+			},
+			want: &GetLatestApproversResult{
+				Users: []string{"alice", "carol"},
+				Teams: []string{"platform", "security"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeGitea{
+				reviews: tc.reviews,
+				teams: map[string][]string{
+					"platform": {"alice"},
+					"security": {"alice", "carol"},
+					"web":      {"bob"},
+				},
+			}
+			g := testGitea(t, fake, &giteaConfig{
+				GiteaPullRequestNumber: 1,
+				IncludeTeams:           tc.includeTeams,
+			})
+
+			got, err := g.GetLatestApprovers(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("approvers not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestGitea_GetPolicyData(t *testing.T) {
+	t.Parallel()
+
+	pullRequest := &ChangeRequestData{
+		Number:       1,
+		Author:       &ChangeRequestAuthor{Username: "alice"},
+		BaseBranch:   "main",
+		HeadBranch:   "feature",
+		HeadSHA:      "sha2",
+		Draft:        true,
+		Labels:       []string{"infra"},
+		ChangedFiles: []string{"terraform/dev/main.tf", "modules/new/main.tf", "modules/old/main.tf"},
+		Commits: []*ChangeRequestCommit{
+			{SHA: "sha1", Author: "alice", AuthorName: "Alice", CommittedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+			{SHA: "sha2", AuthorName: "Bot", CommittedAt: time.Date(2025, 1, 3, 3, 4, 5, 0, time.UTC)},
+		},
+		ApprovalsPredateLatestPush: gitea.OptionalBool(true),
+		StaleApprovers:             []string{"bob"},
+	}
+
+	cases := []struct {
+		name    string
+		actor   string
+		want    *GetPolicyDataResult
+		wantErr string
+	}{
+		{
+			name:  "collaborator",
+			actor: "alice",
+			want: &GetPolicyDataResult{
+				Gitea: &GiteaPolicyData{
+					PullRequestApprovers: &GetLatestApproversResult{Users: []string{"bob", "carol"}},
+					Actor:                &GiteaActorData{Username: "alice", AccessLevel: "write"},
+					PullRequest:          pullRequest,
+				},
+			},
+		},
+		{
+			name:  "not_a_collaborator",
+			actor: "mallory",
+			want: &GetPolicyDataResult{
+				Gitea: &GiteaPolicyData{
+					PullRequestApprovers: &GetLatestApproversResult{Users: []string{"bob", "carol"}},
+					Actor:                &GiteaActorData{Username: "mallory", AccessLevel: "none"},
+					PullRequest:          pullRequest,
+				},
+			},
+		},
+		{
+			name:    "missing_actor",
+			wantErr: "gitea-actor is required",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeGitea{
+				collaborators: map[string]string{"alice": "write"},
+				reviews: []map[string]any{
+					{"user": map[string]any{"login": "bob"}, "state": "APPROVED", "commit_id": "sha1"},
+					{"user": map[string]any{"login": "carol"}, "state": "APPROVED", "commit_id": "sha2"},
+				},
+				pullRequests: []map[string]any{
+					{
+						"number": 1,
+						"user":   map[string]any{"login": "alice"},
+						"draft":  true,
+						"labels": []map[string]any{{"name": "infra"}},
+						"base":   map[string]any{"ref": "main", "sha": "base"},
+						"head":   map[string]any{"ref": "feature", "sha": "sha2"},
+					},
+				},
+				files: []map[string]any{
+					{"filename": "terraform/dev/main.tf", "status": "modified"},
+					{"filename": "modules/new/main.tf", "previous_filename": "modules/old/main.tf", "status": "renamed"},
+				},
+				commits: []map[string]any{
+					{"sha": "sha2", "commit": map[string]any{"author": map[string]any{"name": "Bot", "date": "2025-01-03T03:04:05Z"}}},
+					{"sha": "sha1", "author": map[string]any{"login": "alice"}, "commit": map[string]any{"author": map[string]any{"name": "Alice", "date": "2025-01-02T03:04:05Z"}}},
+				},
+			}
+			g := testGitea(t, fake, &giteaConfig{
+				GiteaPullRequestNumber: 1,
+				GiteaActor:             tc.actor,
+			})
+
+			got, err := g.GetPolicyData(t.Context())
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("policy data not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestGitea_GetChangeRequestEditors(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeGitea{
+		timeline: []map[string]any{
+			{"type": "comment", "body": "looks good", "user": map[string]any{"login": "bob"}, "created_at": "2025-01-01T00:00:00Z", "updated_at": "2025-01-01T00:00:00Z"},
+			{"type": "comment", "body": "GUARDIAN_EXEMPT=iam:incident", "user": map[string]any{"login": "dave"}, "created_at": "2025-01-01T00:00:00Z", "updated_at": "2025-01-02T00:00:00Z"},
+			{"type": "label", "body": "1", "label": map[string]any{"name": "guardian-exempt:iam"}, "user": map[string]any{"login": "bob"}},
+			{"type": "label", "body": "1", "label": map[string]any{"name": "infra"}, "user": map[string]any{"login": "bob"}},
+			{"type": "label", "body": "", "label": map[string]any{"name": "guardian-exempt:iam"}, "user": map[string]any{"login": "bob"}},
+			{"type": "label", "body": "1", "label": map[string]any{"name": "guardian-exempt:iam"}, "user": map[string]any{"login": "carol"}},
+		},
+	}
+	g := testGitea(t, fake, &giteaConfig{})
+
+	got, err := g.GetChangeRequestEditors(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &ChangeRequestEditors{
+		Labels: map[string]string{
+			"guardian-exempt:iam": "carol",
+			"infra":               "bob",
+		},
+		Comments: []*ChangeRequestComment{
+			{Author: "bob", Body: "looks good"},
+			{Author: "dave", Body: "GUARDIAN_EXEMPT=iam:incident", Edited: true},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("editors not as expected; (-got,+want): %s", diff)
+	}
+}
+
+func TestGitea_StoragePrefix(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		cfg     *giteaConfig
+		want    string
+		wantErr string
+	}{
+		{
+			name: "pull_request",
+			cfg: &giteaConfig{
+				GiteaEventName:         "pull_request",
+				GiteaPullRequestNumber: 1,
+			},
+			want: "guardian-plans/owner/repo/1",
+		},
+		{
+			name: "pull_request_missing_number",
+			cfg: &giteaConfig{
+				GiteaEventName: "pull_request",
+			},
+			wantErr: "gitea pull request number is required for storage",
+		},
+		{
+			name: "push_merge_commit",
+			cfg: &giteaConfig{
+				GiteaEventName: "push",
+				GiteaSHA:       "merge123",
+			},
+			want: "guardian-plans/owner/repo/7",
+		},
+		{
+			name: "push_no_pull_requests",
+			cfg: &giteaConfig{
+				GiteaEventName: "push",
+				GiteaSHA:       "unknown",
+			},
+			wantErr: "no pull requests found for commit sha: unknown",
+		},
+		{
+			name: "schedule",
+			cfg: &giteaConfig{
+				GiteaEventName: "schedule",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeGitea{
+				pullRequests: []map[string]any{
+					{"id": 800, "number": 8, "head": map[string]any{"sha": "head888"}},
+					{"id": 700, "number": 7, "head": map[string]any{"sha": "head777"}, "merge_commit_sha": "merge123"},
+				},
+			}
+			g := testGitea(t, fake, tc.cfg)
+
+			got, err := g.StoragePrefix(t.Context())
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Error(diff)
+			}
+			if got != tc.want {
+				t.Errorf("expected storage prefix %q to be %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGitea_ClearReports(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeGitea{
+		comments: map[int64]string{
+			1: commentPrefix + " old status",
+			2: "a human comment",
+			3: commentPrefix + " another status",
+		},
+		nextCommentID: 4,
+	}
+	g := testGitea(t, fake, &giteaConfig{GiteaPullRequestNumber: 1})
+
+	if err := g.ClearReports(t.Context(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ReportStatus(t.Context(), StatusSuccess, &StatusParams{Operation: "plan", Dir: "terraform"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := fake.comments[2]; !ok || len(fake.comments) != 2 {
+		t.Errorf("expected only guardian comments to be cleared, got %v", fake.comments)
+	}
+	if body := fake.comments[4]; !strings.HasPrefix(body, commentPrefix) {
+		t.Errorf("expected status comment to be created, got %q", body)
+	}
+}
+
+func TestGitea_ReportStatusParts(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeGitea{
+		comments: map[int64]string{
+			1: "a human comment",
+		},
+		nextCommentID: 2,
+	}
+	g := testGitea(t, fake, &giteaConfig{GiteaPullRequestNumber: 1})
+
+	var details strings.Builder
+	for i := range 2000 {
+		fmt.Fprintf(&details, "  # null_resource.r%d will be created\n  + resource \"null_resource\" \"r%d\" {}\n", i, i)
+	}
+
+	if err := g.ReportStatus(t.Context(), StatusSuccess, &StatusParams{
+		Operation: "plan",
+		Dir:       "terraform",
+		Details:   details.String(),
+		HasDiff:   true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(fake.comments); got < 3 {
+		t.Fatalf("expected status to be split across multiple comments, got %d comments", got)
+	}
+	for id, body := range fake.comments {
+		if id > 1 && len([]rune(body)) > giteaMaxCommentLength {
+			t.Errorf("expected comment %d length %d to be at most %d", id, len([]rune(body)), giteaMaxCommentLength)
+		}
+	}
+
+	if err := g.ClearReports(t.Context(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.comments[1]; !ok || len(fake.comments) != 1 {
+		t.Errorf("expected every part to be cleared, got %d comments", len(fake.comments))
+	}
+}
+
+func TestGitea_UpsertStatus(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeGitea{
+		comments: map[int64]string{
+			1: "a human comment",
+		},
+		nextCommentID: 2,
+	}
+	g := testGitea(t, fake, &giteaConfig{GiteaPullRequestNumber: 1})
+
+	for _, p := range []*StatusParams{
+		{Operation: "plan", Dir: "terraform"},
+		{Operation: "plan", Dir: "other"},
+	} {
+		if err := g.UpsertStatus(t.Context(), StatusFailure, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.UpsertStatus(t.Context(), StatusSuccess, &StatusParams{Operation: "plan", Dir: "terraform"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(fake.comments), 3; got != want {
+		t.Fatalf("expected %d comments, got %d: %v", want, got, fake.comments)
+	}
+
+	body := fake.comments[2]
+	if !strings.Contains(body, statusText[StatusSuccess]) {
+		t.Errorf("expected comment to be updated with the new status, got %q", body)
+	}
+	if !strings.Contains(body, "Previous statuses") || !strings.Contains(body, "- "+markdownPill("PLAN")+" "+markdownPill(statusText[StatusFailure])) {
+		t.Errorf("expected comment to contain the previous status, got %q", body)
+	}
+	if body := fake.comments[3]; strings.Contains(body, "Previous statuses") {
+		t.Errorf("expected comment for other entrypoint to be unchanged, got %q", body)
+	}
+}
+
+func TestGitea_UpsertStatus_Parts(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeGitea{
+		comments:      map[int64]string{},
+		nextCommentID: 1,
+	}
+	g := testGitea(t, fake, &giteaConfig{GiteaPullRequestNumber: 1})
+
+	details := strings.Repeat("  # null_resource.r will be created\n"+strings.Repeat("      + id = (known after apply)\n", 200), 30)
+
+	if err := g.UpsertStatus(t.Context(), StatusFailure, &StatusParams{Operation: "plan", Dir: "terraform", Details: details}); err != nil {
+		t.Fatal(err)
+	}
+	parts := len(fake.comments)
+	if parts < 2 {
+		t.Fatalf("expected the status to be split across comments, got %d comments", parts)
+	}
+	for id, body := range fake.comments {
+		if len([]rune(body)) > giteaMaxCommentLength {
+			t.Errorf("expected comment %d length %d to be at most %d", id, len([]rune(body)), giteaMaxCommentLength)
+		}
+	}
+
+	if err := g.UpsertStatus(t.Context(), StatusFailure, &StatusParams{Operation: "plan", Dir: "terraform", Details: details}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(fake.comments); got != parts {
+		t.Errorf("expected the %d comments to be edited in place, got %d comments", parts, got)
+	}
+
+	if err := g.UpsertStatus(t.Context(), StatusSuccess, &StatusParams{Operation: "plan", Dir: "terraform"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(fake.comments), 1; got != want {
+		t.Errorf("expected the continuation comments to be deleted, got %d comments: %v", got, fake.comments)
+	}
+	if body := fake.comments[1]; !strings.Contains(body, statusText[StatusSuccess]) {
+		t.Errorf("expected comment to be updated with the new status, got %q", body)
+	}
+}
+
+func TestGitea_ListJobs(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeGitea{
+		jobs: []map[string]any{
+			{"id": 1, "name": "plan (a)", "status": "completed", "conclusion": "success", "html_url": "https://gitea.com/jobs/1"},
+			{"id": 2, "name": "plan (b)", "status": "in_progress", "html_url": "https://gitea.com/jobs/2"},
+		},
+	}
+	g := testGitea(t, fake, &giteaConfig{})
+
+	got, err := g.ListJobs(t.Context(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Job{
+		{ID: 1, Name: "plan (a)", URL: "https://gitea.com/jobs/1", Status: "completed", Conclusion: "success"},
+		{ID: 2, Name: "plan (b)", URL: "https://gitea.com/jobs/2", Status: "in_progress"},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("jobs not as expected; (-got,+want): %s", diff)
+	}
+}
+
+func TestGitea_ListJobsError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"forbidden"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	g, err := NewGitea(t.Context(), &giteaConfig{
+		GiteaServerURL:    srv.URL,
+		GiteaOwner:        "owner",
+		GiteaRepo:         "repo",
+		InitialRetryDelay: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = g.ListJobs(t.Context(), 10)
+	if diff := testutil.DiffErrString(err, "unexpected response status: 403 Forbidden"); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -33,11 +33,35 @@ import (
 	"github.com/abcxyz/pkg/logging"
 )
 
-const githubMaxCommentLength = 65536
+const (
+	githubMaxCommentLength = 65536
+
+	// githubMaxCheckRunTextLength is the maximum length of the summary and text
+	// of a check run output.
+	githubMaxCheckRunTextLength = 65535
+
+	// githubMaxCheckRunAnnotations is the maximum number of annotations that
+	// can be sent in a single check run request.
+	githubMaxCheckRunAnnotations = 50
+)
 
 var (
 	_ Platform = (*GitHub)(nil)
 
+	// checkRunConclusions maps the status of a run to the check run conclusion.
+	// No changes and policy advisories are reported as neutral, which
+	// satisfies required status checks.
+	checkRunConclusions = map[Status]string{
+		StatusSuccess:         "success",
+		StatusNoOperation:     "neutral",
+		StatusFailure:         "failure",
+		StatusUnknown:         "failure",
+		StatusPolicyViolation: "action_required", // failure without a details URL
+		StatusPolicyAdvisory:  "neutral",
+		StatusStalePlan:       "failure",
+		StatusDestroy:         "success",
+	}
+
 	// ignoredStatusCodes are status codes that should not be retried. This list
 	// is taken from the GitHub REST API documentation.
 	ignoredStatusCodes = map[int]struct{}{
@@ -56,6 +80,12 @@ type GitHub struct {
 
 // NewGitHub creates a new GitHub client.
 func NewGitHub(ctx context.Context, cfg *gh.Config) (*GitHub, error) {
+	switch cfg.ReportMode {
+	case "", gh.ReportModeComment, gh.ReportModeCheck:
+	default:
+		return nil, fmt.Errorf("unsupported github report mode: %s", cfg.ReportMode)
+	}
+
 	if cfg.MaxRetries <= 0 {
 		cfg.MaxRetries = 3
 	}
@@ -163,7 +193,9 @@ func (g *GitHub) AssignReviewers(ctx context.Context, input *AssignReviewersInpu
 		result.Users = append(result.Users, u)
 	}
 	for _, t := range input.Teams {
-		if err := g.requestReviewers(ctx, &github.ReviewersRequest{TeamReviewers: []string{t}}); err != nil {
+		// teams may be qualified by the organization, as in CODEOWNERS files
+		slug := strings.TrimPrefix(t, g.cfg.GitHubOwner+"/")
+		if err := g.requestReviewers(ctx, &github.ReviewersRequest{TeamReviewers: []string{slug}}); err != nil {
 			logger.ErrorContext(ctx, "failed to assign reviewer for pull request",
 				"team", t,
 				"error", err,
@@ -334,15 +366,21 @@ func (g *GitHub) GetUserTeamMemberships(ctx context.Context, username string) ([
 // GetUserRepoPermissions returns the repo permission for the user that
 // triggered the workflow.
 func (g *GitHub) GetUserRepoPermissions(ctx context.Context) (string, error) {
-	logger := logging.FromContext(ctx)
-	logger.DebugContext(ctx, "querying user repo permissions")
-
 	if g.cfg.GitHubActor == "" {
 		return "", fmt.Errorf("github-actor is required")
 	}
+	return g.GetRepoPermissionsForUser(ctx, g.cfg.GitHubActor)
+}
+
+// GetRepoPermissionsForUser returns the repo permission for the given user.
+func (g *GitHub) GetRepoPermissionsForUser(ctx context.Context, username string) (string, error) {
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "querying user repo permissions",
+		"username", username)
+
 	var result string
 	return result, g.withRetries(ctx, func(ctx context.Context) error {
-		permissionLevel, resp, err := g.client.Repositories.GetPermissionLevel(ctx, g.cfg.GitHubOwner, g.cfg.GitHubRepo, g.cfg.GitHubActor)
+		permissionLevel, resp, err := g.client.Repositories.GetPermissionLevel(ctx, g.cfg.GitHubOwner, g.cfg.GitHubRepo, username)
 		if err != nil {
 			if resp != nil {
 				if _, ok := ignoredStatusCodes[resp.StatusCode]; !ok {
@@ -356,6 +394,88 @@ func (g *GitHub) GetUserRepoPermissions(ctx context.Context) (string, error) {
 	})
 }
 
+// GetChangeRequestEditors retrieves the users that last added the labels of a
+// pull request from the issue events, and the comments of the pull request.
+func (g *GitHub) GetChangeRequestEditors(ctx context.Context, changeRequestID int) (*ChangeRequestEditors, error) {
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "querying pull request editors",
+		"pull_request_number", changeRequestID)
+
+	result := &ChangeRequestEditors{
+		Labels: make(map[string]string),
+	}
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var events []*github.IssueEvent
+		var nextPage int
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			var resp *github.Response
+			var err error
+			events, resp, err = g.client.Issues.ListIssueEvents(ctx, g.cfg.GitHubOwner, g.cfg.GitHubRepo, changeRequestID, opts)
+			if err != nil {
+				return maybeRetryable(resp, fmt.Errorf("failed to list issue events: %w", err))
+			}
+			nextPage = resp.NextPage
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+
+		// events are listed oldest first, so the last event for a label wins
+		for _, e := range events {
+			switch e.GetEvent() {
+			case "labeled":
+				result.Labels[e.GetLabel().GetName()] = e.GetActor().GetLogin()
+			case "unlabeled":
+				delete(result.Labels, e.GetLabel().GetName())
+			}
+		}
+
+		if nextPage == 0 {
+			break
+		}
+		opts.Page = nextPage
+	}
+
+	commentsOpts := &github.IssueListCommentsOptions{
+		Sort:        github.String("created"),
+		Direction:   github.String("asc"),
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		var comments []*github.IssueComment
+		var nextPage int
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			var resp *github.Response
+			var err error
+			comments, resp, err = g.client.Issues.ListComments(ctx, g.cfg.GitHubOwner, g.cfg.GitHubRepo, changeRequestID, commentsOpts)
+			if err != nil {
+				return maybeRetryable(resp, fmt.Errorf("failed to list comments: %w", err))
+			}
+			nextPage = resp.NextPage
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+
+		for _, c := range comments {
+			result.Comments = append(result.Comments, &ChangeRequestComment{
+				Author: c.GetUser().GetLogin(),
+				Body:   c.GetBody(),
+				Edited: c.GetUpdatedAt().After(c.GetCreatedAt().Time),
+			})
+		}
+
+		if nextPage == 0 {
+			break
+		}
+		commentsOpts.Page = nextPage
+	}
+
+	return result, nil
+}
+
 // GitHubActorData defines the payload of the actor used for policy evaluation.
 type GitHubActorData struct {
 	Username    string   `json:"username"`
@@ -368,6 +488,7 @@ type GitHubActorData struct {
 type GitHubPolicyData struct {
 	PullRequestApprovers *GetLatestApproversResult `json:"pull_request_approvers"`
 	Actor                *GitHubActorData          `json:"actor"`
+	PullRequest          *ChangeRequestData        `json:"pull_request,omitempty"`
 }
 
 // GetPolicyData aggregates data from GitHub into a payload used for policy
@@ -393,22 +514,263 @@ func (g *GitHub) GetPolicyData(ctx context.Context) (*GetPolicyDataResult, error
 	}
 
 	var approvers *GetLatestApproversResult
+	var pullRequest *ChangeRequestData
 	// Skip, if the command is not running in the context of a pull request.
 	if g.cfg.GitHubPullRequestNumber > 0 {
 		approvers, err = g.GetLatestApprovers(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get latest approvers: %w", err)
 		}
+
+		pullRequest, err = g.pullRequestData(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pull request data: %w", err)
+		}
 	}
 
 	return &GetPolicyDataResult{
 		GitHub: &GitHubPolicyData{
 			PullRequestApprovers: approvers,
 			Actor:                actor,
+			PullRequest:          pullRequest,
 		},
 	}, nil
 }
 
+type graphqlPageInfo struct {
+	HasNextPage bool
+	EndCursor   githubv4.String
+}
+
+type pullRequestLabels struct {
+	Nodes []struct {
+		Name string
+	}
+	PageInfo graphqlPageInfo
+}
+
+type pullRequestCommits struct {
+	Nodes []struct {
+		Commit struct {
+			Oid           string
+			CommittedDate githubv4.DateTime
+			Author        struct {
+				Name string
+				User *struct {
+					Login string
+				}
+			}
+		}
+	}
+	PageInfo graphqlPageInfo
+}
+
+type pullRequestLatestReviews struct {
+	Nodes []struct {
+		State  string
+		Author struct {
+			Login string
+		}
+		Commit *struct {
+			Oid string
+		}
+	}
+	PageInfo graphqlPageInfo
+}
+
+type pullRequestFiles struct {
+	Nodes []struct {
+		Path string
+	}
+	PageInfo graphqlPageInfo
+}
+
+type pullRequestContextQuery struct {
+	Repository struct {
+		PullRequest struct {
+			Number      int
+			BaseRefName string
+			HeadRefName string
+			HeadRefOid  string
+			IsDraft     bool
+			Author      struct {
+				Login string
+			}
+			Labels        pullRequestLabels        `graphql:"labels(first: 100)"`
+			Commits       pullRequestCommits       `graphql:"commits(first: 100)"`
+			LatestReviews pullRequestLatestReviews `graphql:"latestReviews(first: 100)"`
+			Files         pullRequestFiles         `graphql:"files(first: 100)"`
+		} `graphql:"pullRequest(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type pullRequestLabelsQuery struct {
+	Repository struct {
+		PullRequest struct {
+			Labels pullRequestLabels `graphql:"labels(first: 100, after: $cursor)"`
+		} `graphql:"pullRequest(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type pullRequestCommitsQuery struct {
+	Repository struct {
+		PullRequest struct {
+			Commits pullRequestCommits `graphql:"commits(first: 100, after: $cursor)"`
+		} `graphql:"pullRequest(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type pullRequestLatestReviewsQuery struct {
+	Repository struct {
+		PullRequest struct {
+			LatestReviews pullRequestLatestReviews `graphql:"latestReviews(first: 100, after: $cursor)"`
+		} `graphql:"pullRequest(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type pullRequestFilesQuery struct {
+	Repository struct {
+		PullRequest struct {
+			Files pullRequestFiles `graphql:"files(first: 100, after: $cursor)"`
+		} `graphql:"pullRequest(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+// pullRequestData retrieves the pull request context used for policy
+// evaluation. The first page of every list is read with the pull request, the
+// following pages are only queried for the lists that have more.
+func (g *GitHub) pullRequestData(ctx context.Context) (*ChangeRequestData, error) {
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "querying pull request data")
+
+	var query pullRequestContextQuery
+	if err := g.queryPullRequest(ctx, &query, nil); err != nil {
+		return nil, err
+	}
+	pr := query.Repository.PullRequest
+
+	result := &ChangeRequestData{
+		Number:       pr.Number,
+		Author:       &ChangeRequestAuthor{Username: pr.Author.Login},
+		BaseBranch:   pr.BaseRefName,
+		HeadBranch:   pr.HeadRefName,
+		HeadSHA:      pr.HeadRefOid,
+		Draft:        pr.IsDraft,
+		Labels:       make([]string, 0, len(pr.Labels.Nodes)),
+		ChangedFiles: make([]string, 0, len(pr.Files.Nodes)),
+		Commits:      make([]*ChangeRequestCommit, 0, len(pr.Commits.Nodes)),
+	}
+
+	labels := pr.Labels
+	for {
+		for _, l := range labels.Nodes {
+			result.Labels = append(result.Labels, l.Name)
+		}
+		if !labels.PageInfo.HasNextPage {
+			break
+		}
+		var q pullRequestLabelsQuery
+		if err := g.queryPullRequest(ctx, &q, &labels.PageInfo.EndCursor); err != nil {
+			return nil, err
+		}
+		labels = q.Repository.PullRequest.Labels
+	}
+
+	commits := pr.Commits
+	for {
+		for _, c := range commits.Nodes {
+			commit := &ChangeRequestCommit{
+				SHA:         c.Commit.Oid,
+				AuthorName:  c.Commit.Author.Name,
+				CommittedAt: c.Commit.CommittedDate.UTC(),
+			}
+			if c.Commit.Author.User != nil {
+				commit.Author = c.Commit.Author.User.Login
+			}
+			result.Commits = append(result.Commits, commit)
+		}
+		if !commits.PageInfo.HasNextPage {
+			break
+		}
+		var q pullRequestCommitsQuery
+		if err := g.queryPullRequest(ctx, &q, &commits.PageInfo.EndCursor); err != nil {
+			return nil, err
+		}
+		commits = q.Repository.PullRequest.Commits
+	}
+
+	// reviews are given for the head commit at the time, so an approval for
+	// another commit was given before the latest push
+	stale := make([]string, 0)
+	reviews := pr.LatestReviews
+	for {
+		for _, r := range reviews.Nodes {
+			if r.State == "APPROVED" && r.Commit != nil && r.Commit.Oid != pr.HeadRefOid {
+				stale = append(stale, r.Author.Login)
+			}
+		}
+		if !reviews.PageInfo.HasNextPage {
+			break
+		}
+		var q pullRequestLatestReviewsQuery
+		if err := g.queryPullRequest(ctx, &q, &reviews.PageInfo.EndCursor); err != nil {
+			return nil, err
+		}
+		reviews = q.Repository.PullRequest.LatestReviews
+	}
+	predates := len(stale) > 0
+	result.StaleApprovers = stale
+	result.ApprovalsPredateLatestPush = &predates
+
+	files := pr.Files
+	for {
+		for _, f := range files.Nodes {
+			result.ChangedFiles = append(result.ChangedFiles, f.Path)
+		}
+		if !files.PageInfo.HasNextPage {
+			break
+		}
+		var q pullRequestFilesQuery
+		if err := g.queryPullRequest(ctx, &q, &files.PageInfo.EndCursor); err != nil {
+			return nil, err
+		}
+		files = q.Repository.PullRequest.Files
+	}
+
+	if g.cfg.IncludeTeams && result.Author.Username != "" {
+		teams, err := g.GetUserTeamMemberships(ctx, result.Author.Username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get author teams: %w", err)
+		}
+		result.Author.Teams = teams
+	}
+
+	return result, nil
+}
+
+// queryPullRequest runs a query for the pull request of the current workflow
+// run, with the cursor of the page to read if set.
+func (g *GitHub) queryPullRequest(ctx context.Context, query any, cursor *githubv4.String) error {
+	variables := map[string]any{
+		"owner":  githubv4.String(g.cfg.GitHubOwner),
+		"repo":   githubv4.String(g.cfg.GitHubRepo),
+		"number": githubv4.Int(g.cfg.GitHubPullRequestNumber),
+	}
+	if cursor != nil {
+		variables["cursor"] = cursor
+	}
+
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		if err := g.graphqlClient.Query(ctx, query, variables); err != nil {
+			return retry.RetryableError(fmt.Errorf("failed to query pull request: %w", err))
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to query pull request data: %w", err)
+	}
+	return nil
+}
+
 func (g *GitHub) withRetries(ctx context.Context, retryFunc retry.RetryFunc) error {
 	backoff := retry.NewFibonacci(g.cfg.InitialRetryDelay)
 	backoff = retry.WithMaxRetries(g.cfg.MaxRetries, backoff)
@@ -501,42 +863,316 @@ func (g *GitHub) StoragePrefix(ctx context.Context) (string, error) {
 
 // ReportStatus reports the status of a run.
 func (g *GitHub) ReportStatus(ctx context.Context, st Status, p *StatusParams) error {
+	if g.cfg.ReportMode == gh.ReportModeCheck {
+		return g.reportCheckRun(ctx, st, p)
+	}
+
 	if err := validateGitHubReporterInputs(g.cfg); err != nil {
 		return fmt.Errorf("failed to validate reporter inputs: %w", err)
 	}
 
 	// Look up PR number if not provided by flag or environment, else status
 	// cannot be reported.
-	if g.cfg.GitHubPullRequestNumber <= 0 {
-		result, err := g.ListChangeRequestsByCommit(ctx, g.cfg.GitHubSHA, nil)
+	if err := g.resolvePullRequestNumber(ctx); err != nil {
+		return err
+	}
+
+	msgs, err := statusMessageParts(st, p, g.logURL, githubMaxCommentLength)
+	if err != nil {
+		return fmt.Errorf("failed to generate status message: %w", err)
+	}
+
+	for _, msg := range msgs {
+		if err := g.createIssueComment(
+			ctx,
+			g.cfg.GitHubOwner,
+			g.cfg.GitHubRepo,
+			g.cfg.GitHubPullRequestNumber,
+			msg,
+		); err != nil {
+			return fmt.Errorf("failed to report: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UpsertStatus reports the status of a run, editing the previous status comment
+// for the same operation and entrypoint in place if one exists.
+func (g *GitHub) UpsertStatus(ctx context.Context, st Status, p *StatusParams) error {
+	if g.cfg.ReportMode == gh.ReportModeCheck {
+		return g.reportCheckRun(ctx, st, p)
+	}
+
+	if err := validateGitHubReporterInputs(g.cfg); err != nil {
+		return fmt.Errorf("failed to validate reporter inputs: %w", err)
+	}
+
+	if err := g.resolvePullRequestNumber(ctx); err != nil {
+		return err
+	}
+
+	previous, previousParts, err := g.findStatusReports(ctx, p)
+	if err != nil {
+		return fmt.Errorf("failed to find previous status: %w", err)
+	}
+
+	var previousBody string
+	if previous != nil {
+		previousBody = previous.Body
+	}
+
+	msgs, err := upsertStatusMessages(st, p, g.logURL, githubMaxCommentLength, previousBody)
+	if err != nil {
+		return fmt.Errorf("failed to generate status message: %w", err)
+	}
+
+	if err := upsertStatusReports(ctx, msgs, previous, previousParts, &statusReportOps{
+		create: func(ctx context.Context, body string) error {
+			return g.createIssueComment(ctx, g.cfg.GitHubOwner, g.cfg.GitHubRepo, g.cfg.GitHubPullRequestNumber, body)
+		},
+		edit: func(ctx context.Context, r *Report, body string) error {
+			commentID, ok := r.ID.(int64)
+			if !ok {
+				return fmt.Errorf("expected comment id of type int64")
+			}
+			return g.editIssueComment(ctx, g.cfg.GitHubOwner, g.cfg.GitHubRepo, commentID, body)
+		},
+		delete: func(ctx context.Context, r *Report) error {
+			return g.DeleteReport(ctx, r.ID)
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to report: %w", err)
+	}
+
+	return nil
+}
+
+// findStatusReports returns the most recent status comment for the operation
+// and entrypoint, or nil if there is none, and its continuation comments in
+// order.
+func (g *GitHub) findStatusReports(ctx context.Context, p *StatusParams) (*Report, []*Report, error) {
+	marker, partMarker := statusMarker(p), statusPartMarker(p)
+
+	listOpts := &ListReportsOptions{
+		GitHub: &github.IssueListCommentsOptions{
+			ListOptions: github.ListOptions{PerPage: 100},
+		},
+	}
+
+	var found *Report
+	var parts []*Report
+	for {
+		response, err := g.ListReports(ctx, g.cfg.GitHubPullRequestNumber, listOpts)
 		if err != nil {
-			return fmt.Errorf("failed to get pull request number for commit sha: %s", g.cfg.GitHubSHA)
+			return nil, nil, fmt.Errorf("failed to list comments: %w", err)
+		}
+
+		for _, comment := range response.Reports {
+			if !strings.HasPrefix(comment.Body, commentPrefix) {
+				continue
+			}
+			if strings.Contains(comment.Body, marker) {
+				found = comment
+			} else if strings.Contains(comment.Body, partMarker) {
+				parts = append(parts, comment)
+			}
 		}
 
-		if len(result.PullRequests) == 0 {
-			return fmt.Errorf("no pull requests found for commit sha: %s", g.cfg.GitHubSHA)
+		if response.Pagination == nil {
+			return found, parts, nil
 		}
-		g.cfg.GitHubPullRequestNumber = result.PullRequests[0].Number
+		listOpts.GitHub.Page = response.Pagination.NextPage
+	}
+}
+
+// resolvePullRequestNumber looks up the pull request number from the commit
+// SHA, if it was not provided by flag or environment.
+func (g *GitHub) resolvePullRequestNumber(ctx context.Context) error {
+	if g.cfg.GitHubPullRequestNumber > 0 {
+		return nil
 	}
 
-	msg, err := statusMessage(st, p, g.logURL, githubMaxCommentLength)
+	result, err := g.ListChangeRequestsByCommit(ctx, g.cfg.GitHubSHA, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get pull request number for commit sha: %s", g.cfg.GitHubSHA)
+	}
+
+	if len(result.PullRequests) == 0 {
+		return fmt.Errorf("no pull requests found for commit sha: %s", g.cfg.GitHubSHA)
+	}
+	g.cfg.GitHubPullRequestNumber = result.PullRequests[0].Number
+
+	return nil
+}
+
+// reportCheckRun reports the status of a run as a completed check run on the
+// head commit, named for the operation and entrypoint.
+func (g *GitHub) reportCheckRun(ctx context.Context, st Status, p *StatusParams) error {
+	if err := validateGitHubCheckRunInputs(g.cfg); err != nil {
+		return fmt.Errorf("failed to validate reporter inputs: %w", err)
+	}
+
+	headSHA := g.cfg.GitHubPullRequestSHA
+	if headSHA == "" {
+		headSHA = g.cfg.GitHubSHA
+	}
+
+	conclusion, ok := checkRunConclusions[st]
+	if !ok {
+		conclusion = checkRunConclusions[StatusUnknown]
+	}
+	// GitHub requires a details URL for checks that require action.
+	if conclusion == "action_required" && g.logURL == "" {
+		conclusion = "failure"
+	}
+
+	// the details are reported in the output text, which has its own length
+	// limit, instead of the summary.
+	summary, err := statusMessage(st, &StatusParams{
+		Dir:          p.Dir,
+		ErrorMessage: p.ErrorMessage,
+		Message:      p.Message,
+		Operation:    p.Operation,
+	}, g.logURL, githubMaxCheckRunTextLength)
 	if err != nil {
 		return fmt.Errorf("failed to generate status message: %w", err)
 	}
 
-	if err = g.createIssueComment(
-		ctx,
-		g.cfg.GitHubOwner,
-		g.cfg.GitHubRepo,
-		g.cfg.GitHubPullRequestNumber,
-		msg.String(),
-	); err != nil {
+	name := checkRunName(p)
+	output := &github.CheckRunOutput{
+		Title:   github.String(statusText[st]),
+		Summary: github.String(summary.String()),
+	}
+	if text := checkRunText(p); text != "" {
+		output.Text = github.String(text)
+	}
+
+	annotations := checkRunAnnotations(p.Annotations)
+	batch := annotations[:min(len(annotations), githubMaxCheckRunAnnotations)]
+	annotations = annotations[len(batch):]
+	output.Annotations = batch
+
+	opts := github.CreateCheckRunOptions{
+		Name:        name,
+		HeadSHA:     headSHA,
+		Status:      github.String("completed"),
+		Conclusion:  github.String(conclusion),
+		CompletedAt: &github.Timestamp{Time: time.Now().UTC()},
+		Output:      output,
+	}
+	if g.logURL != "" {
+		opts.DetailsURL = github.String(g.logURL)
+	}
+
+	var checkRunID int64
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		checkRun, resp, err := g.client.Checks.CreateCheckRun(ctx, g.cfg.GitHubOwner, g.cfg.GitHubRepo, opts)
+		if err != nil {
+			if resp != nil {
+				if _, ok := ignoredStatusCodes[resp.StatusCode]; !ok {
+					return retry.RetryableError(err)
+				}
+			}
+			return fmt.Errorf("failed to create check run: %w", err)
+		}
+		checkRunID = checkRun.GetID()
+		return nil
+	}); err != nil {
 		return fmt.Errorf("failed to report: %w", err)
 	}
 
+	// annotations beyond the per request limit are appended to the check run by
+	// updating it in batches.
+	for len(annotations) > 0 {
+		batch := annotations[:min(len(annotations), githubMaxCheckRunAnnotations)]
+		annotations = annotations[len(batch):]
+
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			_, resp, err := g.client.Checks.UpdateCheckRun(ctx, g.cfg.GitHubOwner, g.cfg.GitHubRepo, checkRunID, github.UpdateCheckRunOptions{
+				Name: name,
+				Output: &github.CheckRunOutput{
+					Title:       output.Title,
+					Summary:     output.Summary,
+					Annotations: batch,
+				},
+			})
+			if err != nil {
+				if resp != nil {
+					if _, ok := ignoredStatusCodes[resp.StatusCode]; !ok {
+						return retry.RetryableError(err)
+					}
+				}
+				return fmt.Errorf("failed to update check run: %w", err)
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to report annotations: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// checkRunName returns the name of the check run for an operation and
+// entrypoint.
+func checkRunName(p *StatusParams) string {
+	name := "guardian"
+	if op := strings.ToLower(strings.TrimSpace(p.Operation)); op != "" {
+		name = fmt.Sprintf("%s/%s", name, op)
+	}
+	if p.Dir != "" {
+		name = fmt.Sprintf("%s (%s)", name, p.Dir)
+	}
+	return name
+}
+
+// checkRunText returns the check run output text for the status details,
+// truncated to the maximum length allowed by GitHub.
+func checkRunText(p *StatusParams) string {
+	if p.Details == "" {
+		return ""
+	}
+
+	text := fmt.Sprintf("```\n%s\n```", p.Details)
+	if p.HasDiff {
+		text = fmt.Sprintf("```diff\n%s\n```", formatOutputForDiff(p.Details))
+	}
+
+	if runes := []rune(text); len(runes) > githubMaxCheckRunTextLength {
+		keep := githubMaxCheckRunTextLength - len([]rune(truncatedMessage)) - len("\n```")
+		text = string(runes[:keep]) + "\n```" + truncatedMessage
+	}
+	return text
+}
+
+// checkRunAnnotations converts the annotations to check run annotations.
+func checkRunAnnotations(annotations []*Annotation) []*github.CheckRunAnnotation {
+	res := make([]*github.CheckRunAnnotation, 0, len(annotations))
+	for _, a := range annotations {
+		startLine := max(a.StartLine, 1)
+		endLine := max(a.EndLine, startLine)
+
+		level := a.Level
+		if level == "" {
+			level = AnnotationLevelFailure
+		}
+
+		ca := &github.CheckRunAnnotation{
+			Path:            github.String(a.Path),
+			StartLine:       github.Int(startLine),
+			EndLine:         github.Int(endLine),
+			AnnotationLevel: github.String(string(level)),
+			Message:         github.String(a.Message),
+		}
+		if a.Title != "" {
+			ca.Title = github.String(a.Title)
+		}
+		res = append(res, ca)
+	}
+	return res
+}
+
 // ReportEntrypointsSummary implements the reporter EntrypointsSummary function by writing a GitHub comment.
 func (g *GitHub) ReportEntrypointsSummary(ctx context.Context, p *EntrypointsSummaryParams) error {
 	if err := validateGitHubReporterInputs(g.cfg); err != nil {
@@ -545,16 +1181,8 @@ func (g *GitHub) ReportEntrypointsSummary(ctx context.Context, p *EntrypointsSum
 
 	// Look up PR number if not provided by flag or environment, else status
 	// cannot be reported.
-	if g.cfg.GitHubPullRequestNumber <= 0 {
-		result, err := g.ListChangeRequestsByCommit(ctx, g.cfg.GitHubSHA, nil)
-		if err != nil {
-			return fmt.Errorf("failed to get pull request number for commit sha: %s", g.cfg.GitHubSHA)
-		}
-
-		if len(result.PullRequests) == 0 {
-			return fmt.Errorf("no pull requests found for commit sha: %s", g.cfg.GitHubSHA)
-		}
-		g.cfg.GitHubPullRequestNumber = result.PullRequests[0].Number
+	if err := g.resolvePullRequestNumber(ctx); err != nil {
+		return err
 	}
 
 	msg, err := entrypointsSummaryMessage(p, g.logURL)
@@ -705,6 +1333,28 @@ func (g *GitHub) createIssueComment(ctx context.Context, owner, repo string, num
 	return nil
 }
 
+// editIssueComment edits an existing comment on an issue or pull request.
+func (g *GitHub) editIssueComment(ctx context.Context, owner, repo string, id int64, body string) error {
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		_, resp, err := g.client.Issues.EditComment(ctx, owner, repo, id, &github.IssueComment{
+			Body: &body,
+		})
+		if err != nil {
+			if resp != nil {
+				if _, ok := ignoredStatusCodes[resp.StatusCode]; !ok {
+					return retry.RetryableError(err)
+				}
+			}
+			return fmt.Errorf("failed to edit pull-request/issue comment: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to edit pull-request/issue comment with retries: %w", err)
+	}
+
+	return nil
+}
+
 // validateGitHubReporterInputs validates the inputs required for reporting.
 func validateGitHubReporterInputs(cfg *gh.Config) error {
 	var merr error
@@ -723,6 +1373,25 @@ func validateGitHubReporterInputs(cfg *gh.Config) error {
 	return merr
 }
 
+// validateGitHubCheckRunInputs validates the inputs required for reporting
+// check runs.
+func validateGitHubCheckRunInputs(cfg *gh.Config) error {
+	var merr error
+	if cfg.GitHubOwner == "" {
+		merr = errors.Join(merr, fmt.Errorf("github owner is required"))
+	}
+
+	if cfg.GitHubRepo == "" {
+		merr = errors.Join(merr, fmt.Errorf("github repo is required"))
+	}
+
+	if cfg.GitHubPullRequestSHA == "" && cfg.GitHubSHA == "" {
+		merr = errors.Join(merr, fmt.Errorf("one of github pull request sha or github sha are required"))
+	}
+
+	return merr
+}
+
 func (g *GitHub) resolveJobLogsURL(ctx context.Context) (string, error) {
 	var jobs []*Job
 
@@ -799,9 +1468,10 @@ func (g *GitHub) ListJobs(ctx context.Context, runID int64) ([]*Job, error) {
 
 // PullRequest is a GitHub pull request.
 type PullRequest struct {
-	ID     int64
-	Number int
-	Body   string
+	ID      int64
+	Number  int
+	Body    string
+	HeadSHA string
 }
 
 // ListChangeRequestsByCommit lists the merge requests associated with a commit SHA.
@@ -821,7 +1491,7 @@ func (g *GitHub) ListChangeRequestsByCommit(ctx context.Context, sha string, opt
 		}
 
 		for _, c := range ghPullRequests {
-			pullRequests = append(pullRequests, &PullRequest{ID: c.GetID(), Number: c.GetNumber(), Body: c.GetBody()})
+			pullRequests = append(pullRequests, &PullRequest{ID: c.GetID(), Number: c.GetNumber(), Body: c.GetBody(), HeadSHA: c.GetHead().GetSHA()})
 		}
 
 		if resp.NextPage != 0 {
@@ -15,12 +15,19 @@
 package platform
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v53/github"
 	"github.com/shurcooL/githubv4"
 
 	gh "github.com/abcxyz/guardian/pkg/github"
@@ -182,3 +189,420 @@ func TestGitHub_GetUserTeamMemberships(t *testing.T) {
 		})
 	}
 }
+
+func TestGitHub_PullRequestData(t *testing.T) {
+	t.Parallel()
+
+	page := func(files, hasNext, cursor string) string {
+		return `{
+			"data": {
+				"repository": {
+					"pullRequest": {
+						"number": 12,
+						"baseRefName": "main",
+						"headRefName": "feature",
+						"headRefOid": "sha2",
+						"isDraft": true,
+						"author": {"login": "alice"},
+						"labels": {"nodes": [{"name": "infra"}, {"name": "guardian-exempt:deny_public"}]},
+						"commits": {
+							"nodes": [
+								{"commit": {"oid": "sha1", "committedDate": "2025-01-02T03:04:05Z", "author": {"name": "Alice", "user": {"login": "alice"}}}},
+								{"commit": {"oid": "sha2", "committedDate": "2025-01-03T03:04:05Z", "author": {"name": "Bot", "user": null}}}
+							]
+						},
+						"latestReviews": {
+							"nodes": [
+								{"state": "APPROVED", "author": {"login": "bob"}, "commit": {"oid": "sha1"}},
+								{"state": "APPROVED", "author": {"login": "carol"}, "commit": {"oid": "sha2"}},
+								{"state": "COMMENTED", "author": {"login": "dave"}, "commit": {"oid": "sha1"}}
+							]
+						},
+						"files": {
+							"nodes": [` + files + `],
+							"pageInfo": {"hasNextPage": ` + hasNext + `, "endCursor": "` + cursor + `"}
+						}
+					}
+				}
+			}
+		}`
+	}
+
+	// list returns a page of a single list of the pull request
+	list := func(name, nodes string) string {
+		return `{"data": {"repository": {"pullRequest": {"` + name + `": {
+			"nodes": [` + nodes + `],
+			"pageInfo": {"hasNextPage": false, "endCursor": "end"}
+		}}}}}`
+	}
+
+	cases := []struct {
+		name         string
+		mockResponse []string
+		want         *ChangeRequestData
+		wantErr      string
+	}{
+		{
+			name: "success_multi_page",
+			mockResponse: []string{
+				page(`{"path": "terraform/dev/main.tf"}`, "true", "cursor1"),
+				list("files", `{"path": "modules/bucket/main.tf"}`),
+			},
+			want: &ChangeRequestData{
+				Number:       12,
+				Author:       &ChangeRequestAuthor{Username: "alice"},
+				BaseBranch:   "main",
+				HeadBranch:   "feature",
+				HeadSHA:      "sha2",
+				Draft:        true,
+				Labels:       []string{"infra", "guardian-exempt:deny_public"},
+				ChangedFiles: []string{"terraform/dev/main.tf", "modules/bucket/main.tf"},
+				Commits: []*ChangeRequestCommit{
+					{
+						SHA:         "sha1",
+						Author:      "alice",
+						AuthorName:  "Alice",
+						CommittedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+					},
+					{
+						SHA:         "sha2",
+						AuthorName:  "Bot",
+						CommittedAt: time.Date(2025, 1, 3, 3, 4, 5, 0, time.UTC),
+					},
+				},
+				ApprovalsPredateLatestPush: github.Bool(true),
+				StaleApprovers:             []string{"bob"},
+			},
+		},
+		{
+			name: "paginates_lists",
+			mockResponse: []string{
+				`{
+					"data": {
+						"repository": {
+							"pullRequest": {
+								"number": 12,
+								"baseRefName": "main",
+								"headRefName": "feature",
+								"headRefOid": "sha2",
+								"author": {"login": "alice"},
+								"labels": {
+									"nodes": [{"name": "infra"}],
+									"pageInfo": {"hasNextPage": true, "endCursor": "labels1"}
+								},
+								"commits": {
+									"nodes": [{"commit": {"oid": "sha1", "committedDate": "2025-01-02T03:04:05Z", "author": {"name": "Alice", "user": {"login": "alice"}}}}],
+									"pageInfo": {"hasNextPage": true, "endCursor": "commits1"}
+								},
+								"latestReviews": {
+									"nodes": [{"state": "APPROVED", "author": {"login": "bob"}, "commit": {"oid": "sha2"}}],
+									"pageInfo": {"hasNextPage": true, "endCursor": "reviews1"}
+								},
+								"files": {
+									"nodes": [{"path": "terraform/dev/main.tf"}],
+									"pageInfo": {"hasNextPage": false, "endCursor": "files1"}
+								}
+							}
+						}
+					}
+				}`,
+				list("labels", `{"name": "guardian-exempt:deny_public"}`),
+				list("commits", `{"commit": {"oid": "sha2", "committedDate": "2025-01-03T03:04:05Z", "author": {"name": "Bot", "user": null}}}`),
+				list("latestReviews", `{"state": "APPROVED", "author": {"login": "carol"}, "commit": {"oid": "sha1"}}`),
+			},
+			want: &ChangeRequestData{
+				Number:       12,
+				Author:       &ChangeRequestAuthor{Username: "alice"},
+				BaseBranch:   "main",
+				HeadBranch:   "feature",
+				HeadSHA:      "sha2",
+				Labels:       []string{"infra", "guardian-exempt:deny_public"},
+				ChangedFiles: []string{"terraform/dev/main.tf"},
+				Commits: []*ChangeRequestCommit{
+					{
+						SHA:         "sha1",
+						Author:      "alice",
+						AuthorName:  "Alice",
+						CommittedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+					},
+					{
+						SHA:         "sha2",
+						AuthorName:  "Bot",
+						CommittedAt: time.Date(2025, 1, 3, 3, 4, 5, 0, time.UTC),
+					},
+				},
+				ApprovalsPredateLatestPush: github.Bool(true),
+				StaleApprovers:             []string{"carol"},
+			},
+		},
+		{
+			name:    "query_error",
+			wantErr: "failed to query pull request data",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var reqCount int
+			client := githubv4.NewClient(&http.Client{
+				Transport: roundTripperFunc(func(req *http.Request) *http.Response {
+					if reqCount >= len(tc.mockResponse) {
+						return &http.Response{
+							StatusCode: http.StatusInternalServerError,
+							Body:       io.NopCloser(strings.NewReader(`{"errors":[{"message":"unexpected request"}]}`)),
+						}
+					}
+					resp := tc.mockResponse[reqCount]
+					reqCount++
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(resp)),
+					}
+				}),
+			})
+
+			g := &GitHub{
+				cfg: &gh.Config{
+					GitHubOwner:             "abcxyz",
+					GitHubRepo:              "guardian",
+					GitHubPullRequestNumber: 12,
+					InitialRetryDelay:       time.Millisecond,
+					MaxRetryDelay:           time.Millisecond,
+				},
+				graphqlClient: client,
+			}
+
+			got, err := g.pullRequestData(t.Context())
+			if err != nil {
+				if tc.wantErr == "" {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err mismatch got: %v, want to contain: %s", err, tc.wantErr)
+				}
+			} else if tc.wantErr != "" {
+				t.Fatalf("expected error containing %q, got none", tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("got mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGitHub_GetChangeRequestEditors(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/owner/repo/issues/12/events", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "2" {
+			w.Header().Set("Link", `<`+"http://"+r.Host+r.URL.Path+`?page=2>; rel="next"`)
+			fmt.Fprint(w, `[
+				{"event": "labeled", "label": {"name": "guardian-exempt:iam"}, "actor": {"login": "bob"}},
+				{"event": "labeled", "label": {"name": "infra"}, "actor": {"login": "bob"}},
+				{"event": "unlabeled", "label": {"name": "guardian-exempt:iam"}, "actor": {"login": "bob"}}
+			]`)
+			return
+		}
+		fmt.Fprint(w, `[
+			{"event": "review_requested", "actor": {"login": "alice"}},
+			{"event": "labeled", "label": {"name": "guardian-exempt:iam"}, "actor": {"login": "carol"}}
+		]`)
+	})
+	mux.HandleFunc("GET /repos/owner/repo/issues/12/comments", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "2" {
+			w.Header().Set("Link", `<`+"http://"+r.Host+r.URL.Path+`?page=2>; rel="next"`)
+			fmt.Fprint(w, `[
+				{"body": "looks good", "user": {"login": "bob"}, "created_at": "2025-01-01T00:00:00Z", "updated_at": "2025-01-01T00:00:00Z"}
+			]`)
+			return
+		}
+		fmt.Fprint(w, `[
+			{"body": "GUARDIAN_EXEMPT=iam:incident", "user": {"login": "dave"}, "created_at": "2025-01-01T00:00:00Z", "updated_at": "2025-01-02T00:00:00Z"}
+		]`)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.BaseURL = baseURL
+
+	g := &GitHub{
+		cfg: &gh.Config{
+			MaxRetries:        1,
+			InitialRetryDelay: time.Millisecond,
+			MaxRetryDelay:     time.Millisecond,
+			GitHubOwner:       "owner",
+			GitHubRepo:        "repo",
+		},
+		client: client,
+	}
+
+	got, err := g.GetChangeRequestEditors(t.Context(), 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &ChangeRequestEditors{
+		Labels: map[string]string{
+			"guardian-exempt:iam": "carol",
+			"infra":               "bob",
+		},
+		Comments: []*ChangeRequestComment{
+			{Author: "bob", Body: "looks good"},
+			{Author: "dave", Body: "GUARDIAN_EXEMPT=iam:incident", Edited: true},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("editors not as expected; (-got,+want): %s", diff)
+	}
+}
+
+func TestGitHub_ReportStatusCheckRun(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var created *github.CreateCheckRunOptions
+	var updates []*github.UpdateCheckRunOptions
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/owner/repo/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": 42}`)
+	})
+	mux.HandleFunc("PATCH /repos/owner/repo/check-runs/42", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var opts *github.UpdateCheckRunOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		updates = append(updates, opts)
+		fmt.Fprint(w, `{"id": 42}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.BaseURL = baseURL
+
+	g := &GitHub{
+		cfg: &gh.Config{
+			MaxRetries:           1,
+			InitialRetryDelay:    time.Millisecond,
+			MaxRetryDelay:        time.Millisecond,
+			GitHubOwner:          "owner",
+			GitHubRepo:           "repo",
+			GitHubPullRequestSHA: "abc123",
+			ReportMode:           gh.ReportModeCheck,
+		},
+		client: client,
+	}
+
+	annotations := make([]*Annotation, 0, 60)
+	for i := range 60 {
+		annotations = append(annotations, &Annotation{Path: "terraform/main.tf", StartLine: i + 1, Message: "error"})
+	}
+
+	if err := g.ReportStatus(t.Context(), StatusPolicyViolation, &StatusParams{
+		Operation:   "plan",
+		Dir:         "terraform",
+		Details:     "+ resource",
+		HasDiff:     true,
+		Annotations: annotations,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := created.Name, "guardian/plan (terraform)"; got != want {
+		t.Errorf("expected check run name %q to be %q", got, want)
+	}
+	if got, want := created.HeadSHA, "abc123"; got != want {
+		t.Errorf("expected head sha %q to be %q", got, want)
+	}
+	if got, want := created.GetConclusion(), "failure"; got != want {
+		t.Errorf("expected conclusion without details url %q to be %q", got, want)
+	}
+	if got, want := created.Output.GetText(), "```diff\n+ resource\n```"; got != want {
+		t.Errorf("expected text %q to be %q", got, want)
+	}
+	if got, want := len(created.Output.Annotations), githubMaxCheckRunAnnotations; got != want {
+		t.Errorf("expected %d annotations on create, got %d", want, got)
+	}
+	if got, want := len(updates), 1; got != want {
+		t.Fatalf("expected %d updates, got %d", want, got)
+	}
+	if got, want := len(updates[0].Output.Annotations), 10; got != want {
+		t.Errorf("expected %d annotations on update, got %d", want, got)
+	}
+	if got, want := updates[0].Output.Annotations[0].GetStartLine(), 51; got != want {
+		t.Errorf("expected first updated annotation to start at line %d, got %d", want, got)
+	}
+
+	g.logURL = "https://github.com/owner/repo/actions/runs/1/attempts/1"
+	if err := g.ReportStatus(t.Context(), StatusPolicyViolation, &StatusParams{
+		Operation: "plan",
+		Dir:       "terraform",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := created.GetConclusion(), "action_required"; got != want {
+		t.Errorf("expected conclusion %q to be %q", got, want)
+	}
+	if got, want := created.GetDetailsURL(), g.logURL; got != want {
+		t.Errorf("expected details url %q to be %q", got, want)
+	}
+}
+
+func TestCheckRunAnnotations(t *testing.T) {
+	t.Parallel()
+
+	got := checkRunAnnotations([]*Annotation{
+		{Path: "main.tf", Message: "missing lines"},
+		{Path: "main.tf", StartLine: 5, EndLine: 7, Level: AnnotationLevelWarning, Title: "title", Message: "message"},
+	})
+
+	exp := []*github.CheckRunAnnotation{
+		{
+			Path:            github.String("main.tf"),
+			StartLine:       github.Int(1),
+			EndLine:         github.Int(1),
+			AnnotationLevel: github.String("failure"),
+			Message:         github.String("missing lines"),
+		},
+		{
+			Path:            github.String("main.tf"),
+			StartLine:       github.Int(5),
+			EndLine:         github.Int(7),
+			AnnotationLevel: github.String("warning"),
+			Message:         github.String("message"),
+			Title:           github.String("title"),
+		},
+	}
+	if diff := cmp.Diff(got, exp); diff != "" {
+		t.Errorf("annotations not as expected; (-got,+want): %s", diff)
+	}
+}
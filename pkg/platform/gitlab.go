@@ -18,7 +18,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -47,7 +51,9 @@ var (
 // Based on https://docs.gitlab.com/ee/administration/instance_limits.html#size-of-comments-and-descriptions-of-issues-merge-requests-and-epics.
 const gitlabMaxCommentLength = 1000000
 
-// TODO(gjonathanhong): Implement GitLab platform.
+// gitLabApprovalRulePrefix is the name prefix of merge request approval rules
+// created for group reviewers.
+const gitLabApprovalRulePrefix = "guardian: "
 
 // GitLab implements the Platform interface.
 type GitLab struct {
@@ -55,6 +61,9 @@ type GitLab struct {
 	client *gitlab.Client
 
 	logURL string
+
+	// groupPaths caches the full paths of groups by ID.
+	groupPaths map[int]string
 }
 
 type gitLabConfig struct {
@@ -66,8 +75,18 @@ type gitLabConfig struct {
 	GuardianGitLabToken string
 	GitLabBaseURL       string
 
-	GitLabProjectID       int
-	GitLabMergeRequestIID int
+	GitLabProjectID               int
+	GitLabMergeRequestIID         int
+	GitLabMergeRequestSHA         string
+	GitLabMergeRequestDescription string
+	GitLabMergeRequestLabels      []string
+	GitLabCommitSHA               string
+	GitLabPipelineID              int
+	GitLabPipelineSource          string
+	GitLabUserLogin               string
+
+	// Policy
+	IncludeGroups bool
 }
 
 type gitLabPredefinedConfig struct {
@@ -77,6 +96,15 @@ type gitLabPredefinedConfig struct {
 	// The merge request IID is the number used in the GitLab API, and not ID.
 	// See https://docs.gitlab.com/ee/ci/variables/predefined_variables.html.
 	CIMergeRequestIID int
+	// The merge request source branch SHA is only set for merged results
+	// pipelines, otherwise CI_COMMIT_SHA is the head of the source branch.
+	CIMergeRequestSourceBranchSHA string
+	CIMergeRequestDescription     string
+	CIMergeRequestLabels          []string
+	CICommitSHA                   string
+	CIPipelineID                  int
+	CIPipelineSource              string
+	GitLabUserLogin               string
 }
 
 // Load retrieves the predefined GitLab CI/CD variables from environment. See
@@ -97,6 +125,34 @@ func (c *gitLabPredefinedConfig) Load() {
 	if v, err := strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID")); err == nil {
 		c.CIMergeRequestIID = v
 	}
+
+	if v := os.Getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_SHA"); v != "" {
+		c.CIMergeRequestSourceBranchSHA = v
+	}
+
+	if v := os.Getenv("CI_MERGE_REQUEST_DESCRIPTION"); v != "" {
+		c.CIMergeRequestDescription = v
+	}
+
+	if v := os.Getenv("CI_MERGE_REQUEST_LABELS"); v != "" {
+		c.CIMergeRequestLabels = strings.Split(v, ",")
+	}
+
+	if v := os.Getenv("CI_COMMIT_SHA"); v != "" {
+		c.CICommitSHA = v
+	}
+
+	if v, err := strconv.Atoi(os.Getenv("CI_PIPELINE_ID")); err == nil {
+		c.CIPipelineID = v
+	}
+
+	if v := os.Getenv("CI_PIPELINE_SOURCE"); v != "" {
+		c.CIPipelineSource = v
+	}
+
+	if v := os.Getenv("GITLAB_USER_LOGIN"); v != "" {
+		c.GitLabUserLogin = v
+	}
 }
 
 func (c *gitLabConfig) RegisterFlags(set *cli.FlagSet) {
@@ -141,6 +197,78 @@ func (c *gitLabConfig) RegisterFlags(set *cli.FlagSet) {
 		Usage:   "The GitLab project-level merge request internal ID.",
 		Hidden:  true,
 	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "gitlab-merge-request-sha",
+		EnvVar:  "GITLAB_MERGE_REQUEST_SHA",
+		Target:  &c.GitLabMergeRequestSHA,
+		Default: cfgDefaults.CIMergeRequestSourceBranchSHA,
+		Usage:   "The head commit SHA of the GitLab merge request.",
+		Hidden:  true,
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "gitlab-merge-request-description",
+		EnvVar:  "GITLAB_MERGE_REQUEST_DESCRIPTION",
+		Target:  &c.GitLabMergeRequestDescription,
+		Default: cfgDefaults.CIMergeRequestDescription,
+		Usage:   "The description of the GitLab merge request.",
+		Hidden:  true,
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "gitlab-merge-request-labels",
+		EnvVar:  "GITLAB_MERGE_REQUEST_LABELS",
+		Target:  &c.GitLabMergeRequestLabels,
+		Default: cfgDefaults.CIMergeRequestLabels,
+		Usage:   "The labels of the GitLab merge request.",
+		Hidden:  true,
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "gitlab-commit-sha",
+		EnvVar:  "GITLAB_COMMIT_SHA",
+		Target:  &c.GitLabCommitSHA,
+		Default: cfgDefaults.CICommitSHA,
+		Usage:   "The commit SHA the GitLab pipeline was triggered for.",
+		Hidden:  true,
+	})
+
+	f.IntVar(&cli.IntVar{
+		Name:    "gitlab-pipeline-id",
+		EnvVar:  "GITLAB_PIPELINE_ID",
+		Target:  &c.GitLabPipelineID,
+		Default: cfgDefaults.CIPipelineID,
+		Usage:   "The GitLab pipeline ID.",
+		Hidden:  true,
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "gitlab-pipeline-source",
+		EnvVar:  "GITLAB_PIPELINE_SOURCE",
+		Target:  &c.GitLabPipelineSource,
+		Default: cfgDefaults.CIPipelineSource,
+		Example: "merge_request_event",
+		Usage:   "The event that triggered the GitLab pipeline.",
+		Hidden:  true,
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "gitlab-user-login",
+		EnvVar:  "GITLAB_USER_LOGIN",
+		Target:  &c.GitLabUserLogin,
+		Default: cfgDefaults.GitLabUserLogin,
+		Usage:   "The username of the GitLab user that triggered the pipeline.",
+		Hidden:  true,
+	})
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "include-groups",
+		Default: false,
+		Target:  &c.IncludeGroups,
+		Usage:   "If true, includes group data in payload. Requires an administrator token to read user memberships.",
+		Hidden:  true,
+	})
 }
 
 // NewGitLab creates a new GitLab client.
@@ -170,63 +298,706 @@ func NewGitLab(ctx context.Context, cfg *gitLabConfig) (*GitLab, error) {
 	}, nil
 }
 
-// AssignReviewers adds users to the reviewers list of a Merge Request.
+// AssignReviewers assigns a list of users and groups as reviewers of the target
+// Merge Request. Users are added to the merge request reviewers, while groups
+// are added as approval rules requiring an approval from a group member.
 func (g *GitLab) AssignReviewers(ctx context.Context, inputs *AssignReviewersInput) (*AssignReviewersResult, error) {
-	return &AssignReviewersResult{}, nil
+	logger := logging.FromContext(ctx)
+	if inputs == nil {
+		return nil, fmt.Errorf("inputs cannot be nil")
+	}
+
+	if err := g.resolveMergeRequestIID(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := validateGitLabReporterInputs(g.cfg); err != nil {
+		return nil, fmt.Errorf("failed to validate gitlab inputs: %w", err)
+	}
+
+	var result AssignReviewersResult
+	if len(inputs.Users) > 0 {
+		userIDs := make(map[string]int, len(inputs.Users))
+		for _, u := range inputs.Users {
+			id, err := g.userID(ctx, u)
+			if err != nil {
+				logger.ErrorContext(ctx, "failed to assign reviewer for merge request",
+					"user", u,
+					"error", err,
+				)
+				continue
+			}
+			userIDs[u] = id
+		}
+
+		if len(userIDs) > 0 {
+			if err := g.addMergeRequestReviewers(ctx, userIDs); err != nil {
+				logger.ErrorContext(ctx, "failed to assign reviewers for merge request",
+					"users", inputs.Users,
+					"error", err,
+				)
+			} else {
+				for _, u := range inputs.Users {
+					if _, ok := userIDs[u]; ok {
+						result.Users = append(result.Users, u)
+					}
+				}
+			}
+		}
+	}
+
+	for _, t := range inputs.Teams {
+		if err := g.createGroupApprovalRule(ctx, t); err != nil {
+			logger.ErrorContext(ctx, "failed to assign reviewer for merge request",
+				"group", t,
+				"error", err,
+			)
+			continue
+		}
+		result.Teams = append(result.Teams, t)
+	}
+
+	if len(result.Users) == 0 && len(result.Teams) == 0 {
+		return nil, fmt.Errorf("failed to assign all requested reviewers to merge request")
+	}
+
+	return &result, nil
+}
+
+// addMergeRequestReviewers adds users to the reviewers of the merge request.
+// The GitLab API replaces the full list of reviewers, so the existing reviewers
+// are retained.
+func (g *GitLab) addMergeRequestReviewers(ctx context.Context, userIDs map[string]int) error {
+	var mr *gitlab.MergeRequest
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		var resp *gitlab.Response
+		var err error
+		mr, resp, err = g.client.MergeRequests.GetMergeRequest(g.cfg.GitLabProjectID, g.cfg.GitLabMergeRequestIID, nil, gitlab.WithContext(ctx))
+		if err != nil {
+			return maybeGitLabRetryable(resp, fmt.Errorf("failed to get merge request: %w", err))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	reviewerIDs := make([]int, 0, len(mr.Reviewers)+len(userIDs))
+	seen := make(map[int]struct{}, cap(reviewerIDs))
+	for _, r := range mr.Reviewers {
+		reviewerIDs = append(reviewerIDs, r.ID)
+		seen[r.ID] = struct{}{}
+	}
+	for _, id := range userIDs {
+		if _, ok := seen[id]; !ok {
+			reviewerIDs = append(reviewerIDs, id)
+			seen[id] = struct{}{}
+		}
+	}
+	sort.Ints(reviewerIDs)
+
+	return g.withRetries(ctx, func(ctx context.Context) error {
+		if _, resp, err := g.client.MergeRequests.UpdateMergeRequest(g.cfg.GitLabProjectID, g.cfg.GitLabMergeRequestIID, &gitlab.UpdateMergeRequestOptions{
+			ReviewerIDs: &reviewerIDs,
+		}, gitlab.WithContext(ctx)); err != nil {
+			return maybeGitLabRetryable(resp, fmt.Errorf("failed to update merge request reviewers: %w", err))
+		}
+		return nil
+	})
+}
+
+// createGroupApprovalRule creates a merge request approval rule that requires
+// an approval from a member of the group.
+func (g *GitLab) createGroupApprovalRule(ctx context.Context, groupPath string) error {
+	var group *gitlab.Group
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		var resp *gitlab.Response
+		var err error
+		group, resp, err = g.client.Groups.GetGroup(groupPath, nil, gitlab.WithContext(ctx))
+		if err != nil {
+			return maybeGitLabRetryable(resp, fmt.Errorf("failed to get group %s: %w", groupPath, err))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return g.withRetries(ctx, func(ctx context.Context) error {
+		if _, resp, err := g.client.MergeRequestApprovals.CreateApprovalRule(g.cfg.GitLabProjectID, g.cfg.GitLabMergeRequestIID, &gitlab.CreateMergeRequestApprovalRuleOptions{
+			Name:              gitlab.Ptr(gitLabApprovalRulePrefix + groupPath),
+			ApprovalsRequired: gitlab.Ptr(1),
+			GroupIDs:          &[]int{group.ID},
+		}, gitlab.WithContext(ctx)); err != nil {
+			return maybeGitLabRetryable(resp, fmt.Errorf("failed to create approval rule: %w", err))
+		}
+		return nil
+	})
 }
 
-// GetLatestApprovers retrieves the reviewers whose latest review is an
-// approval.
+// GetLatestApprovers retrieves the users that have approved the merge request.
+// When groups are included, it also returns the groups that the approvers are
+// members of to indicate approval on behalf of those groups. GitLab resets
+// approvals when new commits are pushed, depending on the project settings.
 func (g *GitLab) GetLatestApprovers(ctx context.Context) (*GetLatestApproversResult, error) {
-	return &GetLatestApproversResult{}, nil
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "querying merge request approvals")
+
+	if err := g.resolveMergeRequestIID(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := validateGitLabReporterInputs(g.cfg); err != nil {
+		return nil, fmt.Errorf("failed to validate gitlab inputs: %w", err)
+	}
+
+	var approvals *gitlab.MergeRequestApprovals
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		var resp *gitlab.Response
+		var err error
+		approvals, resp, err = g.client.MergeRequestApprovals.GetConfiguration(g.cfg.GitLabProjectID, g.cfg.GitLabMergeRequestIID, gitlab.WithContext(ctx))
+		if err != nil {
+			return maybeGitLabRetryable(resp, fmt.Errorf("failed to get merge request approvals: %w", err))
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to query latest approvers: %w", err)
+	}
+
+	// Explicitly sets the default Users to an empty slice. If these are not
+	// explicitly provided to OPA, then the policy result may be incorrect.
+	result := &GetLatestApproversResult{
+		Users: []string{},
+	}
+	for _, a := range approvals.ApprovedBy {
+		if a.User == nil {
+			continue
+		}
+		result.Users = append(result.Users, a.User.Username)
+	}
+
+	logger.DebugContext(ctx, "found latest approvers from",
+		"users", result.Users,
+	)
+	if !g.cfg.IncludeGroups {
+		logger.DebugContext(ctx, "skipped fetching group approvers")
+		return result, nil
+	}
+
+	found := make(map[string]struct{})
+	for _, username := range result.Users {
+		groups, err := g.GetUserTeamMemberships(ctx, username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get group memberships for approvers: %w", err)
+		}
+		for _, grp := range groups {
+			if _, ok := found[grp]; !ok {
+				result.Teams = append(result.Teams, grp)
+				found[grp] = struct{}{}
+			}
+		}
+	}
+	logger.DebugContext(ctx, "found latest approvers from",
+		"groups", result.Teams,
+	)
+
+	return result, nil
 }
 
-// GetUserRepoPermissions returns a user's access level to a GitLab project.
+// GetUserRepoPermissions returns the project access level for the user that
+// triggered the pipeline, including access inherited from parent groups.
 func (g *GitLab) GetUserRepoPermissions(ctx context.Context) (string, error) {
-	return "", nil
+	if g.cfg.GitLabUserLogin == "" {
+		return "", fmt.Errorf("gitlab-user-login is required")
+	}
+	return g.GetRepoPermissionsForUser(ctx, g.cfg.GitLabUserLogin)
 }
 
-// GetUserTeamMemberships retrieves a list of groups that a user is a
-// member of.
+// GetRepoPermissionsForUser returns the project access level for the given
+// user, including access inherited from parent groups.
+func (g *GitLab) GetRepoPermissionsForUser(ctx context.Context, username string) (string, error) {
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "querying user project permissions",
+		"username", username)
+
+	id, err := g.userID(ctx, username)
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	return result, g.withRetries(ctx, func(ctx context.Context) error {
+		member, resp, err := g.client.ProjectMembers.GetInheritedProjectMember(g.cfg.GitLabProjectID, id, gitlab.WithContext(ctx))
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				result = gitLabAccessLevelName(gitlab.NoPermissions)
+				return nil
+			}
+			return maybeGitLabRetryable(resp, fmt.Errorf("failed to get user project permissions: %w", err))
+		}
+		result = gitLabAccessLevelName(member.AccessLevel)
+		return nil
+	})
+}
+
+// GetChangeRequestEditors retrieves the users that last added the labels of a
+// merge request from the resource label events, and the notes of the merge
+// request that were not created by GitLab.
+func (g *GitLab) GetChangeRequestEditors(ctx context.Context, changeRequestID int) (*ChangeRequestEditors, error) {
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "querying merge request editors",
+		"merge_request_iid", changeRequestID)
+
+	result := &ChangeRequestEditors{
+		Labels: make(map[string]string),
+	}
+
+	notesOpts := &gitlab.ListMergeRequestNotesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+		OrderBy:     gitlab.Ptr("created_at"),
+		Sort:        gitlab.Ptr("asc"),
+	}
+	for {
+		var notes []*gitlab.Note
+		var nextPage int
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			var resp *gitlab.Response
+			var err error
+			notes, resp, err = g.client.Notes.ListMergeRequestNotes(g.cfg.GitLabProjectID, changeRequestID, notesOpts, gitlab.WithContext(ctx))
+			if err != nil {
+				return maybeGitLabRetryable(resp, fmt.Errorf("failed to list merge request notes: %w", err))
+			}
+			nextPage = resp.NextPage
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to query merge request notes: %w", err)
+		}
+
+		for _, n := range notes {
+			if n.System {
+				continue
+			}
+			result.Comments = append(result.Comments, &ChangeRequestComment{
+				Author: n.Author.Username,
+				Body:   n.Body,
+				Edited: n.CreatedAt != nil && n.UpdatedAt != nil && n.UpdatedAt.After(*n.CreatedAt),
+			})
+		}
+
+		if nextPage == 0 {
+			break
+		}
+		notesOpts.Page = nextPage
+	}
+
+	eventsOpts := &gitlab.ListLabelEventsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+	}
+	for {
+		var events []*gitlab.LabelEvent
+		var nextPage int
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			var resp *gitlab.Response
+			var err error
+			events, resp, err = g.client.ResourceLabelEvents.ListMergeRequestsLabelEvents(g.cfg.GitLabProjectID, changeRequestID, eventsOpts, gitlab.WithContext(ctx))
+			if err != nil {
+				return maybeGitLabRetryable(resp, fmt.Errorf("failed to list merge request label events: %w", err))
+			}
+			nextPage = resp.NextPage
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to query merge request label events: %w", err)
+		}
+
+		// events are listed oldest first, so the last event for a label wins
+		for _, e := range events {
+			switch e.Action {
+			case "add":
+				result.Labels[e.Label.Name] = e.User.Username
+			case "remove":
+				delete(result.Labels, e.Label.Name)
+			}
+		}
+
+		if nextPage == 0 {
+			break
+		}
+		eventsOpts.Page = nextPage
+	}
+
+	return result, nil
+}
+
+// GetUserTeamMemberships returns the full paths of the groups that a user is a
+// member of, e.g. parent/group. Reading the memberships of another user
+// requires an administrator token.
 func (g *GitLab) GetUserTeamMemberships(ctx context.Context, username string) ([]string, error) {
-	return []string{}, nil
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "querying user group memberships",
+		"user", username)
+
+	if username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+
+	id, err := g.userID(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &gitlab.GetUserMembershipOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+		Type:        gitlab.Ptr("Namespace"),
+	}
+
+	res := make([]string, 0)
+	for {
+		var memberships []*gitlab.UserMembership
+		var nextPage int
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			var resp *gitlab.Response
+			var err error
+			memberships, resp, err = g.client.Users.GetUserMemberships(id, opts, gitlab.WithContext(ctx))
+			if err != nil {
+				return maybeGitLabRetryable(resp, fmt.Errorf("failed to get user memberships: %w", err))
+			}
+			nextPage = resp.NextPage
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to query user group memberships: %w", err)
+		}
+
+		for _, m := range memberships {
+			fullPath, err := g.groupFullPath(ctx, m.SourceID)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, fullPath)
+		}
+
+		if nextPage == 0 {
+			break
+		}
+		opts.Page = nextPage
+	}
+
+	return res, nil
+}
+
+// groupFullPath returns the full path of a group, memberships only report the
+// group name.
+func (g *GitLab) groupFullPath(ctx context.Context, id int) (string, error) {
+	if fullPath, ok := g.groupPaths[id]; ok {
+		return fullPath, nil
+	}
+
+	var group *gitlab.Group
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		var resp *gitlab.Response
+		var err error
+		group, resp, err = g.client.Groups.GetGroup(id, nil, gitlab.WithContext(ctx))
+		if err != nil {
+			return maybeGitLabRetryable(resp, fmt.Errorf("failed to get group %d: %w", id, err))
+		}
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	if g.groupPaths == nil {
+		g.groupPaths = make(map[int]string)
+	}
+	g.groupPaths[id] = group.FullPath
+	return group.FullPath, nil
+}
+
+// GitLabActorData defines the payload of the actor used for policy evaluation.
+type GitLabActorData struct {
+	Username    string   `json:"username"`
+	AccessLevel string   `json:"access_level"`
+	Groups      []string `json:"groups,omitempty"`
 }
 
-// GetPolicyData retrieves the required data for policy evaluation.
+// GitLabPolicyData defines the payload of GitLab contextual data used for
+// policy evaluation.
+type GitLabPolicyData struct {
+	MergeRequestApprovers *GetLatestApproversResult `json:"merge_request_approvers"`
+	Actor                 *GitLabActorData          `json:"actor"`
+	MergeRequest          *ChangeRequestData        `json:"merge_request,omitempty"`
+}
+
+// GetPolicyData aggregates data from GitLab into a payload used for policy
+// evaluation.
 func (g *GitLab) GetPolicyData(ctx context.Context) (*GetPolicyDataResult, error) {
-	return &GetPolicyDataResult{}, nil
+	p, err := g.GetUserRepoPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user project permissions: %w", err)
+	}
+
+	var actorGroups []string
+	if g.cfg.IncludeGroups {
+		actorGroups, err = g.GetUserTeamMemberships(ctx, g.cfg.GitLabUserLogin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user groups: %w", err)
+		}
+	}
+
+	actor := &GitLabActorData{
+		Username:    g.cfg.GitLabUserLogin,
+		AccessLevel: p,
+		Groups:      actorGroups,
+	}
+
+	var approvers *GetLatestApproversResult
+	var mergeRequest *ChangeRequestData
+	// Skip, if the command is not running in the context of a merge request.
+	if g.cfg.GitLabMergeRequestIID > 0 {
+		approvers, err = g.GetLatestApprovers(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest approvers: %w", err)
+		}
+
+		mergeRequest, err = g.mergeRequestData(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get merge request data: %w", err)
+		}
+	}
+
+	return &GetPolicyDataResult{
+		GitLab: &GitLabPolicyData{
+			MergeRequestApprovers: approvers,
+			Actor:                 actor,
+			MergeRequest:          mergeRequest,
+		},
+	}, nil
+}
+
+// mergeRequestData retrieves the merge request context used for policy
+// evaluation. GitLab resets approvals on push depending on the project
+// settings, so whether approvals predate the latest push is left unset.
+func (g *GitLab) mergeRequestData(ctx context.Context) (*ChangeRequestData, error) {
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "querying merge request data")
+
+	var mr *gitlab.MergeRequest
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		var resp *gitlab.Response
+		var err error
+		mr, resp, err = g.client.MergeRequests.GetMergeRequest(g.cfg.GitLabProjectID, g.cfg.GitLabMergeRequestIID, nil, gitlab.WithContext(ctx))
+		if err != nil {
+			return maybeGitLabRetryable(resp, fmt.Errorf("failed to get merge request: %w", err))
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to query merge request: %w", err)
+	}
+
+	result := &ChangeRequestData{
+		Number:     mr.IID,
+		Author:     &ChangeRequestAuthor{},
+		BaseBranch: mr.TargetBranch,
+		HeadBranch: mr.SourceBranch,
+		HeadSHA:    mr.SHA,
+		Draft:      mr.Draft,
+		Labels:     append([]string{}, mr.Labels...),
+	}
+	if mr.Author != nil {
+		result.Author.Username = mr.Author.Username
+	}
+
+	files, err := g.mergeRequestChangedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.ChangedFiles = files
+
+	commits, err := g.mergeRequestCommits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Commits = commits
+
+	if g.cfg.IncludeGroups && result.Author.Username != "" {
+		groups, err := g.GetUserTeamMemberships(ctx, result.Author.Username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get author groups: %w", err)
+		}
+		result.Author.Teams = groups
+	}
+
+	return result, nil
 }
 
-// StoragePrefix generates the unique storage prefix for the GitLab platform type.
+// mergeRequestChangedFiles returns the old and new paths of the files changed
+// in the merge request.
+func (g *GitLab) mergeRequestChangedFiles(ctx context.Context) ([]string, error) {
+	opts := &gitlab.ListMergeRequestDiffsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+	}
+
+	res := make([]string, 0)
+	seen := make(map[string]struct{})
+	for {
+		var diffs []*gitlab.MergeRequestDiff
+		var nextPage int
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			var resp *gitlab.Response
+			var err error
+			diffs, resp, err = g.client.MergeRequests.ListMergeRequestDiffs(g.cfg.GitLabProjectID, g.cfg.GitLabMergeRequestIID, opts, gitlab.WithContext(ctx))
+			if err != nil {
+				return maybeGitLabRetryable(resp, fmt.Errorf("failed to list merge request diffs: %w", err))
+			}
+			nextPage = resp.NextPage
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to query merge request changed files: %w", err)
+		}
+
+		for _, d := range diffs {
+			for _, p := range []string{d.NewPath, d.OldPath} {
+				if _, ok := seen[p]; p == "" || ok {
+					continue
+				}
+				seen[p] = struct{}{}
+				res = append(res, p)
+			}
+		}
+
+		if nextPage == 0 {
+			break
+		}
+		opts.Page = nextPage
+	}
+
+	return res, nil
+}
+
+// mergeRequestCommits returns the commits of the merge request. GitLab only
+// reports the git author name of a commit, so the platform username is empty.
+func (g *GitLab) mergeRequestCommits(ctx context.Context) ([]*ChangeRequestCommit, error) {
+	opts := &gitlab.GetMergeRequestCommitsOptions{PerPage: 100}
+
+	res := make([]*ChangeRequestCommit, 0)
+	for {
+		var commits []*gitlab.Commit
+		var nextPage int
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			var resp *gitlab.Response
+			var err error
+			commits, resp, err = g.client.MergeRequests.GetMergeRequestCommits(g.cfg.GitLabProjectID, g.cfg.GitLabMergeRequestIID, opts, gitlab.WithContext(ctx))
+			if err != nil {
+				return maybeGitLabRetryable(resp, fmt.Errorf("failed to get merge request commits: %w", err))
+			}
+			nextPage = resp.NextPage
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to query merge request commits: %w", err)
+		}
+
+		for _, c := range commits {
+			commit := &ChangeRequestCommit{
+				SHA:        c.ID,
+				AuthorName: c.AuthorName,
+			}
+			if c.CommittedDate != nil {
+				commit.CommittedAt = c.CommittedDate.UTC()
+			}
+			res = append(res, commit)
+		}
+
+		if nextPage == 0 {
+			break
+		}
+		opts.Page = nextPage
+	}
+
+	// GitLab lists the commits newest first.
+	slices.Reverse(res)
+
+	return res, nil
+}
+
+// StoragePrefix generates the unique storage prefix for the GitLab platform
+// type. For push pipelines, the merge request is looked up from the commit SHA.
 func (g *GitLab) StoragePrefix(ctx context.Context) (string, error) {
+	logger := logging.FromContext(ctx)
+
+	if g.cfg.GitLabPipelineSource == "merge_request_event" {
+		var merr error
+		if g.cfg.GitLabProjectID <= 0 {
+			merr = errors.Join(merr, fmt.Errorf("gitlab project id is required for storage"))
+		}
+		if g.cfg.GitLabMergeRequestIID <= 0 {
+			merr = errors.Join(merr, fmt.Errorf("gitlab merge request iid is required for storage"))
+		}
+
+		if merr != nil {
+			return "", merr
+		}
+
+		logger.DebugContext(ctx, "storage prefix from merge request event data",
+			"gitlab_merge_request_iid", g.cfg.GitLabMergeRequestIID)
+
+		return fmt.Sprintf("guardian-plans/%d/%d", g.cfg.GitLabProjectID, g.cfg.GitLabMergeRequestIID), nil
+	}
+
+	if g.cfg.GitLabPipelineSource == "push" {
+		logger.DebugContext(ctx, "looking up merge request from commit sha",
+			"project", g.cfg.GitLabProjectID,
+			"commit_sha", g.cfg.GitLabCommitSHA)
+
+		resp, err := g.ListChangeRequestsByCommit(ctx, g.cfg.GitLabCommitSHA, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to list merge requests: %w", err)
+		}
+
+		if len(resp.PullRequests) == 0 {
+			return "", fmt.Errorf("no merge requests found for commit sha: %s", g.cfg.GitLabCommitSHA)
+		}
+
+		iid := resp.PullRequests[0].Number
+		logger.DebugContext(ctx, "computed merge request iid from commit sha",
+			"gitlab_merge_request_iid", iid)
+
+		return fmt.Sprintf("guardian-plans/%d/%d", g.cfg.GitLabProjectID, iid), nil
+	}
+
+	logger.DebugContext(ctx, "returning no storage prefix")
 	return "", nil
 }
 
+// gitLabNoteID identifies a note on a merge request. Notes can only be deleted
+// through the merge request they belong to, which may differ from the merge
+// request of the current pipeline, e.g. when reporting on push pipelines.
+type gitLabNoteID struct {
+	MergeRequestIID int
+	NoteID          int
+}
+
 // ListReports lists existing reports for an issue or change request.
 func (g *GitLab) ListReports(ctx context.Context, changeRequestID int, opts *ListReportsOptions) (*ListReportsResult, error) {
-	if err := validateGitLabReporterInputs(g.cfg); err != nil {
-		return nil, fmt.Errorf("failed to validate reporter inputs: %w", err)
-	}
-
 	mrIID := changeRequestID
 	if mrIID <= 0 {
 		mrIID = g.cfg.GitLabMergeRequestIID
 	}
 
+	if err := validateGitLabMergeRequestInputs(g.cfg, mrIID); err != nil {
+		return nil, fmt.Errorf("failed to validate reporter inputs: %w", err)
+	}
+
+	var listOpts *gitlab.ListMergeRequestNotesOptions
+	if opts != nil {
+		listOpts = opts.GitLab
+	}
+
 	var reports []*Report
 	var pagination *Pagination
 
 	if err := g.withRetries(ctx, func(ctx context.Context) error {
-		notes, resp, err := g.client.Notes.ListMergeRequestNotes(g.cfg.GitLabProjectID, mrIID, opts.GitLab)
+		notes, resp, err := g.client.Notes.ListMergeRequestNotes(g.cfg.GitLabProjectID, mrIID, listOpts, gitlab.WithContext(ctx))
 		if err != nil {
-			if resp != nil {
-				if _, ok := gitLabIgnoredStatusCodes[resp.StatusCode]; !ok {
-					return retry.RetryableError(err)
-				}
-			}
+			return maybeGitLabRetryable(resp, fmt.Errorf("failed to list merge request notes: %w", err))
 		}
 		for _, n := range notes {
-			reports = append(reports, &Report{ID: n.ID, Body: n.Body})
+			reports = append(reports, &Report{ID: gitLabNoteID{MergeRequestIID: mrIID, NoteID: n.ID}, Body: n.Body})
 		}
 
 		if resp.NextPage != 0 {
@@ -243,24 +1014,24 @@ func (g *GitLab) ListReports(ctx context.Context, changeRequestID int, opts *Lis
 
 // DeleteReport deletes an existing comment from an issue or change request.
 func (g *GitLab) DeleteReport(ctx context.Context, id any) error {
-	if err := validateGitLabReporterInputs(g.cfg); err != nil {
-		return fmt.Errorf("failed to validate reporter inputs: %w", err)
+	var noteID gitLabNoteID
+	switch v := id.(type) {
+	case gitLabNoteID:
+		noteID = v
+	case int:
+		noteID = gitLabNoteID{MergeRequestIID: g.cfg.GitLabMergeRequestIID, NoteID: v}
+	default:
+		return fmt.Errorf("expected note id of type int")
 	}
 
-	noteID, ok := id.(int)
-	if !ok {
-		return fmt.Errorf("expected note id of type int")
+	if err := validateGitLabMergeRequestInputs(g.cfg, noteID.MergeRequestIID); err != nil {
+		return fmt.Errorf("failed to validate reporter inputs: %w", err)
 	}
 
 	if err := g.withRetries(ctx, func(ctx context.Context) error {
-		resp, err := g.client.Notes.DeleteMergeRequestNote(g.cfg.GitLabProjectID, g.cfg.GitLabMergeRequestIID, noteID)
+		resp, err := g.client.Notes.DeleteMergeRequestNote(g.cfg.GitLabProjectID, noteID.MergeRequestIID, noteID.NoteID, gitlab.WithContext(ctx))
 		if err != nil {
-			if resp != nil {
-				if _, ok := gitLabIgnoredStatusCodes[resp.StatusCode]; !ok {
-					return retry.RetryableError(err)
-				}
-			}
-			return fmt.Errorf("failed to delete merge request note: %w", err)
+			return maybeGitLabRetryable(resp, fmt.Errorf("failed to delete merge request note: %w", err))
 		}
 
 		return nil
@@ -273,23 +1044,125 @@ func (g *GitLab) DeleteReport(ctx context.Context, id any) error {
 
 // ReportStatus reports the status of a run.
 func (g *GitLab) ReportStatus(ctx context.Context, st Status, p *StatusParams) error {
+	if err := g.resolveMergeRequestIID(ctx); err != nil {
+		return err
+	}
+
 	if err := validateGitLabReporterInputs(g.cfg); err != nil {
 		return fmt.Errorf("failed to validate GitLab reporter inputs: %w", err)
 	}
 
-	msg, err := statusMessage(st, p, g.logURL, gitlabMaxCommentLength)
+	msgs, err := statusMessageParts(st, p, g.logURL, gitlabMaxCommentLength)
 	if err != nil {
 		return fmt.Errorf("failed to generate status message: %w", err)
 	}
 
-	if err := g.createMergeRequestNote(ctx, msg.String()); err != nil {
+	for _, msg := range msgs {
+		if err := g.createMergeRequestNote(ctx, msg); err != nil {
+			return fmt.Errorf("failed to report status: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpsertStatus reports the status of a run, editing the previous status note
+// for the same operation and entrypoint in place if one exists.
+func (g *GitLab) UpsertStatus(ctx context.Context, st Status, p *StatusParams) error {
+	if err := g.resolveMergeRequestIID(ctx); err != nil {
+		return err
+	}
+
+	if err := validateGitLabReporterInputs(g.cfg); err != nil {
+		return fmt.Errorf("failed to validate GitLab reporter inputs: %w", err)
+	}
+
+	previous, previousParts, err := g.findStatusReports(ctx, p)
+	if err != nil {
+		return fmt.Errorf("failed to find previous status: %w", err)
+	}
+
+	var previousBody string
+	if previous != nil {
+		previousBody = previous.Body
+	}
+
+	msgs, err := upsertStatusMessages(st, p, g.logURL, gitlabMaxCommentLength, previousBody)
+	if err != nil {
+		return fmt.Errorf("failed to generate status message: %w", err)
+	}
+
+	if err := upsertStatusReports(ctx, msgs, previous, previousParts, &statusReportOps{
+		create: g.createMergeRequestNote,
+		edit: func(ctx context.Context, r *Report, body string) error {
+			noteID, ok := r.ID.(gitLabNoteID)
+			if !ok {
+				return fmt.Errorf("expected note id of type gitLabNoteID")
+			}
+			return g.withRetries(ctx, func(ctx context.Context) error {
+				_, resp, err := g.client.Notes.UpdateMergeRequestNote(g.cfg.GitLabProjectID, noteID.MergeRequestIID, noteID.NoteID, &gitlab.UpdateMergeRequestNoteOptions{
+					Body: &body,
+				}, gitlab.WithContext(ctx))
+				if err != nil {
+					return maybeGitLabRetryable(resp, fmt.Errorf("failed to update merge request note: %w", err))
+				}
+				return nil
+			})
+		},
+		delete: func(ctx context.Context, r *Report) error {
+			return g.DeleteReport(ctx, r.ID)
+		},
+	}); err != nil {
 		return fmt.Errorf("failed to report status: %w", err)
 	}
+
 	return nil
 }
 
+// findStatusReports returns the most recent status note for the operation and
+// entrypoint, or nil if there is none, and its continuation notes in order.
+func (g *GitLab) findStatusReports(ctx context.Context, p *StatusParams) (*Report, []*Report, error) {
+	marker, partMarker := statusMarker(p), statusPartMarker(p)
+
+	listOpts := &ListReportsOptions{
+		GitLab: &gitlab.ListMergeRequestNotesOptions{
+			ListOptions: gitlab.ListOptions{PerPage: 100},
+			OrderBy:     gitlab.Ptr("created_at"),
+			Sort:        gitlab.Ptr("asc"),
+		},
+	}
+
+	var found *Report
+	var parts []*Report
+	for {
+		response, err := g.ListReports(ctx, g.cfg.GitLabMergeRequestIID, listOpts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list comments: %w", err)
+		}
+
+		for _, note := range response.Reports {
+			if !strings.HasPrefix(note.Body, commentPrefix) {
+				continue
+			}
+			if strings.Contains(note.Body, marker) {
+				found = note
+			} else if strings.Contains(note.Body, partMarker) {
+				parts = append(parts, note)
+			}
+		}
+
+		if response.Pagination == nil {
+			return found, parts, nil
+		}
+		listOpts.GitLab.Page = response.Pagination.NextPage
+	}
+}
+
 // ReportEntrypointsSummary reports the summary for the entrypoints command.
 func (g *GitLab) ReportEntrypointsSummary(ctx context.Context, p *EntrypointsSummaryParams) error {
+	if err := g.resolveMergeRequestIID(ctx); err != nil {
+		return err
+	}
+
 	if err := validateGitLabReporterInputs(g.cfg); err != nil {
 		return fmt.Errorf("failed to validate reporter inputs: %w", err)
 	}
@@ -308,6 +1181,10 @@ func (g *GitLab) ReportEntrypointsSummary(ctx context.Context, p *EntrypointsSum
 
 // ClearReports clears any existing reports that can be removed.
 func (g *GitLab) ClearReports(ctx context.Context, changeRequestID int) error {
+	if err := g.resolveMergeRequestIID(ctx); err != nil {
+		return err
+	}
+
 	if err := validateGitLabReporterInputs(g.cfg); err != nil {
 		return fmt.Errorf("failed to validate reporter inputs: %w", err)
 	}
@@ -363,13 +1240,44 @@ func (g *GitLab) createMergeRequestNote(ctx context.Context, msg string) error {
 	return nil
 }
 
+// resolveMergeRequestIID looks up the merge request from the commit SHA if the
+// IID is not provided by flag or environment, e.g. on push pipelines, else the
+// merge request cannot be reported on.
+func (g *GitLab) resolveMergeRequestIID(ctx context.Context) error {
+	if g.cfg.GitLabMergeRequestIID > 0 || g.cfg.GitLabProjectID <= 0 || g.cfg.GitLabCommitSHA == "" {
+		return nil
+	}
+
+	result, err := g.ListChangeRequestsByCommit(ctx, g.cfg.GitLabCommitSHA, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get merge request iid for commit sha %s: %w", g.cfg.GitLabCommitSHA, err)
+	}
+
+	if len(result.PullRequests) == 0 {
+		return fmt.Errorf("no merge requests found for commit sha: %s", g.cfg.GitLabCommitSHA)
+	}
+	g.cfg.GitLabMergeRequestIID = result.PullRequests[0].Number
+
+	logging.FromContext(ctx).DebugContext(ctx, "computed merge request iid from commit sha",
+		"gitlab_merge_request_iid", g.cfg.GitLabMergeRequestIID)
+
+	return nil
+}
+
 func validateGitLabReporterInputs(cfg *gitLabConfig) error {
+	return validateGitLabMergeRequestInputs(cfg, cfg.GitLabMergeRequestIID)
+}
+
+// validateGitLabMergeRequestInputs validates the inputs required to interact
+// with a specific merge request, which may not be the merge request of the
+// current pipeline.
+func validateGitLabMergeRequestInputs(cfg *gitLabConfig, mrIID int) error {
 	var merr error
 	if cfg.GitLabProjectID <= 0 {
 		merr = errors.Join(merr, fmt.Errorf("gitlab project id is required"))
 	}
 
-	if cfg.GitLabMergeRequestIID <= 0 {
+	if mrIID <= 0 {
 		merr = errors.Join(merr, fmt.Errorf("gitlab merge request id is required"))
 	}
 
@@ -393,24 +1301,190 @@ func (g *GitLab) withRetries(ctx context.Context, retryFunc retry.RetryFunc) err
 
 // ListChangeRequestsByCommit lists the merge requests associated with a commit SHA.
 func (g *GitLab) ListChangeRequestsByCommit(ctx context.Context, sha string, opts *ListChangeRequestsByCommitOptions) (*ListChangeRequestsByCommitResponse, error) {
-	return nil, nil
+	if sha == "" {
+		return nil, fmt.Errorf("commit sha is required")
+	}
+
+	listOpts := &gitlab.ListOptions{PerPage: 100}
+	if opts != nil && opts.GitLab != nil {
+		listOpts = opts.GitLab
+	}
+
+	var pullRequests []*PullRequest
+	var pagination *Pagination
+
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		// the client does not accept list options for this endpoint, so the
+		// request is built with them as query parameters
+		u := fmt.Sprintf("projects/%d/repository/commits/%s/merge_requests", g.cfg.GitLabProjectID, url.PathEscape(sha))
+		req, err := g.client.NewRequest(http.MethodGet, u, listOpts, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+
+		var mrs []*gitlab.MergeRequest
+		resp, err := g.client.Do(req, &mrs)
+		if err != nil {
+			return maybeGitLabRetryable(resp, fmt.Errorf("failed to list merge requests for commit: %w", err))
+		}
+
+		for _, mr := range mrs {
+			pullRequests = append(pullRequests, &PullRequest{ID: int64(mr.ID), Number: mr.IID, Body: mr.Description, HeadSHA: mr.SHA})
+		}
+
+		if resp.NextPage != 0 {
+			pagination = &Pagination{NextPage: resp.NextPage}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list merge requests for commit sha [%s]: %w", sha, err)
+	}
+
+	return &ListChangeRequestsByCommitResponse{PullRequests: pullRequests, Pagination: pagination}, nil
 }
 
-// ListJobs is a no-op because GitLab platform support is currently not implemented.
+// ListJobs lists all jobs running as part of a GitLab pipeline with pagination.
+// The GitLab job status is mapped to the equivalent job conclusion once the job
+// has finished.
 func (g *GitLab) ListJobs(ctx context.Context, runID int64) ([]*Job, error) {
-	return nil, nil
+	var jobs []*Job
+	listOpts := &gitlab.ListJobsOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	for {
+		var glJobs []*gitlab.Job
+		var nextPage int
+		if err := g.withRetries(ctx, func(ctx context.Context) error {
+			var resp *gitlab.Response
+			var err error
+			glJobs, resp, err = g.client.Jobs.ListPipelineJobs(g.cfg.GitLabProjectID, int(runID), listOpts, gitlab.WithContext(ctx))
+			if err != nil {
+				return maybeGitLabRetryable(resp, fmt.Errorf("failed to list jobs for pipeline [%d]: %w", runID, err))
+			}
+			nextPage = resp.NextPage
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+
+		for _, j := range glJobs {
+			status, conclusion := gitLabJobStatus(j.Status)
+			jobs = append(jobs, &Job{
+				ID:         int64(j.ID),
+				Name:       j.Name,
+				URL:        j.WebURL,
+				Status:     status,
+				Conclusion: conclusion,
+			})
+		}
+
+		if nextPage == 0 {
+			break
+		}
+		listOpts.Page = nextPage
+	}
+
+	return jobs, nil
+}
+
+// userID resolves the ID of a GitLab user from the username.
+func (g *GitLab) userID(ctx context.Context, username string) (int, error) {
+	var users []*gitlab.User
+	if err := g.withRetries(ctx, func(ctx context.Context) error {
+		var resp *gitlab.Response
+		var err error
+		users, resp, err = g.client.Users.ListUsers(&gitlab.ListUsersOptions{
+			Username: gitlab.Ptr(username),
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return maybeGitLabRetryable(resp, fmt.Errorf("failed to list users: %w", err))
+		}
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("failed to look up user %s: %w", username, err)
+	}
+
+	for _, u := range users {
+		if strings.EqualFold(u.Username, username) {
+			return u.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("gitlab user %s not found", username)
+}
+
+// gitLabPlannerPermissions is the access level of the planner role, which is
+// not defined by the client.
+const gitLabPlannerPermissions gitlab.AccessLevelValue = 15
+
+// gitLabAccessLevelName returns the name of a GitLab access level, see
+// https://docs.gitlab.com/ee/api/members.html#roles.
+func gitLabAccessLevelName(l gitlab.AccessLevelValue) string {
+	switch l {
+	case gitlab.NoPermissions:
+		return "no_access"
+	case gitlab.MinimalAccessPermissions:
+		return "minimal_access"
+	case gitlab.GuestPermissions:
+		return "guest"
+	case gitLabPlannerPermissions:
+		return "planner"
+	case gitlab.ReporterPermissions:
+		return "reporter"
+	case gitlab.DeveloperPermissions:
+		return "developer"
+	case gitlab.MaintainerPermissions:
+		return "maintainer"
+	case gitlab.OwnerPermissions:
+		return "owner"
+	default:
+		return strconv.Itoa(int(l))
+	}
+}
+
+// gitLabJobStatus maps a GitLab job status to the job status and conclusion
+// used by GitHub, so that jobs can be reported the same way on both platforms.
+func gitLabJobStatus(s string) (status, conclusion string) {
+	switch s {
+	case "success":
+		return "completed", "success"
+	case "failed":
+		return "completed", "failure"
+	case "canceled":
+		return "completed", "cancelled"
+	case "skipped":
+		return "completed", "skipped"
+	default:
+		return s, ""
+	}
+}
+
+func maybeGitLabRetryable(resp *gitlab.Response, err error) error {
+	if resp != nil {
+		if _, ok := gitLabIgnoredStatusCodes[resp.StatusCode]; ok {
+			return err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return err
+		}
+	}
+	return retry.RetryableError(err)
 }
 
 // CreateReport posts a comment/note on an issue or change request.
 func (g *GitLab) CreateReport(ctx context.Context, changeRequestID int, body string) error {
-	if err := validateGitLabReporterInputs(g.cfg); err != nil {
-		return fmt.Errorf("failed to validate reporter inputs: %w", err)
-	}
 	mrIID := changeRequestID
 	if mrIID <= 0 {
 		mrIID = g.cfg.GitLabMergeRequestIID
 	}
 
+	if err := validateGitLabMergeRequestInputs(g.cfg, mrIID); err != nil {
+		return fmt.Errorf("failed to validate reporter inputs: %w", err)
+	}
+
 	logger := logging.FromContext(ctx)
 	logger.DebugContext(ctx, "creating merge request note",
 		"project", g.cfg.GitLabProjectID,
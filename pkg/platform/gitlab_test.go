@@ -0,0 +1,860 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platform
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+// fakeGitLab is a minimal in-memory implementation of the GitLab REST API for
+// a single project and merge request.
+type fakeGitLab struct {
+	mu sync.Mutex
+
+	// users maps usernames to user IDs.
+	users map[string]int
+	// groups maps group paths to group IDs.
+	groups map[string]int
+	// memberships maps user IDs to the paths of the groups they are members of.
+	memberships map[int][]string
+	// accessLevels maps user IDs to their project access level.
+	accessLevels map[int]int
+
+	reviewerIDs   []int
+	approvedBy    []string
+	approvalRules []map[string]any
+
+	// mergeRequest holds additional fields of the merge request.
+	mergeRequest map[string]any
+	// diffs are the pages of merge request diffs.
+	diffs [][]map[string]any
+	// commits are the merge request commits, newest first.
+	commits []map[string]any
+	// notes are the merge request notes, oldest first.
+	notes []map[string]any
+	// createdNotes are the bodies of the notes created on the merge request.
+	createdNotes []string
+	// labelEvents are the merge request resource label events, oldest first.
+	labelEvents []map[string]any
+
+	// mergeRequests maps commit SHAs to the merge requests containing them.
+	mergeRequests map[string][]map[string]any
+	// jobs are the pages of pipeline jobs.
+	jobs [][]map[string]any
+}
+
+func (f *fakeGitLab) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v4/users", func(w http.ResponseWriter, r *http.Request) {
+		username := r.URL.Query().Get("username")
+		res := []map[string]any{}
+		if id, ok := f.users[username]; ok {
+			res = append(res, map[string]any{"id": id, "username": username})
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	mux.HandleFunc("GET /api/v4/users/{user}/memberships", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("user"))
+		res := []map[string]any{}
+		for _, pth := range f.memberships[id] {
+			res = append(res, map[string]any{
+				"source_id":   f.groups[pth],
+				"source_name": pth[strings.LastIndex(pth, "/")+1:],
+				"source_type": "Namespace",
+			})
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	mux.HandleFunc("GET /api/v4/groups/{group}", func(w http.ResponseWriter, r *http.Request) {
+		// groups are requested by path or by ID
+		for pth, id := range f.groups {
+			if r.PathValue("group") == pth || r.PathValue("group") == strconv.Itoa(id) {
+				writeJSON(w, http.StatusOK, map[string]any{"id": id, "full_path": pth})
+				return
+			}
+		}
+		writeJSON(w, http.StatusNotFound, map[string]any{"message": "404 Group Not Found"})
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/members/all/{user}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("user"))
+		level, ok := f.accessLevels[id]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]any{"message": "404 Not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"id": id, "access_level": level})
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/merge_requests/2", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		reviewers := []map[string]any{}
+		for _, id := range f.reviewerIDs {
+			reviewers = append(reviewers, map[string]any{"id": id})
+		}
+		res := map[string]any{"id": 200, "iid": 2, "reviewers": reviewers}
+		for k, v := range f.mergeRequest {
+			res[k] = v
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/merge_requests/2/diffs", func(w http.ResponseWriter, r *http.Request) {
+		page := 1
+		if v := r.URL.Query().Get("page"); v != "" {
+			page, _ = strconv.Atoi(v)
+		}
+		if page < len(f.diffs) {
+			w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		}
+		res := []map[string]any{}
+		if page <= len(f.diffs) {
+			res = f.diffs[page-1]
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/merge_requests/2/commits", func(w http.ResponseWriter, r *http.Request) {
+		res := f.commits
+		if res == nil {
+			res = []map[string]any{}
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/merge_requests/2/notes", func(w http.ResponseWriter, r *http.Request) {
+		res := f.notes
+		if res == nil {
+			res = []map[string]any{}
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	mux.HandleFunc("POST /api/v4/projects/1/merge_requests/2/notes", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		var body struct {
+			Body string `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"message": err.Error()})
+			return
+		}
+		f.createdNotes = append(f.createdNotes, body.Body)
+		writeJSON(w, http.StatusCreated, map[string]any{"id": len(f.createdNotes), "body": body.Body})
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/merge_requests/2/resource_label_events", func(w http.ResponseWriter, r *http.Request) {
+		res := f.labelEvents
+		if res == nil {
+			res = []map[string]any{}
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	mux.HandleFunc("PUT /api/v4/projects/1/merge_requests/2", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		var body struct {
+			ReviewerIDs []int `json:"reviewer_ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"message": err.Error()})
+			return
+		}
+		f.reviewerIDs = body.ReviewerIDs
+		writeJSON(w, http.StatusOK, map[string]any{"id": 200, "iid": 2})
+	})
+
+	mux.HandleFunc("POST /api/v4/projects/1/merge_requests/2/approval_rules", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"message": err.Error()})
+			return
+		}
+		f.approvalRules = append(f.approvalRules, body)
+		writeJSON(w, http.StatusCreated, map[string]any{"id": len(f.approvalRules)})
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/merge_requests/2/approvals", func(w http.ResponseWriter, r *http.Request) {
+		approvedBy := []map[string]any{}
+		for _, u := range f.approvedBy {
+			approvedBy = append(approvedBy, map[string]any{"user": map[string]any{"id": f.users[u], "username": u}})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"iid": 2, "approved_by": approvedBy})
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/repository/commits/{sha}/merge_requests", func(w http.ResponseWriter, r *http.Request) {
+		res, ok := f.mergeRequests[r.PathValue("sha")]
+		if !ok {
+			res = []map[string]any{}
+		}
+
+		page, perPage := 1, 20
+		if v := r.URL.Query().Get("page"); v != "" {
+			page, _ = strconv.Atoi(v)
+		}
+		if v := r.URL.Query().Get("per_page"); v != "" {
+			perPage, _ = strconv.Atoi(v)
+		}
+		start, end := min((page-1)*perPage, len(res)), min(page*perPage, len(res))
+		if end < len(res) {
+			w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		}
+		writeJSON(w, http.StatusOK, res[start:end])
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/1/pipelines/{pipeline}/jobs", func(w http.ResponseWriter, r *http.Request) {
+		page := 1
+		if v := r.URL.Query().Get("page"); v != "" {
+			page, _ = strconv.Atoi(v)
+		}
+		if page < len(f.jobs) {
+			w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		}
+		writeJSON(w, http.StatusOK, f.jobs[page-1])
+	})
+
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		panic(err)
+	}
+}
+
+func testGitLab(tb testing.TB, fake *fakeGitLab, cfg *gitLabConfig) *GitLab {
+	tb.Helper()
+
+	srv := httptest.NewServer(fake.handler())
+	tb.Cleanup(srv.Close)
+
+	client, err := gitlab.NewClient("token", gitlab.WithBaseURL(srv.URL))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	cfg.GuardianGitLabToken = "token"
+	cfg.GitLabBaseURL = srv.URL
+	cfg.InitialRetryDelay = time.Millisecond
+	cfg.MaxRetryDelay = time.Millisecond
+
+	return &GitLab{cfg: cfg, client: client}
+}
+
+func TestGitLab_AssignReviewers(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name             string
+		input            *AssignReviewersInput
+		want             *AssignReviewersResult
+		wantReviewerIDs  []int
+		wantRuleGroupIDs []any
+		wantErr          string
+	}{
+		{
+			name:            "users",
+			input:           &AssignReviewersInput{Users: []string{"alice", "bob"}},
+			want:            &AssignReviewersResult{Users: []string{"alice", "bob"}},
+			wantReviewerIDs: []int{1, 2, 3},
+		},
+		{
+			name:             "groups",
+			input:            &AssignReviewersInput{Teams: []string{"platform"}},
+			want:             &AssignReviewersResult{Teams: []string{"platform"}},
+			wantReviewerIDs:  []int{3},
+			wantRuleGroupIDs: []any{float64(100)},
+		},
+		{
+			name:            "skips_unknown_user",
+			input:           &AssignReviewersInput{Users: []string{"alice", "unknown"}},
+			want:            &AssignReviewersResult{Users: []string{"alice"}},
+			wantReviewerIDs: []int{1, 3},
+		},
+		{
+			name:            "all_failed",
+			input:           &AssignReviewersInput{Users: []string{"unknown"}, Teams: []string{"unknown"}},
+			wantReviewerIDs: []int{3},
+			wantErr:         "failed to assign all requested reviewers to merge request",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeGitLab{
+				users:       map[string]int{"alice": 1, "bob": 2, "carol": 3},
+				groups:      map[string]int{"platform": 100},
+				reviewerIDs: []int{3},
+			}
+			g := testGitLab(t, fake, &gitLabConfig{
+				GitLabProjectID:       1,
+				GitLabMergeRequestIID: 2,
+			})
+
+			got, err := g.AssignReviewers(t.Context(), tc.input)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("result not as expected; (-got,+want): %s", diff)
+			}
+			if diff := cmp.Diff(fake.reviewerIDs, tc.wantReviewerIDs); diff != "" {
+				t.Errorf("reviewers not as expected; (-got,+want): %s", diff)
+			}
+
+			var gotRuleGroupIDs []any
+			for _, r := range fake.approvalRules {
+				if got, want := r["name"], "guardian: platform"; got != want {
+					t.Errorf("expected approval rule name %q to be %q", got, want)
+				}
+				gotRuleGroupIDs = append(gotRuleGroupIDs, r["group_ids"].([]any)...)
+			}
+			if diff := cmp.Diff(gotRuleGroupIDs, tc.wantRuleGroupIDs); diff != "" {
+				t.Errorf("approval rule groups not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestGitLab_GetLatestApprovers(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name          string
+		includeGroups bool
+		approvedBy    []string
+		want          *GetLatestApproversResult
+	}{
+		{
+			name: "no_approvers",
+			want: &GetLatestApproversResult{Users: []string{}},
+		},
+		{
+			name:       "users",
+			approvedBy: []string{"alice", "bob"},
+			want:       &GetLatestApproversResult{Users: []string{"alice", "bob"}},
+		},
+		{
+			name:          "users_and_groups",
+			includeGroups: true,
+			approvedBy:    []string{"alice", "bob"},
+			want: &GetLatestApproversResult{
+				Users: []string{"alice", "bob"},
+				Teams: []string{"infra/platform", "security"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeGitLab{
+				users:  map[string]int{"alice": 1, "bob": 2},
+				groups: map[string]int{"infra/platform": 100, "security": 101},
+				memberships: map[int][]string{
+					1: {"infra/platform"},
+					2: {"infra/platform", "security"},
+				},
+				approvedBy: tc.approvedBy,
+			}
+			g := testGitLab(t, fake, &gitLabConfig{
+				GitLabProjectID:       1,
+				GitLabMergeRequestIID: 2,
+				IncludeGroups:         tc.includeGroups,
+			})
+
+			got, err := g.GetLatestApprovers(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("approvers not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestGitLab_GetUserRepoPermissions(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		username string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "maintainer",
+			username: "alice",
+			want:     "maintainer",
+		},
+		{
+			name:     "not_a_member",
+			username: "bob",
+			want:     "no_access",
+		},
+		{
+			name:     "unknown_user",
+			username: "unknown",
+			wantErr:  "gitlab user unknown not found",
+		},
+		{
+			name:    "missing_username",
+			wantErr: "gitlab-user-login is required",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeGitLab{
+				users:        map[string]int{"alice": 1, "bob": 2},
+				accessLevels: map[int]int{1: 40},
+			}
+			g := testGitLab(t, fake, &gitLabConfig{
+				GitLabProjectID: 1,
+				GitLabUserLogin: tc.username,
+			})
+
+			got, err := g.GetUserRepoPermissions(t.Context())
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Error(diff)
+			}
+			if got != tc.want {
+				t.Errorf("expected permission %q to be %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGitLab_GetChangeRequestEditors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		notes       []map[string]any
+		labelEvents []map[string]any
+		exp         *ChangeRequestEditors
+	}{
+		{
+			name: "comments",
+			notes: []map[string]any{
+				{"id": 1, "body": "looks good", "author": map[string]any{"username": "bob"}, "created_at": "2025-01-01T00:00:00Z", "updated_at": "2025-01-01T00:00:00Z"},
+				{"id": 2, "body": "added 1 commit", "system": true, "author": map[string]any{"username": "bob"}},
+				{"id": 3, "body": "GUARDIAN_EXEMPT=iam:incident", "author": map[string]any{"username": "carol"}, "created_at": "2025-01-01T00:00:00Z", "updated_at": "2025-01-02T00:00:00Z"},
+			},
+			exp: &ChangeRequestEditors{
+				Labels: map[string]string{},
+				Comments: []*ChangeRequestComment{
+					{Author: "bob", Body: "looks good"},
+					{Author: "carol", Body: "GUARDIAN_EXEMPT=iam:incident", Edited: true},
+				},
+			},
+		},
+		{
+			name: "labels",
+			notes: []map[string]any{
+				{"id": 1, "body": "changed the description", "system": true, "author": map[string]any{"username": "bob"}},
+			},
+			labelEvents: []map[string]any{
+				{"id": 1, "action": "add", "label": map[string]any{"name": "guardian-exempt:iam"}, "user": map[string]any{"username": "bob"}},
+				{"id": 2, "action": "add", "label": map[string]any{"name": "infra"}, "user": map[string]any{"username": "bob"}},
+				{"id": 3, "action": "remove", "label": map[string]any{"name": "guardian-exempt:iam"}, "user": map[string]any{"username": "bob"}},
+				{"id": 4, "action": "add", "label": map[string]any{"name": "guardian-exempt:iam"}, "user": map[string]any{"username": "carol"}},
+			},
+			exp: &ChangeRequestEditors{
+				Labels: map[string]string{
+					"guardian-exempt:iam": "carol",
+					"infra":               "bob",
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeGitLab{
+				notes:       tc.notes,
+				labelEvents: tc.labelEvents,
+			}
+			g := testGitLab(t, fake, &gitLabConfig{
+				GitLabProjectID:       1,
+				GitLabMergeRequestIID: 2,
+			})
+
+			got, err := g.GetChangeRequestEditors(t.Context(), 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("editors not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestGitLab_StoragePrefix(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		cfg     *gitLabConfig
+		want    string
+		wantErr string
+	}{
+		{
+			name: "merge_request_event",
+			cfg: &gitLabConfig{
+				GitLabProjectID:       1,
+				GitLabMergeRequestIID: 2,
+				GitLabPipelineSource:  "merge_request_event",
+			},
+			want: "guardian-plans/1/2",
+		},
+		{
+			name: "merge_request_event_missing_iid",
+			cfg: &gitLabConfig{
+				GitLabProjectID:      1,
+				GitLabPipelineSource: "merge_request_event",
+			},
+			wantErr: "gitlab merge request iid is required for storage",
+		},
+		{
+			name: "push",
+			cfg: &gitLabConfig{
+				GitLabProjectID:      1,
+				GitLabCommitSHA:      "abc123",
+				GitLabPipelineSource: "push",
+			},
+			want: "guardian-plans/1/7",
+		},
+		{
+			name: "push_no_merge_requests",
+			cfg: &gitLabConfig{
+				GitLabProjectID:      1,
+				GitLabCommitSHA:      "def456",
+				GitLabPipelineSource: "push",
+			},
+			wantErr: "no merge requests found for commit sha: def456",
+		},
+		{
+			name: "schedule",
+			cfg: &gitLabConfig{
+				GitLabProjectID:      1,
+				GitLabPipelineSource: "schedule",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeGitLab{
+				mergeRequests: map[string][]map[string]any{
+					"abc123": {{"id": 700, "iid": 7, "sha": "abc123"}},
+				},
+			}
+			g := testGitLab(t, fake, tc.cfg)
+
+			got, err := g.StoragePrefix(t.Context())
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Error(diff)
+			}
+			if got != tc.want {
+				t.Errorf("expected storage prefix %q to be %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGitLab_ReportStatus(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		cfg       *gitLabConfig
+		wantNotes int
+		wantErr   string
+	}{
+		{
+			name: "merge_request_event",
+			cfg: &gitLabConfig{
+				GitLabProjectID:       1,
+				GitLabMergeRequestIID: 2,
+			},
+			wantNotes: 1,
+		},
+		{
+			name: "push",
+			cfg: &gitLabConfig{
+				GitLabProjectID: 1,
+				GitLabCommitSHA: "abc123",
+			},
+			wantNotes: 1,
+		},
+		{
+			name: "push_no_merge_requests",
+			cfg: &gitLabConfig{
+				GitLabProjectID: 1,
+				GitLabCommitSHA: "def456",
+			},
+			wantErr: "no merge requests found for commit sha: def456",
+		},
+		{
+			name: "missing_merge_request",
+			cfg: &gitLabConfig{
+				GitLabProjectID: 1,
+			},
+			wantErr: "gitlab merge request id is required",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeGitLab{
+				mergeRequests: map[string][]map[string]any{
+					"abc123": {{"id": 200, "iid": 2, "sha": "abc123"}},
+				},
+			}
+			g := testGitLab(t, fake, tc.cfg)
+
+			err := g.ReportStatus(t.Context(), StatusSuccess, &StatusParams{Operation: "apply", Dir: "terraform"})
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Error(diff)
+			}
+
+			if got := len(fake.createdNotes); got != tc.wantNotes {
+				t.Errorf("expected %d notes to be created, got %d", tc.wantNotes, got)
+			}
+			for _, note := range fake.createdNotes {
+				if !strings.Contains(note, statusText[StatusSuccess]) {
+					t.Errorf("expected note %q to contain %q", note, statusText[StatusSuccess])
+				}
+			}
+		})
+	}
+}
+
+func TestGitLab_ListChangeRequestsByCommit(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		opts *ListChangeRequestsByCommitOptions
+		want *ListChangeRequestsByCommitResponse
+	}{
+		{
+			name: "default_options",
+			want: &ListChangeRequestsByCommitResponse{
+				PullRequests: []*PullRequest{
+					{ID: 700, Number: 7, Body: "body", HeadSHA: "def456"},
+					{ID: 800, Number: 8, Body: "other", HeadSHA: "abc123"},
+				},
+			},
+		},
+		{
+			name: "first_page",
+			opts: &ListChangeRequestsByCommitOptions{
+				GitLab: &gitlab.ListOptions{Page: 1, PerPage: 1},
+			},
+			want: &ListChangeRequestsByCommitResponse{
+				PullRequests: []*PullRequest{
+					{ID: 700, Number: 7, Body: "body", HeadSHA: "def456"},
+				},
+				Pagination: &Pagination{NextPage: 2},
+			},
+		},
+		{
+			name: "last_page",
+			opts: &ListChangeRequestsByCommitOptions{
+				GitLab: &gitlab.ListOptions{Page: 2, PerPage: 1},
+			},
+			want: &ListChangeRequestsByCommitResponse{
+				PullRequests: []*PullRequest{
+					{ID: 800, Number: 8, Body: "other", HeadSHA: "abc123"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeGitLab{
+				mergeRequests: map[string][]map[string]any{
+					"abc123": {
+						{"id": 700, "iid": 7, "description": "body", "sha": "def456"},
+						{"id": 800, "iid": 8, "description": "other", "sha": "abc123"},
+					},
+				},
+			}
+			g := testGitLab(t, fake, &gitLabConfig{GitLabProjectID: 1})
+
+			got, err := g.ListChangeRequestsByCommit(t.Context(), "abc123", tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("merge requests not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestGitLab_ListJobs(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeGitLab{
+		jobs: [][]map[string]any{
+			{
+				{"id": 1, "name": "plan (a)", "status": "success", "web_url": "https://gitlab.com/jobs/1"},
+				{"id": 2, "name": "plan (b)", "status": "failed", "web_url": "https://gitlab.com/jobs/2"},
+			},
+			{
+				{"id": 3, "name": "plan (c)", "status": "canceled", "web_url": "https://gitlab.com/jobs/3"},
+				{"id": 4, "name": "plan (d)", "status": "running", "web_url": "https://gitlab.com/jobs/4"},
+			},
+		},
+	}
+	g := testGitLab(t, fake, &gitLabConfig{GitLabProjectID: 1})
+
+	got, err := g.ListJobs(t.Context(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Job{
+		{ID: 1, Name: "plan (a)", URL: "https://gitlab.com/jobs/1", Status: "completed", Conclusion: "success"},
+		{ID: 2, Name: "plan (b)", URL: "https://gitlab.com/jobs/2", Status: "completed", Conclusion: "failure"},
+		{ID: 3, Name: "plan (c)", URL: "https://gitlab.com/jobs/3", Status: "completed", Conclusion: "cancelled"},
+		{ID: 4, Name: "plan (d)", URL: "https://gitlab.com/jobs/4", Status: "running"},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("jobs not as expected; (-got,+want): %s", diff)
+	}
+}
+
+func TestGitLab_GetPolicyData(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeGitLab{
+		users:        map[string]int{"alice": 1, "bob": 2},
+		groups:       map[string]int{"platform": 100, "security": 101},
+		memberships:  map[int][]string{1: {"platform"}, 2: {"security"}},
+		accessLevels: map[int]int{1: 30},
+		approvedBy:   []string{"bob"},
+		mergeRequest: map[string]any{
+			"author":        map[string]any{"id": 1, "username": "alice"},
+			"target_branch": "main",
+			"source_branch": "feature",
+			"sha":           "sha2",
+			"draft":         true,
+			"labels":        []string{"infra"},
+		},
+		diffs: [][]map[string]any{
+			{
+				{"old_path": "terraform/dev/main.tf", "new_path": "terraform/dev/main.tf"},
+			},
+			{
+				{"old_path": "modules/old/main.tf", "new_path": "modules/new/main.tf", "renamed_file": true},
+			},
+		},
+		commits: []map[string]any{
+			{"id": "sha2", "author_name": "Bot", "committed_date": "2025-01-03T03:04:05Z"},
+			{"id": "sha1", "author_name": "Alice", "committed_date": "2025-01-02T03:04:05Z"},
+		},
+	}
+	g := testGitLab(t, fake, &gitLabConfig{
+		GitLabProjectID:       1,
+		GitLabMergeRequestIID: 2,
+		GitLabUserLogin:       "alice",
+		IncludeGroups:         true,
+	})
+
+	got, err := g.GetPolicyData(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &GetPolicyDataResult{
+		GitLab: &GitLabPolicyData{
+			MergeRequestApprovers: &GetLatestApproversResult{
+				Users: []string{"bob"},
+				Teams: []string{"security"},
+			},
+			Actor: &GitLabActorData{
+				Username:    "alice",
+				AccessLevel: "developer",
+				Groups:      []string{"platform"},
+			},
+			MergeRequest: &ChangeRequestData{
+				Number: 2,
+				Author: &ChangeRequestAuthor{
+					Username: "alice",
+					Teams:    []string{"platform"},
+				},
+				BaseBranch:   "main",
+				HeadBranch:   "feature",
+				HeadSHA:      "sha2",
+				Draft:        true,
+				Labels:       []string{"infra"},
+				ChangedFiles: []string{"terraform/dev/main.tf", "modules/new/main.tf", "modules/old/main.tf"},
+				Commits: []*ChangeRequestCommit{
+					{SHA: "sha1", AuthorName: "Alice", CommittedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+					{SHA: "sha2", AuthorName: "Bot", CommittedAt: time.Date(2025, 1, 3, 3, 4, 5, 0, time.UTC)},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("policy data not as expected; (-got,+want): %s", diff)
+	}
+}
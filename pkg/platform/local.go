@@ -42,6 +42,16 @@ func (l *Local) GetUserRepoPermissions(ctx context.Context) (string, error) {
 	return "", nil
 }
 
+// GetRepoPermissionsForUser is a no-op and returns an empty string.
+func (l *Local) GetRepoPermissionsForUser(ctx context.Context, username string) (string, error) {
+	return "", nil
+}
+
+// GetChangeRequestEditors returns an empty result.
+func (l *Local) GetChangeRequestEditors(ctx context.Context, changeRequestID int) (*ChangeRequestEditors, error) {
+	return &ChangeRequestEditors{}, nil
+}
+
 // GetUserTeamMemberships is a no-op and returns an empty slice.
 func (l *Local) GetUserTeamMemberships(ctx context.Context, username string) ([]string, error) {
 	return []string{}, nil
@@ -77,6 +87,11 @@ func (l *Local) ReportStatus(ctx context.Context, status Status, params *StatusP
 	return nil
 }
 
+// UpsertStatus is a no-op.
+func (l *Local) UpsertStatus(ctx context.Context, status Status, params *StatusParams) error {
+	return nil
+}
+
 // ReportEntrypointsSummary is a no-op.
 func (l *Local) ReportEntrypointsSummary(ctx context.Context, params *EntrypointsSummaryParams) error {
 	return nil
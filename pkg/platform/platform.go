@@ -21,9 +21,13 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
+	"code.gitea.io/sdk/gitea"
 	"github.com/google/go-github/v53/github"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	"github.com/abcxyz/guardian/pkg/modifiers"
 )
 
 const (
@@ -31,6 +35,7 @@ const (
 	TypeLocal       = "local"
 	TypeGitHub      = "github"
 	TypeGitLab      = "gitlab"
+	TypeGitea       = "gitea"
 )
 
 var (
@@ -38,10 +43,11 @@ var (
 		TypeLocal:  {},
 		TypeGitHub: {},
 		TypeGitLab: {},
+		TypeGitea:  {},
 	}
 	// SortedTypes are the sorted Platform types for printing messages and prediction.
 	SortedTypes = func() []string {
-		allowed := append([]string{}, TypeLocal, TypeGitHub, TypeGitLab)
+		allowed := append([]string{}, TypeLocal, TypeGitHub, TypeGitLab, TypeGitea)
 		sort.Strings(allowed)
 		return allowed
 	}()
@@ -70,11 +76,149 @@ type GetLatestApproversResult struct {
 	Teams []string `json:"teams,omitempty"`
 }
 
+// PolicyDataSchemaVersion is the version of the policy data payload. Fields
+// are only added within a version, the version is incremented if a field is
+// changed or removed.
+const PolicyDataSchemaVersion = 1
+
 // GetPolicyDataResult contains the required data for policy evaluation, by
 // platform.
 type GetPolicyDataResult struct {
+	// SchemaVersion is the PolicyDataSchemaVersion of the payload.
+	SchemaVersion int `json:"schema_version"`
+
 	GitHub *GitHubPolicyData `json:"github,omitempty"`
+	GitLab *GitLabPolicyData `json:"gitlab,omitempty"`
+	Gitea  *GiteaPolicyData  `json:"gitea,omitempty"`
 	Mock   *MockPolicyData   `json:"mock,omitempty"`
+
+	// Modifiers are the plan modifiers requested in the change request body,
+	// keyed by entrypoint.
+	Modifiers map[string]*modifiers.PlanModifiers `json:"modifiers,omitempty"`
+
+	// Exemptions are the policy exemptions requested in the change request body
+	// or labels, keyed by policy name.
+	Exemptions map[string]*modifiers.Exemption `json:"exemptions,omitempty"`
+
+	// ChangedEntrypoints are the entrypoints affected by the changed files of
+	// the change request, relative to the repository root.
+	ChangedEntrypoints []string `json:"changed_entrypoints,omitempty"`
+
+	// PlanSummary is the summary of the resource changes of the plan the
+	// policies are evaluated for, if a plan is provided.
+	PlanSummary *PlanSummaryData `json:"plan_summary,omitempty"`
+}
+
+// PlanSummaryData defines the payload of the summary of the resource changes
+// of a plan. Resources are empty rather than omitted.
+type PlanSummaryData struct {
+	Create    int                       `json:"create"`
+	Update    int                       `json:"update"`
+	Replace   int                       `json:"replace"`
+	Delete    int                       `json:"delete"`
+	Resources []*PlanResourceChangeData `json:"resources"`
+}
+
+// PlanResourceChangeData defines the payload of a planned change to a single
+// resource.
+type PlanResourceChangeData struct {
+	Address string `json:"address"`
+	// Module is the address of the module containing the resource, or empty for
+	// the root module.
+	Module   string `json:"module"`
+	Type     string `json:"type"`
+	Provider string `json:"provider"`
+	Action   string `json:"action"`
+}
+
+// ChangeRequest returns the change request data of the platform, nil if the
+// platform does not provide it or the command is not running in the context of
+// a change request.
+func (r *GetPolicyDataResult) ChangeRequest() *ChangeRequestData {
+	switch {
+	case r.GitHub != nil:
+		return r.GitHub.PullRequest
+	case r.GitLab != nil:
+		return r.GitLab.MergeRequest
+	case r.Gitea != nil:
+		return r.Gitea.PullRequest
+	case r.Mock != nil:
+		return r.Mock.ChangeRequest
+	default:
+		return nil
+	}
+}
+
+// ChangeRequestData defines the payload of the change request used for policy
+// evaluation. Lists are empty rather than omitted, so policies can rely on
+// them being set.
+type ChangeRequestData struct {
+	Number     int                  `json:"number"`
+	Author     *ChangeRequestAuthor `json:"author"`
+	BaseBranch string               `json:"base_branch"`
+	HeadBranch string               `json:"head_branch"`
+	HeadSHA    string               `json:"head_sha"`
+	Draft      bool                 `json:"draft"`
+	Labels     []string             `json:"labels"`
+
+	// ChangedFiles are the paths of the changed files, relative to the
+	// repository root. Renamed files are included with their new path, and
+	// their old path if the platform reports it.
+	ChangedFiles []string `json:"changed_files"`
+
+	// Commits are the commits of the change request, oldest first.
+	Commits []*ChangeRequestCommit `json:"commits"`
+
+	// ApprovalsPredateLatestPush is true if an approval was given for a commit
+	// before the head commit. It is null if the platform does not report the
+	// commit approvals were given for.
+	ApprovalsPredateLatestPush *bool `json:"approvals_predate_latest_push"`
+
+	// StaleApprovers are the users whose latest approval predates the latest
+	// push, null if the platform does not report it.
+	StaleApprovers []string `json:"stale_approvers"`
+}
+
+// ChangeRequestAuthor defines the payload of the author of a change request.
+type ChangeRequestAuthor struct {
+	Username string   `json:"username"`
+	Teams    []string `json:"teams,omitempty"`
+}
+
+// ChangeRequestCommit defines the payload of a commit of a change request.
+type ChangeRequestCommit struct {
+	SHA string `json:"sha"`
+
+	// Author is the platform username of the commit author, empty if the
+	// author is not linked to a user.
+	Author      string    `json:"author"`
+	AuthorName  string    `json:"author_name"`
+	CommittedAt time.Time `json:"committed_at,omitzero"`
+}
+
+// ChangeRequestEditors contains the users that added the labels and comments
+// of a change request. The users that edited the change request body are not
+// included, as the platforms do not report which user added each line.
+type ChangeRequestEditors struct {
+	// Labels are the users that last added the current labels, keyed by label
+	// name.
+	Labels map[string]string
+
+	// Comments are the comments on the change request, oldest first.
+	Comments []*ChangeRequestComment
+}
+
+// ChangeRequestComment is a comment on a change request.
+type ChangeRequestComment struct {
+	// Author is the user that created the comment.
+	Author string
+
+	// Body is the current contents of the comment.
+	Body string
+
+	// Edited is true if the comment was changed after it was created, which
+	// may have been done by a user other than the author.
+	Edited bool
 }
 
 // Report is a comment/note on an issue or change request.
@@ -87,6 +231,7 @@ type Report struct {
 type ListReportsOptions struct {
 	GitHub *github.IssueListCommentsOptions
 	GitLab *gitlab.ListMergeRequestNotesOptions
+	Gitea  *gitea.ListIssueCommentOptions
 }
 
 // ListReportsResults contains the results of listing reports.
@@ -99,6 +244,8 @@ type ListReportsResult struct {
 // platform.
 type ListChangeRequestsByCommitOptions struct {
 	GitHub *github.PullRequestListOptions
+	GitLab *gitlab.ListOptions
+	Gitea  *gitea.ListPullRequestsOptions
 }
 
 // ListChangeRequestsByCommitResponse contains the changes requests and
@@ -130,6 +277,10 @@ type Platform interface {
 	// GetUserRepoPermissions returns a user's access level to a repository.
 	GetUserRepoPermissions(ctx context.Context) (string, error)
 
+	// GetRepoPermissionsForUser returns the access level of the given user to
+	// a repository.
+	GetRepoPermissionsForUser(ctx context.Context, username string) (string, error)
+
 	// GetLatestApprovers retrieves the reviewers whose latest review is an
 	// approval.
 	GetLatestApprovers(ctx context.Context) (*GetLatestApproversResult, error)
@@ -138,6 +289,10 @@ type Platform interface {
 	// of, within the given organization.
 	GetUserTeamMemberships(ctx context.Context, username string) ([]string, error)
 
+	// GetChangeRequestEditors retrieves the users that added the labels of a
+	// change request and its comments.
+	GetChangeRequestEditors(ctx context.Context, changeRequestID int) (*ChangeRequestEditors, error)
+
 	// GetPolicyData retrieves the required data for policy evaluation.
 	GetPolicyData(ctx context.Context) (*GetPolicyDataResult, error)
 
@@ -156,6 +311,10 @@ type Platform interface {
 	// ReportStatus reports the status of a run.
 	ReportStatus(ctx context.Context, status Status, params *StatusParams) error
 
+	// UpsertStatus reports the status of a run, updating the previous report
+	// for the same operation and entrypoint in place if one exists.
+	UpsertStatus(ctx context.Context, status Status, params *StatusParams) error
+
 	// ReportEntrypointsSummary reports the summary for the entrypoints command.
 	ReportEntrypointsSummary(ctx context.Context, params *EntrypointsSummaryParams) error
 
@@ -190,5 +349,13 @@ func NewPlatform(ctx context.Context, cfg *Config) (Platform, error) {
 		}
 		return gl, nil
 	}
+
+	if strings.EqualFold(cfg.Type, TypeGitea) {
+		gt, err := NewGitea(ctx, &cfg.Gitea)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gitea: %w", err)
+		}
+		return gt, nil
+	}
 	return nil, fmt.Errorf("unknown platform type: %s", cfg.Type)
 }
@@ -44,7 +44,10 @@ type MockPlatform struct {
 	TeamApprovers      []string
 	UserApprovers      []string
 	UserAccessLevel    string
+	UserAccessLevels   map[string]string
 	UserTeams          []string
+	ChangeRequest      *ChangeRequestData
+	Editors            *ChangeRequestEditors
 
 	ReportStatusErr             error
 	ReportEntrypointsSummaryErr error
@@ -82,8 +85,9 @@ type MockActorData struct {
 }
 
 type MockPolicyData struct {
-	Approvers *GetLatestApproversResult `json:"approvers"`
-	Actor     *MockActorData            `json:"actor"`
+	Approvers     *GetLatestApproversResult `json:"approvers"`
+	Actor         *MockActorData            `json:"actor"`
+	ChangeRequest *ChangeRequestData        `json:"change_request,omitempty"`
 }
 
 func (m *MockPlatform) GetUserRepoPermissions(ctx context.Context) (string, error) {
@@ -96,6 +100,34 @@ func (m *MockPlatform) GetUserRepoPermissions(ctx context.Context) (string, erro
 	return m.UserAccessLevel, nil
 }
 
+func (m *MockPlatform) GetRepoPermissionsForUser(ctx context.Context, username string) (string, error) {
+	m.reqMu.Lock()
+	defer m.reqMu.Unlock()
+	m.Reqs = append(m.Reqs, &Request{
+		Name:   "GetRepoPermissionsForUser",
+		Params: []any{username},
+	})
+
+	if v, ok := m.UserAccessLevels[username]; ok {
+		return v, nil
+	}
+	return m.UserAccessLevel, nil
+}
+
+func (m *MockPlatform) GetChangeRequestEditors(ctx context.Context, changeRequestID int) (*ChangeRequestEditors, error) {
+	m.reqMu.Lock()
+	defer m.reqMu.Unlock()
+	m.Reqs = append(m.Reqs, &Request{
+		Name:   "GetChangeRequestEditors",
+		Params: []any{changeRequestID},
+	})
+
+	if m.Editors != nil {
+		return m.Editors, nil
+	}
+	return &ChangeRequestEditors{}, nil
+}
+
 func (m *MockPlatform) GetLatestApprovers(ctx context.Context) (*GetLatestApproversResult, error) {
 	m.reqMu.Lock()
 	defer m.reqMu.Unlock()
@@ -121,6 +153,9 @@ func (m *MockPlatform) GetUserTeamMemberships(ctx context.Context, username stri
 		Name: "GetTeamMemberships",
 	})
 
+	if m.UserTeams != nil {
+		return m.UserTeams, nil
+	}
 	return []string{}, nil
 }
 
@@ -144,11 +179,13 @@ func (m *MockPlatform) GetPolicyData(ctx context.Context) (*GetPolicyDataResult,
 	}
 
 	var approvers *GetLatestApproversResult
+	var changeRequest *ChangeRequestData
 	if m.IsPullRequest {
 		approvers = &GetLatestApproversResult{
 			Teams: approverTeams,
 			Users: m.UserApprovers,
 		}
+		changeRequest = m.ChangeRequest
 	}
 
 	return &GetPolicyDataResult{
@@ -159,6 +196,7 @@ func (m *MockPlatform) GetPolicyData(ctx context.Context) (*GetPolicyDataResult,
 				AccessLevel: m.UserAccessLevel,
 				Teams:       userTeams,
 			},
+			ChangeRequest: changeRequest,
 		},
 	}, nil
 }
@@ -190,6 +228,23 @@ func (m *MockPlatform) ReportStatus(ctx context.Context, s Status, p *StatusPara
 	return m.ReportStatusErr
 }
 
+// UpsertStatus reports the status of a run, updating the previous report.
+func (m *MockPlatform) UpsertStatus(ctx context.Context, s Status, p *StatusParams) error {
+	m.reqMu.Lock()
+	defer m.reqMu.Unlock()
+
+	// make a copy to prevent outside modification
+	pCopy := new(StatusParams)
+	*pCopy = *p
+
+	m.Reqs = append(m.Reqs, &Request{
+		Name:   "UpsertStatus",
+		Params: []any{s, pCopy},
+	})
+
+	return m.ReportStatusErr
+}
+
 // ListReports lists existing reports for an issue or change request.
 func (m *MockPlatform) ListReports(ctx context.Context, changeRequestID int, opts *ListReportsOptions) (*ListReportsResult, error) {
 	m.reqMu.Lock()
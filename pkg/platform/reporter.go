@@ -15,6 +15,7 @@
 package platform
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -25,6 +26,9 @@ const (
 	StatusFailure         Status = Status("FAILURE")
 	StatusNoOperation     Status = Status("NO CHANGES")
 	StatusPolicyViolation Status = Status("POLICY VIOLATION")
+	StatusPolicyAdvisory  Status = Status("POLICY ADVISORY")
+	StatusStalePlan       Status = Status("STALE PLAN")
+	StatusDestroy         Status = Status("DESTROY ENTRYPOINT")
 	StatusUnknown         Status = Status("UNKNOWN")
 )
 
@@ -39,12 +43,23 @@ var (
 			"^([\t ]*)" + // only match tilde at start of line, can lead with tabs or spaces
 			`((\-(\/\+)*)|(\+(\/\-)*)|(!))`) // match characters to swap whitespace for git diff (+, +/-, -, -/+, !)
 
+	// resourceBoundary matches the first line of a resource in the Terraform
+	// plan and apply output, e.g. "  # google_storage_bucket.b will be created".
+	resourceBoundary = regexp.MustCompile(`^\s*# \S`)
+
+	// statusPartPill matches the part pill of a status message split across
+	// multiple messages, e.g. "**`PART 1/3`**".
+	statusPartPill = regexp.MustCompile("\\*\\*`PART \\d+/\\d+`\\*\\*")
+
 	statusText = map[Status]string{
 		StatusSuccess:         "🟩 SUCCESS",
 		StatusNoOperation:     "🟦 NO CHANGES",
 		StatusFailure:         "🟥 FAILED",
 		StatusUnknown:         "⛔️ UNKNOWN",
 		StatusPolicyViolation: "🚨 ATTENTION REQUIRED",
+		StatusPolicyAdvisory:  "⚠️ POLICY ADVISORY",
+		StatusStalePlan:       "🟧 STALE PLAN",
+		StatusDestroy:         "💥 DESTROY ENTRYPOINT",
 	}
 )
 
@@ -59,6 +74,30 @@ type StatusParams struct {
 	ErrorMessage string
 	Message      string
 	Operation    string
+
+	// Annotations are reported alongside the status on platforms that support
+	// attaching messages to lines of a file.
+	Annotations []*Annotation
+}
+
+// AnnotationLevel is the severity of an annotation.
+type AnnotationLevel string
+
+const (
+	AnnotationLevelNotice  AnnotationLevel = "notice"
+	AnnotationLevelWarning AnnotationLevel = "warning"
+	AnnotationLevelFailure AnnotationLevel = "failure"
+)
+
+// Annotation is a message attached to a range of lines of a file.
+type Annotation struct {
+	// Path is the path of the file, relative to the repository root.
+	Path      string
+	StartLine int
+	EndLine   int
+	Level     AnnotationLevel
+	Title     string
+	Message   string
 }
 
 // EntrypointsSummaryParams are the parameters for writing entrypoints summary reports.
@@ -99,10 +138,19 @@ func formatOutputForDiff(content string) string {
 const (
 	commentPrefix    = "#### 🔱 Guardian 🔱"
 	truncatedMessage = "\n\n> Message has been truncated. See workflow logs to view the full message."
+
+	// maxStatusMessageParts is the maximum number of messages a status message
+	// is split across before the details are truncated.
+	maxStatusMessageParts = 10
+
+	// minStatusMessagePartSize is the minimum length of the details in each
+	// part of a split status message, below which the details are truncated
+	// instead.
+	minStatusMessagePartSize = 1000
 )
 
-// statusMessage generates the status message based on the provided reporter values.
-func statusMessage(st Status, p *StatusParams, logURL string, maxCommentLength int) (strings.Builder, error) {
+// statusTitle generates the first line of a status message.
+func statusTitle(st Status, p *StatusParams, logURL string) string {
 	var msg strings.Builder
 
 	fmt.Fprintf(&msg, "%s", commentPrefix)
@@ -123,6 +171,14 @@ func statusMessage(st Status, p *StatusParams, logURL string, maxCommentLength i
 		fmt.Fprintf(&msg, " [%s]", markdownURL("logs", logURL))
 	}
 
+	return msg.String()
+}
+
+// statusSummary generates the entrypoint, message and error of a status
+// message.
+func statusSummary(p *StatusParams) string {
+	var msg strings.Builder
+
 	if p.Dir != "" {
 		fmt.Fprintf(&msg, "\n\n**Entrypoint:** %s", p.Dir)
 	}
@@ -135,12 +191,25 @@ func statusMessage(st Status, p *StatusParams, logURL string, maxCommentLength i
 		fmt.Fprintf(&msg, "\n\n **Error:** `%s`", p.ErrorMessage)
 	}
 
-	if p.Details != "" {
-		detailsText := fmt.Sprintf("\n\n%s", markdownZippy("Details", p.Details))
+	return msg.String()
+}
 
-		if p.HasDiff {
-			detailsText = fmt.Sprintf("\n\n%s", markdownDiffZippy("Details", formatOutputForDiff(p.Details)))
-		}
+// statusDetails generates the collapsible details section of a status message.
+func statusDetails(p *StatusParams, details string) string {
+	if p.HasDiff {
+		return fmt.Sprintf("\n\n%s", markdownDiffZippy("Details", formatOutputForDiff(details)))
+	}
+	return fmt.Sprintf("\n\n%s", markdownZippy("Details", details))
+}
+
+// statusMessage generates the status message based on the provided reporter values.
+func statusMessage(st Status, p *StatusParams, logURL string, maxCommentLength int) (strings.Builder, error) {
+	var msg strings.Builder
+
+	fmt.Fprintf(&msg, "%s%s", statusTitle(st, p, logURL), statusSummary(p))
+
+	if p.Details != "" {
+		detailsText := statusDetails(p, p.Details)
 
 		// if the length of the entire message would exceed the max length
 		// append a truncated message instead of the details text.
@@ -155,6 +224,133 @@ func statusMessage(st Status, p *StatusParams, logURL string, maxCommentLength i
 	return msg, nil
 }
 
+// statusMessageParts generates the status message based on the provided
+// reporter values. If the message would exceed the max length, the details are
+// split on resource boundaries across numbered continuation messages, up to
+// maxStatusMessageParts, after which the details are truncated.
+func statusMessageParts(st Status, p *StatusParams, logURL string, maxCommentLength int) ([]string, error) {
+	msg, err := statusMessage(st, p, logURL, -1)
+	if err != nil {
+		return nil, err
+	}
+	if maxCommentLength < 0 || p.Details == "" || len([]rune(msg.String())) <= maxCommentLength {
+		return []string{msg.String()}, nil
+	}
+
+	title := statusTitle(st, p, logURL)
+	summary := statusSummary(p)
+
+	// reserve room for the longest possible part header and the details
+	// wrapper, so the details of every part fit within the max length.
+	partPill := func(part, total int) string {
+		return " " + markdownPill(fmt.Sprintf("PART %d/%d", part, total))
+	}
+	overhead := len([]rune(title)) + len([]rune(summary)) +
+		len([]rune(partPill(maxStatusMessageParts, maxStatusMessageParts))) +
+		len([]rune(statusDetails(p, ""))) + len([]rune(truncatedMessage))
+
+	size := maxCommentLength - overhead
+	if size < minStatusMessagePartSize {
+		msg, err := statusMessage(st, p, logURL, maxCommentLength)
+		if err != nil {
+			return nil, err
+		}
+		return []string{msg.String()}, nil
+	}
+
+	chunks := splitDetails(p.Details, size)
+	truncated := len(chunks) > maxStatusMessageParts
+	if truncated {
+		chunks = chunks[:maxStatusMessageParts]
+	}
+
+	parts := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		var part strings.Builder
+
+		fmt.Fprintf(&part, "%s%s", title, partPill(i+1, len(chunks)))
+		if i == 0 {
+			fmt.Fprintf(&part, "%s", summary)
+		} else if p.Dir != "" {
+			fmt.Fprintf(&part, "\n\n**Entrypoint:** %s", p.Dir)
+		}
+		fmt.Fprintf(&part, "%s", statusDetails(p, chunk))
+
+		if truncated && i == len(chunks)-1 {
+			fmt.Fprintf(&part, "%s", truncatedMessage)
+		}
+
+		parts = append(parts, part.String())
+	}
+
+	return parts, nil
+}
+
+// splitDetails splits the details into chunks of at most size characters,
+// preferring to split between resources, then between lines.
+func splitDetails(details string, size int) []string {
+	// group the lines into segments, starting a new segment for each resource.
+	var segments []string
+	var segment strings.Builder
+	for _, line := range strings.SplitAfter(details, "\n") {
+		if resourceBoundary.MatchString(line) && segment.Len() > 0 {
+			segments = append(segments, segment.String())
+			segment.Reset()
+		}
+		segment.WriteString(line)
+	}
+	if segment.Len() > 0 {
+		segments = append(segments, segment.String())
+	}
+
+	var chunks []string
+	var chunk []rune
+	for _, segment := range segments {
+		for _, piece := range splitToSize(segment, size) {
+			if len(chunk) > 0 && len(chunk)+len(piece) > size {
+				chunks = append(chunks, string(chunk))
+				chunk = nil
+			}
+			chunk = append(chunk, piece...)
+		}
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, string(chunk))
+	}
+
+	return chunks
+}
+
+// splitToSize splits text into pieces of at most size characters, splitting
+// between lines where possible.
+func splitToSize(text string, size int) [][]rune {
+	if runes := []rune(text); len(runes) <= size {
+		return [][]rune{runes}
+	}
+
+	var pieces [][]rune
+	var piece []rune
+	for _, line := range strings.SplitAfter(text, "\n") {
+		runes := []rune(line)
+		if len(piece) > 0 && len(piece)+len(runes) > size {
+			pieces = append(pieces, piece)
+			piece = nil
+		}
+
+		// lines longer than the size are split at the size
+		for len(runes) > size {
+			pieces = append(pieces, runes[:size])
+			runes = runes[size:]
+		}
+		piece = append(piece, runes...)
+	}
+	if len(piece) > 0 {
+		pieces = append(pieces, piece)
+	}
+
+	return pieces
+}
+
 // entrypointsSummaryMessage generates the entrypoints summary message based on the provided reporter values.
 func entrypointsSummaryMessage(p *EntrypointsSummaryParams, logURL string) (strings.Builder, error) {
 	var msg strings.Builder
@@ -178,3 +374,134 @@ func entrypointsSummaryMessage(p *EntrypointsSummaryParams, logURL string) (stri
 
 	return msg, nil
 }
+
+const (
+	statusMarkerFormat     = "<!-- guardian:status operation=%q dir=%q -->"
+	statusPartMarkerFormat = "<!-- guardian:status-part operation=%q dir=%q -->"
+	statusHistoryMarker    = "<!-- guardian:history -->"
+
+	// statusHistoryLimit is the maximum number of previous statuses kept when
+	// a status report is updated in place.
+	statusHistoryLimit = 5
+)
+
+// statusMarker returns the hidden marker used to find the previous status
+// report for the same operation and entrypoint.
+func statusMarker(p *StatusParams) string {
+	return fmt.Sprintf(statusMarkerFormat, strings.ToLower(strings.TrimSpace(p.Operation)), p.Dir)
+}
+
+// statusPartMarker returns the hidden marker used to find the continuation
+// reports of the previous status report for the same operation and entrypoint.
+func statusPartMarker(p *StatusParams) string {
+	return fmt.Sprintf(statusPartMarkerFormat, strings.ToLower(strings.TrimSpace(p.Operation)), p.Dir)
+}
+
+// upsertStatusMessages generates the status messages that replace a previous
+// status report, keeping a short history of the previous statuses. The
+// previous report body may be empty if there is no previous report. The first
+// message carries the history and the status marker, continuation messages
+// carry the status part marker.
+func upsertStatusMessages(st Status, p *StatusParams, logURL string, maxCommentLength int, previous string) ([]string, error) {
+	var suffix strings.Builder
+
+	if history := statusHistory(previous); len(history) > 0 {
+		fmt.Fprintf(&suffix, "\n\n<details>\n<summary>Previous statuses</summary>\n\n%s\n%s\n</details>",
+			statusHistoryMarker, strings.Join(history, "\n"))
+	}
+	fmt.Fprintf(&suffix, "\n\n%s", statusMarker(p))
+
+	partSuffix := fmt.Sprintf("\n\n%s", statusPartMarker(p))
+
+	// reserve room for the history and markers so they are never truncated.
+	if maxCommentLength >= 0 {
+		reserved := max(len([]rune(suffix.String())), len([]rune(partSuffix)))
+		maxCommentLength = max(maxCommentLength-reserved, 0)
+	}
+
+	parts, err := statusMessageParts(st, p, logURL, maxCommentLength)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]string, 0, len(parts))
+	for i, part := range parts {
+		if i == 0 {
+			msgs = append(msgs, part+suffix.String())
+			continue
+		}
+		msgs = append(msgs, part+partSuffix)
+	}
+
+	return msgs, nil
+}
+
+// statusReportOps are the platform operations used to upsert a status report.
+type statusReportOps struct {
+	create func(ctx context.Context, body string) error
+	edit   func(ctx context.Context, r *Report, body string) error
+	delete func(ctx context.Context, r *Report) error
+}
+
+// upsertStatusReports edits the previous status report and its continuation
+// reports in place, creating or deleting continuation reports as the number
+// of messages changes. If there is no previous report, every message is
+// created.
+func upsertStatusReports(ctx context.Context, msgs []string, previous *Report, previousParts []*Report, ops *statusReportOps) error {
+	if previous == nil {
+		previousParts = nil
+	}
+
+	for i, msg := range msgs {
+		switch {
+		case i == 0 && previous != nil:
+			if err := ops.edit(ctx, previous, msg); err != nil {
+				return err
+			}
+		case i > 0 && i <= len(previousParts):
+			if err := ops.edit(ctx, previousParts[i-1], msg); err != nil {
+				return err
+			}
+		default:
+			if err := ops.create(ctx, msg); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := len(msgs) - 1; i < len(previousParts); i++ {
+		if err := ops.delete(ctx, previousParts[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// statusHistory returns the history entries for a status report that replaces
+// the previous report, newest first.
+func statusHistory(previous string) []string {
+	if previous == "" {
+		return nil
+	}
+
+	headline, _, _ := strings.Cut(previous, "\n")
+	headline = strings.TrimSpace(strings.TrimPrefix(headline, commentPrefix))
+	headline = strings.TrimSpace(statusPartPill.ReplaceAllString(headline, ""))
+
+	history := []string{"- " + headline}
+
+	if _, rest, ok := strings.Cut(previous, statusHistoryMarker); ok {
+		rest, _, _ = strings.Cut(rest, "</details>")
+		for _, line := range strings.Split(rest, "\n") {
+			if strings.HasPrefix(line, "- ") {
+				history = append(history, line)
+			}
+		}
+	}
+
+	if len(history) > statusHistoryLimit {
+		history = history[:statusHistoryLimit]
+	}
+	return history
+}
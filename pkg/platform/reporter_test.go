@@ -0,0 +1,380 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStatusHistory(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		previous string
+		exp      []string
+	}{
+		{
+			name:     "no_previous",
+			previous: "",
+			exp:      nil,
+		},
+		{
+			name:     "previous_without_history",
+			previous: commentPrefix + " **`PLAN`** **`🟥 FAILED`**\n\n**Entrypoint:** terraform",
+			exp:      []string{"- **`PLAN`** **`🟥 FAILED`**"},
+		},
+		{
+			name: "previous_with_history",
+			previous: commentPrefix + " **`PLAN`** **`🟥 FAILED`**\n\n- not history\n\n<details>\n<summary>Previous statuses</summary>\n\n" +
+				statusHistoryMarker + "\n- 1\n- 2\n</details>\n\n" + statusMarker(&StatusParams{Operation: "plan"}),
+			exp: []string{"- **`PLAN`** **`🟥 FAILED`**", "- 1", "- 2"},
+		},
+		{
+			name: "limits_history",
+			previous: commentPrefix + " latest\n\n<details>\n<summary>Previous statuses</summary>\n\n" +
+				statusHistoryMarker + "\n- 1\n- 2\n- 3\n- 4\n- 5\n</details>",
+			exp: []string{"- latest", "- 1", "- 2", "- 3", "- 4"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := statusHistory(tc.previous)
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("history not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestUpsertStatusMessages(t *testing.T) {
+	t.Parallel()
+
+	p := &StatusParams{
+		Operation: "plan",
+		Dir:       "terraform",
+		Details:   strings.Repeat("a", 100),
+	}
+
+	first, err := upsertStatusMessages(StatusFailure, p, "", -1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(first), 1; got != want {
+		t.Fatalf("expected %d messages, got %d", want, got)
+	}
+	if got, want := first[0], statusMarker(p); !strings.HasSuffix(got, want) {
+		t.Errorf("expected message %q to end with marker %q", got, want)
+	}
+	if strings.Contains(first[0], statusHistoryMarker) {
+		t.Errorf("expected message without previous status to have no history, got %q", first[0])
+	}
+
+	second, err := upsertStatusMessages(StatusSuccess, p, "", 300, first[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := second[0]
+	if !strings.HasPrefix(got, commentPrefix) || !strings.HasSuffix(got, statusMarker(p)) {
+		t.Errorf("expected message to keep the prefix and marker, got %q", got)
+	}
+	if !strings.Contains(got, truncatedMessage) {
+		t.Errorf("expected details to be truncated to make room for the history, got %q", got)
+	}
+	if !strings.Contains(got, "- "+markdownPill("PLAN")+" "+markdownPill(statusText[StatusFailure])) {
+		t.Errorf("expected message to contain the previous status, got %q", got)
+	}
+}
+
+func TestUpsertStatusMessages_Parts(t *testing.T) {
+	t.Parallel()
+
+	var details strings.Builder
+	for i := range 12 {
+		fmt.Fprintf(&details, "  # null_resource.r%d will be created\n%s", i, strings.Repeat("      + id = (known after apply)\n", 20))
+	}
+
+	p := &StatusParams{
+		Operation: "plan",
+		Dir:       "terraform",
+		Details:   details.String(),
+	}
+
+	const maxCommentLength = 2000
+
+	first, err := upsertStatusMessages(StatusFailure, p, "", maxCommentLength, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) < 2 {
+		t.Fatalf("expected the message to be split, got %d messages", len(first))
+	}
+
+	msgs, err := upsertStatusMessages(StatusSuccess, p, "", maxCommentLength, first[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, msg := range msgs {
+		if got := len([]rune(msg)); got > maxCommentLength {
+			t.Errorf("expected message %d length %d to be at most %d", i, got, maxCommentLength)
+		}
+		if !strings.Contains(msg, fmt.Sprintf("PART %d/%d", i+1, len(msgs))) {
+			t.Errorf("expected message %d to contain its part number, got %q", i, msg)
+		}
+
+		marker := statusPartMarker(p)
+		if i == 0 {
+			marker = statusMarker(p)
+		}
+		if !strings.HasSuffix(msg, marker) {
+			t.Errorf("expected message %d to end with marker %q, got %q", i, marker, msg)
+		}
+		if i > 0 && strings.Contains(msg, statusMarker(p)) {
+			t.Errorf("expected continuation message %d not to contain the status marker, got %q", i, msg)
+		}
+	}
+
+	// the history should not include the part pill of the previous status.
+	if want := "- " + markdownPill("PLAN") + " " + markdownPill(statusText[StatusFailure]) + "\n"; !strings.Contains(msgs[0], want) {
+		t.Errorf("expected message to contain the previous status %q, got %q", want, msgs[0])
+	}
+}
+
+func TestUpsertStatusReports(t *testing.T) {
+	t.Parallel()
+
+	report := func(id int) *Report {
+		return &Report{ID: id}
+	}
+
+	cases := []struct {
+		name          string
+		msgs          []string
+		previous      *Report
+		previousParts []*Report
+		exp           []string
+	}{
+		{
+			name: "no_previous",
+			msgs: []string{"a", "b"},
+			exp:  []string{"create a", "create b"},
+		},
+		{
+			name:          "no_previous_ignores_parts",
+			msgs:          []string{"a"},
+			previousParts: []*Report{report(2)},
+			exp:           []string{"create a"},
+		},
+		{
+			name:     "edit",
+			msgs:     []string{"a"},
+			previous: report(1),
+			exp:      []string{"edit 1 a"},
+		},
+		{
+			name:          "more_parts",
+			msgs:          []string{"a", "b", "c"},
+			previous:      report(1),
+			previousParts: []*Report{report(2)},
+			exp:           []string{"edit 1 a", "edit 2 b", "create c"},
+		},
+		{
+			name:          "fewer_parts",
+			msgs:          []string{"a", "b"},
+			previous:      report(1),
+			previousParts: []*Report{report(2), report(3), report(4)},
+			exp:           []string{"edit 1 a", "edit 2 b", "delete 3", "delete 4"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			if err := upsertStatusReports(t.Context(), tc.msgs, tc.previous, tc.previousParts, &statusReportOps{
+				create: func(ctx context.Context, body string) error {
+					got = append(got, "create "+body)
+					return nil
+				},
+				edit: func(ctx context.Context, r *Report, body string) error {
+					got = append(got, fmt.Sprintf("edit %v %s", r.ID, body))
+					return nil
+				},
+				delete: func(ctx context.Context, r *Report) error {
+					got = append(got, fmt.Sprintf("delete %v", r.ID))
+					return nil
+				},
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("operations not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestStatusMessageParts(t *testing.T) {
+	t.Parallel()
+
+	resource := func(name string) string {
+		return fmt.Sprintf("  # null_resource.%s will be created\n  + resource \"null_resource\" %q {\n%s  }\n", name, name, strings.Repeat("      + id = (known after apply)\n", 20))
+	}
+
+	var details strings.Builder
+	for i := range 12 {
+		details.WriteString(resource(fmt.Sprintf("r%d", i)))
+	}
+
+	cases := []struct {
+		name             string
+		details          string
+		maxCommentLength int
+		expParts         int
+		expTruncated     bool
+	}{
+		{
+			name:             "fits",
+			details:          resource("a"),
+			maxCommentLength: 65536,
+			expParts:         1,
+		},
+		{
+			name:             "no_max",
+			details:          details.String(),
+			maxCommentLength: -1,
+			expParts:         1,
+		},
+		{
+			name:             "splits",
+			details:          details.String(),
+			maxCommentLength: 4000,
+			expParts:         3,
+		},
+		{
+			name:             "truncates_after_max_parts",
+			details:          strings.Repeat(details.String(), 4),
+			maxCommentLength: 2000,
+			expParts:         maxStatusMessageParts,
+			expTruncated:     true,
+		},
+		{
+			name:             "truncates_below_min_part_size",
+			details:          details.String(),
+			maxCommentLength: 1000,
+			expParts:         1,
+			expTruncated:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := &StatusParams{
+				Operation: "plan",
+				Dir:       "terraform",
+				Details:   tc.details,
+				HasDiff:   true,
+			}
+
+			parts, err := statusMessageParts(StatusSuccess, p, "", tc.maxCommentLength)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(parts), tc.expParts; got != want {
+				t.Fatalf("expected %d parts, got %d", want, got)
+			}
+
+			for i, part := range parts {
+				if !strings.HasPrefix(part, commentPrefix) {
+					t.Errorf("expected part %d to start with the comment prefix, got %q", i, part)
+				}
+				if tc.maxCommentLength >= 0 && len([]rune(part)) > tc.maxCommentLength && !tc.expTruncated {
+					t.Errorf("expected part %d length %d to be at most %d", i, len([]rune(part)), tc.maxCommentLength)
+				}
+				if len(parts) > 1 && !strings.Contains(part, markdownPill(fmt.Sprintf("PART %d/%d", i+1, len(parts)))) {
+					t.Errorf("expected part %d to be numbered, got %q", i, part)
+				}
+			}
+
+			last := parts[len(parts)-1]
+			if got, want := strings.Contains(last, truncatedMessage), tc.expTruncated; got != want {
+				t.Errorf("expected truncated to be %t, got %t", want, got)
+			}
+		})
+	}
+}
+
+func TestSplitDetails(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		details string
+		size    int
+		exp     []string
+	}{
+		{
+			name:    "resource_boundaries",
+			details: "  # a will be created\n  + a\n  # b will be created\n  + b\n",
+			size:    30,
+			exp:     []string{"  # a will be created\n  + a\n", "  # b will be created\n  + b\n"},
+		},
+		{
+			name:    "packs_resources",
+			details: "# a\n+ a\n# b\n+ b\n# c\n+ c\n",
+			size:    16,
+			exp:     []string{"# a\n+ a\n# b\n+ b\n", "# c\n+ c\n"},
+		},
+		{
+			name:    "splits_lines",
+			details: "# a\n+ 1\n+ 2\n+ 3\n",
+			size:    8,
+			exp:     []string{"# a\n+ 1\n", "+ 2\n+ 3\n"},
+		},
+		{
+			name:    "splits_long_lines",
+			details: "0123456789",
+			size:    4,
+			exp:     []string{"0123", "4567", "89"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := splitDetails(tc.details, tc.size)
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("chunks not as expected; (-got,+want): %s", diff)
+			}
+			if got, want := strings.Join(got, ""), tc.details; got != want {
+				t.Errorf("expected chunks to join to %q, got %q", want, got)
+			}
+		})
+	}
+}
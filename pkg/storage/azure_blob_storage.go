@@ -0,0 +1,274 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+)
+
+var _ Storage = (*AzureBlobStorage)(nil)
+
+// AzureBlobConfig is the configuration for the Azure Blob Storage client. When
+// no values are set, they are read from the AZURE_STORAGE_CONNECTION_STRING and
+// AZURE_STORAGE_ACCOUNT environment variables.
+type AzureBlobConfig struct {
+	// ConnectionString is a storage account connection string. This takes
+	// precedence over the service URL and is the simplest way to connect to
+	// Azurite.
+	ConnectionString string
+
+	// ServiceURL is the blob service URL of the storage account, e.g.
+	// https://myaccount.blob.core.windows.net. Requests are authenticated using
+	// the default Azure credential chain.
+	ServiceURL string
+}
+
+// AzureBlobStorage implements the Storage interface for Azure Blob Storage.
+type AzureBlobStorage struct {
+	client    *azblob.Client
+	container string
+	prefix    string
+}
+
+// NewAzureBlobStorage creates a new AzureBlobStorage client. The parent is the
+// container name, optionally followed by a blob name prefix, e.g.
+// "my-container/guardian".
+func NewAzureBlobStorage(ctx context.Context, parent string, cfg *AzureBlobConfig) (*AzureBlobStorage, error) {
+	if cfg == nil {
+		cfg = &AzureBlobConfig{}
+	}
+
+	connectionString := cfg.ConnectionString
+	serviceURL := cfg.ServiceURL
+	if connectionString == "" && serviceURL == "" {
+		connectionString = os.Getenv("AZURE_STORAGE_CONNECTION_STRING")
+		if account := os.Getenv("AZURE_STORAGE_ACCOUNT"); account != "" {
+			serviceURL = fmt.Sprintf("https://%s.blob.core.windows.net", account)
+		}
+	}
+
+	var client *azblob.Client
+	switch {
+	case connectionString != "":
+		c, err := azblob.NewClientFromConnectionString(connectionString, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create azure blob client from connection string: %w", err)
+		}
+		client = c
+	case serviceURL != "":
+		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create azure credential: %w", err)
+		}
+
+		c, err := azblob.NewClient(serviceURL, cred, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create azure blob client: %w", err)
+		}
+		client = c
+	default:
+		return nil, fmt.Errorf("azure storage account is required, set AZURE_STORAGE_ACCOUNT or AZURE_STORAGE_CONNECTION_STRING")
+	}
+
+	return newAzureBlobStorage(client, parent)
+}
+
+// newAzureBlobStorage creates a new AzureBlobStorage client using an existing
+// Azure Blob client.
+func newAzureBlobStorage(client *azblob.Client, parent string) (*AzureBlobStorage, error) {
+	container, prefix, _ := strings.Cut(strings.Trim(parent, "/"), "/")
+	if container == "" {
+		return nil, fmt.Errorf("azure blob container is required")
+	}
+
+	return &AzureBlobStorage{
+		client:    client,
+		container: container,
+		prefix:    prefix,
+	}, nil
+}
+
+// Parent returns the Azure Blob Storage container name and blob name prefix.
+func (s *AzureBlobStorage) Parent() string {
+	return path.Join(s.container, s.prefix)
+}
+
+// blobName returns the blob name for a name, relative to the blob name prefix.
+func (s *AzureBlobStorage) blobName(name string) string {
+	return path.Join(s.prefix, name)
+}
+
+// CreateObject uploads a blob to an Azure Blob Storage container using a set of
+// upload options. Unless overwriting is allowed, the upload fails if the blob
+// already exists. Conditional updates use the ETag of the blob as its version.
+func (s *AzureBlobStorage) CreateObject(ctx context.Context, name string, contents []byte, opts ...CreateOption) error {
+	cfg := makeCreateConfig(len(contents), opts)
+
+	uploadOpts := &azblob.UploadBufferOptions{
+		HTTPHeaders: &blob.HTTPHeaders{
+			BlobCacheControl: to.Ptr(cfg.cacheControl),
+		},
+	}
+
+	if cfg.contentType != "" {
+		uploadOpts.HTTPHeaders.BlobContentType = to.Ptr(cfg.contentType)
+	}
+
+	if cfg.metadata != nil {
+		m := make(map[string]*string, len(cfg.metadata))
+
+		for k, v := range cfg.metadata {
+			m[k] = to.Ptr(v)
+		}
+
+		uploadOpts.Metadata = m
+	}
+
+	switch {
+	case cfg.ifVersionMatch != "":
+		uploadOpts.AccessConditions = &blob.AccessConditions{
+			ModifiedAccessConditions: &blob.ModifiedAccessConditions{
+				IfMatch: to.Ptr(azcore.ETag(cfg.ifVersionMatch)),
+			},
+		}
+	case !cfg.allowOverwrite:
+		uploadOpts.AccessConditions = &blob.AccessConditions{
+			ModifiedAccessConditions: &blob.ModifiedAccessConditions{
+				IfNoneMatch: to.Ptr(azcore.ETagAny),
+			},
+		}
+	}
+
+	if _, err := s.client.UploadBuffer(ctx, s.container, s.blobName(name), contents, uploadOpts); err != nil {
+		if bloberror.HasCode(err, bloberror.BlobAlreadyExists, bloberror.ConditionNotMet) {
+			return fmt.Errorf("failed to upload blob: %w: %w", ErrPreconditionFailed, err)
+		}
+		return fmt.Errorf("failed to upload blob: %w", err)
+	}
+	return nil
+}
+
+// GetObject downloads a blob from an Azure Blob Storage container. The caller
+// must call Close on the returned Reader when done reading.
+func (s *AzureBlobStorage) GetObject(ctx context.Context, name string) (io.ReadCloser, map[string]string, error) {
+	resp, err := s.client.DownloadStream(ctx, s.container, s.blobName(name), nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, nil, fmt.Errorf("failed to download blob: %w", ErrObjectNotFound)
+		}
+		return nil, nil, fmt.Errorf("failed to download blob: %w", err)
+	}
+
+	// Metadata names are case-insensitive in Azure, but are returned in the
+	// canonical HTTP header case, so they are normalized to lowercase to match the
+	// other storage clients.
+	metadata := make(map[string]string, len(resp.Metadata))
+	for k, v := range resp.Metadata {
+		if v != nil {
+			metadata[strings.ToLower(k)] = *v
+		}
+	}
+
+	return resp.Body, metadata, nil
+}
+
+// ObjectVersion returns the ETag of a blob in an Azure Blob Storage container.
+func (s *AzureBlobStorage) ObjectVersion(ctx context.Context, name string) (string, error) {
+	resp, err := s.client.ServiceClient().
+		NewContainerClient(s.container).
+		NewBlobClient(s.blobName(name)).
+		GetProperties(ctx, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return "", fmt.Errorf("failed to get blob properties: %w", ErrObjectNotFound)
+		}
+		return "", fmt.Errorf("failed to get blob properties: %w", err)
+	}
+
+	if resp.ETag == nil {
+		return "", fmt.Errorf("blob properties did not include an etag")
+	}
+	return string(*resp.ETag), nil
+}
+
+// DeleteObject deletes a blob from an Azure Blob Storage container. If the blob
+// does not exist, no error will be returned.
+func (s *AzureBlobStorage) DeleteObject(ctx context.Context, name string) error {
+	if _, err := s.client.DeleteBlob(ctx, s.container, s.blobName(name), nil); err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+// ObjectsWithName returns all blobs under the blob name prefix with a given
+// file name.
+func (s *AzureBlobStorage) ObjectsWithName(ctx context.Context, filename string) ([]string, error) {
+	opts := &azblob.ListBlobsFlatOptions{}
+	if s.prefix != "" {
+		opts.Prefix = to.Ptr(s.prefix + "/")
+	}
+
+	var uris []string
+	p := s.client.NewListBlobsFlatPager(s.container, opts)
+	for p.More() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			if bloberror.HasCode(err, bloberror.ContainerNotFound) {
+				return nil, ErrBucketNotFound
+			}
+			return nil, fmt.Errorf("failed to list container contents: %w", err)
+		}
+
+		if page.Segment == nil {
+			continue
+		}
+
+		for _, item := range page.Segment.BlobItems {
+			if item.Name == nil {
+				continue
+			}
+			if strings.HasSuffix(*item.Name, filename) {
+				uris = append(uris, fmt.Sprintf("azblob://%s/%s", s.container, *item.Name))
+			}
+		}
+	}
+	return uris, nil
+}
+
+// azureBlobConfigFromQuery parses the Azure Blob Storage configuration from the
+// query parameters of a storage url, e.g.
+// "azblob://container/prefix?service_url=http://127.0.0.1:10000/devstoreaccount1".
+func azureBlobConfigFromQuery(q map[string][]string) *AzureBlobConfig {
+	cfg := &AzureBlobConfig{}
+	if v := q["service_url"]; len(v) > 0 {
+		cfg.ServiceURL = v[0]
+	}
+	return cfg
+}
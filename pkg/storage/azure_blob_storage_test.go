@@ -0,0 +1,427 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+// fakeBlob is a blob stored by fakeAzureBlob.
+type fakeBlob struct {
+	body     []byte
+	metadata map[string]string
+}
+
+// etag returns the entity tag of the blob, derived from its contents.
+func (b *fakeBlob) etag() string {
+	return fmt.Sprintf("%q", fmt.Sprintf("%x", sha256.Sum256(b.body)))
+}
+
+// fakeAzureBlob is a minimal in-memory implementation of the Azure Blob
+// Storage API, supporting put, get, head, delete and list requests for a
+// single container.
+type fakeAzureBlob struct {
+	container string
+
+	mu    sync.Mutex
+	blobs map[string]*fakeBlob
+}
+
+func (f *fakeAzureBlob) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	container, name, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/account/"), "/")
+	if container != f.container {
+		f.writeError(w, http.StatusNotFound, "ContainerNotFound")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPut:
+		existing, ok := f.blobs[name]
+		if ok && r.Header.Get("If-None-Match") == "*" {
+			f.writeError(w, http.StatusConflict, "BlobAlreadyExists")
+			return
+		}
+		if v := r.Header.Get("If-Match"); v != "" && (!ok || existing.etag() != v) {
+			f.writeError(w, http.StatusPreconditionFailed, "ConditionNotMet")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			f.writeError(w, http.StatusInternalServerError, "InternalError")
+			return
+		}
+
+		metadata := make(map[string]string)
+		for k, v := range r.Header {
+			if name, ok := strings.CutPrefix(strings.ToLower(k), "x-ms-meta-"); ok {
+				metadata[name] = v[0]
+			}
+		}
+		b := &fakeBlob{body: body, metadata: metadata}
+		f.blobs[name] = b
+		w.Header().Set("ETag", b.etag())
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == http.MethodHead:
+		b, ok := f.blobs[name]
+		if !ok {
+			f.writeError(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		w.Header().Set("ETag", b.etag())
+		w.Header().Set("Content-Length", strconv.Itoa(len(b.body)))
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == http.MethodGet && r.URL.Query().Get("comp") == "list":
+		type blobItem struct {
+			Name string `xml:"Name"`
+		}
+		type result struct {
+			XMLName       xml.Name   `xml:"EnumerationResults"`
+			ContainerName string     `xml:"ContainerName,attr"`
+			Blobs         []blobItem `xml:"Blobs>Blob"`
+			NextMarker    string     `xml:"NextMarker"`
+		}
+
+		res := &result{ContainerName: f.container}
+		for k := range f.blobs {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				res.Blobs = append(res.Blobs, blobItem{Name: k})
+			}
+		}
+		sort.Slice(res.Blobs, func(i, j int) bool { return res.Blobs[i].Name < res.Blobs[j].Name })
+
+		w.Header().Set("Content-Type", "application/xml")
+		if err := xml.NewEncoder(w).Encode(res); err != nil {
+			panic(err)
+		}
+
+	case r.Method == http.MethodGet:
+		b, ok := f.blobs[name]
+		if !ok {
+			f.writeError(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+
+		for k, v := range b.metadata {
+			w.Header().Set("X-Ms-Meta-"+k, v)
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(b.body)))
+		if _, err := w.Write(b.body); err != nil {
+			panic(err)
+		}
+
+	case r.Method == http.MethodDelete:
+		if _, ok := f.blobs[name]; !ok {
+			f.writeError(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		delete(f.blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		f.writeError(w, http.StatusMethodNotAllowed, "UnsupportedHttpVerb")
+	}
+}
+
+func (f *fakeAzureBlob) writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("x-ms-error-code", code)
+	w.WriteHeader(status)
+}
+
+func testAzureBlobStorage(tb testing.TB, parent string) (*AzureBlobStorage, *fakeAzureBlob) {
+	tb.Helper()
+
+	fake := &fakeAzureBlob{
+		container: "my-container",
+		blobs:     make(map[string]*fakeBlob),
+	}
+	srv := httptest.NewServer(fake)
+	tb.Cleanup(srv.Close)
+
+	client, err := azblob.NewClientWithNoCredential(srv.URL+"/account", &azblob.ClientOptions{
+		ClientOptions: azcore.ClientOptions{
+			Retry: policy.RetryOptions{MaxRetries: -1},
+		},
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	s, err := newAzureBlobStorage(client, parent)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return s, fake
+}
+
+func TestAzureBlobStorage_CreateObject(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		existing    bool
+		opts        []CreateOption
+		expContents string
+		expMetadata map[string]string
+		err         string
+	}{
+		{
+			name:        "creates_with_metadata",
+			opts:        []CreateOption{WithMetadata(map[string]string{"plan_exit_code": "2"})},
+			expContents: "new",
+			expMetadata: map[string]string{"plan_exit_code": "2"},
+		},
+		{
+			name:        "fails_if_exists",
+			existing:    true,
+			expContents: "old",
+			expMetadata: map[string]string{},
+			err:         "BlobAlreadyExists",
+		},
+		{
+			name:        "overwrites_if_allowed",
+			existing:    true,
+			opts:        []CreateOption{WithAllowOverwrite(true)},
+			expContents: "new",
+			expMetadata: map[string]string{},
+		},
+		{
+			name:        "overwrites_if_version_matches",
+			existing:    true,
+			opts:        []CreateOption{WithIfVersionMatch((&fakeBlob{body: []byte("old")}).etag())},
+			expContents: "new",
+			expMetadata: map[string]string{},
+		},
+		{
+			name:        "fails_if_version_changed",
+			existing:    true,
+			opts:        []CreateOption{WithIfVersionMatch(`"stale"`)},
+			expContents: "old",
+			expMetadata: map[string]string{},
+			err:         ErrPreconditionFailed.Error(),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+			s, fake := testAzureBlobStorage(t, "my-container/guardian")
+
+			if tc.existing {
+				fake.blobs["guardian/dir/tfplan.binary"] = &fakeBlob{
+					body:     []byte("old"),
+					metadata: map[string]string{},
+				}
+			}
+
+			err := s.CreateObject(ctx, "dir/tfplan.binary", []byte("new"), tc.opts...)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			b, ok := fake.blobs["guardian/dir/tfplan.binary"]
+			if !ok {
+				t.Fatal("expected blob to exist")
+			}
+			if got, want := string(b.body), tc.expContents; got != want {
+				t.Errorf("expected contents %q to be %q", got, want)
+			}
+			if diff := cmp.Diff(b.metadata, tc.expMetadata); diff != "" {
+				t.Errorf("metadata not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestAzureBlobStorage_GetObject(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		object      string
+		expContents string
+		expMetadata map[string]string
+		err         string
+	}{
+		{
+			name:        "success",
+			object:      "dir/tfplan.binary",
+			expContents: "plan data",
+			expMetadata: map[string]string{"plan_exit_code": "2"},
+		},
+		{
+			name:   "not_found",
+			object: "missing/tfplan.binary",
+			err:    ErrObjectNotFound.Error(),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+			s, fake := testAzureBlobStorage(t, "my-container/guardian")
+			fake.blobs["guardian/dir/tfplan.binary"] = &fakeBlob{
+				body:     []byte("plan data"),
+				metadata: map[string]string{"plan_exit_code": "2"},
+			}
+
+			rc, metadata, err := s.GetObject(ctx, tc.object)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				return
+			}
+			defer rc.Close()
+
+			got, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := string(got), tc.expContents; got != want {
+				t.Errorf("expected contents %q to be %q", got, want)
+			}
+			if diff := cmp.Diff(metadata, tc.expMetadata); diff != "" {
+				t.Errorf("metadata not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestAzureBlobStorage_ObjectVersion(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	s, fake := testAzureBlobStorage(t, "my-container/guardian")
+	b := &fakeBlob{body: []byte("plan data")}
+	fake.blobs["guardian/dir/tfplan.binary"] = b
+
+	got, err := s.ObjectVersion(ctx, "dir/tfplan.binary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := b.etag(); got != want {
+		t.Errorf("expected version %q to be %q", got, want)
+	}
+
+	if _, err := s.ObjectVersion(ctx, "missing/tfplan.binary"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected %q to be %q", err, ErrObjectNotFound)
+	}
+}
+
+func TestAzureBlobStorage_DeleteObject(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	s, fake := testAzureBlobStorage(t, "my-container/guardian")
+	fake.blobs["guardian/dir/tfplan.binary"] = &fakeBlob{body: []byte("plan data")}
+
+	if err := s.DeleteObject(ctx, "dir/tfplan.binary"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.blobs["guardian/dir/tfplan.binary"]; ok {
+		t.Error("expected blob to be deleted")
+	}
+
+	if err := s.DeleteObject(ctx, "dir/tfplan.binary"); err != nil {
+		t.Errorf("expected deleting a missing blob to succeed: %s", err)
+	}
+}
+
+func TestAzureBlobStorage_ObjectsWithName(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		parent string
+		exp    []string
+		err    string
+	}{
+		{
+			name:   "with_prefix",
+			parent: "my-container/guardian",
+			exp: []string{
+				"azblob://my-container/guardian/a/default.tfstate",
+				"azblob://my-container/guardian/b/c/default.tfstate",
+			},
+		},
+		{
+			name:   "without_prefix",
+			parent: "my-container",
+			exp: []string{
+				"azblob://my-container/guardian/a/default.tfstate",
+				"azblob://my-container/guardian/b/c/default.tfstate",
+				"azblob://my-container/other/default.tfstate",
+			},
+		},
+		{
+			name:   "container_not_found",
+			parent: "missing-container",
+			err:    ErrBucketNotFound.Error(),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+			s, fake := testAzureBlobStorage(t, tc.parent)
+			for _, k := range []string{
+				"guardian/a/default.tfstate",
+				"guardian/a/tfplan.binary",
+				"guardian/b/c/default.tfstate",
+				"other/default.tfstate",
+			} {
+				fake.blobs[k] = &fakeBlob{}
+			}
+
+			got, err := s.ObjectsWithName(ctx, "default.tfstate")
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("objects not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
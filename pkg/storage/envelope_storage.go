@@ -0,0 +1,321 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	envelopeVersion = 1
+
+	envelopeSignatureHMACSHA256 = "HMAC-SHA256"
+	envelopeEncryptionAESGCM    = "AES-GCM"
+)
+
+var (
+	_ Storage = (*EnvelopeStorage)(nil)
+
+	// ErrInvalidSignature is returned when the signature of an envelope does not
+	// match its contents.
+	ErrInvalidSignature = errors.New("invalid envelope signature")
+)
+
+// envelope is the format objects are stored in by EnvelopeStorage. The
+// metadata is stored inside the envelope, so it is covered by the signature
+// and available for storage backends that do not support object metadata.
+type envelope struct {
+	Version    int               `json:"version"`
+	Signature  string            `json:"signature"`
+	Encryption string            `json:"encryption,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Nonce      []byte            `json:"nonce,omitempty"`
+	Payload    []byte            `json:"payload"`
+	MAC        []byte            `json:"mac,omitempty"`
+}
+
+// EnvelopeStorage implements the Storage interface by wrapping the objects of
+// another Storage client in a signed and optionally encrypted envelope.
+type EnvelopeStorage struct {
+	client        Storage
+	signingKey    []byte
+	encryptionKey []byte
+}
+
+// NewEnvelopeStorage creates a new EnvelopeStorage client wrapping the given
+// client. Objects are signed using HMAC-SHA256 with the signing key. If an
+// encryption key is provided, objects are also encrypted using AES-GCM, the
+// encryption key must be 16, 24 or 32 bytes.
+func NewEnvelopeStorage(ctx context.Context, client Storage, signingKey, encryptionKey []byte) (*EnvelopeStorage, error) {
+	if client == nil {
+		return nil, fmt.Errorf("storage client is required")
+	}
+
+	if len(signingKey) == 0 {
+		return nil, fmt.Errorf("signing key is required")
+	}
+
+	if len(encryptionKey) > 0 {
+		if _, err := aes.NewCipher(encryptionKey); err != nil {
+			return nil, fmt.Errorf("invalid encryption key: %w", err)
+		}
+	}
+
+	return &EnvelopeStorage{
+		client:        client,
+		signingKey:    signingKey,
+		encryptionKey: encryptionKey,
+	}, nil
+}
+
+// Parent returns the parent of the wrapped storage client.
+func (s *EnvelopeStorage) Parent() string {
+	return s.client.Parent()
+}
+
+// CreateObject seals the contents and metadata in an envelope and creates the
+// object using the wrapped storage client.
+func (s *EnvelopeStorage) CreateObject(ctx context.Context, name string, contents []byte, opts ...CreateOption) error {
+	cfg := &createConfig{}
+	for _, opt := range opts {
+		cfg = opt(cfg)
+	}
+
+	env, err := s.seal(name, contents, cfg.metadata)
+	if err != nil {
+		return fmt.Errorf("failed to seal envelope: %w", err)
+	}
+
+	b, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("failed to marshal envelope: %w", err)
+	}
+
+	// The content type is replaced as the stored object is always the JSON
+	// envelope, regardless of the original contents.
+	opts = append(opts, WithContentType("application/json"))
+
+	if err := s.client.CreateObject(ctx, name, b, opts...); err != nil {
+		return fmt.Errorf("failed to create envelope object: %w", err)
+	}
+	return nil
+}
+
+// GetObject gets the object from the wrapped storage client, verifies the
+// envelope signature and returns the original contents and metadata. The
+// caller must call Close on the returned Reader when done reading.
+func (s *EnvelopeStorage) GetObject(ctx context.Context, name string) (_ io.ReadCloser, _ map[string]string, outErr error) {
+	rc, _, err := s.client.GetObject(ctx, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get envelope object: %w", err)
+	}
+	defer func() {
+		if closeErr := rc.Close(); closeErr != nil {
+			outErr = errors.Join(outErr, fmt.Errorf("failed to close envelope object reader: %w", closeErr))
+		}
+	}()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read envelope object: %w", err)
+	}
+
+	var env envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse envelope: %w", err)
+	}
+
+	contents, err := s.open(name, &env)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open envelope: %w", err)
+	}
+
+	metadata := env.Metadata
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+
+	return io.NopCloser(bytes.NewReader(contents)), metadata, nil
+}
+
+// ObjectVersion returns the version of the object using the wrapped storage
+// client.
+func (s *EnvelopeStorage) ObjectVersion(ctx context.Context, name string) (string, error) {
+	version, err := s.client.ObjectVersion(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get envelope object version: %w", err)
+	}
+	return version, nil
+}
+
+// DeleteObject deletes the object using the wrapped storage client.
+func (s *EnvelopeStorage) DeleteObject(ctx context.Context, name string) error {
+	if err := s.client.DeleteObject(ctx, name); err != nil {
+		return fmt.Errorf("failed to delete envelope object: %w", err)
+	}
+	return nil
+}
+
+// ObjectsWithName returns the paths of objects with the given name using the
+// wrapped storage client.
+func (s *EnvelopeStorage) ObjectsWithName(ctx context.Context, name string) ([]string, error) {
+	objects, err := s.client.ObjectsWithName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list envelope objects: %w", err)
+	}
+	return objects, nil
+}
+
+// seal creates a signed envelope for the contents and metadata, encrypting the
+// contents if an encryption key is configured.
+func (s *EnvelopeStorage) seal(name string, contents []byte, metadata map[string]string) (*envelope, error) {
+	env := &envelope{
+		Version:   envelopeVersion,
+		Signature: envelopeSignatureHMACSHA256,
+		Metadata:  metadata,
+		Payload:   contents,
+	}
+
+	if len(s.encryptionKey) > 0 {
+		gcm, err := s.gcm()
+		if err != nil {
+			return nil, err
+		}
+
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, fmt.Errorf("failed to generate nonce: %w", err)
+		}
+
+		env.Encryption = envelopeEncryptionAESGCM
+		env.Nonce = nonce
+		env.Payload = gcm.Seal(nil, nonce, contents, []byte(name))
+	}
+
+	mac, err := s.mac(name, env)
+	if err != nil {
+		return nil, err
+	}
+	env.MAC = mac
+
+	return env, nil
+}
+
+// open verifies the envelope signature and returns the original contents,
+// decrypting them if the envelope is encrypted.
+func (s *EnvelopeStorage) open(name string, env *envelope) ([]byte, error) {
+	if env.Version != envelopeVersion {
+		return nil, fmt.Errorf("unsupported envelope version: %d", env.Version)
+	}
+
+	if env.Signature != envelopeSignatureHMACSHA256 {
+		return nil, fmt.Errorf("unsupported envelope signature: %q", env.Signature)
+	}
+
+	want, err := s.mac(name, env)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(env.MAC, want) {
+		return nil, ErrInvalidSignature
+	}
+
+	switch env.Encryption {
+	case "":
+		if len(s.encryptionKey) > 0 {
+			return nil, fmt.Errorf("envelope is not encrypted, but an encryption key is configured")
+		}
+		return env.Payload, nil
+	case envelopeEncryptionAESGCM:
+		if len(s.encryptionKey) == 0 {
+			return nil, fmt.Errorf("envelope is encrypted, but no encryption key is configured")
+		}
+
+		gcm, err := s.gcm()
+		if err != nil {
+			return nil, err
+		}
+
+		contents, err := gcm.Open(nil, env.Nonce, env.Payload, []byte(name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt envelope payload: %w", err)
+		}
+		return contents, nil
+	default:
+		return nil, fmt.Errorf("unsupported envelope encryption: %q", env.Encryption)
+	}
+}
+
+// mac computes the HMAC-SHA256 of the object name and every envelope field
+// except the MAC itself. The object name is included so an envelope cannot be
+// moved to a different path without invalidating the signature.
+func (s *EnvelopeStorage) mac(name string, env *envelope) ([]byte, error) {
+	unsigned := *env
+	unsigned.MAC = nil
+
+	// json.Marshal sorts map keys, which keeps the encoding of the metadata
+	// stable.
+	b, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal envelope for signing: %w", err)
+	}
+
+	h := hmac.New(sha256.New, s.signingKey)
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write(b)
+	return h.Sum(nil), nil
+}
+
+// gcm returns the AES-GCM cipher for the encryption key.
+func (s *EnvelopeStorage) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcm: %w", err)
+	}
+	return gcm, nil
+}
+
+// ParseEnvelopeKey parses a base64 encoded envelope key. Surrounding whitespace
+// is ignored, so the value can be read directly from a file.
+func ParseEnvelopeKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 key: %w", err)
+	}
+	return b, nil
+}
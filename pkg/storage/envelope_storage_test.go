@@ -0,0 +1,296 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestEnvelopeStorage(t *testing.T) {
+	t.Parallel()
+
+	signingKey := []byte("test-signing-key")
+	otherSigningKey := []byte("other-signing-key")
+	encryptionKey := bytes.Repeat([]byte("k"), 32)
+	otherEncryptionKey := bytes.Repeat([]byte("o"), 32)
+
+	cases := []struct {
+		name            string
+		writeSigningKey []byte
+		writeEncryption []byte
+		readSigningKey  []byte
+		readEncryption  []byte
+		modify          func(env *envelope)
+		readName        string
+		expPlaintext    bool
+		err             string
+		expContents     string
+		expMetadata     map[string]string
+	}{
+		{
+			name:            "signed",
+			writeSigningKey: signingKey,
+			readSigningKey:  signingKey,
+			expPlaintext:    true,
+			expContents:     "plan data",
+			expMetadata:     map[string]string{"plan_exit_code": "2"},
+		},
+		{
+			name:            "signed_and_encrypted",
+			writeSigningKey: signingKey,
+			writeEncryption: encryptionKey,
+			readSigningKey:  signingKey,
+			readEncryption:  encryptionKey,
+			expContents:     "plan data",
+			expMetadata:     map[string]string{"plan_exit_code": "2"},
+		},
+		{
+			name:            "wrong_signing_key",
+			writeSigningKey: signingKey,
+			readSigningKey:  otherSigningKey,
+			expPlaintext:    true,
+			err:             "invalid envelope signature",
+		},
+		{
+			name:            "tampered_payload",
+			writeSigningKey: signingKey,
+			readSigningKey:  signingKey,
+			expPlaintext:    true,
+			modify: func(env *envelope) {
+				env.Payload = []byte("malicious plan data")
+			},
+			err: "invalid envelope signature",
+		},
+		{
+			name:            "tampered_metadata",
+			writeSigningKey: signingKey,
+			readSigningKey:  signingKey,
+			expPlaintext:    true,
+			modify: func(env *envelope) {
+				env.Metadata["plan_exit_code"] = "0"
+			},
+			err: "invalid envelope signature",
+		},
+		{
+			name:            "moved_object",
+			writeSigningKey: signingKey,
+			readSigningKey:  signingKey,
+			expPlaintext:    true,
+			readName:        "other/tfplan.binary",
+			err:             "invalid envelope signature",
+		},
+		{
+			name:            "wrong_encryption_key",
+			writeSigningKey: signingKey,
+			writeEncryption: encryptionKey,
+			readSigningKey:  signingKey,
+			readEncryption:  otherEncryptionKey,
+			err:             "failed to decrypt envelope payload",
+		},
+		{
+			name:            "missing_encryption_key",
+			writeSigningKey: signingKey,
+			writeEncryption: encryptionKey,
+			readSigningKey:  signingKey,
+			err:             "envelope is encrypted, but no encryption key is configured",
+		},
+		{
+			name:            "unexpected_plaintext",
+			writeSigningKey: signingKey,
+			readSigningKey:  signingKey,
+			readEncryption:  encryptionKey,
+			expPlaintext:    true,
+			err:             "envelope is not encrypted, but an encryption key is configured",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+			name := "testdir/tfplan.binary"
+
+			fs, err := NewFilesystemStorage(ctx, t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			writer, err := NewEnvelopeStorage(ctx, fs, tc.writeSigningKey, tc.writeEncryption)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := writer.CreateObject(ctx, name, []byte("plan data"),
+				WithMetadata(map[string]string{"plan_exit_code": "2"}),
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			pth := filepath.Join(fs.Parent(), name)
+			raw, err := os.ReadFile(pth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := bytes.Contains(raw, []byte(`"payload":"cGxhbiBkYXRh"`)); got != tc.expPlaintext {
+				t.Errorf("expected plaintext payload to be %t, got %t", tc.expPlaintext, got)
+			}
+
+			if tc.modify != nil {
+				var env envelope
+				if err := json.Unmarshal(raw, &env); err != nil {
+					t.Fatal(err)
+				}
+				tc.modify(&env)
+				b, err := json.Marshal(&env)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(pth, b, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			readName := name
+			if tc.readName != "" {
+				if err := fs.CreateObject(ctx, tc.readName, raw); err != nil {
+					t.Fatal(err)
+				}
+				readName = tc.readName
+			}
+
+			reader, err := NewEnvelopeStorage(ctx, fs, tc.readSigningKey, tc.readEncryption)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rc, metadata, err := reader.GetObject(ctx, readName)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() {
+				if err := rc.Close(); err != nil {
+					t.Error(err)
+				}
+			})
+
+			got, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(string(got), tc.expContents); diff != "" {
+				t.Errorf("contents not as expected; (-got,+want): %s", diff)
+			}
+			if diff := cmp.Diff(metadata, tc.expMetadata); diff != "" {
+				t.Errorf("metadata not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestNewEnvelopeStorage(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name          string
+		signingKey    []byte
+		encryptionKey []byte
+		err           string
+	}{
+		{
+			name:       "signing_only",
+			signingKey: []byte("key"),
+		},
+		{
+			name:          "with_encryption",
+			signingKey:    []byte("key"),
+			encryptionKey: bytes.Repeat([]byte("k"), 16),
+		},
+		{
+			name: "missing_signing_key",
+			err:  "signing key is required",
+		},
+		{
+			name:          "invalid_encryption_key",
+			signingKey:    []byte("key"),
+			encryptionKey: []byte("short"),
+			err:           "invalid encryption key",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewEnvelopeStorage(t.Context(), &MockStorageClient{}, tc.signingKey, tc.encryptionKey)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestParseEnvelopeKey(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		value string
+		exp   []byte
+		err   string
+	}{
+		{
+			name:  "success",
+			value: "a2V5\n",
+			exp:   []byte("key"),
+		},
+		{
+			name:  "empty",
+			value: "  ",
+			exp:   nil,
+		},
+		{
+			name:  "invalid",
+			value: "not base64!",
+			err:   "failed to decode base64 key",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseEnvelopeKey(tc.value)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("key not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !unix
+
+package storage
+
+import "errors"
+
+// lockFile is not supported on this platform, so conditional updates of the
+// filesystem storage are rejected rather than performed without a lock.
+func lockFile(pth string) (func() error, error) {
+	return nil, errors.New("conditional updates are not supported by the filesystem storage on this platform")
+}
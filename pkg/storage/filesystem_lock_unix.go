@@ -0,0 +1,48 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile blocks until it holds an exclusive lock on the file at pth, creating
+// the file if needed. The lock is released by the returned function, or by the
+// operating system if the process exits first.
+func lockFile(pth string) (func() error, error) {
+	f, err := os.OpenFile(pth, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return nil, errors.Join(
+			fmt.Errorf("failed to acquire lock: %w", err),
+			f.Close(),
+		)
+	}
+
+	return func() error {
+		// Closing the file releases the lock.
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to close lock file: %w", err)
+		}
+		return nil
+	}, nil
+}
@@ -16,6 +16,9 @@ package storage
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -33,8 +36,14 @@ func NewFilesystemStorage(ctx context.Context, parent string) (*FilesystemStorag
 	return &FilesystemStorage{parent: parent}, nil
 }
 
-// CreateObject creates a file in the supplied to the local filesystem..
-func (s *FilesystemStorage) CreateObject(ctx context.Context, filename string, contents []byte, _ ...CreateOption) (merr error) {
+// CreateObject creates a file in the supplied to the local filesystem. Unless
+// overwriting is allowed, creating the file fails if it already exists.
+// Conditional updates hold an exclusive lock on a sidecar lock file while the
+// version is compared and the new contents are renamed into place, so
+// concurrent conditional updates of the same file succeed at most once.
+func (s *FilesystemStorage) CreateObject(ctx context.Context, filename string, contents []byte, opts ...CreateOption) (merr error) {
+	cfg := makeCreateConfig(len(contents), opts)
+
 	pth := filepath.Join(s.parent, filename)
 	dir := filepath.Dir(pth)
 
@@ -42,9 +51,86 @@ func (s *FilesystemStorage) CreateObject(ctx context.Context, filename string, c
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	if err := os.WriteFile(pth, contents, 0o600); err != nil {
+	if cfg.ifVersionMatch != "" {
+		return s.replaceIfVersionMatch(ctx, filename, contents, cfg.ifVersionMatch)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !cfg.allowOverwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+
+	f, err := os.OpenFile(pth, flags, 0o600)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("failed to create object: %w: %w", ErrPreconditionFailed, err)
+		}
 		return fmt.Errorf("failed to create object: %w", err)
 	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil {
+			merr = errors.Join(merr, fmt.Errorf("failed to close object: %w", closeErr))
+		}
+	}()
+
+	if _, err := f.Write(contents); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	return nil
+}
+
+// replaceIfVersionMatch replaces the contents of a file if its current version
+// matches the given version. The version check and the write happen while an
+// exclusive lock is held on a sidecar lock file, and the new contents are
+// written to a temporary file that is renamed over the original so readers
+// never observe a partial write. The sidecar is left in place, as removing it
+// would allow a waiting writer to lock a file that is no longer shared.
+func (s *FilesystemStorage) replaceIfVersionMatch(ctx context.Context, filename string, contents []byte, ifVersionMatch string) (merr error) {
+	pth := filepath.Join(s.parent, filename)
+
+	unlock, err := lockFile(pth + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock object: %w", err)
+	}
+	defer func() {
+		if unlockErr := unlock(); unlockErr != nil {
+			merr = errors.Join(merr, fmt.Errorf("failed to unlock object: %w", unlockErr))
+		}
+	}()
+
+	version, err := s.ObjectVersion(ctx, filename)
+	if err != nil && !errors.Is(err, ErrObjectNotFound) {
+		return fmt.Errorf("failed to get object version: %w", err)
+	}
+	if version != ifVersionMatch {
+		return fmt.Errorf("failed to create object: %w", ErrPreconditionFailed)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(pth), "."+filepath.Base(pth)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary object: %w", err)
+	}
+	tmp := f.Name()
+	defer func() {
+		// The temporary file no longer exists once it has been renamed.
+		if removeErr := os.Remove(tmp); removeErr != nil && !errors.Is(removeErr, fs.ErrNotExist) {
+			merr = errors.Join(merr, fmt.Errorf("failed to remove temporary object: %w", removeErr))
+		}
+	}()
+
+	if _, err := f.Write(contents); err != nil {
+		return errors.Join(
+			fmt.Errorf("failed to write object: %w", err),
+			f.Close(),
+		)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close object: %w", err)
+	}
+
+	if err := os.Rename(tmp, pth); err != nil {
+		return fmt.Errorf("failed to replace object: %w", err)
+	}
 	return nil
 }
 
@@ -63,6 +149,21 @@ func (s *FilesystemStorage) GetObject(ctx context.Context, filename string) (io.
 	return f, nil, nil
 }
 
+// ObjectVersion returns the SHA256 hash of a file on the local filesystem.
+func (s *FilesystemStorage) ObjectVersion(ctx context.Context, filename string) (string, error) {
+	pth := filepath.Join(s.parent, filename)
+	b, err := os.ReadFile(pth)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("failed to read file: %w", ErrObjectNotFound)
+		}
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 // DeleteObject deletes an object from a from the local filesystem. If the object does not exist, no error
 // will be returned.
 func (s *FilesystemStorage) DeleteObject(ctx context.Context, filename string) error {
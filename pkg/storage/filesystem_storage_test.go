@@ -0,0 +1,198 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestFilesystemStorage_CreateObject(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		existing    bool
+		opts        func(t *testing.T, s *FilesystemStorage) []CreateOption
+		expContents string
+		err         string
+	}{
+		{
+			name:        "creates",
+			expContents: "new",
+		},
+		{
+			name:        "fails_if_exists",
+			existing:    true,
+			expContents: "old",
+			err:         ErrPreconditionFailed.Error(),
+		},
+		{
+			name:     "overwrites_if_allowed",
+			existing: true,
+			opts: func(t *testing.T, s *FilesystemStorage) []CreateOption {
+				return []CreateOption{WithAllowOverwrite(true)}
+			},
+			expContents: "new",
+		},
+		{
+			name:     "overwrites_if_version_matches",
+			existing: true,
+			opts: func(t *testing.T, s *FilesystemStorage) []CreateOption {
+				version, err := s.ObjectVersion(t.Context(), "dir/tfplan.binary")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return []CreateOption{WithIfVersionMatch(version)}
+			},
+			expContents: "new",
+		},
+		{
+			name:     "fails_if_version_changed",
+			existing: true,
+			opts: func(t *testing.T, s *FilesystemStorage) []CreateOption {
+				return []CreateOption{WithIfVersionMatch("stale")}
+			},
+			expContents: "old",
+			err:         ErrPreconditionFailed.Error(),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+			dir := t.TempDir()
+			s, err := NewFilesystemStorage(ctx, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pth := filepath.Join(dir, "dir", "tfplan.binary")
+			if tc.existing {
+				if err := os.MkdirAll(filepath.Dir(pth), 0o700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(pth, []byte("old"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var opts []CreateOption
+			if tc.opts != nil {
+				opts = tc.opts(t, s)
+			}
+
+			err = s.CreateObject(ctx, "dir/tfplan.binary", []byte("new"), opts...)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			got, err := os.ReadFile(pth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := string(got), tc.expContents; got != want {
+				t.Errorf("expected contents %q to be %q", got, want)
+			}
+		})
+	}
+}
+
+func TestFilesystemStorage_CreateObject_concurrentVersionMatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	dir := t.TempDir()
+	s, err := NewFilesystemStorage(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.CreateObject(ctx, "apply.lock", []byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	version, err := s.ObjectVersion(ctx, "apply.lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const writers = 20
+
+	var wg sync.WaitGroup
+	errs := make([]error, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.CreateObject(ctx, "apply.lock", []byte(fmt.Sprintf("new-%d", i)), WithIfVersionMatch(version))
+		}()
+	}
+	wg.Wait()
+
+	var succeeded int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrPreconditionFailed):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if got, want := succeeded, 1; got != want {
+		t.Errorf("expected %d conditional writes to succeed, got %d", want, got)
+	}
+}
+
+func TestFilesystemStorage_ObjectVersion(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	s, err := NewFilesystemStorage(ctx, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.ObjectVersion(ctx, "dir/tfplan.binary"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected %q to be %q", err, ErrObjectNotFound)
+	}
+
+	if err := s.CreateObject(ctx, "dir/tfplan.binary", []byte("one")); err != nil {
+		t.Fatal(err)
+	}
+	v1, err := s.ObjectVersion(ctx, "dir/tfplan.binary")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.CreateObject(ctx, "dir/tfplan.binary", []byte("two"), WithAllowOverwrite(true)); err != nil {
+		t.Fatal(err)
+	}
+	v2, err := s.ObjectVersion(ctx, "dir/tfplan.binary")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v1 == v2 {
+		t.Errorf("expected version to change after update, got %q", v2)
+	}
+}
@@ -20,11 +20,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"cloud.google.com/go/storage"
 	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 )
 
@@ -128,13 +131,25 @@ func (s *GoogleCloudStorage) CreateObject(ctx context.Context, name string, cont
 	o, ctx, cancel := s.objectHandleWithRetries(ctx, name)
 	defer cancel()
 
-	if !cfg.allowOverwrite {
+	switch {
+	case cfg.ifVersionMatch != "":
+		generation, err := strconv.ParseInt(cfg.ifVersionMatch, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse object generation %q: %w", cfg.ifVersionMatch, err)
+		}
+		o = o.If(storage.Conditions{GenerationMatch: generation})
+	case !cfg.allowOverwrite:
 		o = o.If(storage.Conditions{DoesNotExist: true})
 	}
 
 	gcsWriter := o.NewWriter(ctx)
 	defer func() {
 		if closeErr := gcsWriter.Close(); closeErr != nil {
+			// the upload is only committed when the writer is closed, which is
+			// when the preconditions are checked
+			if isGCSPreconditionFailed(closeErr) {
+				closeErr = fmt.Errorf("%w: %w", ErrPreconditionFailed, closeErr)
+			}
 			merr = errors.Join(merr, fmt.Errorf("failed to close gcs writer: %w", closeErr))
 		}
 	}()
@@ -203,6 +218,22 @@ func (s *GoogleCloudStorage) GetObject(ctx context.Context, name string) (io.Rea
 	}, attrs.Metadata, nil
 }
 
+// ObjectVersion returns the generation of an object in a Google Cloud Storage
+// bucket.
+func (s *GoogleCloudStorage) ObjectVersion(ctx context.Context, name string) (string, error) {
+	o, ctx, cancel := s.objectHandleWithRetries(ctx, name)
+	defer cancel()
+
+	attrs, err := o.Attrs(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return "", fmt.Errorf("failed to get object metadata: %w", ErrObjectNotFound)
+		}
+		return "", fmt.Errorf("failed to get object metadata: %w", err)
+	}
+	return strconv.FormatInt(attrs.Generation, 10), nil
+}
+
 // DeleteObject deletes an object from a Google Cloud Storage bucket. If the object does not exist, no error
 // will be returned.
 func (s *GoogleCloudStorage) DeleteObject(ctx context.Context, name string) error {
@@ -244,6 +275,13 @@ func (s *GoogleCloudStorage) ObjectsWithName(ctx context.Context, filename strin
 	return uris, nil
 }
 
+// isGCSPreconditionFailed returns true if the error is a precondition failure
+// response from Google Cloud Storage.
+func isGCSPreconditionFailed(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed
+}
+
 type readCloserCanceller struct {
 	io.ReadCloser
 	cancelFunc context.CancelFunc
@@ -21,6 +21,7 @@ type createConfig struct {
 	cacheMaxAgeSeconds int
 	chunkSize          int
 	contentType        string
+	ifVersionMatch     string
 	metadata           map[string]string
 }
 
@@ -68,3 +69,14 @@ func WithMetadata(metadata map[string]string) CreateOption {
 		return c
 	}
 }
+
+// WithIfVersionMatch only overwrites the destination object if its current
+// version, as returned by ObjectVersion, matches the given version. This takes
+// precedence over WithAllowOverwrite. ErrPreconditionFailed is returned if the
+// object has changed.
+func WithIfVersionMatch(version string) CreateOption {
+	return func(c *createConfig) *createConfig {
+		c.ifVersionMatch = version
+		return c
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+var _ Storage = (*S3Storage)(nil)
+
+// S3Config is the configuration for the S3 storage client. All values are
+// optional, by default the client uses the AWS SDK defaults, including the
+// AWS_REGION and AWS_ENDPOINT_URL_S3 environment variables.
+type S3Config struct {
+	// Endpoint is a custom endpoint URL, e.g. for MinIO or a local fake.
+	Endpoint string
+
+	// Region is the AWS region of the bucket.
+	Region string
+
+	// UsePathStyle addresses objects as endpoint/bucket/key instead of
+	// bucket.endpoint/key. This is required by most S3-compatible servers and is
+	// enabled automatically when a custom endpoint is configured.
+	UsePathStyle bool
+}
+
+// S3Storage implements the Storage interface for Amazon S3 and S3-compatible
+// object storage.
+type S3Storage struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Storage creates a new S3Storage client. The parent is the bucket name,
+// optionally followed by a key prefix, e.g. "my-bucket/guardian".
+func NewS3Storage(ctx context.Context, parent string, cfg *S3Config) (*S3Storage, error) {
+	if cfg == nil {
+		cfg = &S3Config{}
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aws config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Region != "" {
+			o.Region = cfg.Region
+		}
+
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+
+			// S3-compatible servers do not consistently support the flexible
+			// checksums the SDK sends by default.
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		}
+
+		if cfg.UsePathStyle {
+			o.UsePathStyle = true
+		}
+	})
+
+	return newS3Storage(client, parent)
+}
+
+// newS3Storage creates a new S3Storage client using an existing S3 client.
+func newS3Storage(client *s3.Client, parent string) (*S3Storage, error) {
+	bucket, prefix, _ := strings.Cut(strings.Trim(parent, "/"), "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+
+	return &S3Storage{
+		client: client,
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+// Parent returns the S3 bucket name and key prefix.
+func (s *S3Storage) Parent() string {
+	return path.Join(s.bucket, s.prefix)
+}
+
+// key returns the object key for a name, relative to the key prefix.
+func (s *S3Storage) key(name string) string {
+	return path.Join(s.prefix, name)
+}
+
+// CreateObject uploads an object to an S3 bucket using a set of upload options.
+// Unless overwriting is allowed, the upload fails if the object already exists.
+// Conditional updates use the ETag of the object as its version.
+func (s *S3Storage) CreateObject(ctx context.Context, name string, contents []byte, opts ...CreateOption) error {
+	cfg := makeCreateConfig(len(contents), opts)
+
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(s.key(name)),
+		Body:          bytes.NewReader(contents),
+		ContentLength: aws.Int64(int64(len(contents))),
+		CacheControl:  aws.String(cfg.cacheControl),
+	}
+
+	if cfg.contentType != "" {
+		input.ContentType = aws.String(cfg.contentType)
+	}
+
+	if cfg.metadata != nil {
+		m := make(map[string]string, len(cfg.metadata))
+
+		for k, v := range cfg.metadata {
+			m[k] = v
+		}
+
+		input.Metadata = m
+	}
+
+	switch {
+	case cfg.ifVersionMatch != "":
+		input.IfMatch = aws.String(cfg.ifVersionMatch)
+	case !cfg.allowOverwrite:
+		input.IfNoneMatch = aws.String("*")
+	}
+
+	if _, err := s.client.PutObject(ctx, input); err != nil {
+		if isS3PreconditionFailed(err) {
+			return fmt.Errorf("failed to put object: %w: %w", ErrPreconditionFailed, err)
+		}
+		return fmt.Errorf("failed to put object: %w", err)
+	}
+	return nil
+}
+
+// GetObject downloads an object from an S3 bucket. The caller must call Close
+// on the returned Reader when done reading.
+func (s *S3Storage) GetObject(ctx context.Context, name string) (io.ReadCloser, map[string]string, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, nil, fmt.Errorf("failed to get object: %w", ErrObjectNotFound)
+		}
+		return nil, nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	return out.Body, out.Metadata, nil
+}
+
+// ObjectVersion returns the ETag of an object in an S3 bucket.
+func (s *S3Storage) ObjectVersion(ctx context.Context, name string) (string, error) {
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return "", fmt.Errorf("failed to head object: %w", ErrObjectNotFound)
+		}
+		return "", fmt.Errorf("failed to head object: %w", err)
+	}
+	return aws.ToString(out.ETag), nil
+}
+
+// DeleteObject deletes an object from an S3 bucket. If the object does not
+// exist, no error will be returned.
+func (s *S3Storage) DeleteObject(ctx context.Context, name string) error {
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+	}); err != nil {
+		if isS3NotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	return nil
+}
+
+// ObjectsWithName returns all objects under the key prefix with a given file
+// name.
+func (s *S3Storage) ObjectsWithName(ctx context.Context, filename string) ([]string, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+	}
+	if s.prefix != "" {
+		input.Prefix = aws.String(s.prefix + "/")
+	}
+
+	var uris []string
+	p := s3.NewListObjectsV2Paginator(s.client, input)
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			if isS3NotFound(err) {
+				return nil, ErrBucketNotFound
+			}
+			return nil, fmt.Errorf("failed to list bucket contents: %w", err)
+		}
+
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if strings.HasSuffix(key, filename) {
+				uris = append(uris, fmt.Sprintf("s3://%s/%s", s.bucket, key))
+			}
+		}
+	}
+	return uris, nil
+}
+
+// isS3NotFound returns true if the error is a not found response from S3.
+func isS3NotFound(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchKey", "NoSuchBucket", "NotFound":
+			return true
+		}
+	}
+
+	var respErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &respErr) {
+		return respErr.HTTPStatusCode() == http.StatusNotFound
+	}
+	return false
+}
+
+// isS3PreconditionFailed returns true if the error is a failed conditional
+// write response from S3.
+func isS3PreconditionFailed(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "PreconditionFailed", "ConditionalRequestConflict":
+			return true
+		}
+	}
+
+	var respErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &respErr) {
+		return respErr.HTTPStatusCode() == http.StatusPreconditionFailed
+	}
+	return false
+}
+
+// s3ConfigFromQuery parses the S3 configuration from the query parameters of a
+// storage url, e.g. "s3://bucket/prefix?endpoint=http://localhost:9000".
+func s3ConfigFromQuery(q map[string][]string) (*S3Config, error) {
+	get := func(k string) string {
+		if v := q[k]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	cfg := &S3Config{
+		Endpoint: get("endpoint"),
+		Region:   get("region"),
+	}
+
+	if v := get("path_style"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse path_style: %w", err)
+		}
+		cfg.UsePathStyle = b
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,470 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+// fakeS3Object is an object stored by fakeS3.
+type fakeS3Object struct {
+	body     []byte
+	metadata map[string]string
+}
+
+// etag returns the entity tag of the object, derived from its contents.
+func (o *fakeS3Object) etag() string {
+	return fmt.Sprintf("%q", fmt.Sprintf("%x", sha256.Sum256(o.body)))
+}
+
+// fakeS3 is a minimal in-memory implementation of the S3 API, supporting
+// path-style put, get, head, delete and list requests for a single bucket.
+type fakeS3 struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string]*fakeS3Object
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		f.writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPut:
+		existing, ok := f.objects[key]
+		if ok && r.Header.Get("If-None-Match") == "*" {
+			f.writeError(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		if v := r.Header.Get("If-Match"); v != "" && (!ok || existing.etag() != v) {
+			f.writeError(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			f.writeError(w, http.StatusInternalServerError, "InternalError")
+			return
+		}
+
+		metadata := make(map[string]string)
+		for k, v := range r.Header {
+			if name, ok := strings.CutPrefix(strings.ToLower(k), "x-amz-meta-"); ok {
+				metadata[name] = v[0]
+			}
+		}
+		obj := &fakeS3Object{body: body, metadata: metadata}
+		f.objects[key] = obj
+		w.Header().Set("ETag", obj.etag())
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", obj.etag())
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == http.MethodGet && key == "":
+		type content struct {
+			Key string `xml:"Key"`
+		}
+		type result struct {
+			XMLName  xml.Name  `xml:"ListBucketResult"`
+			Name     string    `xml:"Name"`
+			Contents []content `xml:"Contents"`
+		}
+
+		res := &result{Name: f.bucket}
+		for k := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				res.Contents = append(res.Contents, content{Key: k})
+			}
+		}
+		sort.Slice(res.Contents, func(i, j int) bool { return res.Contents[i].Key < res.Contents[j].Key })
+
+		w.Header().Set("Content-Type", "application/xml")
+		if err := xml.NewEncoder(w).Encode(res); err != nil {
+			panic(err)
+		}
+
+	case r.Method == http.MethodGet:
+		obj, ok := f.objects[key]
+		if !ok {
+			f.writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+
+		for k, v := range obj.metadata {
+			w.Header().Set("X-Amz-Meta-"+k, v)
+		}
+		if _, err := w.Write(obj.body); err != nil {
+			panic(err)
+		}
+
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		f.writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (f *fakeS3) writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func testS3Storage(tb testing.TB, parent string) (*S3Storage, *fakeS3) {
+	tb.Helper()
+
+	fake := &fakeS3{
+		bucket:  "my-bucket",
+		objects: make(map[string]*fakeS3Object),
+	}
+	srv := httptest.NewServer(fake)
+	tb.Cleanup(srv.Close)
+
+	client := s3.New(s3.Options{
+		BaseEndpoint:               aws.String(srv.URL),
+		Region:                     "us-east-1",
+		Credentials:                credentials.NewStaticCredentialsProvider("key", "secret", ""),
+		UsePathStyle:               true,
+		RequestChecksumCalculation: aws.RequestChecksumCalculationWhenRequired,
+		ResponseChecksumValidation: aws.ResponseChecksumValidationWhenRequired,
+	})
+
+	s, err := newS3Storage(client, parent)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return s, fake
+}
+
+func TestS3Storage_CreateObject(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		existing    bool
+		opts        []CreateOption
+		expContents string
+		expMetadata map[string]string
+		err         string
+	}{
+		{
+			name:        "creates_with_metadata",
+			opts:        []CreateOption{WithMetadata(map[string]string{"plan_exit_code": "2"})},
+			expContents: "new",
+			expMetadata: map[string]string{"plan_exit_code": "2"},
+		},
+		{
+			name:        "fails_if_exists",
+			existing:    true,
+			expContents: "old",
+			expMetadata: map[string]string{},
+			err:         "PreconditionFailed",
+		},
+		{
+			name:        "overwrites_if_allowed",
+			existing:    true,
+			opts:        []CreateOption{WithAllowOverwrite(true)},
+			expContents: "new",
+			expMetadata: map[string]string{},
+		},
+		{
+			name:        "overwrites_if_version_matches",
+			existing:    true,
+			opts:        []CreateOption{WithIfVersionMatch((&fakeS3Object{body: []byte("old")}).etag())},
+			expContents: "new",
+			expMetadata: map[string]string{},
+		},
+		{
+			name:        "fails_if_version_changed",
+			existing:    true,
+			opts:        []CreateOption{WithIfVersionMatch(`"stale"`)},
+			expContents: "old",
+			expMetadata: map[string]string{},
+			err:         ErrPreconditionFailed.Error(),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+			s, fake := testS3Storage(t, "my-bucket/guardian")
+
+			if tc.existing {
+				fake.objects["guardian/dir/tfplan.binary"] = &fakeS3Object{
+					body:     []byte("old"),
+					metadata: map[string]string{},
+				}
+			}
+
+			err := s.CreateObject(ctx, "dir/tfplan.binary", []byte("new"), tc.opts...)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			obj, ok := fake.objects["guardian/dir/tfplan.binary"]
+			if !ok {
+				t.Fatal("expected object to exist")
+			}
+			if got, want := string(obj.body), tc.expContents; got != want {
+				t.Errorf("expected contents %q to be %q", got, want)
+			}
+			if diff := cmp.Diff(obj.metadata, tc.expMetadata); diff != "" {
+				t.Errorf("metadata not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestS3Storage_GetObject(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		object      string
+		expContents string
+		expMetadata map[string]string
+		err         string
+	}{
+		{
+			name:        "success",
+			object:      "dir/tfplan.binary",
+			expContents: "plan data",
+			expMetadata: map[string]string{"plan_exit_code": "2"},
+		},
+		{
+			name:   "not_found",
+			object: "missing/tfplan.binary",
+			err:    ErrObjectNotFound.Error(),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+			s, fake := testS3Storage(t, "my-bucket/guardian")
+			fake.objects["guardian/dir/tfplan.binary"] = &fakeS3Object{
+				body:     []byte("plan data"),
+				metadata: map[string]string{"plan_exit_code": "2"},
+			}
+
+			rc, metadata, err := s.GetObject(ctx, tc.object)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				return
+			}
+			defer rc.Close()
+
+			got, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := string(got), tc.expContents; got != want {
+				t.Errorf("expected contents %q to be %q", got, want)
+			}
+			if diff := cmp.Diff(metadata, tc.expMetadata); diff != "" {
+				t.Errorf("metadata not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestS3Storage_ObjectVersion(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	s, fake := testS3Storage(t, "my-bucket/guardian")
+	obj := &fakeS3Object{body: []byte("plan data")}
+	fake.objects["guardian/dir/tfplan.binary"] = obj
+
+	got, err := s.ObjectVersion(ctx, "dir/tfplan.binary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := obj.etag(); got != want {
+		t.Errorf("expected version %q to be %q", got, want)
+	}
+
+	if _, err := s.ObjectVersion(ctx, "missing/tfplan.binary"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected %q to be %q", err, ErrObjectNotFound)
+	}
+}
+
+func TestS3Storage_DeleteObject(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	s, fake := testS3Storage(t, "my-bucket/guardian")
+	fake.objects["guardian/dir/tfplan.binary"] = &fakeS3Object{body: []byte("plan data")}
+
+	if err := s.DeleteObject(ctx, "dir/tfplan.binary"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.objects["guardian/dir/tfplan.binary"]; ok {
+		t.Error("expected object to be deleted")
+	}
+
+	if err := s.DeleteObject(ctx, "dir/tfplan.binary"); err != nil {
+		t.Errorf("expected deleting a missing object to succeed: %s", err)
+	}
+}
+
+func TestS3Storage_ObjectsWithName(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		parent string
+		exp    []string
+		err    string
+	}{
+		{
+			name:   "with_prefix",
+			parent: "my-bucket/guardian",
+			exp: []string{
+				"s3://my-bucket/guardian/a/default.tfstate",
+				"s3://my-bucket/guardian/b/c/default.tfstate",
+			},
+		},
+		{
+			name:   "without_prefix",
+			parent: "my-bucket",
+			exp: []string{
+				"s3://my-bucket/guardian/a/default.tfstate",
+				"s3://my-bucket/guardian/b/c/default.tfstate",
+				"s3://my-bucket/other/default.tfstate",
+			},
+		},
+		{
+			name:   "bucket_not_found",
+			parent: "missing-bucket",
+			err:    ErrBucketNotFound.Error(),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+			s, fake := testS3Storage(t, tc.parent)
+			for _, k := range []string{
+				"guardian/a/default.tfstate",
+				"guardian/a/tfplan.binary",
+				"guardian/b/c/default.tfstate",
+				"other/default.tfstate",
+			} {
+				fake.objects[k] = &fakeS3Object{}
+			}
+
+			got, err := s.ObjectsWithName(ctx, "default.tfstate")
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("objects not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestS3ConfigFromQuery(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		query map[string][]string
+		exp   *S3Config
+		err   string
+	}{
+		{
+			name:  "empty",
+			query: map[string][]string{},
+			exp:   &S3Config{},
+		},
+		{
+			name: "all_values",
+			query: map[string][]string{
+				"endpoint":   {"http://localhost:9000"},
+				"region":     {"eu-west-1"},
+				"path_style": {"true"},
+			},
+			exp: &S3Config{
+				Endpoint:     "http://localhost:9000",
+				Region:       "eu-west-1",
+				UsePathStyle: true,
+			},
+		},
+		{
+			name: "invalid_path_style",
+			query: map[string][]string{
+				"path_style": {"maybe"},
+			},
+			err: "failed to parse path_style",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := s3ConfigFromQuery(tc.query)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("config not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
@@ -29,11 +30,23 @@ import (
 const (
 	TypeFilesystem         = "file"
 	TypeGoogleCloudStorage = "gcs"
+	TypeS3                 = "s3"
+	TypeAzureBlobStorage   = "azblob"
+)
+
+var (
+	// ErrObjectNotFound is returned when an object does not exist.
+	ErrObjectNotFound = errors.New("object not found")
+
+	// ErrPreconditionFailed is returned when an object could not be created
+	// because it already exists, or could not be updated because it was changed
+	// since its version was read.
+	ErrPreconditionFailed = errors.New("precondition failed")
 )
 
 // SortedStorageTypes are the sorted Storage types for printing messages and prediction.
 var SortedStorageTypes = func() []string {
-	allowed := append([]string{}, TypeFilesystem, TypeGoogleCloudStorage)
+	allowed := append([]string{}, TypeFilesystem, TypeGoogleCloudStorage, TypeS3, TypeAzureBlobStorage)
 	sort.Strings(allowed)
 	return allowed
 }()
@@ -49,6 +62,12 @@ type Storage interface {
 	// GetObject gets a blob storage object and metadata if any. The caller must call Close on the returned Reader when done reading.
 	GetObject(ctx context.Context, name string) (io.ReadCloser, map[string]string, error)
 
+	// ObjectVersion returns the current version of a blob storage object. The
+	// version is opaque and can be passed to WithIfVersionMatch to only update
+	// the object if it has not changed since. ErrObjectNotFound is returned if
+	// the object does not exist.
+	ObjectVersion(ctx context.Context, name string) (string, error)
+
 	// DeleteObject deletes a blob storage object.
 	DeleteObject(ctx context.Context, name string) error
 
@@ -66,6 +85,14 @@ func NewStorageClient(ctx context.Context, t, parent string) (Storage, error) {
 		return NewGoogleCloudStorage(ctx, parent)
 	}
 
+	if strings.EqualFold(t, TypeS3) {
+		return NewS3Storage(ctx, parent, nil)
+	}
+
+	if strings.EqualFold(t, TypeAzureBlobStorage) {
+		return NewAzureBlobStorage(ctx, parent, nil)
+	}
+
 	return nil, fmt.Errorf("unknown storage type: %s", t)
 }
 
@@ -90,5 +117,17 @@ func Parse(ctx context.Context, u string) (Storage, error) {
 		return NewGoogleCloudStorage(ctx, parent)
 	}
 
+	if strings.EqualFold(parsed.Scheme, TypeS3) {
+		cfg, err := s3ConfigFromQuery(parsed.Query())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse s3 storage url: %w", err)
+		}
+		return NewS3Storage(ctx, parent, cfg)
+	}
+
+	if strings.EqualFold(parsed.Scheme, TypeAzureBlobStorage) {
+		return NewAzureBlobStorage(ctx, parent, azureBlobConfigFromQuery(parsed.Query()))
+	}
+
 	return nil, fmt.Errorf("unknown storage type: %s", parsed.Scheme)
 }
@@ -38,6 +38,8 @@ type MockStorageClient struct {
 	DownloadErr    error
 	Metadata       map[string]string
 	MetadataErr    error
+	VersionResp    string
+	VersionErr     error
 	DeleteErr      error
 	ListObjectURIs []string
 	ListObjectErr  error
@@ -92,6 +94,20 @@ func (m *MockStorageClient) GetObject(ctx context.Context, name string) (io.Read
 	return &BufferReadCloser{bytes.NewBufferString(m.DownloadData)}, metadata, nil
 }
 
+func (m *MockStorageClient) ObjectVersion(ctx context.Context, name string) (string, error) {
+	m.reqMu.Lock()
+	defer m.reqMu.Unlock()
+	m.Reqs = append(m.Reqs, &Request{
+		Name:   "ObjectVersion",
+		Params: []any{name},
+	})
+
+	if m.VersionErr != nil {
+		return "", m.VersionErr
+	}
+	return m.VersionResp, nil
+}
+
 func (m *MockStorageClient) DeleteObject(ctx context.Context, name string) error {
 	m.reqMu.Lock()
 	defer m.reqMu.Unlock()
@@ -0,0 +1,126 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// BinaryTerraform is the Terraform executable.
+	BinaryTerraform = "terraform"
+
+	// BinaryOpenTofu is the OpenTofu executable.
+	BinaryOpenTofu = "tofu"
+
+	// BinaryAuto detects the executable from the entrypoint directory.
+	BinaryAuto = "auto"
+)
+
+// openTofuRegistry is the provider registry host used by OpenTofu, which is
+// recorded in the dependency lock file of an entrypoint initialized with it.
+const openTofuRegistry = "registry.opentofu.org/"
+
+// ResolveBinary returns the executable to run for the entrypoint directory.
+// The binary is either "terraform", "tofu", the path to an executable or
+// "auto", which selects OpenTofu if the directory uses OpenTofu specific files,
+// or if only OpenTofu is installed, and Terraform otherwise.
+func ResolveBinary(dir, binary string) (string, error) {
+	switch binary {
+	case "", BinaryTerraform:
+		return BinaryTerraform, nil
+	case BinaryAuto:
+		return detectBinary(dir, exec.LookPath)
+	default:
+		return binary, nil
+	}
+}
+
+// detectBinary detects the executable from the files of the entrypoint
+// directory, falling back to the executables found by lookPath.
+func detectBinary(dir string, lookPath func(string) (string, error)) (string, error) {
+	usesOpenTofu, err := usesOpenTofu(dir)
+	if err != nil {
+		return "", err
+	}
+	if usesOpenTofu {
+		return BinaryOpenTofu, nil
+	}
+
+	if _, err := lookPath(BinaryTerraform); err != nil {
+		if _, err := lookPath(BinaryOpenTofu); err == nil {
+			return BinaryOpenTofu, nil
+		}
+	}
+	return BinaryTerraform, nil
+}
+
+// usesOpenTofu returns true if the directory contains OpenTofu configuration
+// files, an OpenTofu version file, or a lock file with OpenTofu providers.
+func usesOpenTofu(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if e.Name() == ".opentofu-version" || filepath.Ext(e.Name()) == ".tofu" {
+			return true, nil
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, ".terraform.lock.hcl"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read lock file: %w", err)
+	}
+	return bytes.Contains(b, []byte(openTofuRegistry)), nil
+}
+
+// IsOpenTofu returns true if the executable is OpenTofu, based on its name.
+func IsOpenTofu(binary string) bool {
+	return strings.HasPrefix(filepath.Base(binary), BinaryOpenTofu)
+}
+
+// BinaryName returns the name of the Terraform compatible product run by the
+// executable, "tofu" or "terraform".
+func BinaryName(binary string) string {
+	if IsOpenTofu(binary) {
+		return BinaryOpenTofu
+	}
+	return BinaryTerraform
+}
+
+// isConfigFile returns true if the path is a Terraform or OpenTofu
+// configuration file.
+func isConfigFile(pth string) bool {
+	ext := filepath.Ext(pth)
+	return ext == ".tf" || ext == ".tofu"
+}
@@ -0,0 +1,174 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveBinary(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		binary string
+		exp    string
+	}{
+		{
+			name:   "default",
+			binary: "",
+			exp:    "terraform",
+		},
+		{
+			name:   "terraform",
+			binary: "terraform",
+			exp:    "terraform",
+		},
+		{
+			name:   "tofu",
+			binary: "tofu",
+			exp:    "tofu",
+		},
+		{
+			name:   "path",
+			binary: "/opt/tofu/bin/tofu",
+			exp:    "/opt/tofu/bin/tofu",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ResolveBinary(t.TempDir(), tc.binary)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.exp {
+				t.Errorf("expected %q to be %q", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestDetectBinary(t *testing.T) {
+	t.Parallel()
+
+	installed := func(binaries ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, b := range binaries {
+				if b == name {
+					return filepath.Join("/usr/bin", name), nil
+				}
+			}
+			return "", fmt.Errorf("executable %q not found", name)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		files    map[string]string
+		lookPath func(string) (string, error)
+		exp      string
+	}{
+		{
+			name:     "terraform_files",
+			files:    map[string]string{"main.tf": ""},
+			lookPath: installed("terraform", "tofu"),
+			exp:      "terraform",
+		},
+		{
+			name:     "tofu_files",
+			files:    map[string]string{"main.tf": "", "encryption.tofu": ""},
+			lookPath: installed("terraform", "tofu"),
+			exp:      "tofu",
+		},
+		{
+			name:     "opentofu_version_file",
+			files:    map[string]string{".opentofu-version": "1.8.0"},
+			lookPath: installed("terraform", "tofu"),
+			exp:      "tofu",
+		},
+		{
+			name: "opentofu_lock_file",
+			files: map[string]string{
+				".terraform.lock.hcl": `provider "registry.opentofu.org/hashicorp/google" {}`,
+			},
+			lookPath: installed("terraform", "tofu"),
+			exp:      "tofu",
+		},
+		{
+			name: "terraform_lock_file",
+			files: map[string]string{
+				".terraform.lock.hcl": `provider "registry.terraform.io/hashicorp/google" {}`,
+			},
+			lookPath: installed("terraform", "tofu"),
+			exp:      "terraform",
+		},
+		{
+			name:     "only_tofu_installed",
+			files:    map[string]string{"main.tf": ""},
+			lookPath: installed("tofu"),
+			exp:      "tofu",
+		},
+		{
+			name:     "none_installed",
+			files:    map[string]string{"main.tf": ""},
+			lookPath: installed(),
+			exp:      "terraform",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			for name, contents := range tc.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := detectBinary(dir, tc.lookPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.exp {
+				t.Errorf("expected %q to be %q", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestBinaryName(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"terraform":              "terraform",
+		"/usr/local/bin/tofu":    "tofu",
+		"tofu-1.8":               "tofu",
+		"/opt/terraform/bin/tf2": "terraform",
+	}
+
+	for binary, want := range cases {
+		if got := BinaryName(binary); got != want {
+			t.Errorf("expected BinaryName(%q) %q to be %q", binary, got, want)
+		}
+	}
+}
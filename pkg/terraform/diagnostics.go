@@ -0,0 +1,140 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	diagnosticHeader   = regexp.MustCompile(`^(Error|Warning): (.+)$`)
+	diagnosticLocation = regexp.MustCompile(`^\s*on (\S+) line (\d+)`)
+	// diagnosticSource matches the source snippet and expression values printed
+	// below the location of a diagnostic.
+	diagnosticSource = regexp.MustCompile(`^\s+(\d+:|├|│)`)
+
+	formatDiffFile = regexp.MustCompile(`^--- old/(.+)$`)
+	formatDiffHunk = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+\d+(?:,\d+)? @@`)
+)
+
+// Diagnostic is an error or warning reported by Terraform for a line of a
+// configuration file.
+type Diagnostic struct {
+	// Severity is either "error" or "warning".
+	Severity string
+	Summary  string
+	Detail   string
+	// Filename is the path of the file relative to the Terraform directory.
+	Filename string
+	Line     int
+}
+
+// ParseDiagnostics parses the diagnostics that refer to a line of a
+// configuration file from the human readable output of a Terraform command.
+// Diagnostics without a file location are ignored.
+func ParseDiagnostics(output string) []*Diagnostic {
+	var diagnostics []*Diagnostic
+	var current *Diagnostic
+	var detail []string
+
+	flush := func() {
+		if current != nil && current.Filename != "" {
+			current.Detail = strings.TrimSpace(strings.Join(detail, "\n"))
+			diagnostics = append(diagnostics, current)
+		}
+		current = nil
+		detail = nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, " \r")
+
+		// strip the box drawing Terraform prints around each diagnostic
+		if line == "╷" || line == "╵" {
+			flush()
+			continue
+		}
+		line = strings.TrimPrefix(strings.TrimPrefix(line, "│"), " ")
+
+		if m := diagnosticHeader.FindStringSubmatch(line); m != nil {
+			flush()
+			current = &Diagnostic{Severity: strings.ToLower(m[1]), Summary: m[2]}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if current.Filename == "" {
+			if m := diagnosticLocation.FindStringSubmatch(line); m != nil {
+				current.Filename = m[1]
+				current.Line, _ = strconv.Atoi(m[2])
+			}
+			continue
+		}
+
+		if diagnosticSource.MatchString(line) {
+			continue
+		}
+		detail = append(detail, line)
+	}
+	flush()
+
+	return diagnostics
+}
+
+// FormatDiff is a range of lines of a file that are not formatted correctly.
+type FormatDiff struct {
+	// Filename is the path of the file relative to the Terraform directory.
+	Filename  string
+	StartLine int
+	EndLine   int
+}
+
+// ParseFormatDiff parses the ranges of lines that are not formatted correctly
+// from the output of terraform fmt with the -diff option.
+func ParseFormatDiff(output string) []*FormatDiff {
+	var diffs []*FormatDiff
+	var filename string
+
+	for _, line := range strings.Split(output, "\n") {
+		if m := formatDiffFile.FindStringSubmatch(line); m != nil {
+			filename = strings.TrimSpace(m[1])
+			continue
+		}
+
+		m := formatDiffHunk.FindStringSubmatch(line)
+		if m == nil || filename == "" {
+			continue
+		}
+
+		start, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+
+		diffs = append(diffs, &FormatDiff{
+			Filename:  filename,
+			StartLine: max(start, 1),
+			EndLine:   max(start+count-1, start, 1),
+		})
+	}
+
+	return diffs
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		output string
+		exp    []*Diagnostic
+	}{
+		{
+			name:   "empty",
+			output: "",
+			exp:    nil,
+		},
+		{
+			name: "boxed",
+			output: `╷
+│ Error: Unsupported argument
+│ 
+│   on main.tf line 3, in resource "null_resource" "test":
+│    3:   foo = "bar"
+│ 
+│ An argument named "foo" is not expected here.
+╵
+╷
+│ Warning: Deprecated attribute
+│ 
+│   on modules/network/main.tf line 12, in resource "google_compute_network" "vpc":
+│   12:   name = var.legacy
+│     ├────────────────
+│     │ var.legacy is "legacy"
+│ 
+│ The attribute "legacy" is deprecated.
+╵
+╷
+│ Error: No configuration files
+│ 
+│ Plan requires configuration to be present.
+╵
+`,
+			exp: []*Diagnostic{
+				{
+					Severity: "error",
+					Summary:  "Unsupported argument",
+					Detail:   `An argument named "foo" is not expected here.`,
+					Filename: "main.tf",
+					Line:     3,
+				},
+				{
+					Severity: "warning",
+					Summary:  "Deprecated attribute",
+					Detail:   `The attribute "legacy" is deprecated.`,
+					Filename: "modules/network/main.tf",
+					Line:     12,
+				},
+			},
+		},
+		{
+			name: "plain",
+			output: `
+Error: Missing required argument
+
+  on main.tf line 7, in module "test":
+   7: module "test" {
+
+The argument "name" is required, but no definition was found.
+`,
+			exp: []*Diagnostic{
+				{
+					Severity: "error",
+					Summary:  "Missing required argument",
+					Detail:   `The argument "name" is required, but no definition was found.`,
+					Filename: "main.tf",
+					Line:     7,
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := ParseDiagnostics(tc.output)
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("diagnostics not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestParseFormatDiff(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		output string
+		exp    []*FormatDiff
+	}{
+		{
+			name:   "empty",
+			output: "",
+			exp:    nil,
+		},
+		{
+			name: "multiple_files",
+			output: `main.tf
+--- old/main.tf
++++ new/main.tf
+@@ -1,4 +1,4 @@
+ resource "null_resource" "test" {
+-  triggers   = {}
++  triggers = {}
+ }
+@@ -10 +10 @@
+-output "a" {  }
++output "a" {}
+modules/test/variables.tf
+--- old/modules/test/variables.tf
++++ new/modules/test/variables.tf
+@@ -2,3 +2,3 @@
+ variable "a" {
+-  type    = string
++  type = string
+ }
+`,
+			exp: []*FormatDiff{
+				{Filename: "main.tf", StartLine: 1, EndLine: 4},
+				{Filename: "main.tf", StartLine: 10, EndLine: 10},
+				{Filename: "modules/test/variables.tf", StartLine: 2, EndLine: 4},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := ParseFormatDiff(tc.output)
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("format diffs not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
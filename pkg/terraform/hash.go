@@ -0,0 +1,154 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/abcxyz/guardian/pkg/environments"
+	"github.com/abcxyz/guardian/pkg/util"
+)
+
+// lockfileName is the name of the Terraform dependency lock file. This is the
+// only hidden file included in the entrypoint content hash.
+const lockfileName = ".terraform.lock.hcl"
+
+// EntrypointContentHash computes a SHA256 hash over the files of a terraform
+// entrypoint directory, the local modules it uses at any depth, as resolved by
+// ModuleUsage for the given root directory, and the variable and backend config
+// files of its environments. File paths are hashed relative to the root
+// directory, so the result does not depend on where the repository is checked
+// out.
+func EntrypointContentHash(ctx context.Context, rootDir, entrypointDir string) (string, error) {
+	rootAbs, err := util.PathEvalAbs(rootDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path for root directory %s: %w", rootDir, err)
+	}
+
+	entrypointAbs, err := util.PathEvalAbs(entrypointDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path for entrypoint directory %s: %w", entrypointDir, err)
+	}
+
+	graph, err := ModuleUsage(ctx, rootAbs, nil, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to get module usage for %s: %w", rootAbs, err)
+	}
+
+	config, err := environments.Load(entrypointAbs)
+	if err != nil {
+		return "", fmt.Errorf("failed to load environments for %s: %w", entrypointAbs, err)
+	}
+
+	dirs := append([]string{entrypointAbs}, maps.Keys(graph.EntrypointToModules[entrypointAbs])...)
+	sort.Strings(dirs)
+
+	h := sha256.New()
+	hashed := make(map[string]struct{})
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return "", fmt.Errorf("failed to read directory %s: %w", dir, err)
+		}
+
+		// os.ReadDir returns entries sorted by filename, which keeps the hash stable.
+		for _, e := range entries {
+			if !e.Type().IsRegular() {
+				continue
+			}
+
+			if strings.HasPrefix(e.Name(), ".") && e.Name() != lockfileName {
+				continue
+			}
+
+			pth := filepath.Join(dir, e.Name())
+			if err := hashFile(h, rootAbs, pth); err != nil {
+				return "", err
+			}
+			hashed[pth] = struct{}{}
+		}
+	}
+
+	// environment files can be in subdirectories of the entrypoint or outside of
+	// it, which are not hashed above. A missing file is hashed by its path, so
+	// creating it changes the hash.
+	for _, pth := range environmentFiles(entrypointAbs, config) {
+		if _, ok := hashed[pth]; ok {
+			continue
+		}
+		if err := hashFile(h, rootAbs, pth); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return "", err
+			}
+			rel, err := filepath.Rel(rootAbs, pth)
+			if err != nil {
+				return "", fmt.Errorf("failed to get relative path for %s: %w", pth, err)
+			}
+			fmt.Fprintf(h, "%s\x00missing\n", filepath.ToSlash(rel))
+		}
+		hashed[pth] = struct{}{}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashFile writes the path of a file relative to the root directory and the
+// hash of its contents to the hash.
+func hashFile(h io.Writer, rootAbs, pth string) error {
+	b, err := os.ReadFile(pth)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", pth, err)
+	}
+
+	rel, err := filepath.Rel(rootAbs, pth)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path for %s: %w", pth, err)
+	}
+
+	fmt.Fprintf(h, "%s\x00%x\n", filepath.ToSlash(rel), sha256.Sum256(b))
+	return nil
+}
+
+// environmentFiles returns the sorted absolute paths of the variable and
+// backend config files of every environment of an entrypoint. Relative paths
+// are resolved from the entrypoint directory, like Terraform does.
+func environmentFiles(entrypointAbs string, config *environments.Config) []string {
+	files := make(map[string]struct{})
+	for _, e := range config.Environments {
+		for _, f := range slices.Concat(e.VarFiles, e.BackendConfigs) {
+			if !filepath.IsAbs(f) {
+				f = filepath.Join(entrypointAbs, f)
+			}
+			files[filepath.Clean(f)] = struct{}{}
+		}
+	}
+
+	paths := maps.Keys(files)
+	sort.Strings(paths)
+	return paths
+}
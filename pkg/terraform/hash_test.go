@@ -0,0 +1,157 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestEntrypointContentHash(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(t.Context(), logging.TestLogger(t))
+
+	cases := []struct {
+		name       string
+		entrypoint string
+		modify     string
+		expChanged bool
+	}{
+		{
+			name:       "unchanged",
+			entrypoint: "project1",
+			expChanged: false,
+		},
+		{
+			name:       "entrypoint_changed",
+			entrypoint: "project1",
+			modify:     "project1/main.tf",
+			expChanged: true,
+		},
+		{
+			name:       "lockfile_changed",
+			entrypoint: "project1",
+			modify:     "project1/.terraform.lock.hcl",
+			expChanged: true,
+		},
+		{
+			name:       "direct_module_changed",
+			entrypoint: "project1",
+			modify:     "modules/module-a/main.tf",
+			expChanged: true,
+		},
+		{
+			name:       "nested_module_changed",
+			entrypoint: "project2",
+			modify:     "modules/module-a/main.tf",
+			expChanged: true,
+		},
+		{
+			name:       "unrelated_entrypoint_changed",
+			entrypoint: "project1",
+			modify:     "project3/main.tf",
+			expChanged: false,
+		},
+		{
+			name:       "unused_module_changed",
+			entrypoint: "project3",
+			modify:     "modules/module-a/main.tf",
+			expChanged: false,
+		},
+		{
+			name:       "environment_file_in_subdirectory_changed",
+			entrypoint: "project3",
+			modify:     "project3/env/dev.tfvars",
+			expChanged: true,
+		},
+		{
+			name:       "environment_file_outside_entrypoint_changed",
+			entrypoint: "project3",
+			modify:     "environments/prod.tfvars",
+			expChanged: true,
+		},
+		{
+			name:       "environment_backend_config_changed",
+			entrypoint: "project3",
+			modify:     "environments/dev.backend.hcl",
+			expChanged: true,
+		},
+		{
+			name:       "missing_environment_file_created",
+			entrypoint: "project3",
+			modify:     "environments/missing.tfvars",
+			expChanged: true,
+		},
+		{
+			name:       "unrelated_environment_file_changed",
+			entrypoint: "project1",
+			modify:     "environments/prod.tfvars",
+			expChanged: false,
+		},
+		{
+			name:       "ignores_hidden_files",
+			entrypoint: "project1",
+			modify:     "project1/.hidden",
+			expChanged: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Use two separate copies to verify the hash does not depend on the
+			// location of the checkout.
+			before := t.TempDir()
+			if err := os.CopyFS(before, os.DirFS("testdata/with-modules")); err != nil {
+				t.Fatal(err)
+			}
+			after := t.TempDir()
+			if err := os.CopyFS(after, os.DirFS("testdata/with-modules")); err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.modify != "" {
+				f, err := os.OpenFile(filepath.Join(after, tc.modify), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := f.WriteString("\n# modified\n"); err != nil {
+					t.Fatal(err)
+				}
+				if err := f.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			beforeHash, err := EntrypointContentHash(ctx, before, filepath.Join(before, tc.entrypoint))
+			if err != nil {
+				t.Fatal(err)
+			}
+			afterHash, err := EntrypointContentHash(ctx, after, filepath.Join(after, tc.entrypoint))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := beforeHash != afterHash; got != tc.expChanged {
+				t.Errorf("expected hash changed to be %t, got %t (before: %s, after: %s)", tc.expChanged, got, beforeHash, afterHash)
+			}
+		})
+	}
+}
@@ -24,12 +24,13 @@ import (
 
 // InitOptions are the set of options for running a terraform init.
 type InitOptions struct {
-	Backend     *bool
-	Input       *bool
-	NoColor     *bool
-	Lock        *bool
-	LockTimeout *string
-	Lockfile    *string
+	Backend        *bool
+	BackendConfigs []string
+	Input          *bool
+	NoColor        *bool
+	Lock           *bool
+	LockTimeout    *string
+	Lockfile       *string
 }
 
 // initArgsFromOptions generated the terrafrom init arguments from the provided options.
@@ -44,6 +45,10 @@ func initArgsFromOptions(opts *InitOptions) []string {
 		args = append(args, fmt.Sprintf("-backend=%t", *opts.Backend))
 	}
 
+	for _, c := range opts.BackendConfigs {
+		args = append(args, fmt.Sprintf("-backend-config=%s", c))
+	}
+
 	if opts.Input != nil {
 		args = append(args, fmt.Sprintf("-input=%t", *opts.Input))
 	}
@@ -33,15 +33,18 @@ func TestInitArgsFromOptions(t *testing.T) {
 		{
 			name: "truthy",
 			opts: &InitOptions{
-				Backend:     pointer.To(true),
-				Lock:        pointer.To(true),
-				LockTimeout: pointer.To("10m"),
-				Lockfile:    pointer.To("readonly"),
-				Input:       pointer.To(true),
-				NoColor:     pointer.To(true),
+				Backend:        pointer.To(true),
+				BackendConfigs: []string{"env/dev.backend.hcl", "env/common.backend.hcl"},
+				Lock:           pointer.To(true),
+				LockTimeout:    pointer.To("10m"),
+				Lockfile:       pointer.To("readonly"),
+				Input:          pointer.To(true),
+				NoColor:        pointer.To(true),
 			},
 			exp: []string{
 				"-backend=true",
+				"-backend-config=env/dev.backend.hcl",
+				"-backend-config=env/common.backend.hcl",
 				"-input=true",
 				"-no-color",
 				"-lock=true",
@@ -33,6 +33,10 @@ type PlanOptions struct {
 	Lock                   *bool
 	LockTimeout            *string
 	Out                    *string
+	RefreshOnly            *bool
+	Replaces               []string
+	Targets                []string
+	VarFiles               []string
 	DisallowedProviders    []string
 	DisallowedProvisioners []string
 	AllowedProviders       []string
@@ -79,6 +83,22 @@ func planArgsFromOptions(opts *PlanOptions) []string {
 		args = append(args, fmt.Sprintf("-out=%s", *opts.Out))
 	}
 
+	if pointer.Deref(opts.RefreshOnly) {
+		args = append(args, "-refresh-only")
+	}
+
+	for _, r := range opts.Replaces {
+		args = append(args, fmt.Sprintf("-replace=%s", r))
+	}
+
+	for _, t := range opts.Targets {
+		args = append(args, fmt.Sprintf("-target=%s", t))
+	}
+
+	for _, f := range opts.VarFiles {
+		args = append(args, fmt.Sprintf("-var-file=%s", f))
+	}
+
 	return args
 }
 
@@ -0,0 +1,175 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionReplace = "replace"
+	ActionDelete  = "delete"
+)
+
+// DefaultSensitiveResourceTypes are the patterns of resource types that are
+// highlighted when they are deleted or replaced, such as IAM, KMS keys and
+// databases.
+var DefaultSensitiveResourceTypes = []string{
+	`_iam_`,
+	`_kms_`,
+	`_key_vault`,
+	`_sql_database`,
+	`_spanner_`,
+	`_bigtable_`,
+	`_bigquery_dataset$`,
+	`_alloydb_`,
+	`^aws_db_instance$`,
+	`^aws_rds_`,
+	`^aws_dynamodb_table$`,
+	`^azurerm_cosmosdb_`,
+}
+
+// PlanSummary is a summary of the resource changes in a Terraform plan.
+type PlanSummary struct {
+	Create  int
+	Update  int
+	Replace int
+	Delete  int
+
+	// Resources are the resources with a create, update, replace or delete
+	// action, in the order they appear in the plan.
+	Resources []*PlanResourceChange
+}
+
+// PlanResourceChange is a planned change to a single resource.
+type PlanResourceChange struct {
+	Address string
+	// Module is the address of the module containing the resource, or empty for
+	// the root module.
+	Module   string
+	Type     string
+	Provider string
+	Action   string
+}
+
+// planJSON is the subset of the Terraform JSON plan representation used to
+// summarize the plan.
+type planJSON struct {
+	ResourceChanges []struct {
+		Address       string `json:"address"`
+		ModuleAddress string `json:"module_address"`
+		Mode          string `json:"mode"`
+		Type          string `json:"type"`
+		ProviderName  string `json:"provider_name"`
+		Change        struct {
+			Actions []string `json:"actions"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+// ParsePlanSummary summarizes the resource changes of a plan from its JSON
+// representation, as produced by terraform show -json or tofu show -json.
+// Actions that do not change resources, such as forget, are not counted.
+func ParsePlanSummary(data []byte) (*PlanSummary, error) {
+	var plan planJSON
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan json: %w", err)
+	}
+
+	s := &PlanSummary{}
+	for _, rc := range plan.ResourceChanges {
+		if rc.Mode == "data" {
+			continue
+		}
+
+		action := planAction(rc.Change.Actions)
+		switch action {
+		case ActionCreate:
+			s.Create++
+		case ActionUpdate:
+			s.Update++
+		case ActionReplace:
+			s.Replace++
+		case ActionDelete:
+			s.Delete++
+		default:
+			continue
+		}
+
+		s.Resources = append(s.Resources, &PlanResourceChange{
+			Address:  rc.Address,
+			Module:   rc.ModuleAddress,
+			Type:     rc.Type,
+			Provider: rc.ProviderName,
+			Action:   action,
+		})
+	}
+
+	return s, nil
+}
+
+// HasChanges returns true if the plan creates, updates, replaces or deletes any
+// resources.
+func (s *PlanSummary) HasChanges() bool {
+	return len(s.Resources) > 0
+}
+
+// Destroyed returns the resources that are deleted or replaced.
+func (s *PlanSummary) Destroyed() []*PlanResourceChange {
+	var res []*PlanResourceChange
+	for _, r := range s.Resources {
+		if r.Action == ActionDelete || r.Action == ActionReplace {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// SensitiveDestroyed returns the resources that are deleted or replaced, with a
+// type matching any of the patterns.
+func (s *PlanSummary) SensitiveDestroyed(patterns []*regexp.Regexp) []*PlanResourceChange {
+	var res []*PlanResourceChange
+	for _, r := range s.Destroyed() {
+		if slices.ContainsFunc(patterns, func(p *regexp.Regexp) bool { return p.MatchString(r.Type) }) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// planAction returns the single action for the list of actions of a resource
+// change, where a delete and create is a replace.
+func planAction(actions []string) string {
+	hasCreate := slices.Contains(actions, "create")
+	hasDelete := slices.Contains(actions, "delete")
+
+	switch {
+	case hasCreate && hasDelete:
+		return ActionReplace
+	case hasCreate:
+		return ActionCreate
+	case hasDelete:
+		return ActionDelete
+	case slices.Contains(actions, "update"):
+		return ActionUpdate
+	default:
+		return ""
+	}
+}
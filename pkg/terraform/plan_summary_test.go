@@ -0,0 +1,128 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+const testPlanJSON = `{
+  "resource_changes": [
+    {"address": "google_storage_bucket.new", "mode": "managed", "type": "google_storage_bucket", "provider_name": "registry.terraform.io/hashicorp/google", "change": {"actions": ["create"]}},
+    {"address": "google_storage_bucket.same", "mode": "managed", "type": "google_storage_bucket", "provider_name": "registry.terraform.io/hashicorp/google", "change": {"actions": ["no-op"]}},
+    {"address": "module.kms.google_kms_crypto_key.key", "module_address": "module.kms", "mode": "managed", "type": "google_kms_crypto_key", "provider_name": "registry.terraform.io/hashicorp/google", "change": {"actions": ["delete", "create"]}},
+    {"address": "aws_db_instance.main", "mode": "managed", "type": "aws_db_instance", "provider_name": "registry.terraform.io/hashicorp/aws", "change": {"actions": ["delete"]}},
+    {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "provider_name": "registry.terraform.io/hashicorp/aws", "change": {"actions": ["update"]}},
+    {"address": "data.google_project.p", "mode": "data", "type": "google_project", "provider_name": "registry.terraform.io/hashicorp/google", "change": {"actions": ["read"]}}
+  ]
+}`
+
+func TestParsePlanSummary(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		data string
+		exp  *PlanSummary
+		err  string
+	}{
+		{
+			name: "success",
+			data: testPlanJSON,
+			exp: &PlanSummary{
+				Create:  1,
+				Update:  1,
+				Replace: 1,
+				Delete:  1,
+				Resources: []*PlanResourceChange{
+					{Address: "google_storage_bucket.new", Type: "google_storage_bucket", Provider: "registry.terraform.io/hashicorp/google", Action: ActionCreate},
+					{Address: "module.kms.google_kms_crypto_key.key", Module: "module.kms", Type: "google_kms_crypto_key", Provider: "registry.terraform.io/hashicorp/google", Action: ActionReplace},
+					{Address: "aws_db_instance.main", Type: "aws_db_instance", Provider: "registry.terraform.io/hashicorp/aws", Action: ActionDelete},
+					{Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Provider: "registry.terraform.io/hashicorp/aws", Action: ActionUpdate},
+				},
+			},
+		},
+		{
+			name: "opentofu",
+			data: `{
+  "format_version": "1.2",
+  "terraform_version": "1.8.0",
+  "resource_changes": [
+    {"address": "google_storage_bucket.new", "mode": "managed", "type": "google_storage_bucket", "provider_name": "registry.opentofu.org/hashicorp/google", "change": {"actions": ["create"]}},
+    {"address": "google_storage_bucket.removed", "mode": "managed", "type": "google_storage_bucket", "provider_name": "registry.opentofu.org/hashicorp/google", "change": {"actions": ["forget"]}}
+  ]
+}`,
+			exp: &PlanSummary{
+				Create: 1,
+				Resources: []*PlanResourceChange{
+					{Address: "google_storage_bucket.new", Type: "google_storage_bucket", Provider: "registry.opentofu.org/hashicorp/google", Action: ActionCreate},
+				},
+			},
+		},
+		{
+			name: "no_changes",
+			data: `{"format_version": "1.2"}`,
+			exp:  &PlanSummary{},
+		},
+		{
+			name: "invalid_json",
+			data: `{`,
+			err:  "failed to parse plan json",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParsePlanSummary([]byte(tc.data))
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("summary not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestPlanSummary_SensitiveDestroyed(t *testing.T) {
+	t.Parallel()
+
+	s, err := ParsePlanSummary([]byte(testPlanJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(DefaultSensitiveResourceTypes))
+	for _, p := range DefaultSensitiveResourceTypes {
+		patterns = append(patterns, regexp.MustCompile(p))
+	}
+
+	var got []string
+	for _, r := range s.SensitiveDestroyed(patterns) {
+		got = append(got, r.Address)
+	}
+
+	exp := []string{"module.kms.google_kms_crypto_key.key", "aws_db_instance.main"}
+	if diff := cmp.Diff(got, exp); diff != "" {
+		t.Errorf("sensitive resources not as expected; (-got,+want): %s", diff)
+	}
+}
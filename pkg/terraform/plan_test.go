@@ -41,6 +41,10 @@ func TestPlanArgsFromOptions(t *testing.T) {
 				Input:            pointer.To(true),
 				NoColor:          pointer.To(true),
 				Out:              pointer.To("outfile"),
+				RefreshOnly:      pointer.To(true),
+				Replaces:         []string{"google_compute_instance.vm"},
+				Targets:          []string{"module.network", `google_storage_bucket.b["logs"]`},
+				VarFiles:         []string{"env/common.tfvars", "env/dev.tfvars"},
 			},
 			exp: []string{
 				"-compact-warnings",
@@ -51,6 +55,12 @@ func TestPlanArgsFromOptions(t *testing.T) {
 				"-lock=true",
 				"-lock-timeout=10m",
 				"-out=outfile",
+				"-refresh-only",
+				"-replace=google_compute_instance.vm",
+				"-target=module.network",
+				`-target=google_storage_bucket.b["logs"]`,
+				"-var-file=env/common.tfvars",
+				"-var-file=env/dev.tfvars",
 			},
 		},
 		{
@@ -64,6 +74,7 @@ func TestPlanArgsFromOptions(t *testing.T) {
 				Input:            pointer.To(false),
 				NoColor:          pointer.To(false),
 				Out:              pointer.To("outfile"),
+				RefreshOnly:      pointer.To(false),
 			},
 			exp: []string{
 				"-input=false",
@@ -0,0 +1,303 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// RedactedValue replaces the values masked in Terraform output.
+	RedactedValue = "(redacted)"
+
+	// minRedactLength is the minimum length of values from the plan that are
+	// masked, as masking short values such as "1" or "dev" would mask
+	// unrelated output.
+	minRedactLength = 4
+)
+
+// DefaultRedactAttributes are the names of attributes whose values are masked
+// even if the provider does not mark them as sensitive.
+var DefaultRedactAttributes = []string{
+	"access_key",
+	"client_secret",
+	"password",
+	"private_key",
+	"secret",
+	"secret_key",
+	"token",
+}
+
+// Redactor masks sensitive values in Terraform output. Values are masked if
+// they are sensitive in the JSON plan, match one of the patterns, or are
+// assigned to one of the attribute names. A Redactor is not modified after it
+// is created, so it is safe for concurrent use, and a nil Redactor does not
+// mask anything.
+type Redactor struct {
+	values        []string
+	patterns      []*regexp.Regexp
+	attributes    map[string]struct{}
+	attributeLine *regexp.Regexp
+}
+
+// NewRedactor creates a new Redactor masking the matches of the regular
+// expression patterns and the values of the attribute names, which are
+// matched case insensitively.
+func NewRedactor(patterns, attributes []string) (*Redactor, error) {
+	r := &Redactor{
+		attributes: make(map[string]struct{}, len(attributes)),
+	}
+
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse redact pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	names := make([]string, 0, len(attributes))
+	for _, a := range attributes {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == "" {
+			continue
+		}
+		r.attributes[a] = struct{}{}
+		names = append(names, regexp.QuoteMeta(a))
+	}
+
+	// matches attribute assignments in the human readable plan and apply
+	// output, e.g. `+ password = "value"` or `~ "token" = "a" -> "b"`
+	if len(names) > 0 {
+		r.attributeLine = regexp.MustCompile(`(?mi)^(\s*(?:-/\+|\+/-|[-+~])?\s*"?(?:` +
+			strings.Join(names, "|") + `)"?\s*[=:]\s*)(\S.*)$`)
+	}
+
+	return r, nil
+}
+
+// WithPlanJSON returns a copy of the Redactor that also masks the values that
+// are sensitive in the JSON plan, or are assigned to one of the attribute
+// names of the Redactor.
+func (r *Redactor) WithPlanJSON(b []byte) (*Redactor, error) {
+	if r == nil {
+		r = &Redactor{}
+	}
+
+	var plan struct {
+		ResourceChanges []*redactResourceChange  `json:"resource_changes"`
+		ResourceDrift   []*redactResourceChange  `json:"resource_drift"`
+		OutputChanges   map[string]*redactChange `json:"output_changes"`
+	}
+	if err := json.Unmarshal(b, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan json: %w", err)
+	}
+
+	values := make(map[string]struct{}, len(r.values))
+	for _, v := range r.values {
+		values[v] = struct{}{}
+	}
+
+	changes := make([]*redactChange, 0, len(plan.ResourceChanges)+len(plan.ResourceDrift)+len(plan.OutputChanges))
+	for _, rc := range append(plan.ResourceChanges, plan.ResourceDrift...) {
+		if rc != nil && rc.Change != nil {
+			changes = append(changes, rc.Change)
+		}
+	}
+	for _, c := range plan.OutputChanges {
+		if c != nil {
+			changes = append(changes, c)
+		}
+	}
+
+	for _, c := range changes {
+		collectSensitive(values, c.Before, c.BeforeSensitive)
+		collectSensitive(values, c.After, c.AfterSensitive)
+		r.collectAttributes(values, c.Before)
+		r.collectAttributes(values, c.After)
+	}
+
+	next := *r
+	next.values = make([]string, 0, len(values))
+	for v := range values {
+		next.values = append(next.values, v)
+	}
+	// longer values are replaced first, so a value containing another is
+	// masked entirely
+	sort.Slice(next.values, func(i, j int) bool {
+		if len(next.values[i]) != len(next.values[j]) {
+			return len(next.values[i]) > len(next.values[j])
+		}
+		return next.values[i] < next.values[j]
+	})
+
+	return &next, nil
+}
+
+// redactResourceChange is the subset of a resource change in the JSON plan
+// used to find sensitive values.
+type redactResourceChange struct {
+	Change *redactChange `json:"change"`
+}
+
+// redactChange is the subset of a change in the JSON plan used to find
+// sensitive values.
+type redactChange struct {
+	Before          any `json:"before"`
+	After           any `json:"after"`
+	BeforeSensitive any `json:"before_sensitive"`
+	AfterSensitive  any `json:"after_sensitive"`
+}
+
+// collectSensitive adds the values marked as sensitive to the set. The
+// sensitive marks mirror the structure of the value, with true marking a
+// sensitive value.
+func collectSensitive(values map[string]struct{}, value, sensitive any) {
+	switch s := sensitive.(type) {
+	case bool:
+		if s {
+			collectLeaves(values, value)
+		}
+	case map[string]any:
+		if v, ok := value.(map[string]any); ok {
+			for k, ks := range s {
+				collectSensitive(values, v[k], ks)
+			}
+		}
+	case []any:
+		if v, ok := value.([]any); ok {
+			for i, is := range s {
+				if i < len(v) {
+					collectSensitive(values, v[i], is)
+				}
+			}
+		}
+	}
+}
+
+// collectAttributes adds the values assigned to the attribute names to the
+// set.
+func (r *Redactor) collectAttributes(values map[string]struct{}, value any) {
+	if len(r.attributes) == 0 {
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for k, kv := range v {
+			if _, ok := r.attributes[strings.ToLower(k)]; ok {
+				collectLeaves(values, kv)
+				continue
+			}
+			r.collectAttributes(values, kv)
+		}
+	case []any:
+		for _, iv := range v {
+			r.collectAttributes(values, iv)
+		}
+	}
+}
+
+// collectLeaves adds all string values contained in the value to the set.
+// Each line of a multi-line string is added as well, as Terraform shows them
+// on separate lines.
+func collectLeaves(values map[string]struct{}, value any) {
+	switch v := value.(type) {
+	case string:
+		for _, s := range append([]string{v}, strings.Split(v, "\n")...) {
+			s = strings.TrimSpace(s)
+			if len(s) >= minRedactLength {
+				values[s] = struct{}{}
+			}
+		}
+	case map[string]any:
+		for _, kv := range v {
+			collectLeaves(values, kv)
+		}
+	case []any:
+		for _, iv := range v {
+			collectLeaves(values, iv)
+		}
+	}
+}
+
+// Redact returns the output with sensitive values masked.
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, RedactedValue)
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, RedactedValue)
+	}
+	if r.attributeLine != nil {
+		s = r.attributeLine.ReplaceAllString(s, "${1}"+RedactedValue)
+	}
+	return s
+}
+
+// Writer returns a writer masking sensitive values before writing them to w.
+// Output is masked one line at a time, so Flush must be called to write the
+// last line if it does not end with a newline.
+func (r *Redactor) Writer(w io.Writer) *RedactWriter {
+	return &RedactWriter{redactor: r, w: w}
+}
+
+// RedactWriter is a writer masking sensitive values, see Redactor.Writer.
+type RedactWriter struct {
+	redactor *Redactor
+	w        io.Writer
+	buf      []byte
+}
+
+// Write implements io.Writer.
+func (rw *RedactWriter) Write(p []byte) (int, error) {
+	if rw.redactor == nil {
+		return rw.w.Write(p) //nolint:wrapcheck // Want passthrough
+	}
+
+	rw.buf = append(rw.buf, p...)
+	if i := bytes.LastIndexByte(rw.buf, '\n'); i >= 0 {
+		out := rw.redactor.Redact(string(rw.buf[:i+1]))
+		rw.buf = append(rw.buf[:0], rw.buf[i+1:]...)
+		if _, err := io.WriteString(rw.w, out); err != nil {
+			return 0, fmt.Errorf("failed to write redacted output: %w", err)
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes the remaining buffered output.
+func (rw *RedactWriter) Flush() error {
+	if len(rw.buf) == 0 {
+		return nil
+	}
+
+	out := rw.redactor.Redact(string(rw.buf))
+	rw.buf = rw.buf[:0]
+	if _, err := io.WriteString(rw.w, out); err != nil {
+		return fmt.Errorf("failed to write redacted output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+const redactPlanJSON = `{
+  "resource_changes": [
+    {
+      "address": "random_password.db",
+      "change": {
+        "before": null,
+        "after": {"length": 24, "result": "s3cr3t-p4ssw0rd", "special": true},
+        "after_sensitive": {"result": true}
+      }
+    },
+    {
+      "address": "google_secret_manager_secret_version.api",
+      "change": {
+        "before": {"secret_data": "old-api-key"},
+        "after": {"secret_data": "new-api-key", "labels": {"env": "dev"}},
+        "before_sensitive": {"secret_data": true},
+        "after_sensitive": {"secret_data": true, "labels": {}}
+      }
+    },
+    {
+      "address": "example_connection.c",
+      "change": {
+        "before": null,
+        "after": {"settings": [{"name": "db", "password": "hunter2-hunter2"}], "tls": {"private_key": "-----BEGIN KEY-----\nMIIEvQIBADAN\n-----END KEY-----"}}
+      }
+    }
+  ],
+  "output_changes": {
+    "token": {
+      "before": null,
+      "after": "output-token-value",
+      "after_sensitive": true
+    }
+  }
+}`
+
+func TestRedactor_Redact(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name       string
+		patterns   []string
+		attributes []string
+		planJSON   string
+		input      string
+		exp        string
+	}{
+		{
+			name:     "sensitive_values",
+			planJSON: redactPlanJSON,
+			input: `random_password.db: result "s3cr3t-p4ssw0rd"
+secret_data: "old-api-key" -> "new-api-key"
+labels: env = "dev"
+output: output-token-value`,
+			exp: `random_password.db: result "(redacted)"
+secret_data: "(redacted)" -> "(redacted)"
+labels: env = "dev"
+output: (redacted)`,
+		},
+		{
+			name:       "attribute_values",
+			attributes: []string{"password", "private_key"},
+			planJSON:   redactPlanJSON,
+			input: `connection uses hunter2-hunter2
+key line MIIEvQIBADAN`,
+			exp: `connection uses (redacted)
+key line (redacted)`,
+		},
+		{
+			name:       "attribute_lines",
+			attributes: []string{"Password", "token"},
+			input: `  + password   = "plain"
+  ~ "token"    = "a" -> "b"
+  + token_id   = "visible"
+      password = (sensitive value)`,
+			exp: `  + password   = (redacted)
+  ~ "token"    = (redacted)
+  + token_id   = "visible"
+      password = (redacted)`,
+		},
+		{
+			name:     "patterns",
+			patterns: []string{`ghp_[A-Za-z0-9]{8}`, `AKIA[0-9A-Z]{4}`},
+			input:    "token ghp_abcd1234 and key AKIAABCD",
+			exp:      "token (redacted) and key (redacted)",
+		},
+		{
+			name:  "nothing_configured",
+			input: `+ password = "plain"`,
+			exp:   `+ password = "plain"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewRedactor(tc.patterns, tc.attributes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.planJSON != "" {
+				r, err = r.WithPlanJSON([]byte(tc.planJSON))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got, want := r.Redact(tc.input), tc.exp; got != want {
+				t.Errorf("expected\n\n%s\n\nto be\n\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestNewRedactor_invalidPattern(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewRedactor([]string{"("}, nil)
+	if diff := testutil.DiffErrString(err, `failed to parse redact pattern "("`); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestRedactor_nil(t *testing.T) {
+	t.Parallel()
+
+	var r *Redactor
+	if got, want := r.Redact("password = x"), "password = x"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+
+	var b strings.Builder
+	w := r.Writer(&b)
+	if _, err := w.Write([]byte("password = x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "password = x"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+}
+
+func TestRedactWriter(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewRedactor([]string{`secret-[0-9]+`}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	w := r.Writer(&b)
+
+	// a value split across writes is masked once the line is complete
+	for _, chunk := range []string{"first secret-1", "23\nsecond ", "secret-456"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := b.String(), "first (redacted)\n"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "first (redacted)\nsecond (redacted)"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+}
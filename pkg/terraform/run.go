@@ -24,8 +24,9 @@ import (
 )
 
 // overrideEnvVars are the environment variables to inject into the Terraform
-// child process, no matter what the user configured. These take precedence
-// over all other configurables.
+// or OpenTofu child process, no matter what the user configured. These take
+// precedence over all other configurables. OpenTofu reads the same user agent
+// variables as Terraform.
 var defaultOverrideEnvVars = []string{
 	"GOOGLE_TERRAFORM_USERAGENT_EXTENSION=" + version.UserAgent,
 	"TF_APPEND_USER_AGENT=" + version.UserAgent,
@@ -40,13 +41,17 @@ func (t *TerraformClient) Run(ctx context.Context, stdout, stderr io.Writer, sub
 	}
 
 	overrideEnvVars := slices.Concat(nil, t.envVars, defaultOverrideEnvVars)
+	if t.encryption != "" {
+		overrideEnvVars = append(overrideEnvVars, "TF_ENCRYPTION="+t.encryption)
+	}
 
 	return child.Run(ctx, &child.RunConfig{ //nolint:wrapcheck
 		Stdout:          stdout,
 		Stderr:          stderr,
 		WorkingDir:      t.workingDir,
-		Command:         "terraform",
+		Command:         t.binary,
 		Args:            runArgs,
 		OverrideEnvVars: overrideEnvVars,
+		GracePeriod:     t.gracePeriod,
 	})
 }
@@ -0,0 +1,243 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// StateSnapshot is the managed resources and outputs of a Terraform state.
+type StateSnapshot struct {
+	// Resources are the attribute values of the managed resources, by address.
+	Resources map[string]any
+
+	// Outputs are the root module outputs, by name.
+	Outputs map[string]*StateOutput
+}
+
+// StateOutput is a root module output of a Terraform state.
+type StateOutput struct {
+	Value     any  `json:"value"`
+	Sensitive bool `json:"sensitive"`
+}
+
+// stateJSON is the subset of the Terraform JSON state representation used to
+// snapshot the state.
+type stateJSON struct {
+	Values *struct {
+		Outputs    map[string]*StateOutput `json:"outputs"`
+		RootModule *stateModuleJSON        `json:"root_module"`
+	} `json:"values"`
+}
+
+type stateModuleJSON struct {
+	Resources []struct {
+		Address string `json:"address"`
+		Mode    string `json:"mode"`
+		Values  any    `json:"values"`
+	} `json:"resources"`
+	ChildModules []*stateModuleJSON `json:"child_modules"`
+}
+
+// ParseStateSnapshot snapshots a state from its JSON representation, as
+// produced by terraform show -json without a plan file. An empty state, which
+// does not have any values, returns an empty snapshot. Data sources are not
+// included, as they are read again on every run.
+func ParseStateSnapshot(data []byte) (*StateSnapshot, error) {
+	var state stateJSON
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state json: %w", err)
+	}
+
+	s := &StateSnapshot{
+		Resources: make(map[string]any),
+		Outputs:   make(map[string]*StateOutput),
+	}
+	if state.Values == nil {
+		return s, nil
+	}
+
+	for name, o := range state.Values.Outputs {
+		if o != nil {
+			s.Outputs[name] = o
+		}
+	}
+
+	modules := []*stateModuleJSON{state.Values.RootModule}
+	for len(modules) > 0 {
+		m := modules[0]
+		modules = modules[1:]
+		if m == nil {
+			continue
+		}
+
+		for _, r := range m.Resources {
+			if r.Mode == "data" {
+				continue
+			}
+			s.Resources[r.Address] = r.Values
+		}
+		modules = append(modules, m.ChildModules...)
+	}
+
+	return s, nil
+}
+
+// StateDiff is the difference between the state before and after an apply.
+// Resources are listed by address and sorted.
+type StateDiff struct {
+	// Created are the resources added to the state.
+	Created []string `json:"created"`
+
+	// Updated are the resources whose attribute values changed, including
+	// resources that were replaced.
+	Updated []string `json:"updated"`
+
+	// Deleted are the resources removed from the state.
+	Deleted []string `json:"deleted"`
+
+	// Outputs are the root module outputs that changed, sorted by name.
+	Outputs []*OutputChange `json:"outputs"`
+
+	// Unplanned are the resources that changed without a planned change, e.g.
+	// because a provider updated attributes the plan did not show. Unplanned and
+	// Unapplied are nil if the changes were not compared with the plan.
+	Unplanned []string `json:"unplanned"`
+
+	// Unapplied are the resources with a planned change that did not change,
+	// e.g. because the change only resolved unknown values to their current
+	// value.
+	Unapplied []string `json:"unapplied"`
+}
+
+// OutputChange is a change to a root module output. The values of sensitive
+// outputs are not included.
+type OutputChange struct {
+	Name      string `json:"name"`
+	Action    string `json:"action"`
+	Before    any    `json:"before,omitempty"`
+	After     any    `json:"after,omitempty"`
+	Sensitive bool   `json:"sensitive,omitempty"`
+}
+
+// DiffStates returns the resources and outputs that changed between the state
+// snapshots before and after an apply.
+func DiffStates(before, after *StateSnapshot) *StateDiff {
+	d := &StateDiff{
+		Created: []string{},
+		Updated: []string{},
+		Deleted: []string{},
+		Outputs: []*OutputChange{},
+	}
+
+	for addr, values := range after.Resources {
+		prev, ok := before.Resources[addr]
+		switch {
+		case !ok:
+			d.Created = append(d.Created, addr)
+		case !reflect.DeepEqual(prev, values):
+			d.Updated = append(d.Updated, addr)
+		}
+	}
+	for addr := range before.Resources {
+		if _, ok := after.Resources[addr]; !ok {
+			d.Deleted = append(d.Deleted, addr)
+		}
+	}
+
+	for name, o := range after.Outputs {
+		prev, ok := before.Outputs[name]
+		switch {
+		case !ok:
+			d.Outputs = append(d.Outputs, outputChange(name, ActionCreate, nil, o))
+		case !reflect.DeepEqual(prev.Value, o.Value) || prev.Sensitive != o.Sensitive:
+			d.Outputs = append(d.Outputs, outputChange(name, ActionUpdate, prev, o))
+		}
+	}
+	for name, o := range before.Outputs {
+		if _, ok := after.Outputs[name]; !ok {
+			d.Outputs = append(d.Outputs, outputChange(name, ActionDelete, o, nil))
+		}
+	}
+
+	sort.Strings(d.Created)
+	sort.Strings(d.Updated)
+	sort.Strings(d.Deleted)
+	sort.Slice(d.Outputs, func(i, j int) bool {
+		return d.Outputs[i].Name < d.Outputs[j].Name
+	})
+
+	return d
+}
+
+// outputChange returns the change to an output, omitting the values if the
+// output is sensitive before or after the change.
+func outputChange(name, action string, before, after *StateOutput) *OutputChange {
+	c := &OutputChange{Name: name, Action: action}
+	if (before != nil && before.Sensitive) || (after != nil && after.Sensitive) {
+		c.Sensitive = true
+		return c
+	}
+	if before != nil {
+		c.Before = before.Value
+	}
+	if after != nil {
+		c.After = after.Value
+	}
+	return c
+}
+
+// ComparePlan sets the resources that changed differently than planned, using
+// the summary of the applied plan.
+func (d *StateDiff) ComparePlan(s *PlanSummary) {
+	planned := make(map[string]struct{}, len(s.Resources))
+	for _, r := range s.Resources {
+		planned[r.Address] = struct{}{}
+	}
+
+	d.Unplanned, d.Unapplied = []string{}, []string{}
+
+	changed := slices.Concat(d.Created, d.Updated, d.Deleted)
+	applied := make(map[string]struct{}, len(changed))
+	for _, addr := range changed {
+		applied[addr] = struct{}{}
+		if _, ok := planned[addr]; !ok {
+			d.Unplanned = append(d.Unplanned, addr)
+		}
+	}
+	for addr := range planned {
+		if _, ok := applied[addr]; !ok {
+			d.Unapplied = append(d.Unapplied, addr)
+		}
+	}
+
+	sort.Strings(d.Unplanned)
+	sort.Strings(d.Unapplied)
+}
+
+// HasChanges returns true if any resources or outputs changed.
+func (d *StateDiff) HasChanges() bool {
+	return len(d.Created)+len(d.Updated)+len(d.Deleted)+len(d.Outputs) > 0
+}
+
+// MatchesPlan returns true if the resources that changed are the resources
+// with a planned change.
+func (d *StateDiff) MatchesPlan() bool {
+	return len(d.Unplanned)+len(d.Unapplied) == 0
+}
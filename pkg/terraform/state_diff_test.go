@@ -0,0 +1,196 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+const stateBeforeJSON = `{
+  "format_version": "1.0",
+  "values": {
+    "outputs": {
+      "bucket": {"value": "old-bucket", "sensitive": false},
+      "password": {"value": "old-password", "sensitive": true},
+      "removed": {"value": "gone", "sensitive": false}
+    },
+    "root_module": {
+      "resources": [
+        {"address": "google_storage_bucket.b", "mode": "managed", "values": {"name": "old-bucket"}},
+        {"address": "google_project_iam_member.m", "mode": "managed", "values": {"role": "roles/viewer"}},
+        {"address": "data.google_project.p", "mode": "data", "values": {"number": "1"}}
+      ],
+      "child_modules": [
+        {
+          "address": "module.net",
+          "resources": [
+            {"address": "module.net.google_compute_network.n", "mode": "managed", "values": {"name": "net"}}
+          ]
+        }
+      ]
+    }
+  }
+}`
+
+const stateAfterJSON = `{
+  "format_version": "1.0",
+  "values": {
+    "outputs": {
+      "bucket": {"value": "new-bucket", "sensitive": false},
+      "password": {"value": "new-password", "sensitive": true},
+      "added": {"value": ["a"], "sensitive": false}
+    },
+    "root_module": {
+      "resources": [
+        {"address": "google_storage_bucket.b", "mode": "managed", "values": {"name": "new-bucket"}},
+        {"address": "google_service_account.sa", "mode": "managed", "values": {"account_id": "sa"}},
+        {"address": "data.google_project.p", "mode": "data", "values": {"number": "2"}}
+      ],
+      "child_modules": [
+        {
+          "address": "module.net",
+          "resources": [
+            {"address": "module.net.google_compute_network.n", "mode": "managed", "values": {"name": "net"}}
+          ]
+        }
+      ]
+    }
+  }
+}`
+
+func TestParseStateSnapshot(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		data string
+		exp  *StateSnapshot
+		err  string
+	}{
+		{
+			name: "success",
+			data: stateBeforeJSON,
+			exp: &StateSnapshot{
+				Resources: map[string]any{
+					"google_storage_bucket.b":             map[string]any{"name": "old-bucket"},
+					"google_project_iam_member.m":         map[string]any{"role": "roles/viewer"},
+					"module.net.google_compute_network.n": map[string]any{"name": "net"},
+				},
+				Outputs: map[string]*StateOutput{
+					"bucket":   {Value: "old-bucket"},
+					"password": {Value: "old-password", Sensitive: true},
+					"removed":  {Value: "gone"},
+				},
+			},
+		},
+		{
+			name: "empty_state",
+			data: `{"format_version": "1.0"}`,
+			exp: &StateSnapshot{
+				Resources: map[string]any{},
+				Outputs:   map[string]*StateOutput{},
+			},
+		},
+		{
+			name: "invalid_json",
+			data: "not json",
+			err:  "failed to parse state json",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseStateSnapshot([]byte(tc.data))
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(got, tc.exp); diff != "" {
+				t.Errorf("snapshot not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestDiffStates(t *testing.T) {
+	t.Parallel()
+
+	before, err := ParseStateSnapshot([]byte(stateBeforeJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := ParseStateSnapshot([]byte(stateAfterJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := DiffStates(before, after)
+	got.ComparePlan(&PlanSummary{
+		Resources: []*PlanResourceChange{
+			{Address: "google_storage_bucket.b", Action: ActionUpdate},
+			{Address: "google_service_account.sa", Action: ActionCreate},
+			{Address: "module.net.google_compute_network.n", Action: ActionUpdate},
+		},
+	})
+
+	exp := &StateDiff{
+		Created: []string{"google_service_account.sa"},
+		Updated: []string{"google_storage_bucket.b"},
+		Deleted: []string{"google_project_iam_member.m"},
+		Outputs: []*OutputChange{
+			{Name: "added", Action: ActionCreate, After: []any{"a"}},
+			{Name: "bucket", Action: ActionUpdate, Before: "old-bucket", After: "new-bucket"},
+			{Name: "password", Action: ActionUpdate, Sensitive: true},
+			{Name: "removed", Action: ActionDelete, Before: "gone"},
+		},
+		Unplanned: []string{"google_project_iam_member.m"},
+		Unapplied: []string{"module.net.google_compute_network.n"},
+	}
+	if diff := cmp.Diff(got, exp); diff != "" {
+		t.Errorf("diff not as expected; (-got,+want): %s", diff)
+	}
+
+	if !got.HasChanges() {
+		t.Errorf("expected diff to have changes")
+	}
+	if got.MatchesPlan() {
+		t.Errorf("expected diff not to match the plan")
+	}
+}
+
+func TestDiffStates_noChanges(t *testing.T) {
+	t.Parallel()
+
+	before, err := ParseStateSnapshot([]byte(stateBeforeJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := DiffStates(before, before)
+	got.ComparePlan(&PlanSummary{})
+
+	if got.HasChanges() {
+		t.Errorf("expected diff %#v to have no changes", got)
+	}
+	if !got.MatchesPlan() {
+		t.Errorf("expected diff %#v to match the plan", got)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
@@ -48,8 +49,8 @@ var (
 			`((\-(\/\+)*)|(\+(\/\-)*)|(!))`) // match characters to swap whitespace for git diff (+, +/-, -, -/+, !)
 )
 
-// InitRequiredCommands are the Terraform commands that
-// require terraform init to be run first.
+// InitRequiredCommands are the Terraform and OpenTofu commands that
+// require init to be run first.
 var InitRequiredCommands = map[string]struct{}{
 	"validate":     {},
 	"plan":         {},
@@ -67,6 +68,7 @@ var InitRequiredCommands = map[string]struct{}{
 	"untaint":      {},
 	"workspace":    {},
 	"force-unlock": {},
+	"test":         {},
 }
 
 var _ Terraform = (*TerraformClient)(nil)
@@ -91,14 +93,51 @@ type Terraform interface {
 	// Format runs the terraform fmt command.
 	Format(context.Context, io.Writer, io.Writer, *FormatOptions) (int, error)
 
+	// Version runs the terraform version command.
+	Version(context.Context, io.Writer, io.Writer, *VersionOptions) (int, error)
+
+	// WorkspaceSelect runs the terraform workspace select command.
+	WorkspaceSelect(context.Context, io.Writer, io.Writer, *WorkspaceSelectOptions) (int, error)
+
 	// Run runs a terraform command.
 	Run(context.Context, io.Writer, io.Writer, string, ...string) (int, error)
 }
 
 // TerraformClient implements the Terraform interface.
 type TerraformClient struct {
-	workingDir string
-	envVars    []string
+	workingDir  string
+	envVars     []string
+	binary      string
+	encryption  string
+	gracePeriod time.Duration
+}
+
+// ClientOption is an option for creating a TerraformClient.
+type ClientOption func(t *TerraformClient)
+
+// WithBinary sets the executable run by the client, "terraform", "tofu" or the
+// path to an executable. Use ResolveBinary to detect the executable.
+func WithBinary(binary string) ClientOption {
+	return func(t *TerraformClient) {
+		t.binary = binary
+	}
+}
+
+// WithEncryption sets the OpenTofu state and plan encryption configuration,
+// passed to the executable using the TF_ENCRYPTION environment variable.
+func WithEncryption(config string) ClientOption {
+	return func(t *TerraformClient) {
+		t.encryption = config
+	}
+}
+
+// WithGracePeriod sets the time Terraform is given to exit after it is
+// interrupted because the context is canceled, e.g. to release the state lock,
+// before it is killed.
+func WithGracePeriod(d time.Duration) ClientOption {
+	return func(t *TerraformClient) {
+		t.gracePeriod = d
+	}
 }
 
 // TerraformResponse is the response from running a terraform command.
@@ -132,12 +171,23 @@ type ModuleUsageGraph struct {
 	ModulesToEntrypoints map[string]map[string]struct{}
 }
 
-// NewTerraformClient creates a new Terraform client.
-func NewTerraformClient(workingDir string, envVars []string) *TerraformClient {
-	return &TerraformClient{
+// NewTerraformClient creates a new Terraform client. The client runs the
+// terraform executable unless another binary is set using WithBinary.
+func NewTerraformClient(workingDir string, envVars []string, opts ...ClientOption) *TerraformClient {
+	t := &TerraformClient{
 		workingDir: workingDir,
 		envVars:    envVars,
+		binary:     BinaryTerraform,
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Binary returns the executable run by the client.
+func (t *TerraformClient) Binary() string {
+	return t.binary
 }
 
 // ModuleUsage locates all the usages of modules in all terraform entrypoints and vice versa.
@@ -204,7 +254,7 @@ func modules(ctx context.Context, rootDir string, skipUnresolvableModules bool)
 			return nil
 		}
 
-		if filepath.Ext(path) != ".tf" {
+		if !isConfigFile(path) {
 			return nil
 		}
 
@@ -268,7 +318,7 @@ func GetEntrypointDirectories(rootDir string, maxDepth *int) ([]*TerraformEntryp
 			return nil
 		}
 
-		if filepath.Ext(path) != ".tf" {
+		if !isConfigFile(path) {
 			return nil
 		}
 
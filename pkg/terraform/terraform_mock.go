@@ -43,7 +43,10 @@ type MockTerraformClient struct {
 	ShowResponse     *MockTerraformResponse
 	ShowJSONResponse *MockTerraformResponse
 	FormatResponse   *MockTerraformResponse
+	VersionResponse  *MockTerraformResponse
 	RunResponse      *MockTerraformResponse
+
+	WorkspaceSelectResponse *MockTerraformResponse
 }
 
 func (m *MockTerraformClient) Init(ctx context.Context, stdout, stderr io.Writer, opts *InitOptions) (int, error) {
@@ -111,6 +114,24 @@ func (m *MockTerraformClient) Format(ctx context.Context, stdout, stderr io.Writ
 	return 0, nil
 }
 
+func (m *MockTerraformClient) Version(ctx context.Context, stdout, stderr io.Writer, opts *VersionOptions) (int, error) {
+	if m.VersionResponse != nil {
+		stdout.Write([]byte(m.VersionResponse.Stdout))
+		stderr.Write([]byte(m.VersionResponse.Stderr))
+		return m.VersionResponse.ExitCode, m.VersionResponse.Err
+	}
+	return 0, nil
+}
+
+func (m *MockTerraformClient) WorkspaceSelect(ctx context.Context, stdout, stderr io.Writer, opts *WorkspaceSelectOptions) (int, error) {
+	if m.WorkspaceSelectResponse != nil {
+		stdout.Write([]byte(m.WorkspaceSelectResponse.Stdout))
+		stderr.Write([]byte(m.WorkspaceSelectResponse.Stderr))
+		return m.WorkspaceSelectResponse.ExitCode, m.WorkspaceSelectResponse.Err
+	}
+	return 0, nil
+}
+
 func (m *MockTerraformClient) Run(ctx context.Context, stdout, stderr io.Writer, subcommand string, args ...string) (int, error) {
 	if m.RunResponse != nil {
 		stdout.Write([]byte(m.RunResponse.Stdout))
@@ -0,0 +1 @@
+bucket = "dev"
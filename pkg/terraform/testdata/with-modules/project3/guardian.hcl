@@ -0,0 +1,8 @@
+environment "dev" {
+  var_files       = ["env/dev.tfvars"]
+  backend_configs = ["../environments/dev.backend.hcl"]
+}
+
+environment "prod" {
+  var_files = ["../environments/prod.tfvars", "../environments/missing.tfvars"]
+}
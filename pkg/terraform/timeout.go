@@ -0,0 +1,94 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abcxyz/guardian/pkg/child"
+)
+
+// DefaultGracePeriod is the default time Terraform is given to exit after it is
+// interrupted, which is long enough to release the state lock of most
+// backends.
+const DefaultGracePeriod = 30 * time.Second
+
+// StepTimeoutError is returned when a Terraform step does not complete within
+// its timeout.
+type StepTimeoutError struct {
+	// Binary is the name of the product run by the step, "terraform" or "tofu".
+	// Defaults to "terraform".
+	Binary string
+
+	// Step is the name of the step that timed out, e.g. "plan".
+	Step string
+
+	// Timeout is the timeout of the step.
+	Timeout time.Duration
+
+	// Killed is true if Terraform did not exit within the grace period after
+	// being interrupted and was killed, in which case the state lock may not have
+	// been released.
+	Killed bool
+
+	// Err is the error returned by the step.
+	Err error
+}
+
+func (e *StepTimeoutError) Error() string {
+	if e.Killed {
+		return fmt.Sprintf("%s %s timed out after %s and was killed before releasing the state lock: %s", e.BinaryName(), e.Step, e.Timeout, e.Err)
+	}
+	return fmt.Sprintf("%s %s timed out after %s: %s", e.BinaryName(), e.Step, e.Timeout, e.Err)
+}
+
+// BinaryName returns the name of the product run by the step.
+func (e *StepTimeoutError) BinaryName() string {
+	if e.Binary == "" {
+		return BinaryTerraform
+	}
+	return e.Binary
+}
+
+func (e *StepTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// RunStep runs a step of the binary, "terraform" or "tofu", canceling it when
+// the timeout elapses. A StepTimeoutError is returned if the step timed out. A
+// zero timeout runs the step until the context is canceled.
+func RunStep(ctx context.Context, binary, step string, timeout time.Duration, fn func(ctx context.Context) (int, error)) (int, error) {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	stepCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	exitCode, err := fn(stepCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		return exitCode, &StepTimeoutError{
+			Binary:  binary,
+			Step:    step,
+			Timeout: timeout,
+			Killed:  errors.Is(err, child.ErrKilled),
+			Err:     err,
+		}
+	}
+	return exitCode, err
+}
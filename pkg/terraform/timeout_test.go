@@ -0,0 +1,132 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/guardian/pkg/child"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestRunStep(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name       string
+		binary     string
+		timeout    time.Duration
+		fn         func(ctx context.Context) (int, error)
+		err        string
+		expTimeout *StepTimeoutError
+	}{
+		{
+			name:    "success",
+			timeout: time.Minute,
+			fn: func(ctx context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		{
+			name:    "failure",
+			timeout: time.Minute,
+			fn: func(ctx context.Context) (int, error) {
+				return 1, fmt.Errorf("failed to run command: exit status 1")
+			},
+			err: "failed to run command: exit status 1",
+		},
+		{
+			name: "no_timeout",
+			fn: func(ctx context.Context) (int, error) {
+				if _, ok := ctx.Deadline(); ok {
+					return 1, fmt.Errorf("unexpected deadline")
+				}
+				return 0, nil
+			},
+		},
+		{
+			name:    "timeout_interrupted",
+			timeout: time.Millisecond,
+			fn: func(ctx context.Context) (int, error) {
+				<-ctx.Done()
+				return -1, fmt.Errorf("failed to run command: %w", ctx.Err())
+			},
+			err:        "terraform plan timed out after 1ms: failed to run command: context deadline exceeded",
+			expTimeout: &StepTimeoutError{Step: "plan", Timeout: time.Millisecond},
+		},
+		{
+			name:    "timeout_killed",
+			timeout: time.Millisecond,
+			fn: func(ctx context.Context) (int, error) {
+				<-ctx.Done()
+				return -1, fmt.Errorf("failed to run command: %w: signal: killed", child.ErrKilled)
+			},
+			err:        "terraform plan timed out after 1ms and was killed before releasing the state lock",
+			expTimeout: &StepTimeoutError{Step: "plan", Timeout: time.Millisecond, Killed: true},
+		},
+		{
+			name:    "timeout_opentofu",
+			binary:  BinaryOpenTofu,
+			timeout: time.Millisecond,
+			fn: func(ctx context.Context) (int, error) {
+				<-ctx.Done()
+				return -1, fmt.Errorf("failed to run command: %w", ctx.Err())
+			},
+			err:        "tofu plan timed out after 1ms: failed to run command: context deadline exceeded",
+			expTimeout: &StepTimeoutError{Binary: BinaryOpenTofu, Step: "plan", Timeout: time.Millisecond},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := RunStep(t.Context(), tc.binary, "plan", tc.timeout, tc.fn)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+
+			var got *StepTimeoutError
+			if errors.As(err, &got) {
+				got = &StepTimeoutError{Binary: got.Binary, Step: got.Step, Timeout: got.Timeout, Killed: got.Killed}
+			}
+			if diff := cmp.Diff(got, tc.expTimeout); diff != "" {
+				t.Errorf("timeout error not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestRunStep_canceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := RunStep(ctx, BinaryTerraform, "plan", time.Minute, func(ctx context.Context) (int, error) {
+		return -1, fmt.Errorf("failed to start command: %w", ctx.Err())
+	})
+
+	var timeoutErr *StepTimeoutError
+	if errors.As(err, &timeoutErr) {
+		t.Errorf("expected canceled step not to time out, got %v", err)
+	}
+}
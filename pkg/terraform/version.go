@@ -0,0 +1,83 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/abcxyz/pkg/pointer"
+)
+
+// VersionOptions are the set of options for running a terraform version.
+type VersionOptions struct {
+	JSON *bool
+}
+
+// versionArgsFromOptions generated the terrafrom version arguments from the provided options.
+func versionArgsFromOptions(opts *VersionOptions) []string {
+	args := make([]string, 0, 1) // 1 potential args to be added
+
+	if opts == nil {
+		return args
+	}
+
+	if pointer.Deref(opts.JSON) {
+		args = append(args, "-json")
+	}
+
+	return args
+}
+
+// Version runs the Terraform version command.
+func (t *TerraformClient) Version(ctx context.Context, stdout, stderr io.Writer, opts *VersionOptions) (int, error) {
+	return t.Run(ctx, stdout, stderr, "version", versionArgsFromOptions(opts)...)
+}
+
+// versionOutput is the JSON output of the terraform version command.
+type versionOutput struct {
+	TerraformVersion string `json:"terraform_version"`
+}
+
+// GetVersion returns the version of the Terraform CLI used by the client,
+// e.g. "1.5.7".
+func GetVersion(ctx context.Context, tf Terraform) (string, error) {
+	var stdout, stderr strings.Builder
+	if _, err := tf.Version(ctx, &stdout, &stderr, &VersionOptions{
+		JSON: pointer.To(true),
+	}); err != nil {
+		return "", fmt.Errorf("failed to run terraform version: %w: %s", err, stderr.String())
+	}
+
+	return parseVersionOutput(stdout.String())
+}
+
+// parseVersionOutput parses the terraform version from the JSON output of the
+// terraform version command.
+func parseVersionOutput(s string) (string, error) {
+	var out versionOutput
+	if err := json.Unmarshal([]byte(s), &out); err != nil {
+		return "", fmt.Errorf("failed to parse terraform version output: %w", err)
+	}
+
+	if out.TerraformVersion == "" {
+		return "", fmt.Errorf("terraform version output is missing terraform_version")
+	}
+
+	return out.TerraformVersion, nil
+}
@@ -0,0 +1,539 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+	"golang.org/x/exp/maps"
+
+	"github.com/abcxyz/guardian/pkg/util"
+)
+
+// maxReleaseSize is the maximum size of a release archive read from a mirror.
+const maxReleaseSize = 512 << 20
+
+// checksumSuffix is the suffix of the file next to a cached executable that
+// holds the SHA256 checksum the executable had when it was verified.
+const checksumSuffix = ".sha256"
+
+// terraformBlockSchema is the schema of the terraform block attributes used to
+// resolve the required version.
+var terraformBlockSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: "terraform"}},
+}
+
+var requiredVersionSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "required_version"}},
+}
+
+// VersionManager installs the release of Terraform or OpenTofu required by an
+// entrypoint from a release mirror into a local cache directory.
+//
+// The mirror uses the layout of releases.hashicorp.com, a directory per
+// version containing the <product>_<version>_<os>_<arch>.zip archives, and the
+// <product>_<version>_SHA256SUMS file with its detached signature
+// <product>_<version>_SHA256SUMS.sig. An HTTP mirror lists its versions in an
+// index.json file, a local mirror directory is listed directly.
+type VersionManager struct {
+	product  string
+	mirror   string
+	cacheDir string
+	keyring  openpgp.EntityList
+
+	httpClient *http.Client
+	goos       string
+	goarch     string
+}
+
+// NewVersionManager creates a new VersionManager installing releases of the
+// product, "terraform" or "tofu", into the cache directory. Releases are
+// downloaded from the mirror and verified using the armored public keyring.
+// Without a mirror, only releases in the cache directory are used.
+func NewVersionManager(product, mirror, cacheDir string, keyring io.Reader) (*VersionManager, error) {
+	m := &VersionManager{
+		product:    product,
+		mirror:     strings.TrimSuffix(mirror, "/"),
+		cacheDir:   cacheDir,
+		httpClient: http.DefaultClient,
+		goos:       runtime.GOOS,
+		goarch:     runtime.GOARCH,
+	}
+
+	if m.mirror == "" {
+		return m, nil
+	}
+
+	if keyring == nil {
+		return nil, fmt.Errorf("a public key is required to verify releases from %s", m.mirror)
+	}
+
+	entities, err := openpgp.ReadArmoredKeyRing(keyring)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	m.keyring = entities
+
+	return m, nil
+}
+
+// Install returns the path to an executable matching the required version of
+// the entrypoint directory and its local modules, as resolved by
+// EntrypointRequiredVersion, downloading the newest matching release from the
+// mirror if it is not cached. A cached executable is only used if it still
+// matches the checksum recorded when it was installed. An empty path is
+// returned if neither the entrypoint nor its modules declare a required
+// version.
+func (m *VersionManager) Install(ctx context.Context, rootDir, dir string) (string, error) {
+	constraints, err := EntrypointRequiredVersion(ctx, rootDir, dir)
+	if err != nil {
+		return "", err
+	}
+	if len(constraints) == 0 {
+		return "", nil
+	}
+
+	cached, err := m.cachedVersions()
+	if err != nil {
+		return "", err
+	}
+
+	available := cached
+	if m.mirror != "" {
+		available, err = m.mirrorVersions(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	v := newestMatching(available, constraints)
+	if v == nil {
+		return "", fmt.Errorf("no %s release matches required version %q", m.product, constraints)
+	}
+
+	pth := m.binaryPath(v)
+	if slices.ContainsFunc(cached, v.Equal) {
+		err := verifyCached(pth)
+		if err == nil {
+			return pth, nil
+		}
+		if m.mirror == "" {
+			return "", fmt.Errorf("failed to verify cached %s %s: %w", m.product, v, err)
+		}
+	}
+
+	if err := m.download(ctx, v, pth); err != nil {
+		return "", fmt.Errorf("failed to install %s %s: %w", m.product, v, err)
+	}
+	return pth, nil
+}
+
+// RequiredVersion returns the version constraints declared by the
+// required_version attributes of the terraform blocks in the entrypoint
+// directory.
+func RequiredVersion(dir string) (version.Constraints, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var constraints version.Constraints
+	parser := hclparse.NewParser()
+	for _, e := range entries {
+		if e.IsDir() || !isConfigFile(e.Name()) {
+			continue
+		}
+
+		pth := filepath.Join(dir, e.Name())
+		file, diags := parser.ParseHCLFile(pth)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to parse %s: %w", e.Name(), diags)
+		}
+
+		content, _, diags := file.Body.PartialContent(terraformBlockSchema)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to parse %s: %w", e.Name(), diags)
+		}
+
+		for _, block := range content.Blocks {
+			attrs, _, diags := block.Body.PartialContent(requiredVersionSchema)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("failed to parse %s: %w", e.Name(), diags)
+			}
+
+			attr, ok := attrs.Attributes["required_version"]
+			if !ok {
+				continue
+			}
+
+			val, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("failed to evaluate required_version in %s: %w", e.Name(), diags)
+			}
+			if val.Type() != cty.String || val.IsNull() {
+				return nil, fmt.Errorf("required_version in %s must be a string", e.Name())
+			}
+
+			c, err := version.NewConstraint(val.AsString())
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse required_version in %s: %w", e.Name(), err)
+			}
+			constraints = append(constraints, c...)
+		}
+	}
+
+	return constraints, nil
+}
+
+// EntrypointRequiredVersion returns the version constraints of the entrypoint
+// directory and the local modules it uses at any depth, as resolved by
+// ModuleUsage for the given root directory. A version must satisfy all of the
+// constraints, so the result is their intersection.
+func EntrypointRequiredVersion(ctx context.Context, rootDir, dir string) (version.Constraints, error) {
+	rootAbs, err := util.PathEvalAbs(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for root directory %s: %w", rootDir, err)
+	}
+
+	dirAbs, err := util.PathEvalAbs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for entrypoint directory %s: %w", dir, err)
+	}
+
+	graph, err := ModuleUsage(ctx, rootAbs, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get module usage for %s: %w", rootAbs, err)
+	}
+
+	moduleDirs := maps.Keys(graph.EntrypointToModules[dirAbs])
+	sort.Strings(moduleDirs)
+
+	var constraints version.Constraints
+	for _, d := range append([]string{dirAbs}, moduleDirs...) {
+		c, err := RequiredVersion(d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get required version of %s: %w", d, err)
+		}
+		constraints = append(constraints, c...)
+	}
+	return constraints, nil
+}
+
+// newestMatching returns the newest version matching the constraints, or nil
+// if none match.
+func newestMatching(versions []*version.Version, constraints version.Constraints) *version.Version {
+	var newest *version.Version
+	for _, v := range versions {
+		if constraints.Check(v) && (newest == nil || v.GreaterThan(newest)) {
+			newest = v
+		}
+	}
+	return newest
+}
+
+// executableName returns the filename of the executable in a release archive,
+// which has an .exe extension for Windows releases.
+func (m *VersionManager) executableName() string {
+	if m.goos == "windows" {
+		return m.product + ".exe"
+	}
+	return m.product
+}
+
+// binaryPath returns the path of the executable of a release in the cache.
+func (m *VersionManager) binaryPath(v *version.Version) string {
+	return filepath.Join(m.cacheDir, m.product, v.Original(), m.executableName())
+}
+
+// cachedVersions returns the versions installed in the cache directory.
+func (m *VersionManager) cachedVersions() ([]*version.Version, error) {
+	entries, err := os.ReadDir(filepath.Join(m.cacheDir, m.product))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	versions := make([]*version.Version, 0, len(entries))
+	for _, e := range entries {
+		v, err := version.NewVersion(e.Name())
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(m.binaryPath(v)); err != nil {
+			continue
+		}
+		if _, err := os.Stat(m.binaryPath(v) + checksumSuffix); err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// mirrorVersions returns the versions available from the mirror.
+func (m *VersionManager) mirrorVersions(ctx context.Context) ([]*version.Version, error) {
+	var names []string
+	if dir, ok := localMirror(m.mirror); ok {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mirror directory: %w", err)
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				names = append(names, e.Name())
+			}
+		}
+	} else {
+		b, err := m.fetch(ctx, "index.json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch mirror index: %w", err)
+		}
+
+		var index struct {
+			Versions map[string]json.RawMessage `json:"versions"`
+		}
+		if err := json.Unmarshal(b, &index); err != nil {
+			return nil, fmt.Errorf("failed to parse mirror index: %w", err)
+		}
+		for name := range index.Versions {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	versions := make([]*version.Version, 0, len(names))
+	for _, name := range names {
+		v, err := version.NewVersion(name)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// download downloads, verifies and extracts the release executable to the
+// path in the cache.
+func (m *VersionManager) download(ctx context.Context, v *version.Version, pth string) error {
+	prefix := fmt.Sprintf("%s_%s", m.product, v.Original())
+	archiveName := fmt.Sprintf("%s_%s_%s.zip", prefix, m.goos, m.goarch)
+
+	sums, err := m.fetch(ctx, v.Original()+"/"+prefix+"_SHA256SUMS")
+	if err != nil {
+		return fmt.Errorf("failed to fetch checksums: %w", err)
+	}
+
+	sig, err := m.fetch(ctx, v.Original()+"/"+prefix+"_SHA256SUMS.sig")
+	if err != nil {
+		return fmt.Errorf("failed to fetch checksums signature: %w", err)
+	}
+
+	if _, err := openpgp.CheckDetachedSignature(m.keyring, bytes.NewReader(sums), bytes.NewReader(sig), nil); err != nil {
+		return fmt.Errorf("failed to verify checksums signature: %w", err)
+	}
+
+	want, err := checksumFor(sums, archiveName)
+	if err != nil {
+		return err
+	}
+
+	archive, err := m.fetch(ctx, v.Original()+"/"+archiveName)
+	if err != nil {
+		return fmt.Errorf("failed to fetch release: %w", err)
+	}
+
+	got := sha256.Sum256(archive)
+	if hex.EncodeToString(got[:]) != want {
+		return fmt.Errorf("checksum of %s does not match %s", archiveName, want)
+	}
+
+	return m.extract(archive, pth)
+}
+
+// checksumFor returns the checksum of the file from the SHA256SUMS contents.
+func checksumFor(sums []byte, filename string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == filename {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read checksums: %w", err)
+	}
+	return "", fmt.Errorf("checksums do not include %s", filename)
+}
+
+// verifyCached verifies the cached executable at the path still matches the
+// checksum recorded next to it when it was installed.
+func verifyCached(pth string) error {
+	want, err := os.ReadFile(pth + checksumSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to read checksum: %w", err)
+	}
+
+	b, err := os.ReadFile(pth)
+	if err != nil {
+		return fmt.Errorf("failed to read executable: %w", err)
+	}
+
+	got := sha256.Sum256(b)
+	if hex.EncodeToString(got[:]) != strings.TrimSpace(string(want)) {
+		return fmt.Errorf("checksum of %s does not match %s", pth, strings.TrimSpace(string(want)))
+	}
+	return nil
+}
+
+// extract writes the executable from the release archive to the path, and its
+// checksum next to it. The executable is written to a temporary file first, so
+// a partially written executable is never used. The checksum is written last,
+// so an executable without one is never reused from the cache.
+func (m *VersionManager) extract(archive []byte, pth string) (outErr error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return fmt.Errorf("failed to read release archive: %w", err)
+	}
+
+	name := m.executableName()
+
+	var executable *zip.File
+	for _, f := range zr.File {
+		if f.Name == name {
+			executable = f
+			break
+		}
+	}
+	if executable == nil {
+		return fmt.Errorf("release archive does not contain %s", name)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(pth), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	rc, err := executable.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s in release archive: %w", m.product, err)
+	}
+	defer func() {
+		if err := rc.Close(); err != nil {
+			outErr = errors.Join(outErr, fmt.Errorf("failed to close release archive: %w", err))
+		}
+	}()
+
+	tmp, err := os.CreateTemp(filepath.Dir(pth), m.product+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		if outErr != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(rc, maxReleaseSize)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", m.product, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", m.product, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil { //nolint:gosec // Executable
+		return fmt.Errorf("failed to make %s executable: %w", m.product, err)
+	}
+	// Remove a stale checksum first, so it never applies to the new executable.
+	if err := os.Remove(pth + checksumSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove checksum of %s: %w", m.product, err)
+	}
+	if err := os.Rename(tmp.Name(), pth); err != nil {
+		return fmt.Errorf("failed to move %s into the cache: %w", m.product, err)
+	}
+	if err := os.WriteFile(pth+checksumSuffix, []byte(hex.EncodeToString(h.Sum(nil))+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write checksum of %s: %w", m.product, err)
+	}
+	return nil
+}
+
+// fetch reads the file at the path relative to the mirror.
+func (m *VersionManager) fetch(ctx context.Context, name string) ([]byte, error) {
+	if dir, ok := localMirror(m.mirror); ok {
+		b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		return b, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.mirror+"/"+name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: unexpected status %s", name, resp.Status)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxReleaseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return b, nil
+}
+
+// localMirror returns the directory of a local mirror, given as a path or a
+// file:// URL.
+func localMirror(mirror string) (string, bool) {
+	u, err := url.Parse(mirror)
+	if err != nil || u.Scheme == "" {
+		return mirror, true
+	}
+	if u.Scheme == "file" {
+		return u.Path, true
+	}
+	return "", false
+}
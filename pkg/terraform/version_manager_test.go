@@ -0,0 +1,436 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+// testEntity creates a signing key for test releases.
+func testEntity(tb testing.TB) *openpgp.Entity {
+	tb.Helper()
+
+	e, err := openpgp.NewEntity("Guardian Test", "", "test@example.com", &packet.Config{
+		Algorithm: packet.PubKeyAlgoEdDSA,
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return e
+}
+
+// armoredPublicKey returns the armored public key of the entity.
+func armoredPublicKey(tb testing.TB, e *openpgp.Entity) *bytes.Buffer {
+	tb.Helper()
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := e.Serialize(w); err != nil {
+		tb.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		tb.Fatal(err)
+	}
+	return &buf
+}
+
+// writeRelease writes a release of the product for the operating system to the
+// mirror directory, with the checksums signed by the entity.
+func writeRelease(tb testing.TB, mirror string, e *openpgp.Entity, product, goos, v, contents string) {
+	tb.Helper()
+
+	dir := filepath.Join(mirror, v)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		tb.Fatal(err)
+	}
+
+	executable := product
+	if goos == "windows" {
+		executable += ".exe"
+	}
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for name, body := range map[string]string{executable: contents, "LICENSE.txt": "license"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		tb.Fatal(err)
+	}
+
+	archiveName := fmt.Sprintf("%s_%s_%s_testarch.zip", product, v, goos)
+	if err := os.WriteFile(filepath.Join(dir, archiveName), archive.Bytes(), 0o600); err != nil {
+		tb.Fatal(err)
+	}
+
+	sums := fmt.Sprintf("%x  %s\n", sha256.Sum256(archive.Bytes()), archiveName)
+	prefix := filepath.Join(dir, fmt.Sprintf("%s_%s", product, v))
+	if err := os.WriteFile(prefix+"_SHA256SUMS", []byte(sums), 0o600); err != nil {
+		tb.Fatal(err)
+	}
+
+	var sig bytes.Buffer
+	if err := openpgp.DetachSign(&sig, e, bytes.NewReader([]byte(sums)), nil); err != nil {
+		tb.Fatal(err)
+	}
+	if err := os.WriteFile(prefix+"_SHA256SUMS.sig", sig.Bytes(), 0o600); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// writeEntrypoint writes an entrypoint with the required version to a
+// temporary directory. If the module version is set, the entrypoint uses a
+// local module with that required version.
+func writeEntrypoint(tb testing.TB, requiredVersion, moduleVersion string) string {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	contents := "terraform {\n  backend \"local\" {}\n}\n"
+	if requiredVersion != "" {
+		contents = fmt.Sprintf("terraform {\n  required_version = %q\n  backend \"local\" {}\n}\n", requiredVersion)
+	}
+	if moduleVersion != "" {
+		contents += "module \"child\" {\n  source = \"./modules/child\"\n}\n"
+
+		moduleDir := filepath.Join(dir, "modules", "child")
+		if err := os.MkdirAll(moduleDir, 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		module := fmt.Sprintf("terraform {\n  required_version = %q\n}\n", moduleVersion)
+		if err := os.WriteFile(filepath.Join(moduleDir, "main.tf"), []byte(module), 0o600); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(contents), 0o600); err != nil {
+		tb.Fatal(err)
+	}
+	return dir
+}
+
+func TestRequiredVersion(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		files map[string]string
+		exp   string
+		err   string
+	}{
+		{
+			name: "single_constraint",
+			files: map[string]string{
+				"main.tf": `terraform { required_version = "~> 1.5.0" }`,
+			},
+			exp: "~> 1.5.0",
+		},
+		{
+			name: "combines_files",
+			files: map[string]string{
+				"main.tf":     `terraform { required_version = ">= 1.5" }`,
+				"versions.tf": `terraform { required_version = "< 1.7" }`,
+				"main.tofu":   `resource "null_resource" "a" {}`,
+			},
+			exp: ">= 1.5,< 1.7",
+		},
+		{
+			name: "no_constraint",
+			files: map[string]string{
+				"main.tf": "terraform {\n  backend \"gcs\" {}\n}\n",
+			},
+			exp: "",
+		},
+		{
+			name: "not_a_string",
+			files: map[string]string{
+				"main.tf": `terraform { required_version = 1 }`,
+			},
+			err: "required_version in main.tf must be a string",
+		},
+		{
+			name: "null",
+			files: map[string]string{
+				"main.tf": `terraform { required_version = null }`,
+			},
+			err: "required_version in main.tf must be a string",
+		},
+		{
+			name: "invalid_constraint",
+			files: map[string]string{
+				"main.tf": `terraform { required_version = "latest" }`,
+			},
+			err: "failed to parse required_version in main.tf",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			for name, contents := range tc.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := RequiredVersion(dir)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(got.String(), tc.exp); diff != "" {
+				t.Errorf("constraints not as expected; (-got,+want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestVersionManager_Install(t *testing.T) {
+	t.Parallel()
+
+	signer := testEntity(t)
+	other := testEntity(t)
+
+	cases := []struct {
+		name            string
+		requiredVersion string
+		moduleVersion   string
+		goos            string
+		noMirror        bool
+		cached          []string
+		tamperCache     bool
+		tamper          func(t *testing.T, mirror string)
+		key             *openpgp.Entity
+		exp             string
+		expContents     string
+		err             string
+	}{
+		{
+			name:            "installs_newest_matching",
+			requiredVersion: "~> 1.5.0",
+			exp:             "terraform/1.5.7/terraform",
+			expContents:     "1.5.7",
+		},
+		{
+			name:            "installs_windows_executable",
+			requiredVersion: "~> 1.5.0",
+			goos:            "windows",
+			exp:             "terraform/1.5.7/terraform.exe",
+			expContents:     "1.5.7",
+		},
+		{
+			name:            "skips_prerelease",
+			requiredVersion: ">= 1.6",
+			exp:             "terraform/1.6.0/terraform",
+			expContents:     "1.6.0",
+		},
+		{
+			name:            "uses_cache",
+			requiredVersion: "1.5.2",
+			cached:          []string{"1.5.2"},
+			exp:             "terraform/1.5.2/terraform",
+			expContents:     "cached",
+		},
+		{
+			name:            "cache_without_mirror",
+			requiredVersion: "~> 1.5.0",
+			noMirror:        true,
+			cached:          []string{"1.5.2"},
+			exp:             "terraform/1.5.2/terraform",
+			expContents:     "cached",
+		},
+		{
+			name:            "reinstalls_modified_cache",
+			requiredVersion: "1.5.2",
+			cached:          []string{"1.5.2"},
+			tamperCache:     true,
+			exp:             "terraform/1.5.2/terraform",
+			expContents:     "1.5.2",
+		},
+		{
+			name:            "rejects_modified_cache_without_mirror",
+			requiredVersion: "~> 1.5.0",
+			noMirror:        true,
+			cached:          []string{"1.5.2"},
+			tamperCache:     true,
+			err:             "failed to verify cached terraform 1.5.2",
+		},
+		{
+			name:            "intersects_module_required_version",
+			requiredVersion: ">= 1.5",
+			moduleVersion:   "< 1.6",
+			exp:             "terraform/1.5.7/terraform",
+			expContents:     "1.5.7",
+		},
+		{
+			name:          "module_required_version",
+			moduleVersion: "1.5.2",
+			exp:           "terraform/1.5.2/terraform",
+			expContents:   "1.5.2",
+		},
+		{
+			name: "no_required_version",
+			exp:  "",
+		},
+		{
+			name:            "no_matching_version",
+			requiredVersion: "~> 0.12.0",
+			err:             `no terraform release matches required version "~> 0.12.0"`,
+		},
+		{
+			name:            "untrusted_signature",
+			requiredVersion: "1.5.7",
+			key:             other,
+			err:             "failed to verify checksums signature",
+		},
+		{
+			name:            "checksum_mismatch",
+			requiredVersion: "1.5.7",
+			tamper: func(t *testing.T, mirror string) {
+				t.Helper()
+
+				pth := filepath.Join(mirror, "1.5.7", "terraform_1.5.7_testos_testarch.zip")
+				if err := os.WriteFile(pth, []byte("tampered"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			err: "checksum of terraform_1.5.7_testos_testarch.zip does not match",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			goos := "testos"
+			if tc.goos != "" {
+				goos = tc.goos
+			}
+
+			mirror := t.TempDir()
+			for _, v := range []string{"1.5.2", "1.5.7", "1.6.0", "1.7.0-beta1"} {
+				writeRelease(t, mirror, signer, "terraform", goos, v, v)
+			}
+			if tc.tamper != nil {
+				tc.tamper(t, mirror)
+			}
+
+			cacheDir := t.TempDir()
+			for _, v := range tc.cached {
+				pth := filepath.Join(cacheDir, "terraform", v, "terraform")
+				if err := os.MkdirAll(filepath.Dir(pth), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(pth, []byte("cached"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				checksum := fmt.Sprintf("%x\n", sha256.Sum256([]byte("cached")))
+				if err := os.WriteFile(pth+checksumSuffix, []byte(checksum), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				if tc.tamperCache {
+					if err := os.WriteFile(pth, []byte("modified"), 0o600); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			key := signer
+			if tc.key != nil {
+				key = tc.key
+			}
+
+			mirrorURL := "file://" + mirror
+			if tc.noMirror {
+				mirrorURL = ""
+			}
+
+			m, err := NewVersionManager("terraform", mirrorURL, cacheDir, armoredPublicKey(t, key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.goos, m.goarch = goos, "testarch"
+
+			dir := writeEntrypoint(t, tc.requiredVersion, tc.moduleVersion)
+			got, err := m.Install(t.Context(), dir, dir)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+			if err != nil {
+				return
+			}
+
+			if tc.exp == "" {
+				if got != "" {
+					t.Errorf("expected path %q to be empty", got)
+				}
+				return
+			}
+
+			if want := filepath.Join(cacheDir, tc.exp); got != want {
+				t.Errorf("expected path %q to be %q", got, want)
+			}
+
+			b, err := os.ReadFile(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := string(b), tc.expContents; got != want {
+				t.Errorf("expected contents %q to be %q", got, want)
+			}
+
+			if err := verifyCached(got); err != nil {
+				t.Errorf("expected installed %s to match its checksum: %v", got, err)
+			}
+		})
+	}
+}
+
+func TestNewVersionManager(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewVersionManager("terraform", "https://releases.example.com/terraform", t.TempDir(), nil); err == nil {
+		t.Errorf("expected error for mirror without public key")
+	}
+
+	if _, err := NewVersionManager("terraform", "", t.TempDir(), nil); err != nil {
+		t.Errorf("expected cache only version manager without public key, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/pointer"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestVersionArgsFromOptions(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		opts *VersionOptions
+		exp  []string
+	}{
+		{
+			name: "truthy",
+			opts: &VersionOptions{
+				JSON: pointer.To(true),
+			},
+			exp: []string{"-json"},
+		},
+		{
+			name: "falsey",
+			opts: &VersionOptions{
+				JSON: pointer.To(false),
+			},
+			exp: []string{},
+		},
+		{
+			name: "nil",
+			opts: nil,
+			exp:  []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			args := versionArgsFromOptions(tc.opts)
+			if diff := cmp.Diff(args, tc.exp); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestParseVersionOutput(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		output string
+		exp    string
+		err    string
+	}{
+		{
+			name:   "success",
+			output: `{"terraform_version":"1.5.7","platform":"linux_amd64","provider_selections":{},"terraform_outdated":false}`,
+			exp:    "1.5.7",
+		},
+		{
+			name:   "missing_version",
+			output: `{"platform":"linux_amd64"}`,
+			err:    "terraform version output is missing terraform_version",
+		},
+		{
+			name:   "invalid_json",
+			output: `Terraform v1.5.7`,
+			err:    "failed to parse terraform version output",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseVersionOutput(tc.output)
+			if diff := testutil.DiffErrString(err, tc.err); diff != "" {
+				t.Error(diff)
+			}
+			if got != tc.exp {
+				t.Errorf("expected %q to be %q", got, tc.exp)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// WorkspaceSelectOptions are the set of options for running a terraform
+// workspace select.
+type WorkspaceSelectOptions struct {
+	Name     *string
+	OrCreate *bool
+}
+
+// workspaceSelectArgsFromOptions generated the terrafrom workspace select
+// arguments from the provided options.
+func workspaceSelectArgsFromOptions(opts *WorkspaceSelectOptions) []string {
+	args := make([]string, 0, 3) // 3 potential args to be added
+	args = append(args, "select")
+
+	if opts == nil {
+		return args
+	}
+
+	if opts.OrCreate != nil {
+		args = append(args, fmt.Sprintf("-or-create=%t", *opts.OrCreate))
+	}
+
+	if opts.Name != nil {
+		args = append(args, *opts.Name)
+	}
+
+	return args
+}
+
+// WorkspaceSelect runs the terraform workspace select command.
+func (t *TerraformClient) WorkspaceSelect(ctx context.Context, stdout, stderr io.Writer, opts *WorkspaceSelectOptions) (int, error) {
+	return t.Run(ctx, stdout, stderr, "workspace", workspaceSelectArgsFromOptions(opts)...)
+}
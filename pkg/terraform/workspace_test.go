@@ -0,0 +1,79 @@
+// Copyright 2023 The Authors (see AUTHORS file)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/pointer"
+)
+
+func TestWorkspaceSelectArgsFromOptions(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		opts *WorkspaceSelectOptions
+		exp  []string
+	}{
+		{
+			name: "truthy",
+			opts: &WorkspaceSelectOptions{
+				Name:     pointer.To("dev"),
+				OrCreate: pointer.To(true),
+			},
+			exp: []string{
+				"select",
+				"-or-create=true",
+				"dev",
+			},
+		},
+		{
+			name: "falsey",
+			opts: &WorkspaceSelectOptions{
+				Name:     pointer.To("dev"),
+				OrCreate: pointer.To(false),
+			},
+			exp: []string{
+				"select",
+				"-or-create=false",
+				"dev",
+			},
+		},
+		{
+			name: "empty",
+			opts: &WorkspaceSelectOptions{},
+			exp:  []string{"select"},
+		},
+		{
+			name: "nil",
+			opts: nil,
+			exp:  []string{"select"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			args := workspaceSelectArgsFromOptions(tc.opts)
+			if diff := cmp.Diff(args, tc.exp); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -50,6 +50,21 @@ func SliceContainsOnly[T comparable](slice []T, value T) bool {
 	return true
 }
 
+// CodeList formats the values as a comma separated list of markdown inline
+// code. If limit is greater than zero, at most limit values are listed followed
+// by the number of values left out.
+func CodeList(values []string, limit int) string {
+	quoted := make([]string, 0, len(values))
+	for i, v := range values {
+		if limit > 0 && i == limit {
+			quoted = append(quoted, fmt.Sprintf("_and %d more_", len(values)-i))
+			break
+		}
+		quoted = append(quoted, "`"+v+"`")
+	}
+	return strings.Join(quoted, ", ")
+}
+
 // ChildPath returns the child path with respect to the base directory
 // or returns an error if the target directory is not a child of the base directory.
 func ChildPath(base, target string) (string, error) {
@@ -69,6 +69,49 @@ func TestSliceContainsOnly(t *testing.T) {
 	}
 }
 
+func TestCodeList(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		values []string
+		limit  int
+		exp    string
+	}{
+		{
+			name: "empty",
+			exp:  "",
+		},
+		{
+			name:   "no_limit",
+			values: []string{"a", "b", "c"},
+			exp:    "`a`, `b`, `c`",
+		},
+		{
+			name:   "within_limit",
+			values: []string{"a", "b"},
+			limit:  2,
+			exp:    "`a`, `b`",
+		},
+		{
+			name:   "over_limit",
+			values: []string{"a", "b", "c", "d"},
+			limit:  2,
+			exp:    "`a`, `b`, _and 2 more_",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, want := CodeList(tc.values, tc.limit), tc.exp; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		})
+	}
+}
+
 func TestChildPath(t *testing.T) {
 	t.Parallel()
 